@@ -21,16 +21,31 @@ type ChatMessagePartType string
 const (
 	ChatMessagePartTypeText     ChatMessagePartType = "text"
 	ChatMessagePartTypeImageURL ChatMessagePartType = "image_url"
+	ChatMessagePartTypeFile     ChatMessagePartType = "file"
 )
 
 type ChatMessageImageURL struct {
 	URL string `json:"url,omitempty"`
 }
 
+// ChatMessageFile is a document attachment (PDF, text, source code, ...).
+// Data holds the base64 encoded file content.
+type ChatMessageFile struct {
+	Filename string `json:"filename,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	Data     string `json:"data,omitempty"`
+}
+
+// DataURL returns the file content as a data URL
+func (o *ChatMessageFile) DataURL() string {
+	return "data:" + o.MimeType + ";base64," + o.Data
+}
+
 type ChatMessagePart struct {
 	Type     ChatMessagePartType  `json:"type,omitempty"`
 	Text     string               `json:"text,omitempty"`
 	ImageURL *ChatMessageImageURL `json:"image_url,omitempty"`
+	File     *ChatMessageFile     `json:"file,omitempty"`
 }
 
 type FunctionCall struct {
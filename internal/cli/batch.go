@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/tools/batch"
+)
+
+// handleBatchProcessing runs the chat request once per batch item with a pool of workers
+func handleBatchProcessing(currentFlags *Flags, registry *core.PluginRegistry) (err error) {
+	if currentFlags.Session != "" {
+		return fmt.Errorf("--session cannot be used with --batch, each item is processed independently")
+	}
+	if currentFlags.BatchOutput == "" {
+		return fmt.Errorf("--batch requires --batch-output (a directory or a .jsonl file)")
+	}
+
+	var items []*batch.Item
+	if items, err = batch.LoadItems(currentFlags.Batch); err != nil {
+		return
+	}
+
+	var output *batch.Output
+	if output, err = batch.NewOutput(currentFlags.BatchOutput, ""); err != nil {
+		return
+	}
+
+	// Responses are collected per item, so streaming to the console is disabled.
+	var chatter *core.Chatter
+	if chatter, err = newChatter(currentFlags, registry, false); err != nil {
+		return
+	}
+
+	language := registry.Language.DefaultLanguage.Value
+	meta := strings.Join(os.Args[1:], " ")
+
+	runner := &batch.Runner{
+		Workers:       currentFlags.BatchWorkers,
+		RatePerMinute: currentFlags.BatchRate,
+		Output:        output,
+		Process: func(_ context.Context, item *batch.Item) (string, error) {
+			return processBatchItem(currentFlags, chatter, item, language, meta)
+		},
+		Progress: func(done, total int, result *batch.Result) {
+			if result.Status == batch.StatusOK {
+				fmt.Fprintf(os.Stderr, "[%d/%d] %s done\n", done, total, result.ID)
+			} else {
+				fmt.Fprintf(os.Stderr, "[%d/%d] %s failed: %s\n", done, total, result.ID, result.Error)
+			}
+		},
+	}
+
+	processed, skipped, err := runner.Run(context.Background(), items)
+	fmt.Fprintf(os.Stderr, "Batch finished: %d processed, %d skipped (already completed), results in %s\n",
+		processed, skipped, currentFlags.BatchOutput)
+	return
+}
+
+// processBatchItem builds a chat request for one item from a copy of the flags,
+// so that the item input and variables do not leak into other items.
+func processBatchItem(currentFlags *Flags, chatter *core.Chatter, item *batch.Item, language string, meta string) (result string, err error) {
+	itemFlags := *currentFlags
+	itemFlags.Message = AppendMessage(currentFlags.Message, item.Input)
+	itemFlags.PatternVariables = maps.Clone(currentFlags.PatternVariables)
+	if itemFlags.PatternVariables == nil {
+		itemFlags.PatternVariables = map[string]string{}
+	}
+	maps.Copy(itemFlags.PatternVariables, item.Variables)
+
+	var chatReq *domain.ChatRequest
+	if chatReq, err = itemFlags.BuildChatRequest(meta); err != nil {
+		return
+	}
+	if chatReq.Language == "" {
+		chatReq.Language = language
+	}
+
+	var chatOptions *domain.ChatOptions
+	if chatOptions, err = itemFlags.BuildChatOptions(); err != nil {
+		return
+	}
+
+	var session *fsdb.Session
+	if session, err = chatter.Send(chatReq, chatOptions); err != nil {
+		return
+	}
+	result = session.GetLastMessage().Content
+	return
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+func TestHandleBatchProcessingRedact(t *testing.T) {
+	dir := t.TempDir()
+	registry, err := core.NewPluginRegistry(fsdb.NewDb(filepath.Join(dir, "config")))
+	if err != nil {
+		t.Fatalf("NewPluginRegistry() error = %v", err)
+	}
+	inputs := filepath.Join(dir, "inputs.jsonl")
+	if err = os.WriteFile(inputs, []byte(`{"id":"one","input":"Write to jane@example.com"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := filepath.Join(dir, "out")
+	flags := &Flags{Batch: inputs, BatchOutput: outputDir, BatchWorkers: 1, DryRun: true, Redact: true}
+
+	if err = handleBatchProcessing(flags, registry); err != nil {
+		t.Fatalf("handleBatchProcessing() error = %v", err)
+	}
+	result, err := os.ReadFile(filepath.Join(outputDir, "one.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(result), "user:\nWrite to [EMAIL_1]") {
+		t.Errorf("expected the batch request to be redacted, got:\n%s", result)
+	}
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/tools/batch"
+	"github.com/danielmiessler/fabric/internal/tools/youtube"
+)
+
+// usesTranscriptSegments reports whether the YouTube transcript has to be
+// processed per timestamped segment: for a time range, chapters or links.
+func (o *Flags) usesTranscriptSegments() bool {
+	return o.TranscriptStart != "" || o.TranscriptEnd != "" || o.Chapters || o.SplitChapters || o.TimestampLinks
+}
+
+// transcriptFormat renders timestamps for --transcript-with-timestamps and
+// --timestamp-links; chapter headings always link to the video.
+func (o *Flags) transcriptFormat(videoId string) youtube.TranscriptFormat {
+	return youtube.TranscriptFormat{
+		WithTimestamps: o.YouTubeTranscriptWithTimestamps || o.TimestampLinks,
+		VideoId:        videoId,
+		LinkTimestamps: o.TimestampLinks,
+	}
+}
+
+// transcriptRange parses --transcript-start and --transcript-end, an end of 0
+// means until the end of the video.
+func (o *Flags) transcriptRange() (start int, end int, err error) {
+	if o.TranscriptStart != "" {
+		if start, err = youtube.ParseTimeOffset(o.TranscriptStart); err != nil {
+			return
+		}
+	}
+	if o.TranscriptEnd != "" {
+		if end, err = youtube.ParseTimeOffset(o.TranscriptEnd); err != nil {
+			return
+		}
+		if end <= start {
+			err = fmt.Errorf("--transcript-end (%s) must be after --transcript-start (%s)", o.TranscriptEnd, o.TranscriptStart)
+		}
+	}
+	return
+}
+
+// grabTranscriptSegments fetches the timestamped transcript of a video within
+// the requested time range. With --chapters or --split-chapters the segments
+// are also split by the chapters of the video; parts is empty when the video
+// has no chapters.
+func grabTranscriptSegments(flags *Flags, registry *core.PluginRegistry, videoId string) (
+	segments []youtube.TranscriptSegment, parts []youtube.ChapterTranscript, err error) {
+
+	var start, end int
+	if start, end, err = flags.transcriptRange(); err != nil {
+		return
+	}
+
+	var transcript string
+	if transcript, err = registry.YouTube.GrabTranscriptWithTimestampsWithArgs(videoId,
+		transcriptLanguage(flags, registry), flags.YtDlpArgs); err != nil {
+		return
+	}
+	if segments = youtube.FilterSegments(youtube.ParseTimestampedTranscript(transcript), start, end); len(segments) == 0 {
+		err = fmt.Errorf("the transcript of %s has no content between %s and %s", videoId,
+			youtube.FormatTimeOffset(start), rangeEnd(end))
+		return
+	}
+
+	if flags.Chapters || flags.SplitChapters {
+		var chapters []youtube.Chapter
+		if chapters, err = registry.YouTube.GrabChapters(videoId, flags.YtDlpArgs); err != nil {
+			return
+		}
+		if parts = youtube.SplitByChapters(segments, chapters); len(parts) == 0 {
+			fmt.Fprintf(os.Stderr, "Video %s has no chapters, using the whole transcript\n", videoId)
+		}
+	}
+	return
+}
+
+func rangeEnd(end int) string {
+	if end <= 0 {
+		return "the end"
+	}
+	return youtube.FormatTimeOffset(end)
+}
+
+// formatTranscriptSegments renders the transcript of grabTranscriptSegments,
+// with a heading per chapter when there are chapters.
+func formatTranscriptSegments(flags *Flags, registry *core.PluginRegistry, videoId string) (ret string, err error) {
+	var segments []youtube.TranscriptSegment
+	var parts []youtube.ChapterTranscript
+	if segments, parts, err = grabTranscriptSegments(flags, registry, videoId); err != nil {
+		return
+	}
+	format := flags.transcriptFormat(videoId)
+	if len(parts) > 0 {
+		ret = youtube.FormatChapters(parts, format)
+	} else {
+		ret = youtube.FormatSegments(segments, format)
+	}
+	return
+}
+
+// handleChapterSplit runs the pattern once per chapter of a YouTube video and
+// prints the results under the chapter headings. Without a pattern the
+// transcript of each chapter is printed.
+func handleChapterSplit(currentFlags *Flags, registry *core.PluginRegistry) (err error) {
+	if !registry.YouTube.IsConfigured() {
+		return fmt.Errorf("YouTube is not configured, please run the setup procedure")
+	}
+	if currentFlags.Session != "" {
+		return fmt.Errorf("--session cannot be used with --split-chapters, each chapter is processed independently")
+	}
+
+	var videoId string
+	if videoId, _, err = registry.YouTube.GetVideoOrPlaylistId(currentFlags.YouTube); err != nil {
+		return
+	}
+	if videoId == "" || currentFlags.YouTubePlaylist {
+		return fmt.Errorf("--split-chapters requires a single video URL")
+	}
+
+	var segments []youtube.TranscriptSegment
+	var parts []youtube.ChapterTranscript
+	if segments, parts, err = grabTranscriptSegments(currentFlags, registry, videoId); err != nil {
+		return
+	}
+	if len(parts) == 0 {
+		parts = []youtube.ChapterTranscript{{Chapter: youtube.Chapter{Title: "Full video"}, Segments: segments}}
+	}
+
+	var chatter *core.Chatter
+	if currentFlags.IsChatRequest() {
+		if chatter, err = newChatter(currentFlags, registry, false); err != nil {
+			return
+		}
+	}
+
+	language := registry.Language.DefaultLanguage.Value
+	meta := strings.Join(os.Args[1:], " ")
+	format := currentFlags.transcriptFormat(videoId)
+
+	sections := make([]string, 0, len(parts))
+	for i, part := range parts {
+		heading := youtube.FormatChapterHeading(part.Chapter, format)
+		transcript := youtube.FormatSegments(part.Segments, format)
+		if chatter == nil {
+			sections = append(sections, heading+"\n\n"+transcript)
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(parts), part.Chapter.Title)
+		var result string
+		if result, err = processBatchItem(currentFlags, chatter,
+			&batch.Item{ID: part.Chapter.Title, Input: heading + "\n\n" + transcript}, language, meta); err != nil {
+			return fmt.Errorf("chapter %q: %w", part.Chapter.Title, err)
+		}
+		sections = append(sections, heading+"\n\n"+result)
+	}
+
+	err = currentFlags.WriteOutput(strings.Join(sections, "\n\n"))
+	return
+}
@@ -12,6 +12,7 @@ import (
 	debuglog "github.com/danielmiessler/fabric/internal/log"
 	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
 	"github.com/danielmiessler/fabric/internal/tools/notifications"
+	"github.com/danielmiessler/fabric/internal/tools/redact"
 )
 
 // handleChatProcessing handles the main chat processing logic
@@ -33,11 +34,23 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 		}
 	}
 
+	// a text-to-speech model with an audio output file speaks the input
+	if synthesizer, vendorName := inputSynthesizer(currentFlags, registry); synthesizer != nil {
+		return handleSpeakInput(currentFlags, registry, synthesizer, vendorName)
+	}
+
 	var chatter *core.Chatter
-	if chatter, err = registry.GetChatter(currentFlags.Model, currentFlags.ModelContextLength,
-		currentFlags.Vendor, currentFlags.Strategy, currentFlags.Stream, currentFlags.DryRun); err != nil {
+	if chatter, err = newChatter(currentFlags, registry, currentFlags.Stream); err != nil {
+		// an image prompt for an image generator needs no chat model
+		if currentFlags.ImageFile != "" && currentFlags.imagePromptOnly() {
+			return handleImagePrompt(currentFlags, registry)
+		}
 		return
 	}
+	var fileChanges []domain.FileChange
+	chatter.FileChanges = func(changes []domain.FileChange) {
+		fileChanges = changes
+	}
 
 	var session *fsdb.Session
 	var chatReq *domain.ChatRequest
@@ -53,37 +66,23 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 		return
 	}
 
-	// Check if user is requesting audio output or using a TTS model
-	isAudioOutput := currentFlags.Output != "" && IsAudioFormat(currentFlags.Output)
-	isTTSModel := isTTSModel(currentFlags.Model)
-
-	if isTTSModel && !isAudioOutput {
-		err = fmt.Errorf("TTS model '%s' requires audio output. Please specify an audio output file with -o flag (e.g., -o output.wav)", currentFlags.Model)
-		return
-	}
-
-	if isAudioOutput && !isTTSModel {
-		err = fmt.Errorf("audio output file '%s' specified but model '%s' is not a TTS model. Please use a TTS model like gemini-2.5-flash-preview-tts", currentFlags.Output, currentFlags.Model)
-		return
-	}
-
-	// For TTS models, check if output file already exists BEFORE processing
-	if isTTSModel && isAudioOutput {
-		outputFile := currentFlags.Output
-		// Add .wav extension if not provided
-		if filepath.Ext(outputFile) == "" {
-			outputFile += ".wav"
-		}
-		if _, err = os.Stat(outputFile); err == nil {
-			err = fmt.Errorf("file %s already exists. Please choose a different filename or remove the existing file", outputFile)
+	// Check the speech output file BEFORE processing
+	speakFile := currentFlags.speakFile()
+	if speakFile != "" {
+		if _, err = os.Stat(speakFile); err == nil {
+			err = fmt.Errorf("file %s already exists. Please choose a different filename or remove the existing file", speakFile)
 			return
 		}
 	}
 
-	// Set audio options in chat config
-	chatOptions.AudioOutput = isAudioOutput
-	if isAudioOutput {
-		chatOptions.AudioFormat = "wav" // Default to WAV format
+	// Images are created by the image generation tool of the chat model or
+	// by an image generator after the chat
+	imageStage := currentFlags.useImageStage(registry, chatter)
+	if imageStage {
+		chatOptions.ImageFile = ""
+		if currentFlags.imagePromptOnly() {
+			return handleImagePrompt(currentFlags, registry)
+		}
 	}
 
 	if session, err = chatter.Send(chatReq, chatOptions); err != nil {
@@ -93,13 +92,8 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 	result := session.GetLastMessage().Content
 
 	if !currentFlags.Stream || currentFlags.SuppressThink {
-		// For TTS models with audio output, show a user-friendly message instead of raw data
-		if isTTSModel && isAudioOutput && strings.HasPrefix(result, "FABRIC_AUDIO_DATA:") {
-			fmt.Printf("TTS audio generated successfully and saved to: %s\n", currentFlags.Output)
-		} else {
-			// print the result if it was not streamed already or suppress-think disabled streaming output
-			fmt.Println(result)
-		}
+		// print the result if it was not streamed already or suppress-think disabled streaming output
+		fmt.Println(result)
 	}
 
 	// if the copy flag is set, copy the message to the clipboard
@@ -109,26 +103,34 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 		}
 	}
 
-	// if the output flag is set, create an output file
-	if currentFlags.Output != "" {
+	// if the output flag is set, create an output file, audio output files are written by the speech stage
+	if currentFlags.Output != "" && currentFlags.Output != speakFile {
 		if currentFlags.OutputSession {
 			sessionAsString := session.String()
 			err = CreateOutputFile(sessionAsString, currentFlags.Output)
 		} else {
-			// For TTS models, we need to handle audio output differently
-			if isTTSModel && isAudioOutput {
-				// Check if result contains actual audio data
-				if strings.HasPrefix(result, "FABRIC_AUDIO_DATA:") {
-					// Extract the binary audio data
-					audioData := result[len("FABRIC_AUDIO_DATA:"):]
-					err = CreateAudioOutputFile([]byte(audioData), currentFlags.Output)
-				} else {
-					// Fallback for any error messages or unexpected responses
-					err = CreateOutputFile(result, currentFlags.Output)
-				}
-			} else {
-				err = CreateOutputFile(result, currentFlags.Output)
-			}
+			err = CreateOutputFile(result, currentFlags.Output)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	if len(fileChanges) > 0 {
+		if err = handleFileChanges(currentFlags, fileChanges); err != nil {
+			return
+		}
+	}
+
+	if imageStage {
+		if err = handleImage(currentFlags, registry, result); err != nil {
+			return
+		}
+	}
+
+	if speakFile != "" {
+		if err = handleSpeak(currentFlags, registry, result, speakFile); err != nil {
+			return
 		}
 	}
 
@@ -143,6 +145,37 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 	return
 }
 
+// newChatter returns the chatter of the model, vendor and strategy flags,
+// redacting when --redact is set. Every chat entry point uses it.
+func newChatter(flags *Flags, registry *core.PluginRegistry, stream bool) (ret *core.Chatter, err error) {
+	if ret, err = registry.GetChatter(flags.Model, flags.ModelContextLength,
+		flags.Vendor, flags.Strategy, stream, flags.DryRun); err != nil {
+		return
+	}
+	if flags.Redact {
+		ret.Redaction = ret.Redaction.ForceEnabled()
+	}
+	return
+}
+
+// redactForVendor applies the redaction rules of a vendor to text sent
+// outside a chat, like image prompts and speech, as chat requests are redacted
+// for the chat vendor. The redactor is nil when redaction is disabled.
+func redactForVendor(flags *Flags, registry *core.PluginRegistry, vendorName string, text string) (ret string, redactor *redact.Redactor, err error) {
+	var config *redact.Config
+	if config, err = redact.LoadConfig(filepath.Join(registry.Db.Dir, redact.ConfigFileName)); err != nil {
+		return
+	}
+	if flags.Redact {
+		config = config.ForceEnabled()
+	}
+	if redactor, err = config.ForVendor(vendorName); err != nil || redactor == nil {
+		return text, nil, err
+	}
+	ret = redactor.Redact(text)
+	return
+}
+
 // sendNotification sends a desktop notification about command completion.
 //
 // When truncating the result for notification display, this function counts Unicode code points,
@@ -189,11 +222,3 @@ func sendNotification(options *domain.ChatOptions, patternName, result string) e
 
 	return notificationManager.Send(title, message)
 }
-
-// isTTSModel checks if the model is a text-to-speech model
-func isTTSModel(modelName string) bool {
-	lowerModel := strings.ToLower(modelName)
-	return strings.Contains(lowerModel, "tts") ||
-		strings.Contains(lowerModel, "preview-tts") ||
-		strings.Contains(lowerModel, "text-to-speech")
-}
@@ -3,21 +3,28 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/danielmiessler/fabric/internal/core"
 	debuglog "github.com/danielmiessler/fabric/internal/log"
 	"github.com/danielmiessler/fabric/internal/plugins/ai/openai"
+	"github.com/danielmiessler/fabric/internal/plugins/template"
 	"github.com/danielmiessler/fabric/internal/tools/converter"
 	"github.com/danielmiessler/fabric/internal/tools/youtube"
 )
 
 // Cli Controls the cli. It takes in the flags and runs the appropriate functions
 func Cli(version string) (err error) {
+	if len(os.Args) > 1 && os.Args[1] == EvalCommand {
+		return handleEvalCommand(os.Args[2:])
+	}
+
 	var currentFlags *Flags
 	if currentFlags, err = Init(); err != nil {
 		return
 	}
+	defer template.ShutdownExtensions()
 
 	if currentFlags.Setup {
 		if err = ensureEnvFile(); err != nil {
@@ -30,6 +37,18 @@ func Cli(version string) (err error) {
 		return
 	}
 
+	if err = currentFlags.checkFileChangeFlags(); err != nil {
+		return
+	}
+	if currentFlags.UndoChanges {
+		err = handleUndoChanges(currentFlags)
+		return
+	}
+
+	if err = configureTemplatePlugins(currentFlags); err != nil {
+		return
+	}
+
 	// Initialize database and registry
 	var registry, err2 = initializeFabric()
 	if err2 != nil {
@@ -74,6 +93,31 @@ func Cli(version string) (err error) {
 		return
 	}
 
+	// Handle document collection commands
+	if handled, err = handleCollectionCommands(currentFlags, registry); err != nil || handled {
+		return
+	}
+
+	registry.YouTube.RefreshCache = currentFlags.RefreshCache
+
+	// Handle playlist batch processing, each video is processed on its own
+	if currentFlags.YouTube != "" && currentFlags.BatchOutput != "" {
+		err = handlePlaylistBatch(currentFlags, registry)
+		return
+	}
+
+	// Handle chapter splitting, each chapter is sent as its own chat request
+	if currentFlags.YouTube != "" && currentFlags.SplitChapters {
+		err = handleChapterSplit(currentFlags, registry)
+		return
+	}
+
+	// Handle batch processing, each item is sent as its own chat request
+	if currentFlags.Batch != "" {
+		err = handleBatchProcessing(currentFlags, registry)
+		return
+	}
+
 	// Handle transcription if specified
 	if currentFlags.TranscribeFile != "" {
 		var transcriptionMessage string
@@ -83,6 +127,13 @@ func Cli(version string) (err error) {
 		currentFlags.Message = AppendMessage(currentFlags.Message, transcriptionMessage)
 	}
 
+	// Send the files of a project with the message as instructions
+	if currentFlags.Project != "" {
+		if err = handleProject(currentFlags); err != nil {
+			return
+		}
+	}
+
 	// Process HTML readability if needed
 	if currentFlags.HtmlReadability {
 		if msg, cleanErr := converter.HtmlReadability(currentFlags.Message); cleanErr != nil {
@@ -92,6 +143,14 @@ func Cli(version string) (err error) {
 		}
 	}
 
+	// Turn video attachments into frames and attach the screenshot
+	var cleanupVision func()
+	if cleanupVision, err = handleVisionAttachments(currentFlags, registry); err != nil {
+		cleanupVision()
+		return
+	}
+	defer cleanupVision()
+
 	// Handle tool-based message processing
 	var messageTools string
 	if messageTools, err = handleToolProcessing(currentFlags, registry); err != nil {
@@ -108,20 +167,29 @@ func Cli(version string) (err error) {
 	return
 }
 
+// transcriptLanguage returns the language to grab transcripts in: the
+// --language flag, the default language, or English.
+func transcriptLanguage(flags *Flags, registry *core.PluginRegistry) string {
+	if flags.Language != "" {
+		return flags.Language
+	}
+	if registry.Language.DefaultLanguage.Value != "" {
+		return registry.Language.DefaultLanguage.Value
+	}
+	return "en"
+}
+
 func processYoutubeVideo(
 	flags *Flags, registry *core.PluginRegistry, videoId string) (message string, err error) {
 
 	if (!flags.YouTubeComments && !flags.YouTubeMetadata) || flags.YouTubeTranscript || flags.YouTubeTranscriptWithTimestamps {
 		var transcript string
-		var language = "en"
-		if flags.Language != "" || registry.Language.DefaultLanguage.Value != "" {
-			if flags.Language != "" {
-				language = flags.Language
-			} else {
-				language = registry.Language.DefaultLanguage.Value
+		language := transcriptLanguage(flags, registry)
+		if flags.usesTranscriptSegments() {
+			if transcript, err = formatTranscriptSegments(flags, registry, videoId); err != nil {
+				return
 			}
-		}
-		if flags.YouTubeTranscriptWithTimestamps {
+		} else if flags.YouTubeTranscriptWithTimestamps {
 			if transcript, err = registry.YouTube.GrabTranscriptWithTimestampsWithArgs(videoId, language, flags.YtDlpArgs); err != nil {
 				return
 			}
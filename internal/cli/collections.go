@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/tools/rag"
+)
+
+// handleCollectionCommands handles document collection commands used for retrieval
+// Returns (handled, error) where handled indicates if a command was processed and should exit
+func handleCollectionCommands(currentFlags *Flags, registry *core.PluginRegistry) (handled bool, err error) {
+	if currentFlags.ListCollections {
+		err = registry.Db.Collections.ListNames(currentFlags.ShellCompleteOutput)
+		return true, err
+	}
+
+	if currentFlags.PrintCollection != "" {
+		err = registry.Db.Collections.PrintCollection(currentFlags.PrintCollection)
+		return true, err
+	}
+
+	if currentFlags.WipeCollection != "" {
+		err = registry.Db.Collections.Delete(currentFlags.WipeCollection)
+		return true, err
+	}
+
+	if currentFlags.IndexCollection != "" {
+		err = indexCollection(currentFlags.IndexCollection, strings.TrimSpace(currentFlags.Message), registry)
+		return true, err
+	}
+
+	return false, nil
+}
+
+// indexCollection indexes root into the named collection. When root is empty the
+// directory the collection was last indexed from is used.
+func indexCollection(name string, root string, registry *core.PluginRegistry) (err error) {
+	var collection *fsdb.Collection
+	if collection, err = registry.Db.Collections.GetOrCreate(name); err != nil {
+		return
+	}
+	if root == "" {
+		if root = collection.Root; root == "" {
+			return fmt.Errorf("no directory given for new collection %s, use --index-collection %s <dir>", name, name)
+		}
+	}
+
+	var embedder ai.Embedder
+	var model string
+	if embedder, model, err = registry.RAG.Embedder(); err != nil {
+		return
+	}
+
+	indexer := &rag.Indexer{
+		Embedder:       embedder,
+		EmbeddingModel: model,
+		Chunker:        registry.RAG.Chunker(),
+	}
+
+	var stats rag.IndexStats
+	if stats, err = indexer.Index(context.Background(), collection, root); err != nil {
+		return
+	}
+	if err = registry.Db.Collections.SaveCollection(collection); err != nil {
+		return
+	}
+	fmt.Printf("Indexed collection %s from %s: %s\n", name, collection.Root, stats)
+	return
+}
@@ -1,9 +1,18 @@
 package cli
 
 import (
+	"strings"
+
 	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/plugins/template"
 )
 
+// ServerDisabledTemplatePlugins are the template plugin namespaces disabled
+// when serving, unless --disable-template-plugins says otherwise. They expose
+// local files, repository contents and environment variables to whoever
+// can send a request.
+const ServerDisabledTemplatePlugins = "sys,file,git"
+
 // handleConfigurationCommands handles configuration-related commands
 // Returns (handled, error) where handled indicates if a command was processed and should exit
 func handleConfigurationCommands(currentFlags *Flags, registry *core.PluginRegistry) (handled bool, err error) {
@@ -26,3 +35,19 @@ func handleConfigurationCommands(currentFlags *Flags, registry *core.PluginRegis
 
 	return false, nil
 }
+
+// configureTemplatePlugins applies the template plugin policy of the flags
+func configureTemplatePlugins(currentFlags *Flags) error {
+	policy := currentFlags.DisableTemplatePlugins
+	if policy == "" && (currentFlags.Serve || currentFlags.ServeOllama) {
+		policy = ServerDisabledTemplatePlugins
+	}
+
+	var namespaces []string
+	for _, namespace := range strings.Split(policy, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" && namespace != "none" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return template.SetDisabledPlugins(namespaces)
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/tools/eval"
+)
+
+// EvalCommand is the first argument selecting the eval command instead of a chat.
+const EvalCommand = "eval"
+
+// EvalFlags are the options of `fabric eval [suite|pattern|dir ...]`.
+type EvalFlags struct {
+	Models      string  `short:"m" long:"models" description:"Comma separated models to evaluate (default: the configured default model)"`
+	Vendor      string  `short:"V" long:"vendor" description:"Vendor of the evaluated models"`
+	JudgeModel  string  `long:"judge-model" description:"Model grading rubric assertions"`
+	JudgeVendor string  `long:"judge-vendor" description:"Vendor of the judge model"`
+	Temperature float64 `short:"t" long:"temperature" description:"Temperature used for evaluated models" default:"0"`
+	SuitesDir   string  `long:"suites-dir" description:"Directory of suites (default: ~/.config/fabric/evals)"`
+	ReportDir   string  `long:"report-dir" description:"Directory for reports (default: <suites-dir>/reports)"`
+}
+
+// handleEvalCommand parses the eval options, runs the selected suites and
+// writes a report per suite, compared with the previous run of that suite.
+func handleEvalCommand(args []string) (err error) {
+	evalFlags := &EvalFlags{}
+	parser := flags.NewParser(evalFlags, flags.Default)
+	parser.Usage = "eval [OPTIONS] [suite.yaml|pattern|directory ...]"
+	if args, err = parser.ParseArgs(args); err != nil {
+		return
+	}
+
+	var registry *core.PluginRegistry
+	if registry, err = initializeFabric(); err != nil {
+		return
+	}
+
+	if evalFlags.SuitesDir == "" {
+		evalFlags.SuitesDir = filepath.Join(registry.Db.Dir, "evals")
+	}
+	if evalFlags.ReportDir == "" {
+		evalFlags.ReportDir = filepath.Join(evalFlags.SuitesDir, "reports")
+	}
+
+	var suitePaths []string
+	if suitePaths, err = eval.FindSuites(evalFlags.SuitesDir, args); err != nil {
+		return
+	}
+
+	models := splitModels(evalFlags.Models)
+	if len(models) == 0 {
+		models = []string{registry.Defaults.Model.Value}
+	}
+
+	runner := &eval.Runner{
+		Models:     models,
+		JudgeModel: evalFlags.JudgeModel,
+		Run: func(model string, suite *eval.Suite, c *eval.Case) (string, error) {
+			return evalSend(registry, model, evalFlags.Vendor, evalFlags.Temperature, &domain.ChatRequest{
+				PatternName:      suite.Pattern,
+				PatternVariables: c.Variables,
+				Message:          &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: c.Input},
+			})
+		},
+		Judge: func(model string) (eval.CompleteFunc, error) {
+			return func(prompt string) (string, error) {
+				return evalSend(registry, model, evalFlags.JudgeVendor, 0, &domain.ChatRequest{
+					Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: prompt},
+				})
+			}, nil
+		},
+		Progress: func(model string, result *eval.CaseResult) {
+			status := "PASS"
+			if !result.Passed {
+				status = "FAIL"
+			}
+			fmt.Fprintf(os.Stderr, "[%s] %s %s\n", model, status, result.Name)
+		},
+	}
+
+	for _, path := range suitePaths {
+		var suite *eval.Suite
+		if suite, err = eval.LoadSuite(path); err != nil {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Running suite %s (pattern %s, %d cases)\n", suite.Name, suite.Pattern, len(suite.Cases))
+
+		var report *eval.Report
+		if report, err = runner.RunSuite(suite); err != nil {
+			return
+		}
+
+		var previous *eval.Report
+		if previous, err = eval.LoadLatestReport(evalFlags.ReportDir, suite.Name); err != nil {
+			return fmt.Errorf("could not read previous report of %s: %w", suite.Name, err)
+		}
+		report.Compare(previous)
+
+		var reportPath string
+		if reportPath, err = report.Save(evalFlags.ReportDir); err != nil {
+			return
+		}
+		for _, m := range report.Models {
+			fmt.Printf("%s\t%s\t%d/%d passed (%.0f%%)\n", suite.Name, m.Model, m.Passed, m.Total, m.PassRate*100)
+		}
+		fmt.Printf("Report: %s\n", reportPath)
+	}
+	return
+}
+
+// evalSend runs one non-streaming chat request and returns the response text.
+func evalSend(registry *core.PluginRegistry, model string, vendor string, temperature float64, request *domain.ChatRequest) (ret string, err error) {
+	var chatter *core.Chatter
+	if chatter, err = registry.GetChatter(model, 0, vendor, "", false, false); err != nil {
+		return
+	}
+	opts := &domain.ChatOptions{Model: model, Temperature: temperature, ThinkStartTag: "<think>", ThinkEndTag: "</think>"}
+
+	session, err := chatter.Send(request, opts)
+	if err != nil {
+		return
+	}
+	ret = session.GetLastMessage().Content
+	return
+}
+
+func splitModels(value string) (ret []string) {
+	for _, model := range strings.Split(value, ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			ret = append(ret, model)
+		}
+	}
+	return
+}
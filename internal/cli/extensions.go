@@ -17,6 +17,16 @@ func handleExtensionCommands(currentFlags *Flags, registry *core.PluginRegistry)
 		return true, err
 	}
 
+	if currentFlags.InstallExtension != "" {
+		err = registry.TemplateExtensions.InstallExtension(currentFlags.InstallExtension)
+		return true, err
+	}
+
+	if currentFlags.UpdateExtension != "" {
+		err = registry.TemplateExtensions.UpdateExtension(currentFlags.UpdateExtension)
+		return true, err
+	}
+
 	if currentFlags.RemoveExtension != "" {
 		err = registry.TemplateExtensions.RemoveExtension(currentFlags.RemoveExtension)
 		return true, err
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/tools/codechanges"
+	"github.com/danielmiessler/fabric/internal/tools/githelper"
+)
+
+// checkFileChangeFlags rejects contradicting file change flags.
+func (o *Flags) checkFileChangeFlags() error {
+	if o.Apply && o.NoApply {
+		return fmt.Errorf("--apply and --no-apply cannot be used together")
+	}
+	return nil
+}
+
+// projectDir returns the directory file changes apply to: --project or the
+// current directory.
+func (o *Flags) projectDir() string {
+	if o.Project != "" {
+		return o.Project
+	}
+	return "."
+}
+
+// handleFileChanges shows the file changes emitted by a pattern as a diff
+// and applies them to the git worktree of the current directory, or of the
+// --project directory, once approved, with --apply or interactively.
+func handleFileChanges(flags *Flags, changes []domain.FileChange) (err error) {
+	dir := flags.projectDir()
+	var worktree *githelper.Worktree
+	if worktree, err = githelper.OpenWorktree(dir); err != nil {
+		return fmt.Errorf("file changes are only applied inside a git worktree: %w", err)
+	}
+	if changes, err = projectChanges(worktree, dir, changes); err != nil {
+		return
+	}
+	var plan *codechanges.Plan
+	if plan, err = codechanges.NewPlan(worktree, changes); err != nil {
+		return fmt.Errorf("file changes not applied: %w", err)
+	}
+	if len(plan.Changed()) == 0 {
+		fmt.Fprintln(os.Stderr, "The file changes do not change any file.")
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\nFile changes:\n%s\n\n%s\n", plan.Summary(), plan.Diff())
+	if flags.NoApply {
+		return
+	}
+	if !flags.Apply {
+		var approved bool
+		if approved, err = confirm("Apply these changes? [y/N] "); err != nil {
+			fmt.Fprintf(os.Stderr, "Not applied, cannot ask for approval (%v). Use --apply to apply file changes without asking.\n", err)
+			return nil
+		}
+		if !approved {
+			fmt.Fprintln(os.Stderr, "Not applied.")
+			return
+		}
+	}
+
+	var applied []*codechanges.Edit
+	if applied, err = plan.Apply(); err != nil {
+		return fmt.Errorf("failed to apply the file changes: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Applied %d file changes. Review them with 'git diff' or restore the previous state with 'fabric --undo-changes'.\n", len(applied))
+	return
+}
+
+// projectChanges makes the paths of the changes, which are relative to dir,
+// relative to the root of the worktree.
+func projectChanges(worktree *githelper.Worktree, dir string, changes []domain.FileChange) (ret []domain.FileChange, err error) {
+	var absDir, prefix string
+	if absDir, err = filepath.Abs(dir); err != nil {
+		return
+	}
+	if prefix, err = filepath.Rel(worktree.Root, absDir); err != nil {
+		return
+	}
+	if prefix == "." {
+		return changes, nil
+	}
+	prefix = filepath.ToSlash(prefix)
+	inProject := func(relPath string) (string, error) {
+		joined := path.Join(prefix, filepath.ToSlash(relPath))
+		if filepath.IsAbs(relPath) || path.IsAbs(relPath) || !strings.HasPrefix(joined, prefix+"/") {
+			return "", fmt.Errorf("file changes not applied: %s is outside the project %s", relPath, dir)
+		}
+		return joined, nil
+	}
+	for _, change := range changes {
+		if change.Path, err = inProject(change.Path); err != nil {
+			return nil, err
+		}
+		if change.NewPath != "" {
+			if change.NewPath, err = inProject(change.NewPath); err != nil {
+				return nil, err
+			}
+		}
+		ret = append(ret, change)
+	}
+	return
+}
+
+// handleUndoChanges restores the files changed by the last applied file
+// changes of the git worktree of the current directory, or of the --project
+// directory.
+func handleUndoChanges(flags *Flags) (err error) {
+	var worktree *githelper.Worktree
+	if worktree, err = githelper.OpenWorktree(flags.projectDir()); err != nil {
+		return
+	}
+	var restored []string
+	if restored, err = codechanges.Undo(worktree); err != nil {
+		return
+	}
+	fmt.Printf("Restored %d files:\n%s\n", len(restored), strings.Join(restored, "\n"))
+	return
+}
+
+// confirm asks a yes or no question on the terminal, stdin may carry the input
+// of the chat.
+func confirm(question string) (ret bool, err error) {
+	var tty *os.File
+	if tty, err = os.OpenFile("/dev/tty", os.O_RDWR, 0); err != nil {
+		return
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, question)
+	var answer string
+	if answer, err = bufio.NewReader(tty).ReadString('\n'); err != nil {
+		return
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	ret = answer == "y" || answer == "yes"
+	return
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -28,7 +29,17 @@ type Flags struct {
 	PatternVariables                map[string]string    `short:"v" long:"variable" description:"Values for pattern variables, e.g. -v=#role:expert -v=#points:30"`
 	Context                         string               `short:"C" long:"context" description:"Choose a context from the available contexts" default:""`
 	Session                         string               `long:"session" description:"Choose a session from the available sessions"`
-	Attachments                     []string             `short:"a" long:"attachment" description:"Attachment path or URL (e.g. for OpenAI image recognition messages)"`
+	Attachments                     []string             `short:"a" long:"attachment" description:"Attachment path or URL: images, videos (sampled into frames with ffmpeg), PDFs, text or source files (documents are converted to text for vendors without native support)"`
+	Screenshot                      string               `long:"screenshot" optional:"yes" optional-value:"screen" description:"Attach a screenshot of the screen, the active window or a region selected with the mouse (screen, window, region; Linux X11/Wayland tools)"`
+	VideoFPS                        float64              `long:"video-fps" yaml:"videoFps" description:"Frames per second sampled from video attachments, lowered to fit --video-max-frames (default: 1)"`
+	VideoMaxFrames                  int                  `long:"video-max-frames" yaml:"videoMaxFrames" description:"Maximum number of frames sampled from each video attachment (default: 20)"`
+	VideoTranscript                 bool                 `long:"video-transcript" yaml:"videoTranscript" description:"Add a transcript of the audio of video attachments, using the transcription vendor"`
+	Project                         string               `long:"project" description:"Use the files of a project directory as input, honoring .gitignore and skipping binaries, with the message as instructions (the input of create_coding_feature)"`
+	ProjectInclude                  []string             `long:"project-include" description:"Only include project files matching this gitignore style glob, e.g. 'src/**/*.go' (repeatable)"`
+	ProjectExclude                  []string             `long:"project-exclude" description:"Leave out project files matching this gitignore style glob (repeatable)"`
+	ProjectLanguages                []string             `long:"project-lang" description:"Only include project files of this language, e.g. go, python or an extension like .proto (repeatable)"`
+	ProjectMaxTokens                int                  `long:"project-max-tokens" yaml:"projectMaxTokens" description:"Token budget of the project files, keeping the files most relevant to the message and recently changed in git (default: 100000, -1 for no limit)"`
+	ProjectFormat                   string               `long:"project-format" yaml:"projectFormat" description:"Format of the project input: json or markdown" default:"json"`
 	Setup                           bool                 `short:"S" long:"setup" description:"Run setup for all reconfigurable parts of fabric"`
 	Temperature                     float64              `short:"t" long:"temperature" yaml:"temperature" description:"Set temperature" default:"0.7"`
 	TopP                            float64              `short:"T" long:"topp" yaml:"topp" description:"Set top P" default:"0.9"`
@@ -56,9 +67,17 @@ type Flags struct {
 	YouTubeTranscriptWithTimestamps bool                 `long:"transcript-with-timestamps" description:"Grab transcript from YouTube video with timestamps and send to chat"`
 	YouTubeComments                 bool                 `long:"comments" description:"Grab comments from YouTube video and send to chat"`
 	YouTubeMetadata                 bool                 `long:"metadata" description:"Output video metadata"`
+	TranscriptStart                 string               `long:"transcript-start" description:"Only use the YouTube transcript from this time on (seconds, MM:SS or HH:MM:SS)"`
+	TranscriptEnd                   string               `long:"transcript-end" description:"Only use the YouTube transcript up to this time (seconds, MM:SS or HH:MM:SS)"`
+	Chapters                        bool                 `long:"chapters" description:"Fetch the YouTube video chapters and insert them as headings into the transcript"`
+	SplitChapters                   bool                 `long:"split-chapters" description:"Run the pattern once per YouTube video chapter"`
+	TimestampLinks                  bool                 `long:"timestamp-links" description:"Link transcript timestamps to the YouTube video at that time (&t= URLs)"`
+	MediaURL                        string               `long:"media-url" description:"Any yt-dlp supported media \"URL\" (Vimeo, podcasts, conference sites, ...) to grab the transcript from subtitles"`
+	Subtitles                       string               `long:"subtitles" description:"Local .vtt or .srt subtitle file to use as transcript"`
 	YtDlpArgs                       string               `long:"yt-dlp-args" yaml:"ytDlpArgs" description:"Additional arguments to pass to yt-dlp (e.g. '--cookies-from-browser brave')"`
+	RefreshCache                    bool                 `long:"refresh-cache" description:"Fetch YouTube transcripts and metadata again instead of using the transcript cache"`
 	Language                        string               `short:"g" long:"language" description:"Specify the Language Code for the chat, e.g. -g=en -g=zh" default:""`
-	ScrapeURL                       string               `short:"u" long:"scrape_url" description:"Scrape website URL to markdown using Jina AI"`
+	ScrapeURL                       string               `short:"u" long:"scrape_url" description:"Scrape website URL to markdown using Jina AI or the native scraper"`
 	ScrapeQuestion                  string               `short:"q" long:"scrape_question" description:"Search question using Jina AI"`
 	Seed                            int                  `short:"e" long:"seed" yaml:"seed" description:"Seed to be used for LMM generation"`
 	WipeContext                     string               `short:"w" long:"wipecontext" description:"Wipe context"`
@@ -69,6 +88,10 @@ type Flags struct {
 	InputHasVars                    bool                 `long:"input-has-vars" description:"Apply variables to user input"`
 	NoVariableReplacement           bool                 `long:"no-variable-replacement" description:"Disable pattern variable replacement"`
 	DryRun                          bool                 `long:"dry-run" description:"Show what would be sent to the model without actually sending it"`
+	Apply                           bool                 `long:"apply" description:"Apply the file changes of patterns like create_coding_feature without asking for approval"`
+	NoApply                         bool                 `long:"no-apply" description:"Only show the file changes of patterns like create_coding_feature as a diff, do not apply them"`
+	UndoChanges                     bool                 `long:"undo-changes" description:"Restore the files changed by the last applied file changes in the git worktree of the current or --project directory"`
+	Redact                          bool                 `long:"redact" yaml:"redact" description:"Redact secrets and personal data before sending, with the rules of redaction.yaml or the defaults"`
 	Serve                           bool                 `long:"serve" description:"Serve the Fabric Rest API"`
 	ServeOllama                     bool                 `long:"serveOllama" description:"Serve the Fabric Rest API with ollama endpoints"`
 	ServeAddress                    string               `long:"address" description:"The address to bind the REST API" default:":8080"`
@@ -78,14 +101,21 @@ type Flags struct {
 	ListExtensions                  bool                 `long:"listextensions" description:"List all registered extensions"`
 	AddExtension                    string               `long:"addextension" description:"Register a new extension from config file path"`
 	RemoveExtension                 string               `long:"rmextension" description:"Remove a registered extension by name"`
+	InstallExtension                string               `long:"installextension" description:"Install an extension bundle from a git URL (optionally #ref), tarball or directory"`
+	UpdateExtension                 string               `long:"updateextension" description:"Update an installed extension from its source and re-pin its hashes"`
+	ListTemplatePlugins             bool                 `long:"list-template-plugins" description:"List template plugin namespaces, their operations and whether they are enabled"`
+	DisableTemplatePlugins          string               `long:"disable-template-plugins" yaml:"disableTemplatePlugins" description:"Comma separated template plugin namespaces to disable (default with --serve/--serveOllama: sys,file,git; 'none' enables all)"`
 	Strategy                        string               `long:"strategy" description:"Choose a strategy from the available strategies" default:""`
 	ListStrategies                  bool                 `long:"liststrategies" description:"List all strategies"`
 	ListVendors                     bool                 `long:"listvendors" description:"List all vendors"`
 	ShellCompleteOutput             bool                 `long:"shell-complete-list" description:"Output raw list without headers/formatting (for shell completion)"`
-	Search                          bool                 `long:"search" description:"Enable web search tool for supported models (Anthropic, OpenAI, Gemini)"`
+	Search                          bool                 `long:"search" description:"Enable web search (native for Anthropic, OpenAI, Gemini; configured search provider for other vendors)"`
 	SearchLocation                  string               `long:"search-location" description:"Set location for web search results (e.g., 'America/Los_Angeles')"`
 	ImageFile                       string               `long:"image-file" description:"Save generated image to specified file path (e.g., 'output.png')"`
-	ImageSize                       string               `long:"image-size" description:"Image dimensions: 1024x1024, 1536x1024, 1024x1536, auto (default: auto)"`
+	ImageVendor                     string               `long:"image-vendor" description:"Vendor to generate --image-file with (e.g. OpenAI, Gemini, or endpoint for the configured OpenAI-compatible server)"`
+	ImageModel                      string               `long:"image-model" description:"Image model for --image-file (e.g. gpt-image-1, dall-e-3, gemini-2.5-flash-image-preview)"`
+	ImageCount                      int                  `long:"image-count" description:"Number of images to generate, saved as numbered files (default: 1)"`
+	ImageSize                       string               `long:"image-size" description:"Image dimensions: 1024x1024, 1536x1024, 1024x1536, auto, or any WIDTHxHEIGHT the image model supports (default: auto)"`
 	ImageQuality                    string               `long:"image-quality" description:"Image quality: low, medium, high, auto (default: auto)"`
 	ImageCompression                int                  `long:"image-compression" description:"Compression level 0-100 for JPEG/WebP formats (default: not set)"`
 	ImageBackground                 string               `long:"image-background" description:"Background type: opaque, transparent (default: opaque, only for PNG/WebP)"`
@@ -95,13 +125,35 @@ type Flags struct {
 	DisableResponsesAPI             bool                 `long:"disable-responses-api" yaml:"disableResponsesAPI" description:"Disable OpenAI Responses API (default: false)"`
 	TranscribeFile                  string               `long:"transcribe-file" yaml:"transcribeFile" description:"Audio or video file to transcribe"`
 	TranscribeModel                 string               `long:"transcribe-model" yaml:"transcribeModel" description:"Model to use for transcription (separate from chat model)"`
-	SplitMediaFile                  bool                 `long:"split-media-file" yaml:"splitMediaFile" description:"Split audio/video files larger than 25MB using ffmpeg"`
-	Voice                           string               `long:"voice" yaml:"voice" description:"TTS voice name for supported models (e.g., Kore, Charon, Puck)" default:"Kore"`
+	TranscribeVendor                string               `long:"transcribe-vendor" yaml:"transcribeVendor" description:"Vendor to use for transcription (e.g. OpenAI, Gemini, Groq, or endpoint for the configured OpenAI-compatible server)"`
+	TranscribeFormat                string               `long:"transcribe-format" yaml:"transcribeFormat" description:"Transcription output format: text, timestamps, srt, vtt, speakers (speaker-labeled turns), json (segments with times, speakers and confidence)" default:"text"`
+	TranscribeSpeakers              bool                 `long:"transcribe-speakers" yaml:"transcribeSpeakers" description:"Request speaker labels (diarization) from transcription backends supporting it"`
+	SplitMediaFile                  bool                 `long:"split-media-file" yaml:"splitMediaFile" description:"Split audio/video files larger than the size limit of the transcription vendor using ffmpeg"`
+	Speak                           string               `long:"speak" yaml:"speak" description:"Speak the output with a text-to-speech vendor and write it to this audio \"file\" (.wav, or .mp3 and others with ffmpeg)"`
+	SpeakVendor                     string               `long:"speak-vendor" yaml:"speakVendor" description:"Vendor to use for --speak (e.g. OpenAI, Gemini, or endpoint for the configured OpenAI-compatible server)"`
+	SpeakModel                      string               `long:"speak-model" yaml:"speakModel" description:"Text-to-speech model for --speak (e.g. gpt-4o-mini-tts, gemini-2.5-flash-preview-tts)"`
+	Voice                           string               `long:"voice" yaml:"voice" description:"Voice for --speak (e.g., Kore, Charon, Puck for Gemini, alloy for OpenAI)"`
 	ListGeminiVoices                bool                 `long:"list-gemini-voices" description:"List all available Gemini TTS voices"`
 	ListTranscriptionModels         bool                 `long:"list-transcription-models" description:"List all available transcription models"`
 	Notification                    bool                 `long:"notification" yaml:"notification" description:"Send desktop notification when command completes"`
 	NotificationCommand             string               `long:"notification-command" yaml:"notificationCommand" description:"Custom command to run for notifications (overrides built-in notifications)"`
 	Thinking                        domain.ThinkingLevel `long:"thinking" yaml:"thinking" description:"Set reasoning/thinking level (e.g., off, low, medium, high, or numeric tokens for Anthropic or Google Gemini)"`
+	IndexCollection                 string               `long:"index-collection" description:"Index (or incrementally re-index) a document collection, e.g. --index-collection docs ./path"`
+	Rag                             string               `long:"rag" description:"Inject the most relevant chunks of an indexed collection into the system message"`
+	RagTopK                         int                  `long:"rag-top-k" yaml:"ragTopK" description:"Number of chunks to retrieve with --rag" default:"5"`
+	ListCollections                 bool                 `long:"listcollections" description:"List all indexed collections"`
+	PrintCollection                 string               `long:"printcollection" description:"Print the files and stats of an indexed collection"`
+	WipeCollection                  string               `long:"wipecollection" description:"Wipe an indexed collection"`
+	Batch                           string               `long:"batch" description:"Run the pattern over many inputs: a file glob/directory (one item per file) or a .jsonl file of {\"id\",\"input\",\"variables\"} lines"`
+	BatchOutput                     string               `long:"batch-output" description:"Batch output: a directory (one file per item plus manifest.jsonl) or a .jsonl file; completed items are skipped on re-run. With a -y playlist each video is processed into this directory"`
+	BatchWorkers                    int                  `long:"batch-workers" yaml:"batchWorkers" description:"Number of concurrent batch workers" default:"4"`
+	BatchRate                       int                  `long:"batch-rate" yaml:"batchRate" description:"Maximum number of batch requests started per minute (0 = unlimited)" default:"0"`
+	MapReduce                       bool                 `long:"map-reduce" yaml:"mapReduce" description:"Split inputs larger than the model budget into chunks, run the pattern per chunk and reduce the partial results"`
+	MapPattern                      string               `long:"map-pattern" description:"Pattern applied to each chunk in map-reduce mode (default: --pattern)"`
+	ReducePattern                   string               `long:"reduce-pattern" description:"Pattern applied to the combined partial results in map-reduce mode (default: --pattern)"`
+	ChunkSize                       int                  `long:"chunk-size" yaml:"chunkSize" description:"Map-reduce chunk size in tokens (default: half of the model context length)"`
+	ChunkOverlap                    int                  `long:"chunk-overlap" yaml:"chunkOverlap" description:"Map-reduce chunk overlap in tokens" default:"200"`
+	MapWorkers                      int                  `long:"map-workers" yaml:"mapWorkers" description:"Number of chunks processed concurrently in map-reduce mode" default:"4"`
 	Debug                           int                  `long:"debug" description:"Set debug level (0=off, 1=basic, 2=detailed, 3=trace)" default:"0"`
 }
 
@@ -350,6 +402,8 @@ func validateImageFile(imagePath string) error {
 	return fmt.Errorf("invalid image file extension '%s'. Supported formats: .png, .jpeg, .jpg, .webp", ext)
 }
 
+var imageSizePattern = regexp.MustCompile(`^[1-9][0-9]*x[1-9][0-9]*$`)
+
 // validateImageParameters validates image generation parameters
 func validateImageParameters(imagePath, size, quality, background string, compression int) error {
 	if imagePath == "" {
@@ -360,19 +414,9 @@ func validateImageParameters(imagePath, size, quality, background string, compre
 		return nil
 	}
 
-	// Validate size
-	if size != "" {
-		validSizes := []string{"1024x1024", "1536x1024", "1024x1536", "auto"}
-		valid := false
-		for _, validSize := range validSizes {
-			if size == validSize {
-				valid = true
-				break
-			}
-		}
-		if !valid {
-			return fmt.Errorf("invalid image size '%s'. Supported sizes: 1024x1024, 1536x1024, 1024x1536, auto", size)
-		}
+	// Validate size, image models other than gpt-image-1 support other dimensions
+	if size != "" && size != "auto" && !imageSizePattern.MatchString(size) {
+		return fmt.Errorf("invalid image size '%s'. Supported sizes: 1024x1024, 1536x1024, 1024x1536, auto, or WIDTHxHEIGHT", size)
 	}
 
 	// Validate quality
@@ -438,6 +482,12 @@ func (o *Flags) BuildChatOptions() (ret *domain.ChatOptions, err error) {
 	if err = validateImageParameters(o.ImageFile, o.ImageSize, o.ImageQuality, o.ImageBackground, o.ImageCompression); err != nil {
 		return nil, err
 	}
+	if o.ImageFile == "" && (o.ImageVendor != "" || o.ImageModel != "" || o.ImageCount != 0) {
+		return nil, fmt.Errorf("--image-vendor, --image-model and --image-count can only be used with --image-file")
+	}
+	if o.ImageCount < 0 {
+		return nil, fmt.Errorf("image count must be positive, got %d", o.ImageCount)
+	}
 
 	startTag := o.ThinkStartTag
 	if startTag == "" {
@@ -468,7 +518,6 @@ func (o *Flags) BuildChatOptions() (ret *domain.ChatOptions, err error) {
 		SuppressThink:       o.SuppressThink,
 		ThinkStartTag:       startTag,
 		ThinkEndTag:         endTag,
-		Voice:               o.Voice,
 		Notification:        o.Notification || o.NotificationCommand != "",
 		NotificationCommand: o.NotificationCommand,
 	}
@@ -485,6 +534,18 @@ func (o *Flags) BuildChatRequest(Meta string) (ret *domain.ChatRequest, err erro
 		InputHasVars:          o.InputHasVars,
 		NoVariableReplacement: o.NoVariableReplacement,
 		Meta:                  Meta,
+		RagCollection:         o.Rag,
+		RagTopK:               o.RagTopK,
+	}
+
+	if o.MapReduce || o.MapPattern != "" || o.ReducePattern != "" {
+		ret.MapReduce = &domain.MapReduceConfig{
+			MapPattern:    o.MapPattern,
+			ReducePattern: o.ReducePattern,
+			ChunkSize:     o.ChunkSize,
+			ChunkOverlap:  o.ChunkOverlap,
+			Workers:       o.MapWorkers,
+		}
 	}
 
 	var message *chat.ChatCompletionMessage
@@ -505,25 +566,11 @@ func (o *Flags) BuildChatRequest(Meta string) (ret *domain.ChatRequest, err erro
 			if attachment, err = domain.NewAttachment(attachmentValue); err != nil {
 				return
 			}
-			url := attachment.URL
-			if url == nil {
-				var base64Image string
-				if base64Image, err = attachment.Base64Content(); err != nil {
-					return
-				}
-				var mimeType string
-				if mimeType, err = attachment.ResolveType(); err != nil {
-					return
-				}
-				dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, base64Image)
-				url = &dataURL
+			var part chat.ChatMessagePart
+			if part, err = attachment.ChatMessagePart(); err != nil {
+				return
 			}
-			message.MultiContent = append(message.MultiContent, chat.ChatMessagePart{
-				Type: chat.ChatMessagePartTypeImageURL,
-				ImageURL: &chat.ChatMessageImageURL{
-					URL: *url,
-				},
-			})
+			message.MultiContent = append(message.MultiContent, part)
 		}
 	} else if o.Message != "" {
 		message = &chat.ChatCompletionMessage{
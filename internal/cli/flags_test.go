@@ -8,7 +8,12 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/danielmiessler/fabric/internal/core"
 	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/ai/gemini"
+	"github.com/danielmiessler/fabric/internal/plugins/ai/openai"
+	"github.com/go-git/go-git/v5"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -454,4 +459,150 @@ func TestBuildChatOptionsWithImageParameters(t *testing.T) {
 		assert.Contains(t, err.Error(), "image parameters")
 		assert.Contains(t, err.Error(), "can only be used with --image-file")
 	})
+
+	t.Run("Image generator parameters need an image file", func(t *testing.T) {
+		_, err := (&Flags{ImageCount: 2}).BuildChatOptions()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "can only be used with --image-file")
+
+		_, err = (&Flags{ImageFile: "/tmp/test.png", ImageCount: -1}).BuildChatOptions()
+		assert.Error(t, err)
+
+		_, err = (&Flags{ImageFile: "/tmp/test.png", ImageVendor: "endpoint", ImageSize: "512x768", ImageCount: 4}).BuildChatOptions()
+		assert.NoError(t, err, "Endpoints accept any WIDTHxHEIGHT size")
+	})
+}
+
+func TestTranscriptRange(t *testing.T) {
+	flags := &Flags{TranscriptStart: "40:00", TranscriptEnd: "1:15:00"}
+	start, end, err := flags.transcriptRange()
+	assert.NoError(t, err)
+	assert.Equal(t, 2400, start)
+	assert.Equal(t, 4500, end)
+
+	flags = &Flags{TranscriptStart: "90"}
+	start, end, err = flags.transcriptRange()
+	assert.NoError(t, err)
+	assert.Equal(t, 90, start)
+	assert.Equal(t, 0, end)
+
+	_, _, err = (&Flags{TranscriptStart: "10:00", TranscriptEnd: "5:00"}).transcriptRange()
+	assert.Error(t, err)
+	_, _, err = (&Flags{TranscriptStart: "ten"}).transcriptRange()
+	assert.Error(t, err)
+}
+
+func TestSpeakFile(t *testing.T) {
+	assert.Equal(t, "answer.mp3", (&Flags{Speak: "answer.mp3", Output: "answer.md"}).speakFile())
+	assert.Equal(t, "answer.wav", (&Flags{Speak: "answer"}).speakFile())
+	assert.Equal(t, "output.wav", (&Flags{Output: "output.wav"}).speakFile())
+	assert.Empty(t, (&Flags{Output: "output.md"}).speakFile())
+	assert.Empty(t, (&Flags{}).speakFile())
+}
+
+func TestInputSynthesizer(t *testing.T) {
+	vendors := ai.NewVendorsManager()
+	vendors.AddVendors(openai.NewClient(), gemini.NewClient())
+	registry := &core.PluginRegistry{VendorManager: vendors}
+
+	tts := "gemini-2.5-flash-preview-tts"
+	synthesizer, vendorName := inputSynthesizer(&Flags{Model: tts, Output: "out.wav"}, registry)
+	assert.NotNil(t, synthesizer)
+	assert.Equal(t, "Gemini", vendorName)
+	synthesizer, _ = inputSynthesizer(&Flags{Model: tts, Vendor: "Gemini", Output: "out.mp3"}, registry)
+	assert.NotNil(t, synthesizer)
+	for _, flags := range []*Flags{
+		{Model: tts, Vendor: "OpenAI", Output: "out.wav"},
+		{Model: tts, Output: "out.md"},
+		{Model: tts, Speak: "answer.wav"},
+		{Model: "gemini-2.5-flash", Output: "out.wav"},
+	} {
+		synthesizer, _ = inputSynthesizer(flags, registry)
+		assert.Nil(t, synthesizer)
+	}
+
+	err := handleSpeakInput(&Flags{Model: tts, Output: "out.wav"}, registry, gemini.NewClient(), "Gemini")
+	assert.ErrorContains(t, err, "no input to speak")
+}
+
+func TestScreenshotFlag(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"cmd", "--screenshot", "describe this"}
+	flags, err := Init()
+	assert.NoError(t, err)
+	assert.Equal(t, "screen", flags.Screenshot, "--screenshot without value captures the screen")
+
+	os.Args = []string{"cmd", "--screenshot=window"}
+	flags, err = Init()
+	assert.NoError(t, err)
+	assert.Equal(t, "window", flags.Screenshot)
+}
+
+func TestHandleVisionAttachments(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "cat.png")
+	assert.NoError(t, os.WriteFile(image, []byte("\x89PNG\r\n\x1a\n"), 0644))
+
+	flags := &Flags{Attachments: []string{image}, Message: "describe"}
+	cleanup, err := handleVisionAttachments(flags, nil)
+	defer cleanup()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{image}, flags.Attachments, "Images are attached unchanged")
+	assert.Equal(t, "describe", flags.Message)
+
+	_, err = handleVisionAttachments(&Flags{VideoFPS: -1}, nil)
+	assert.Error(t, err)
+}
+
+func TestCheckFileChangeFlags(t *testing.T) {
+	assert.NoError(t, (&Flags{Apply: true}).checkFileChangeFlags())
+	assert.NoError(t, (&Flags{NoApply: true}).checkFileChangeFlags())
+	assert.Error(t, (&Flags{Apply: true, NoApply: true}).checkFileChangeFlags())
+}
+
+func TestHandleFileChangesInProject(t *testing.T) {
+	dir := t.TempDir()
+	_, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	app := filepath.Join(dir, "app")
+	assert.NoError(t, os.MkdirAll(app, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(app, "main.go"), []byte("package main\n"), 0644))
+
+	flags := &Flags{Project: app, Apply: true}
+	changes := []domain.FileChange{
+		{Operation: domain.FileUpdate, Path: "main.go", Content: "package main\n\nfunc main() {}\n"},
+		{Operation: domain.FileRename, Path: "main.go", NewPath: "cmd/main.go"},
+	}
+	assert.NoError(t, handleFileChanges(flags, changes[:1]))
+	content, err := os.ReadFile(filepath.Join(app, "main.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", string(content))
+	assert.NoFileExists(t, filepath.Join(dir, "main.go"))
+
+	assert.NoError(t, handleFileChanges(flags, changes[1:]))
+	assert.FileExists(t, filepath.Join(app, "cmd", "main.go"))
+
+	assert.NoError(t, handleUndoChanges(flags))
+	assert.NoFileExists(t, filepath.Join(app, "cmd", "main.go"))
+	assert.FileExists(t, filepath.Join(app, "main.go"))
+
+	escaping := []domain.FileChange{{Operation: domain.FileCreate, Path: "../other.go", Content: "package other\n"}}
+	assert.ErrorContains(t, handleFileChanges(flags, escaping), "outside the project")
+	assert.NoFileExists(t, filepath.Join(dir, "other.go"))
+}
+
+func TestHandleProject(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes\n"), 0644))
+
+	flags := &Flags{Project: dir, ProjectLanguages: []string{"go"}, ProjectFormat: "markdown", Message: "add a test"}
+	assert.NoError(t, handleProject(flags))
+	assert.Contains(t, flags.Message, "add a test")
+	assert.Contains(t, flags.Message, "### main.go")
+	assert.NotContains(t, flags.Message, "notes.txt")
+
+	assert.Error(t, handleProject(&Flags{Project: dir, ProjectLanguages: []string{"rust"}, ProjectFormat: "json"}))
+	assert.Error(t, handleProject(&Flags{Project: dir, ProjectFormat: "xml"}))
 }
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/tools/images"
+	"github.com/danielmiessler/fabric/internal/tools/redact"
+)
+
+// useImageStage reports whether --image-file is created by an image generator
+// after the chat instead of by the image generation tool of the chat model.
+// The tool is kept when the chat model has it and no image vendor, model or
+// several images are requested.
+func (o *Flags) useImageStage(registry *core.PluginRegistry, chatter *core.Chatter) bool {
+	if o.ImageFile == "" {
+		return false
+	}
+	if o.ImageVendor != "" || o.ImageModel != "" || o.ImageCount > 1 || registry.Images.Vendor.Value != "" {
+		return true
+	}
+	return !chatter.SupportsImageGenerationTool(o.Model)
+}
+
+// imagePromptOnly reports whether the message is the image prompt itself:
+// without pattern, context, session or strategy the chat is skipped.
+func (o *Flags) imagePromptOnly() bool {
+	return o.Pattern == "" && o.Context == "" && o.Session == "" && o.Strategy == ""
+}
+
+// checkImageFiles fails if one of the image files to generate already exists.
+func (o *Flags) checkImageFiles() (err error) {
+	for _, fileName := range images.FileNames(o.ImageFile, o.ImageCount) {
+		if _, statErr := os.Stat(fileName); statErr == nil {
+			return fmt.Errorf("image file already exists: %s", fileName)
+		}
+	}
+	return
+}
+
+// handleImagePrompt generates the --image-file images from the message without a chat.
+func handleImagePrompt(flags *Flags, registry *core.PluginRegistry) (err error) {
+	if _, err = flags.BuildChatOptions(); err != nil {
+		return
+	}
+	return handleImage(flags, registry, flags.Message)
+}
+
+// handleImage generates the --image-file images from prompt. Image attachments
+// are passed as input images, turning the request into an edit.
+func handleImage(flags *Flags, registry *core.PluginRegistry, prompt string) (err error) {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return fmt.Errorf("no prompt to generate an image from")
+	}
+	if err = flags.checkImageFiles(); err != nil {
+		return
+	}
+
+	opts := ai.ImageOptions{
+		Model:       flags.ImageModel,
+		Count:       max(flags.ImageCount, 1),
+		Size:        flags.ImageSize,
+		Quality:     flags.ImageQuality,
+		Background:  flags.ImageBackground,
+		Compression: flags.ImageCompression,
+		Format:      images.FormatFromExtension(flags.ImageFile),
+	}
+	if opts.InputImages, err = inputImages(flags.Attachments); err != nil {
+		return
+	}
+
+	if flags.DryRun {
+		vendorName := flags.ImageVendor
+		if vendorName == "" {
+			vendorName = registry.Images.Vendor.Value
+		}
+		var redactor *redact.Redactor
+		if prompt, redactor, err = redactForVendor(flags, registry, vendorName, prompt); err != nil {
+			return
+		}
+		fmt.Printf("Image generation:\nPrompt: %s\nFile: %s\nCount: %d\n", prompt, flags.ImageFile, opts.Count)
+		if flags.ImageVendor != "" {
+			fmt.Printf("Vendor: %s\n", flags.ImageVendor)
+		}
+		if opts.Model != "" {
+			fmt.Printf("Model: %s\n", opts.Model)
+		}
+		for _, image := range opts.InputImages {
+			fmt.Printf("Input image: %s\n", image.Name)
+		}
+		if redactor != nil {
+			fmt.Printf("\n%s", redact.FormatRedactions(redactor.Redactions()))
+		}
+		return
+	}
+
+	var generator ai.ImageGenerator
+	var vendorName, model string
+	if generator, vendorName, model, err = resolveImageGenerator(flags, registry); err != nil {
+		return
+	}
+	if prompt, _, err = redactForVendor(flags, registry, vendorName, prompt); err != nil {
+		return
+	}
+	if opts.Model == "" {
+		opts.Model = model
+	}
+
+	var generated []*ai.GeneratedImage
+	if generated, err = generator.GenerateImages(context.Background(), prompt, &opts); err != nil {
+		return
+	}
+	if len(generated) > opts.Count {
+		generated = generated[:opts.Count]
+	}
+
+	metadata := ai.ImageMetadata{
+		Prompt:  prompt,
+		Vendor:  vendorName,
+		Model:   opts.Model,
+		Options: &opts,
+		Created: time.Now(),
+	}
+	for _, image := range opts.InputImages {
+		metadata.InputImages = append(metadata.InputImages, image.Name)
+	}
+
+	var fileNames []string
+	fileNames, err = images.Save(generated, flags.ImageFile, metadata)
+	for _, fileName := range fileNames {
+		fmt.Fprintf(os.Stderr, "Image saved to: %s\n", fileName)
+	}
+	return
+}
+
+// resolveImageGenerator selects the image generation backend: --image-vendor,
+// then the configured image vendor, then the chat vendor if it can generate
+// images, then the default vendors.
+func resolveImageGenerator(flags *Flags, registry *core.PluginRegistry) (ret ai.ImageGenerator, vendorName string, model string, err error) {
+	vendorName = flags.ImageVendor
+	if vendorName == "" && registry.Images.Vendor.Value == "" && flags.Vendor != "" {
+		if ret = registry.GetImageGenerator(flags.Vendor); ret != nil {
+			vendorName = flags.Vendor
+			return
+		}
+	}
+	return registry.Images.Generator(vendorName)
+}
+
+// inputImages loads the image attachments, other attachments are left to the chat.
+func inputImages(attachments []string) (ret []ai.InputImage, err error) {
+	for _, value := range attachments {
+		var attachment *domain.Attachment
+		if attachment, err = domain.NewAttachment(value); err != nil {
+			return
+		}
+		var mimeType string
+		if mimeType, err = attachment.ResolveType(); err != nil {
+			return
+		}
+		if !domain.IsImageMimeType(mimeType) {
+			continue
+		}
+		var data []byte
+		if data, err = attachment.ContentBytes(); err != nil {
+			return
+		}
+		ret = append(ret, ai.InputImage{Name: attachment.FileName(), MIMEType: domain.BaseMimeType(mimeType), Data: data})
+	}
+	return
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/tools/images"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleImageRedactsPrompt(t *testing.T) {
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]any{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		prompts = append(prompts, fmt.Sprint(body["prompt"]))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"created":1,"data":[{"b64_json":%q}]}`, base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n0000")))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	registry, err := core.NewPluginRegistry(fsdb.NewDb(filepath.Join(dir, "config")))
+	if err != nil {
+		t.Fatalf("NewPluginRegistry() error = %v", err)
+	}
+	registry.Images.EndpointURL.Value = server.URL
+
+	prompt := "A postcard for jane@example.com"
+	flags := &Flags{ImageFile: filepath.Join(dir, "one.png"), ImageVendor: images.EndpointVendor, Redact: true}
+	assert.NoError(t, handleImage(flags, registry, prompt))
+
+	flags = &Flags{ImageFile: filepath.Join(dir, "two.png"), ImageVendor: images.EndpointVendor}
+	assert.NoError(t, handleImage(flags, registry, prompt))
+
+	assert.Equal(t, []string{"A postcard for [EMAIL_1]", prompt}, prompts)
+}
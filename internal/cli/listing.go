@@ -5,12 +5,13 @@ import (
 	"os"
 	"strconv"
 
-	openai "github.com/openai/openai-go"
-
 	"github.com/danielmiessler/fabric/internal/core"
 	"github.com/danielmiessler/fabric/internal/plugins/ai"
 	"github.com/danielmiessler/fabric/internal/plugins/ai/gemini"
+	"github.com/danielmiessler/fabric/internal/plugins/ai/openai"
 	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/plugins/template"
+	"github.com/danielmiessler/fabric/internal/tools/transcription"
 )
 
 // handleListingCommands handles listing-related commands
@@ -66,6 +67,11 @@ func handleListingCommands(currentFlags *Flags, fabricDb *fsdb.Db, registry *cor
 		return true, err
 	}
 
+	if currentFlags.ListTemplatePlugins {
+		template.ListPlugins(os.Stdout, currentFlags.ShellCompleteOutput)
+		return true, nil
+	}
+
 	if currentFlags.ListGeminiVoices {
 		voicesList := gemini.ListGeminiVoices(currentFlags.ShellCompleteOutput)
 		fmt.Print(voicesList)
@@ -73,29 +79,49 @@ func handleListingCommands(currentFlags *Flags, fabricDb *fsdb.Db, registry *cor
 	}
 
 	if currentFlags.ListTranscriptionModels {
-		listTranscriptionModels(currentFlags.ShellCompleteOutput)
+		listTranscriptionModels(registry, currentFlags.ShellCompleteOutput)
 		return true, nil
 	}
 
 	return false, nil
 }
 
-// listTranscriptionModels lists all available transcription models
-func listTranscriptionModels(shellComplete bool) {
-	models := []string{
-		string(openai.AudioModelWhisper1),
-		string(openai.AudioModelGPT4oMiniTranscribe),
-		string(openai.AudioModelGPT4oTranscribe),
+// listTranscriptionModels lists the transcription models of every configured
+// vendor that can transcribe, plus the configured OpenAI-compatible endpoint
+func listTranscriptionModels(registry *core.PluginRegistry, shellComplete bool) {
+	var vendors []string
+	models := map[string][]string{}
+	for _, vendor := range registry.VendorManager.Vendors {
+		if transcriber, ok := vendor.(ai.Transcriber); ok {
+			if vendorModels := transcriber.TranscriptionModels(); len(vendorModels) > 0 {
+				vendors = append(vendors, vendor.GetName())
+				models[vendor.GetName()] = vendorModels
+			}
+		}
+	}
+	if len(vendors) == 0 {
+		vendors = append(vendors, transcription.DefaultVendor)
+		models[transcription.DefaultVendor] = openai.AllowedTranscriptionModels
 	}
 
 	if shellComplete {
-		for _, model := range models {
-			fmt.Println(model)
+		for _, vendor := range vendors {
+			for _, model := range models[vendor] {
+				fmt.Println(model)
+			}
 		}
-	} else {
-		fmt.Println("Available transcription models:")
-		for _, model := range models {
+		return
+	}
+
+	fmt.Println("Available transcription models:")
+	for _, vendor := range vendors {
+		fmt.Printf("\n%s:\n", vendor)
+		for _, model := range models[vendor] {
 			fmt.Printf("  %s\n", model)
 		}
 	}
+	if registry.Transcription.EndpointURL.Value != "" {
+		fmt.Printf("\n%s (%s):\n  models of the server, select with --transcribe-model\n",
+			transcription.EndpointVendor, registry.Transcription.EndpointURL.Value)
+	}
 }
@@ -36,28 +36,6 @@ func CreateOutputFile(message string, fileName string) (err error) {
 	return
 }
 
-// CreateAudioOutputFile creates a binary file for audio data
-func CreateAudioOutputFile(audioData []byte, fileName string) (err error) {
-	// If no extension is provided, default to .wav
-	if filepath.Ext(fileName) == "" {
-		fileName += ".wav"
-	}
-
-	// File existence check is now done in the CLI layer before TTS generation
-	var file *os.File
-	if file, err = os.Create(fileName); err != nil {
-		err = fmt.Errorf("error creating audio file: %v", err)
-		return
-	}
-	defer file.Close()
-
-	if _, err = file.Write(audioData); err != nil {
-		err = fmt.Errorf("error writing audio data to file: %v", err)
-	}
-	// No redundant output message here - the CLI layer handles success messaging
-	return
-}
-
 // IsAudioFormat checks if the filename suggests an audio format
 func IsAudioFormat(fileName string) bool {
 	ext := strings.ToLower(filepath.Ext(fileName))
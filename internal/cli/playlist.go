@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/tools/batch"
+	"github.com/danielmiessler/fabric/internal/tools/youtube"
+)
+
+// PlaylistIndexFileName lists the videos of a processed playlist with their status.
+const PlaylistIndexFileName = "index.md"
+
+// maxPlaylistIDLength keeps output file names of long video titles manageable
+const maxPlaylistIDLength = 80
+
+// handlePlaylistBatch processes every video of a YouTube playlist on its own:
+// the transcript (and comments/metadata) of each video is sent with the pattern
+// by a pool of workers, like --batch items, into one file per video plus an
+// index. Videos completed by a previous run are skipped, failed ones retried.
+func handlePlaylistBatch(currentFlags *Flags, registry *core.PluginRegistry) (err error) {
+	if currentFlags.Session != "" {
+		return fmt.Errorf("--session cannot be used with playlist batch processing, each video is processed independently")
+	}
+	if currentFlags.SplitChapters {
+		return fmt.Errorf("--split-chapters cannot be used with playlist batch processing, use --chapters for chapter headings")
+	}
+	if strings.EqualFold(filepath.Ext(currentFlags.BatchOutput), ".jsonl") {
+		return fmt.Errorf("playlist batch processing writes one file per video, --batch-output must be a directory")
+	}
+	if !registry.YouTube.IsConfigured() {
+		return fmt.Errorf("YouTube is not configured, please run the setup procedure")
+	}
+
+	var videoId, playlistId string
+	if videoId, playlistId, err = registry.YouTube.GetVideoOrPlaylistId(currentFlags.YouTube); err != nil {
+		return
+	}
+	if playlistId == "" || (videoId != "" && !currentFlags.YouTubePlaylist) {
+		return fmt.Errorf("--batch-output with -y requires a playlist URL (use --playlist if the URL also contains a video)")
+	}
+
+	var videos []*youtube.VideoMeta
+	if videos, err = registry.YouTube.FetchPlaylistVideos(playlistId); err != nil {
+		return fmt.Errorf("error fetching playlist videos: %w", err)
+	}
+	items := playlistItems(videos)
+
+	var output *batch.Output
+	if output, err = batch.NewOutput(currentFlags.BatchOutput, ""); err != nil {
+		return
+	}
+
+	// Without a pattern or message the transcripts themselves are the outputs
+	var chatter *core.Chatter
+	isChat := currentFlags.IsChatRequest()
+	if isChat {
+		if chatter, err = newChatter(currentFlags, registry, false); err != nil {
+			return
+		}
+	}
+
+	language := registry.Language.DefaultLanguage.Value
+	meta := strings.Join(os.Args[1:], " ")
+
+	runner := &batch.Runner{
+		Workers:       currentFlags.BatchWorkers,
+		RatePerMinute: currentFlags.BatchRate,
+		Output:        output,
+		Process: func(_ context.Context, item *batch.Item) (result string, err error) {
+			if result, err = processYoutubeVideo(currentFlags, registry, item.Input); err != nil || !isChat {
+				return
+			}
+			return processBatchItem(currentFlags, chatter, &batch.Item{ID: item.ID, Input: result}, language, meta)
+		},
+		Progress: func(done, total int, result *batch.Result) {
+			if result.Status == batch.StatusOK {
+				fmt.Fprintf(os.Stderr, "[%d/%d] %s done\n", done, total, result.ID)
+			} else {
+				fmt.Fprintf(os.Stderr, "[%d/%d] %s failed: %s\n", done, total, result.ID, result.Error)
+			}
+		},
+	}
+
+	processed, skipped, err := runner.Run(context.Background(), items)
+
+	indexFile := filepath.Join(currentFlags.BatchOutput, PlaylistIndexFileName)
+	failed, indexErr := writePlaylistIndex(indexFile, playlistId, videos, items, output)
+	if err == nil {
+		err = indexErr
+	}
+	fmt.Fprintf(os.Stderr, "Playlist finished: %d processed, %d skipped (already completed), %d failed, index in %s\n",
+		processed, skipped, failed, indexFile)
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "Run the same command again to retry the failed videos.\n")
+	}
+	return
+}
+
+// playlistItems turns the videos into batch items named after their titles.
+// The input of an item is the video ID, the transcript is fetched by the worker.
+func playlistItems(videos []*youtube.VideoMeta) (ret []*batch.Item) {
+	seen := map[string]bool{}
+	for _, video := range videos {
+		id := strings.Trim(video.TitleNormalized, "_")
+		if len(id) > maxPlaylistIDLength {
+			id = strings.TrimRight(id[:maxPlaylistIDLength], "_")
+		}
+		if id == "" || seen[id] {
+			id = strings.Trim(id+"_"+video.Id, "_")
+		}
+		seen[id] = true
+		ret = append(ret, &batch.Item{
+			ID:     id,
+			Input:  video.Id,
+			Source: "https://www.youtube.com/watch?v=" + video.Id,
+		})
+	}
+	return
+}
+
+// writePlaylistIndex writes a markdown table of the videos in playlist order
+// with their status and output file, and returns the number of failed videos.
+func writePlaylistIndex(path string, playlistId string, videos []*youtube.VideoMeta, items []*batch.Item,
+	output *batch.Output) (failed int, err error) {
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Playlist %s\n\n", playlistId)
+	b.WriteString("| # | Video | Status | Output |\n")
+	b.WriteString("| - | ----- | ------ | ------ |\n")
+	for i, item := range items {
+		status, file := "pending", ""
+		if result := output.Result(item.ID); result != nil {
+			status = result.Status
+			if result.Status == batch.StatusOK {
+				if file, _ = filepath.Rel(filepath.Dir(path), result.File); file != "" {
+					file = fmt.Sprintf("[%s](%s)", filepath.Base(file), filepath.ToSlash(file))
+				}
+			} else {
+				failed++
+				status = fmt.Sprintf("%s: %s", result.Status, result.Error)
+			}
+		}
+		title := strings.NewReplacer("|", "\\|", "\n", " ").Replace(videos[i].Title)
+		status = strings.NewReplacer("|", "\\|", "\n", " ").Replace(status)
+		fmt.Fprintf(&b, "| %d | [%s](%s) | %s | %s |\n", i+1, title, item.Source, status, file)
+	}
+	err = os.WriteFile(path, []byte(b.String()), 0644)
+	return
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/tools/batch"
+	"github.com/danielmiessler/fabric/internal/tools/youtube"
+)
+
+func TestPlaylistItems(t *testing.T) {
+	videos := []*youtube.VideoMeta{
+		{Id: "id1", Title: "Intro", TitleNormalized: "Intro"},
+		{Id: "id2", Title: "Intro", TitleNormalized: "Intro"},
+		{Id: "id3", Title: "???", TitleNormalized: "_"},
+	}
+	items := playlistItems(videos)
+	ids := []string{items[0].ID, items[1].ID, items[2].ID}
+	expected := []string{"Intro", "Intro_id2", "id3"}
+	for i := range expected {
+		if ids[i] != expected[i] {
+			t.Errorf("Expected item id %s, got %s", expected[i], ids[i])
+		}
+	}
+	if items[0].Input != "id1" || items[0].Source != "https://www.youtube.com/watch?v=id1" {
+		t.Errorf("Unexpected item %+v", items[0])
+	}
+}
+
+func TestWritePlaylistIndex(t *testing.T) {
+	dir := t.TempDir()
+	videos := []*youtube.VideoMeta{
+		{Id: "id1", Title: "First | video", TitleNormalized: "First_video"},
+		{Id: "id2", Title: "Second", TitleNormalized: "Second"},
+		{Id: "id3", Title: "Third", TitleNormalized: "Third"},
+	}
+	items := playlistItems(videos)
+	output, err := batch.NewOutput(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = output.Record(&batch.Result{ID: items[0].ID, Status: batch.StatusOK}, "summary"); err != nil {
+		t.Fatal(err)
+	}
+	if err = output.Record(&batch.Result{ID: items[1].ID, Status: batch.StatusError, Error: "no subtitles"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	indexFile := filepath.Join(dir, PlaylistIndexFileName)
+	failed, err := writePlaylistIndex(indexFile, "PL1", videos, items, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != 1 {
+		t.Errorf("Expected 1 failed video, got %d", failed)
+	}
+	content, _ := os.ReadFile(indexFile)
+	for _, expected := range []string{
+		"| 1 | [First \\| video](https://www.youtube.com/watch?v=id1) | ok | [First_video.md](First_video.md) |",
+		"| 2 | [Second](https://www.youtube.com/watch?v=id2) | error: no subtitles |  |",
+		"| 3 | [Third](https://www.youtube.com/watch?v=id3) | pending |  |",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected index to contain %q, got:\n%s", expected, content)
+		}
+	}
+}
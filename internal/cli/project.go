@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danielmiessler/fabric/internal/tools/project"
+)
+
+// handleProject replaces the message by the files of the --project
+// directory, with the message as the instructions.
+func handleProject(flags *Flags) (err error) {
+	var scanned *project.Project
+	if scanned, err = project.Scan(flags.Project, &project.Options{
+		Include:      flags.ProjectInclude,
+		Exclude:      flags.ProjectExclude,
+		Languages:    flags.ProjectLanguages,
+		MaxTokens:    flags.ProjectMaxTokens,
+		Instructions: flags.Message,
+	}); err != nil {
+		return
+	}
+
+	var message string
+	if message, err = scanned.Format(flags.ProjectFormat); err != nil {
+		return
+	}
+	if len(scanned.Files) == 0 {
+		return fmt.Errorf("no files of project %s match the filters", flags.Project)
+	}
+
+	budget := 0
+	for _, omitted := range scanned.Omitted {
+		if omitted.Reason == project.OmittedBudget {
+			budget++
+		}
+	}
+	if budget > 0 {
+		fmt.Fprintf(os.Stderr, "Project %s: %d files (~%d tokens) included, %d left out to fit the token budget.\n",
+			flags.Project, len(scanned.Files), scanned.Tokens, budget)
+	}
+	flags.Message = message
+	return
+}
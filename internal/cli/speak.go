@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/tools/redact"
+	"github.com/danielmiessler/fabric/internal/tools/speech"
+)
+
+// speakFile returns the audio file of the speech output stage: --speak, or
+// an -o file with an audio extension. Files without extension are WAV.
+func (o *Flags) speakFile() (ret string) {
+	if ret = o.Speak; ret == "" && o.Output != "" && IsAudioFormat(o.Output) {
+		ret = o.Output
+	}
+	if ret != "" && filepath.Ext(ret) == "" {
+		ret += ".wav"
+	}
+	return
+}
+
+// inputSynthesizer returns the synthesizer and vendor name of a
+// text-to-speech -m model written to an audio -o file without --speak: such a
+// command speaks the input as is instead of chatting. The vendor is -V or the
+// first configured vendor offering the model, nil if none does.
+func inputSynthesizer(flags *Flags, registry *core.PluginRegistry) (ai.SpeechSynthesizer, string) {
+	if flags.Model == "" || flags.Speak != "" || flags.Output == "" || !IsAudioFormat(flags.Output) {
+		return nil, ""
+	}
+	vendors := registry.VendorManager.Vendors
+	if flags.Vendor != "" {
+		vendors = []ai.Vendor{registry.VendorManager.FindByName(flags.Vendor)}
+	}
+	for _, vendor := range vendors {
+		if synthesizer, ok := vendor.(ai.SpeechSynthesizer); ok {
+			for _, model := range synthesizer.SpeechModels() {
+				if strings.EqualFold(model, flags.Model) {
+					return synthesizer, vendor.GetName()
+				}
+			}
+		}
+	}
+	return nil, ""
+}
+
+// handleSpeakInput speaks the input as is with a text-to-speech model,
+// without a chat.
+func handleSpeakInput(flags *Flags, registry *core.PluginRegistry, synthesizer ai.SpeechSynthesizer, vendorName string) (err error) {
+	text := strings.TrimSpace(flags.Message)
+	if text == "" {
+		return fmt.Errorf("no input to speak with the text-to-speech model %s", flags.Model)
+	}
+	if flags.Pattern != "" || flags.Context != "" {
+		fmt.Fprintf(os.Stderr, "The text-to-speech model %s speaks the input as is, use --speak with a chat model to speak the output of a pattern.\n", flags.Model)
+	}
+	fileName := flags.speakFile()
+	if _, err = os.Stat(fileName); err == nil {
+		return fmt.Errorf("file %s already exists. Please choose a different filename or remove the existing file", fileName)
+	}
+
+	var redactor *redact.Redactor
+	if text, redactor, err = redactForVendor(flags, registry, vendorName, text); err != nil {
+		return
+	}
+	opts := ai.SpeechOptions{Model: flags.Model, Voice: flags.Voice}
+	if flags.DryRun {
+		printSpeech(vendorName, &opts, fileName)
+		fmt.Printf("Text: %s\n", text)
+		if redactor != nil {
+			fmt.Printf("\n%s", redact.FormatRedactions(redactor.Redactions()))
+		}
+		return
+	}
+	return speak(synthesizer, text, &opts, fileName)
+}
+
+// handleSpeak turns the result of a chat into speech and writes it to fileName.
+// The result is redacted again with the rules of the speech vendor, as the
+// chat restored the redacted values.
+func handleSpeak(flags *Flags, registry *core.PluginRegistry, text string, fileName string) (err error) {
+	if flags.DryRun {
+		vendorName := flags.SpeakVendor
+		opts := ai.SpeechOptions{Model: flags.SpeakModel, Voice: flags.Voice}
+		if vendorName == "" {
+			vendorName = registry.Speech.Vendor.Value
+			if opts.Model == "" {
+				opts.Model = registry.Speech.Model.Value
+			}
+			if opts.Voice == "" {
+				opts.Voice = registry.Speech.Voice.Value
+			}
+		}
+		printSpeech(vendorName, &opts, fileName)
+		return
+	}
+
+	var synthesizer ai.SpeechSynthesizer
+	var vendorName string
+	var opts ai.SpeechOptions
+	if synthesizer, vendorName, opts, err = resolveSynthesizer(flags, registry); err != nil {
+		return
+	}
+	if flags.SpeakModel != "" {
+		opts.Model = flags.SpeakModel
+	}
+	if flags.Voice != "" {
+		opts.Voice = flags.Voice
+	}
+	if text, _, err = redactForVendor(flags, registry, vendorName, text); err != nil {
+		return
+	}
+	return speak(synthesizer, text, &opts, fileName)
+}
+
+// printSpeech prints the planned speech output of a dry run.
+func printSpeech(vendorName string, opts *ai.SpeechOptions, fileName string) {
+	fmt.Printf("Speech:\nFile: %s\n", fileName)
+	if vendorName != "" {
+		fmt.Printf("Vendor: %s\n", vendorName)
+	}
+	if opts.Model != "" {
+		fmt.Printf("Model: %s\n", opts.Model)
+	}
+	if opts.Voice != "" {
+		fmt.Printf("Voice: %s\n", opts.Voice)
+	}
+}
+
+// speak synthesizes text and writes the audio to fileName.
+func speak(synthesizer ai.SpeechSynthesizer, text string, opts *ai.SpeechOptions, fileName string) (err error) {
+	var result *ai.Speech
+	if result, err = speech.Speak(context.Background(), synthesizer, text, opts); err != nil {
+		return
+	}
+	if err = speech.WriteFile(result, fileName); err != nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Speech saved to: %s\n", fileName)
+	return
+}
+
+// resolveSynthesizer selects the text-to-speech backend: --speak-vendor, then
+// the configured speech vendor, then the chat vendor if it can speak, then
+// the default vendors.
+func resolveSynthesizer(flags *Flags, registry *core.PluginRegistry) (ret ai.SpeechSynthesizer, vendorName string, opts ai.SpeechOptions, err error) {
+	vendorName = flags.SpeakVendor
+	if vendorName == "" && registry.Speech.Vendor.Value == "" && flags.Vendor != "" {
+		if ret = registry.GetSpeechSynthesizer(flags.Vendor); ret != nil {
+			vendorName = flags.Vendor
+			return
+		}
+	}
+	return registry.Speech.Synthesizer(vendorName)
+}
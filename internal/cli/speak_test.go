@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/stretchr/testify/assert"
+)
+
+// speechVendor is a configured vendor that records the text it speaks
+type speechVendor struct {
+	texts []string
+}
+
+func (o *speechVendor) GetName() string                       { return "Speaker" }
+func (o *speechVendor) GetSetupDescription() string           { return "Speaker" }
+func (o *speechVendor) IsConfigured() bool                    { return true }
+func (o *speechVendor) Configure() error                      { return nil }
+func (o *speechVendor) Setup() error                          { return nil }
+func (o *speechVendor) SetupFillEnvFileContent(*bytes.Buffer) {}
+func (o *speechVendor) ListModels() ([]string, error)         { return nil, nil }
+func (o *speechVendor) SendStream([]*chat.ChatCompletionMessage, *domain.ChatOptions, chan string) error {
+	return nil
+}
+func (o *speechVendor) Send(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, error) {
+	return "", nil
+}
+func (o *speechVendor) NeedsRawMode(string) bool { return false }
+
+func (o *speechVendor) Synthesize(_ context.Context, text string, _ *ai.SpeechOptions) (*ai.Speech, error) {
+	o.texts = append(o.texts, text)
+	return &ai.Speech{PCM: []byte{0, 0}, SampleRate: 24000, Channels: 1}, nil
+}
+func (o *speechVendor) SpeechModels() []string { return []string{"speaker-tts"} }
+func (o *speechVendor) SpeechVoices() []string { return nil }
+func (o *speechVendor) MaxSpeechInput() int    { return 4096 }
+
+func newSpeechRegistry(t *testing.T) (*core.PluginRegistry, *speechVendor) {
+	registry, err := core.NewPluginRegistry(fsdb.NewDb(filepath.Join(t.TempDir(), "config")))
+	if err != nil {
+		t.Fatalf("NewPluginRegistry() error = %v", err)
+	}
+	vendor := &speechVendor{}
+	registry.VendorManager = ai.NewVendorsManager()
+	registry.VendorManager.AddVendors(vendor)
+	return registry, vendor
+}
+
+func TestSpeechIsRedacted(t *testing.T) {
+	registry, vendor := newSpeechRegistry(t)
+	dir := t.TempDir()
+	text := "Call jane@example.com tomorrow"
+
+	flags := &Flags{Speak: filepath.Join(dir, "chat.wav"), SpeakVendor: "Speaker", Redact: true}
+	assert.NoError(t, handleSpeak(flags, registry, text, flags.speakFile()))
+
+	flags = &Flags{Model: "speaker-tts", Output: filepath.Join(dir, "input.wav"), Message: text, Redact: true}
+	synthesizer, vendorName := inputSynthesizer(flags, registry)
+	assert.Equal(t, "Speaker", vendorName)
+	assert.NoError(t, handleSpeakInput(flags, registry, synthesizer, vendorName))
+
+	if assert.Len(t, vendor.texts, 2) {
+		for _, spoken := range vendor.texts {
+			assert.Contains(t, spoken, "EMAIL_1")
+			assert.NotContains(t, spoken, "jane@example.com")
+		}
+	}
+}
+
+func TestSpeechDryRun(t *testing.T) {
+	registry, vendor := newSpeechRegistry(t)
+	dir := t.TempDir()
+
+	flags := &Flags{Speak: filepath.Join(dir, "chat.wav"), SpeakVendor: "Speaker", DryRun: true, Redact: true}
+	assert.NoError(t, handleSpeak(flags, registry, "Redactions (1):\n  [EMAIL_1] <- jane@example.com", flags.speakFile()))
+
+	flags = &Flags{Model: "speaker-tts", Output: filepath.Join(dir, "input.wav"), Message: "Hello", DryRun: true}
+	synthesizer, vendorName := inputSynthesizer(flags, registry)
+	assert.NoError(t, handleSpeakInput(flags, registry, synthesizer, vendorName))
+
+	assert.Empty(t, vendor.texts, "a dry run must not call the speech vendor")
+	assert.NoFileExists(t, filepath.Join(dir, "chat.wav"))
+	assert.NoFileExists(t, filepath.Join(dir, "input.wav"))
+}
@@ -4,10 +4,11 @@ import (
 	"fmt"
 
 	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/tools/scraper"
 	"github.com/danielmiessler/fabric/internal/tools/youtube"
 )
 
-// handleToolProcessing handles YouTube and web scraping tool processing
+// handleToolProcessing handles YouTube, media subtitles and web scraping tool processing
 func handleToolProcessing(currentFlags *Flags, registry *core.PluginRegistry) (messageTools string, err error) {
 	if currentFlags.YouTube != "" {
 		if !registry.YouTube.IsConfigured() {
@@ -56,15 +57,38 @@ func handleToolProcessing(currentFlags *Flags, registry *core.PluginRegistry) (m
 		}
 	}
 
-	if currentFlags.ScrapeURL != "" || currentFlags.ScrapeQuestion != "" {
-		if !registry.Jina.IsConfigured() {
-			err = fmt.Errorf("scraping functionality is not configured. Please set up Jina to enable scraping")
+	if currentFlags.MediaURL != "" || currentFlags.Subtitles != "" {
+		var transcript string
+		if currentFlags.MediaURL != "" {
+			if transcript, err = registry.YouTube.GrabMediaTranscript(currentFlags.MediaURL,
+				transcriptLanguage(currentFlags, registry), currentFlags.YtDlpArgs, currentFlags.YouTubeTranscriptWithTimestamps); err != nil {
+				return
+			}
+			messageTools = AppendMessage(messageTools, transcript)
+		}
+
+		if currentFlags.Subtitles != "" {
+			if transcript, err = youtube.ReadSubtitleFile(currentFlags.Subtitles, currentFlags.YouTubeTranscriptWithTimestamps); err != nil {
+				return
+			}
+			messageTools = AppendMessage(messageTools, transcript)
+		}
+
+		if !currentFlags.IsChatRequest() {
+			err = currentFlags.WriteOutput(messageTools)
 			return
 		}
-		// Check if the scrape_url flag is set and call ScrapeURL
+	}
+
+	if currentFlags.ScrapeURL != "" || currentFlags.ScrapeQuestion != "" {
+		// Check if the scrape_url flag is set and call ScrapeURL on the configured backend
 		if currentFlags.ScrapeURL != "" {
+			var urlScraper scraper.URLScraper
+			if urlScraper, err = registry.Scraper.URLScraper(); err != nil {
+				return
+			}
 			var website string
-			if website, err = registry.Jina.ScrapeURL(currentFlags.ScrapeURL); err != nil {
+			if website, err = urlScraper.ScrapeURL(currentFlags.ScrapeURL); err != nil {
 				return
 			}
 			messageTools = AppendMessage(messageTools, website)
@@ -72,6 +96,10 @@ func handleToolProcessing(currentFlags *Flags, registry *core.PluginRegistry) (m
 
 		// Check if the scrape_question flag is set and call ScrapeQuestion
 		if currentFlags.ScrapeQuestion != "" {
+			if !registry.Jina.IsConfigured() {
+				err = fmt.Errorf("search functionality is not configured. Please set up Jina to enable --scrape_question")
+				return
+			}
 			var website string
 			if website, err = registry.Jina.ScrapeQuestion(currentFlags.ScrapeQuestion); err != nil {
 				return
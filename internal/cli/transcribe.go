@@ -2,34 +2,45 @@ package cli
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/tools/transcription"
 )
 
-type transcriber interface {
-	TranscribeFile(ctx context.Context, filePath, model string, split bool) (string, error)
-}
-
 func handleTranscription(flags *Flags, registry *core.PluginRegistry) (message string, err error) {
-	vendorName := flags.Vendor
-	if vendorName == "" {
-		vendorName = "OpenAI"
-	}
-	vendor, ok := registry.VendorManager.VendorsByName[vendorName]
-	if !ok {
-		return "", fmt.Errorf("vendor %s not configured", vendorName)
+	var transcriber ai.Transcriber
+	var model string
+	if transcriber, model, err = resolveTranscriber(flags, registry); err != nil {
+		return
 	}
-	tr, ok := vendor.(transcriber)
-	if !ok {
-		return "", fmt.Errorf("vendor %s does not support audio transcription", vendorName)
+	if flags.TranscribeModel != "" {
+		model = flags.TranscribeModel
 	}
-	model := flags.TranscribeModel
-	if model == "" {
-		return "", fmt.Errorf("transcription model is required (use --transcribe-model)")
+
+	opts := &ai.TranscriptionOptions{
+		Model:    model,
+		Language: flags.Language,
+		Segments: transcription.NeedsSegments(flags.TranscribeFormat),
+		Speakers: flags.TranscribeSpeakers || transcription.NeedsSpeakers(flags.TranscribeFormat),
 	}
-	if message, err = tr.TranscribeFile(context.Background(), flags.TranscribeFile, model, flags.SplitMediaFile); err != nil {
+	var result *ai.Transcription
+	if result, err = transcription.TranscribeFile(context.Background(), transcriber, flags.TranscribeFile, opts,
+		flags.SplitMediaFile); err != nil {
 		return
 	}
-	return
+	return transcription.Format(result, flags.TranscribeFormat)
+}
+
+// resolveTranscriber selects the transcription backend: --transcribe-vendor,
+// then the configured transcription vendor, then the chat vendor if it can
+// transcribe, then OpenAI.
+func resolveTranscriber(flags *Flags, registry *core.PluginRegistry) (ret ai.Transcriber, model string, err error) {
+	vendorName := flags.TranscribeVendor
+	if vendorName == "" && registry.Transcription.Vendor.Value == "" && flags.Vendor != "" {
+		if ret = registry.GetTranscriber(flags.Vendor); ret != nil {
+			return
+		}
+	}
+	return registry.Transcription.Transcriber(vendorName)
 }
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/tools/transcription"
+	"github.com/danielmiessler/fabric/internal/tools/vision"
+)
+
+// handleVisionAttachments attaches the --screenshot and replaces video
+// attachments by frames sampled from them, described in the message and
+// optionally followed by a transcript of their audio. The returned cleanup
+// removes the temporary images once the chat request is built.
+func handleVisionAttachments(flags *Flags, registry *core.PluginRegistry) (cleanup func(), err error) {
+	var dir string
+	cleanup = func() {
+		if dir != "" {
+			os.RemoveAll(dir)
+		}
+	}
+	tempDir := func() (string, error) {
+		if dir == "" {
+			var createErr error
+			if dir, createErr = os.MkdirTemp("", "fabric-vision-*"); createErr != nil {
+				return "", createErr
+			}
+		}
+		return dir, nil
+	}
+
+	if flags.VideoFPS < 0 || flags.VideoMaxFrames < 0 {
+		err = fmt.Errorf("--video-fps and --video-max-frames must be positive")
+		return
+	}
+
+	var attachments []string
+	for i, value := range flags.Attachments {
+		var attachment *domain.Attachment
+		if attachment, err = domain.NewAttachment(value); err != nil {
+			return
+		}
+		var mimeType string
+		if mimeType, err = attachment.ResolveType(); err != nil {
+			return
+		}
+		if !vision.IsVideo(mimeType) {
+			attachments = append(attachments, value)
+			continue
+		}
+
+		var base string
+		if base, err = tempDir(); err != nil {
+			return
+		}
+		videoDir := filepath.Join(base, fmt.Sprintf("video-%d", i+1))
+		if err = os.Mkdir(videoDir, 0700); err != nil {
+			return
+		}
+		name := attachment.FileName()
+		var frames []vision.Frame
+		if frames, err = vision.ExtractFrames(value, videoDir, flags.VideoFPS, flags.VideoMaxFrames); err != nil {
+			return
+		}
+		for _, frame := range frames {
+			attachments = append(attachments, frame.Path)
+		}
+		flags.AppendMessage(vision.Describe(name, frames))
+
+		if flags.VideoTranscript {
+			var transcript string
+			if transcript, err = transcribeVideo(flags, registry, value, videoDir); err != nil {
+				return
+			}
+			flags.AppendMessage(fmt.Sprintf("Transcript of %s:\n%s", name, transcript))
+		}
+	}
+
+	if flags.Screenshot != "" {
+		var base string
+		if base, err = tempDir(); err != nil {
+			return
+		}
+		file := filepath.Join(base, "screenshot.png")
+		if err = vision.Screenshot(flags.Screenshot, file); err != nil {
+			return
+		}
+		attachments = append(attachments, file)
+	}
+
+	flags.Attachments = attachments
+	return
+}
+
+// transcribeVideo transcribes the audio track of a video with the
+// transcription vendor selected as for --transcribe-file.
+func transcribeVideo(flags *Flags, registry *core.PluginRegistry, src string, dir string) (ret string, err error) {
+	var audioFile string
+	if audioFile, err = vision.ExtractAudio(src, dir); err != nil {
+		return
+	}
+
+	var transcriber ai.Transcriber
+	var model string
+	if transcriber, model, err = resolveTranscriber(flags, registry); err != nil {
+		return
+	}
+	if flags.TranscribeModel != "" {
+		model = flags.TranscribeModel
+	}
+
+	opts := &ai.TranscriptionOptions{Model: model, Language: flags.Language}
+	var result *ai.Transcription
+	if result, err = transcription.TranscribeFile(context.Background(), transcriber, audioFile, opts, true); err != nil {
+		return
+	}
+	return transcription.Format(result, transcription.FormatText)
+}
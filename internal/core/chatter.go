@@ -14,15 +14,31 @@ import (
 	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
 	"github.com/danielmiessler/fabric/internal/plugins/strategy"
 	"github.com/danielmiessler/fabric/internal/plugins/template"
+	"github.com/danielmiessler/fabric/internal/tools/rag"
+	"github.com/danielmiessler/fabric/internal/tools/redact"
+	"github.com/danielmiessler/fabric/internal/tools/websearch"
 )
 
 const NoSessionPatternUserMessages = "no session, pattern or user messages provided"
 
 type Chatter struct {
-	db *fsdb.Db
+	db        *fsdb.Db
+	rag       *rag.RAG
+	webSearch *websearch.WebSearch
 
 	Stream bool
 	DryRun bool
+	// Redaction holds the rules that scrub messages before they are sent,
+	// nil disables redaction.
+	Redaction *redact.Config
+	// FileChanges receives the file changes of patterns that emit them, the
+	// message keeps only their summary. Without it the changes stay in the
+	// message.
+	FileChanges func(changes []domain.FileChange)
+
+	// redactionVendor selects the per-vendor rules; in dry-run mode it is the
+	// vendor the request would have been sent to.
+	redactionVendor string
 
 	model              string
 	modelContextLength int
@@ -30,8 +46,13 @@ type Chatter struct {
 	strategy           string
 }
 
-// Send processes a chat request and applies file changes for create_coding_feature pattern
+// Send processes a chat request and hands the file changes of patterns that
+// emit them to FileChanges
 func (o *Chatter) Send(request *domain.ChatRequest, opts *domain.ChatOptions) (session *fsdb.Session, err error) {
+	if plan := o.planChunks(request, opts); plan != nil {
+		return o.sendMapReduce(request, opts, plan)
+	}
+
 	modelToUse := opts.Model
 	if modelToUse == "" {
 		modelToUse = o.model
@@ -39,7 +60,23 @@ func (o *Chatter) Send(request *domain.ChatRequest, opts *domain.ChatOptions) (s
 	if o.vendor.NeedsRawMode(modelToUse) {
 		opts.Raw = true
 	}
-	if session, err = o.BuildSession(request, opts.Raw); err != nil {
+
+	var redactor *redact.Redactor
+	if redactor, err = o.Redaction.ForVendor(o.redactionVendorName()); err != nil {
+		return
+	}
+
+	var searchResults []websearch.Result
+	var searchContext string
+	if opts.Search && !o.DryRun && !o.hasNativeSearch(modelToUse) {
+		var query string
+		if query, searchResults, err = o.searchWeb(request, redactor); err != nil {
+			return
+		}
+		searchContext = websearch.FormatContext(query, searchResults)
+	}
+
+	if session, err = o.buildSession(request, opts.Raw, searchContext, redactor); err != nil {
 		return
 	}
 
@@ -55,6 +92,16 @@ func (o *Chatter) Send(request *domain.ChatRequest, opts *domain.ChatOptions) (s
 		return
 	}
 
+	if vendorMessages, err = o.prepareDocuments(vendorMessages, redactor != nil); err != nil {
+		return
+	}
+
+	// Redact after documents became text so their content is covered too
+	restore := redactor != nil && redactor.Restore && !o.DryRun
+	if redactor != nil {
+		vendorMessages = redactor.RedactMessages(vendorMessages)
+	}
+
 	if opts.Model == "" {
 		opts.Model = o.model
 	}
@@ -72,17 +119,27 @@ func (o *Chatter) Send(request *domain.ChatRequest, opts *domain.ChatOptions) (s
 
 		go func() {
 			defer close(done)
-			if streamErr := o.vendor.SendStream(session.GetVendorMessages(), opts, responseChan); streamErr != nil {
+			if streamErr := o.vendor.SendStream(vendorMessages, opts, responseChan); streamErr != nil {
 				errChan <- streamErr
 			}
 		}()
 
+		var restorer *redact.StreamRestorer
+		if restore {
+			restorer = redactor.NewStreamRestorer()
+		}
 		for response := range responseChan {
 			message += response
 			if !opts.SuppressThink {
+				if restorer != nil {
+					response = restorer.Write(response)
+				}
 				fmt.Print(response)
 			}
 		}
+		if restorer != nil && !opts.SuppressThink {
+			fmt.Print(restorer.Flush())
+		}
 
 		// Wait for goroutine to finish
 		<-done
@@ -98,11 +155,15 @@ func (o *Chatter) Send(request *domain.ChatRequest, opts *domain.ChatOptions) (s
 			// No errors, continue
 		}
 	} else {
-		if message, err = o.vendor.Send(context.Background(), session.GetVendorMessages(), opts); err != nil {
+		if message, err = o.vendor.Send(context.Background(), vendorMessages, opts); err != nil {
 			return
 		}
 	}
 
+	if restore {
+		message = redactor.RestoreText(message)
+	}
+
 	if opts.SuppressThink && !o.DryRun {
 		message = domain.StripThinkBlocks(message, opts.ThinkStartTag, opts.ThinkEndTag)
 	}
@@ -113,25 +174,30 @@ func (o *Chatter) Send(request *domain.ChatRequest, opts *domain.ChatOptions) (s
 		return
 	}
 
-	// Process file changes for create_coding_feature pattern
-	if request.PatternName == "create_coding_feature" {
+	if redactor != nil && o.DryRun {
+		redactions := "\n" + redact.FormatRedactions(redactor.Redactions())
+		message += redactions
+		if o.Stream {
+			fmt.Print(redactions)
+		}
+	}
+
+	if sources := websearch.FormatSources(searchResults); sources != "" {
+		message += sources
+		if o.Stream {
+			fmt.Println(sources)
+		}
+	}
+
+	// Hand the file changes of patterns that emit them to the caller
+	if o.FileChanges != nil && !o.DryRun && o.emitsFileChanges(request) {
 		summary, fileChanges, parseErr := domain.ParseFileChanges(message)
 		if parseErr != nil {
-			fmt.Printf("Warning: Failed to parse file changes: %v\n", parseErr)
+			fmt.Fprintf(os.Stderr, "Warning: Failed to parse file changes: %v\n", parseErr)
 		} else if len(fileChanges) > 0 {
-			projectRoot, err := os.Getwd()
-			if err != nil {
-				fmt.Printf("Warning: Failed to get current directory: %v\n", err)
-			} else {
-				if applyErr := domain.ApplyFileChanges(projectRoot, fileChanges); applyErr != nil {
-					fmt.Printf("Warning: Failed to apply file changes: %v\n", applyErr)
-				} else {
-					fmt.Println("Successfully applied file changes.")
-					fmt.Printf("You can review the changes with 'git diff' if you're using git.\n\n")
-				}
-			}
+			o.FileChanges(fileChanges)
+			message = summary
 		}
-		message = summary
 	}
 
 	session.Append(&chat.ChatCompletionMessage{Role: chat.ChatMessageRoleAssistant, Content: message})
@@ -142,7 +208,134 @@ func (o *Chatter) Send(request *domain.ChatRequest, opts *domain.ChatOptions) (s
 	return
 }
 
+// prepareDocuments converts document attachments the vendor cannot take
+// natively into text extracted locally. With redaction every document becomes
+// text, native document parts would reach the vendor unscrubbed.
+func (o *Chatter) prepareDocuments(msgs []*chat.ChatCompletionMessage, redacting bool) (ret []*chat.ChatCompletionMessage, err error) {
+	var supported func(mimeType string) bool
+	if supporter, ok := o.vendor.(ai.DocumentSupporter); ok && !redacting {
+		supported = supporter.SupportsDocument
+	}
+	if ret, err = domain.FlattenDocuments(msgs, supported); err != nil && redacting {
+		err = fmt.Errorf("documents are sent as text to be redacted, refusing to send one that cannot be: %w", err)
+	}
+	return
+}
+
+// retrieveContext embeds the user input, redacted like the chat messages, and
+// returns the most relevant chunks of the requested collection, formatted with
+// source citations.
+func (o *Chatter) retrieveContext(request *domain.ChatRequest, redactor *redact.Redactor) (ret string, err error) {
+	if o.rag == nil {
+		err = fmt.Errorf("retrieval is not configured, cannot use collection %s", request.RagCollection)
+		return
+	}
+
+	var collection *fsdb.Collection
+	if collection, err = o.db.Collections.Get(request.RagCollection); err != nil {
+		return
+	}
+
+	embedder, model, err := o.rag.Embedder()
+	if err != nil {
+		return
+	}
+	if collection.EmbeddingModel != model {
+		err = fmt.Errorf("collection %s was indexed with %s but %s is configured, re-index it with --index-collection",
+			collection.Name, collection.EmbeddingModel, model)
+		return
+	}
+
+	query := messageText(request.Message)
+	if redactor != nil {
+		query = redactor.Redact(query)
+	}
+	var chunks []rag.ScoredChunk
+	if chunks, err = rag.Retrieve(context.Background(), embedder, collection, query, request.RagTopK); err != nil {
+		return
+	}
+	ret = rag.FormatContext(collection.Name, chunks)
+	return
+}
+
+// hasNativeSearch reports whether the vendor handles --search itself.
+func (o *Chatter) hasNativeSearch(model string) bool {
+	searcher, ok := o.vendor.(ai.NativeSearcher)
+	return ok && searcher.SupportsNativeSearch(model)
+}
+
+// SupportsImageGenerationTool reports whether the chat model creates the
+// --image-file image itself. An empty model is the model of the chatter.
+func (o *Chatter) SupportsImageGenerationTool(model string) bool {
+	if model == "" {
+		model = o.model
+	}
+	tool, ok := o.vendor.(ai.ImageGenerationTool)
+	return ok && tool.SupportsImageGenerationTool(model)
+}
+
+// emitsFileChanges reports whether the pattern of the request asks for file
+// changes.
+func (o *Chatter) emitsFileChanges(request *domain.ChatRequest) bool {
+	if request.PatternName == "" {
+		return false
+	}
+	pattern, err := o.db.Patterns.GetWithoutVariables(request.PatternName, "")
+	return err == nil && pattern != nil && domain.EmitsFileChanges(pattern.Pattern)
+}
+
+// redactionVendorName returns the vendor whose redaction rules apply.
+func (o *Chatter) redactionVendorName() string {
+	if o.redactionVendor != "" || o.vendor == nil {
+		return o.redactionVendor
+	}
+	return o.vendor.GetName()
+}
+
+// searchWeb runs the configured search provider on the user input for vendors
+// without native search. The query is redacted like the messages.
+func (o *Chatter) searchWeb(request *domain.ChatRequest, redactor *redact.Redactor) (query string, ret []websearch.Result, err error) {
+	if o.webSearch == nil || !o.webSearch.IsEnabled() {
+		err = fmt.Errorf("%s has no native web search, configure a search provider with fabric --setup (Web Search) to use --search",
+			o.vendor.GetName())
+		return
+	}
+	var input string
+	if request.Message != nil {
+		input = messageText(request.Message)
+	}
+	if redactor != nil {
+		input = redactor.Redact(input)
+	}
+	return o.webSearch.Search(context.Background(), input)
+}
+
+// messageText returns the text of a message, joining the text parts of multi-part content.
+func messageText(message *chat.ChatCompletionMessage) string {
+	if len(message.MultiContent) == 0 {
+		return message.Content
+	}
+	var texts []string
+	for _, part := range message.MultiContent {
+		if part.Type == chat.ChatMessagePartTypeText {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
 func (o *Chatter) BuildSession(request *domain.ChatRequest, raw bool) (session *fsdb.Session, err error) {
+	var redactor *redact.Redactor
+	if redactor, err = o.Redaction.ForVendor(o.redactionVendorName()); err != nil {
+		return
+	}
+	return o.buildSession(request, raw, "", redactor)
+}
+
+// buildSession builds the session, prepending extraContext (e.g. web search
+// results) to the system message. The redactor, if any, scrubs the input
+// before it is sent to the embedding vendor for retrieval.
+func (o *Chatter) buildSession(request *domain.ChatRequest, raw bool, extraContext string, redactor *redact.Redactor) (session *fsdb.Session, err error) {
 	if request.SessionName != "" {
 		var sess *fsdb.Session
 		if sess, err = o.db.Sessions.Get(request.SessionName); err != nil {
@@ -206,6 +399,20 @@ func (o *Chatter) BuildSession(request *domain.ChatRequest, raw bool) (session *
 
 	systemMessage := strings.TrimSpace(contextContent) + strings.TrimSpace(patternContent)
 
+	if request.RagCollection != "" {
+		var retrieved string
+		if retrieved, err = o.retrieveContext(request, redactor); err != nil {
+			return nil, err
+		}
+		if retrieved != "" {
+			systemMessage = strings.TrimSpace(fmt.Sprintf("%s\n\n%s", retrieved, systemMessage))
+		}
+	}
+
+	if extraContext != "" {
+		systemMessage = strings.TrimSpace(fmt.Sprintf("%s\n\n%s", extraContext, systemMessage))
+	}
+
 	if request.StrategyName != "" {
 		strategy, err := strategy.LoadStrategy(request.StrategyName)
 		if err != nil {
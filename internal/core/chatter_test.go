@@ -3,12 +3,25 @@ package core
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/danielmiessler/fabric/internal/chat"
 	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/ai/dryrun"
 	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/tools/rag"
+	"github.com/danielmiessler/fabric/internal/tools/redact"
+	"github.com/danielmiessler/fabric/internal/tools/websearch"
 )
 
 // mockVendor implements the ai.Vendor interface for testing
@@ -228,3 +241,414 @@ func TestChatter_Send_StreamingSuccessfulAggregation(t *testing.T) {
 		t.Errorf("Expected aggregated message %q, got %q", expectedMessage, assistantMessage.Content)
 	}
 }
+
+func TestChatter_BuildSession_RagInjectsContext(t *testing.T) {
+	tempDir := t.TempDir()
+	db := fsdb.NewDb(tempDir)
+	if err := db.Collections.Configure(); err != nil {
+		t.Fatalf("failed to configure collections: %v", err)
+	}
+
+	embedder := rag.NewLocalEmbedder(rag.LocalEmbeddingDimensions)
+	vectors, _ := embedder.Embed(context.Background(), "", []string{"deploy.md\nkubernetes rollout steps"})
+	collection := &fsdb.Collection{
+		Name:           "docs",
+		EmbeddingModel: rag.LocalEmbeddingModel,
+		Files:          map[string]string{"deploy.md": "hash"},
+		Chunks: []*fsdb.CollectionChunk{
+			{Source: "deploy.md", StartLine: 1, EndLine: 3, Text: "kubernetes rollout steps", Vector: vectors[0]},
+		},
+	}
+	if err := db.Collections.SaveCollection(collection); err != nil {
+		t.Fatalf("failed to save collection: %v", err)
+	}
+
+	chatter := &Chatter{db: db, rag: rag.NewRAG(nil), vendor: &mockVendor{}, model: "test-model"}
+	request := &domain.ChatRequest{
+		RagCollection: "docs",
+		Message: &chat.ChatCompletionMessage{
+			Role:    chat.ChatMessageRoleUser,
+			Content: "how do I roll out to kubernetes?",
+		},
+	}
+
+	session, err := chatter.BuildSession(request, false)
+	if err != nil {
+		t.Fatalf("BuildSession returned error: %v", err)
+	}
+	system := session.Messages[0]
+	if system.Role != chat.ChatMessageRoleSystem {
+		t.Fatalf("expected system message first, got %s", system.Role)
+	}
+	if !strings.Contains(system.Content, "[1] deploy.md (lines 1-3)") {
+		t.Errorf("expected retrieved chunk with citation in system message, got %q", system.Content)
+	}
+}
+
+// recordingEmbedder records the texts sent for embedding
+type recordingEmbedder struct {
+	texts []string
+}
+
+func (r *recordingEmbedder) Embed(_ context.Context, _ string, texts []string) (ret [][]float32, err error) {
+	r.texts = append(r.texts, texts...)
+	for range texts {
+		ret = append(ret, []float32{1, 0})
+	}
+	return
+}
+
+func TestChatter_Send_RedactionRetrievalQuery(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	if err := db.Collections.Configure(); err != nil {
+		t.Fatalf("failed to configure collections: %v", err)
+	}
+	collection := &fsdb.Collection{
+		Name:           "docs",
+		EmbeddingModel: "Remote|model",
+		Files:          map[string]string{"a.md": "hash"},
+		Chunks:         []*fsdb.CollectionChunk{{Source: "a.md", StartLine: 1, EndLine: 1, Text: "text", Vector: []float32{1, 0}}},
+	}
+	if err := db.Collections.SaveCollection(collection); err != nil {
+		t.Fatalf("failed to save collection: %v", err)
+	}
+
+	embedder := &recordingEmbedder{}
+	retrieval := rag.NewRAG(func(string) ai.Embedder { return embedder })
+	retrieval.EmbeddingVendor.Value = "Remote"
+	retrieval.EmbeddingModel.Value = "model"
+	chatter := &Chatter{db: db, rag: retrieval, vendor: &mockVendor{}, model: "test-model", Redaction: &redact.Config{}}
+	request := &domain.ChatRequest{
+		RagCollection: "docs",
+		Message:       &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "what did jane@example.com ask?"},
+	}
+
+	if _, err := chatter.Send(request, &domain.ChatOptions{Model: "test-model"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if len(embedder.texts) != 1 || embedder.texts[0] != "what did [EMAIL_1] ask?" {
+		t.Errorf("expected the redacted query to be embedded, got %q", embedder.texts)
+	}
+}
+
+// nativeSearchVendor is a mock vendor with built-in web search
+type nativeSearchVendor struct {
+	mockVendor
+}
+
+func (m *nativeSearchVendor) SupportsNativeSearch(string) bool {
+	return true
+}
+
+func TestChatter_Send_SearchPreRetrieval(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") != "latest go release" {
+			t.Errorf("unexpected query %q", r.URL.Query().Get("q"))
+		}
+		w.Write([]byte(`{"results":[{"title":"Go 1.24","url":"https://go.dev/doc/go1.24","content":"Release notes"}]}`))
+	}))
+	defer server.Close()
+
+	webSearch := websearch.NewWebSearch()
+	webSearch.Provider.Value = websearch.ProviderSearXNG
+	webSearch.URL.Value = server.URL
+
+	var sent []*chat.ChatCompletionMessage
+	vendor := &mockVendor{sendFunc: func(_ context.Context, msgs []*chat.ChatCompletionMessage, _ *domain.ChatOptions) (string, error) {
+		sent = msgs
+		return "Go 1.24 is out [1].", nil
+	}}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), webSearch: webSearch, vendor: vendor, model: "test-model"}
+	request := &domain.ChatRequest{
+		Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "latest go release"},
+	}
+
+	session, err := chatter.Send(request, &domain.ChatOptions{Model: "test-model", Search: true})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if len(sent) == 0 || !strings.Contains(sent[0].Content, "[1] Go 1.24\nURL: https://go.dev/doc/go1.24") {
+		t.Errorf("expected search results in the system message, got %+v", sent)
+	}
+	want := "Go 1.24 is out [1].\n\n## Sources\n\n- [Go 1.24](https://go.dev/doc/go1.24)"
+	if got := session.GetLastMessage().Content; got != want {
+		t.Errorf("expected sources appended, got %q", got)
+	}
+}
+
+func TestChatter_Send_SearchNativeOrUnconfigured(t *testing.T) {
+	request := func() *domain.ChatRequest {
+		return &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "question"}}
+	}
+
+	// Vendors with native search get the request unchanged
+	native := &nativeSearchVendor{}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), webSearch: websearch.NewWebSearch(), vendor: native, model: "test-model"}
+	if _, err := chatter.Send(request(), &domain.ChatOptions{Model: "test-model", Search: true}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	// Other vendors need a configured provider instead of silently ignoring --search
+	chatter.vendor = &mockVendor{}
+	_, err := chatter.Send(request(), &domain.ChatOptions{Model: "test-model", Search: true})
+	if err == nil || !strings.Contains(err.Error(), "no native web search") {
+		t.Errorf("expected missing provider error, got %v", err)
+	}
+}
+
+func TestChatter_Send_Redaction(t *testing.T) {
+	var sent []*chat.ChatCompletionMessage
+	vendor := &mockVendor{sendFunc: func(_ context.Context, msgs []*chat.ChatCompletionMessage, _ *domain.ChatOptions) (string, error) {
+		sent = msgs
+		return "Contact [EMAIL_1] about the key.", nil
+	}}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: "test-model", Redaction: &redact.Config{}}
+	request := &domain.ChatRequest{
+		Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "jane@example.com leaked sk-abcdefghijklmnopqrstuvwxyz0123"},
+	}
+
+	session, err := chatter.Send(request, &domain.ChatOptions{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if got := sent[len(sent)-1].Content; got != "[EMAIL_1] leaked [API_KEY_1]" {
+		t.Errorf("expected redacted message for the vendor, got %q", got)
+	}
+	if got := session.GetLastMessage().Content; got != "Contact jane@example.com about the key." {
+		t.Errorf("expected restored response, got %q", got)
+	}
+	if got := session.Messages[0].Content; !strings.Contains(got, "jane@example.com") {
+		t.Errorf("expected the session to keep the original input, got %q", got)
+	}
+
+	// Vendors with redaction disabled receive the original text
+	disabled := false
+	chatter.Redaction = &redact.Config{Vendors: map[string]redact.Rules{"mock": {Enabled: &disabled}}}
+	request.Message.Content = "jane@example.com"
+	if _, err = chatter.Send(request, &domain.ChatOptions{Model: "test-model"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if got := sent[len(sent)-1].Content; got != "jane@example.com" {
+		t.Errorf("expected unredacted message, got %q", got)
+	}
+}
+
+// documentVendor takes every document natively
+type documentVendor struct {
+	mockVendor
+}
+
+func (d *documentVendor) SupportsDocument(string) bool { return true }
+
+func TestChatter_Send_RedactionDocuments(t *testing.T) {
+	var sent []*chat.ChatCompletionMessage
+	vendor := &documentVendor{mockVendor{sendFunc: func(_ context.Context, msgs []*chat.ChatCompletionMessage, _ *domain.ChatOptions) (string, error) {
+		sent = msgs
+		return "ok", nil
+	}}}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: "test-model", Redaction: &redact.Config{}}
+	document := func(mimeType string, content string) *domain.ChatRequest {
+		return &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeText, Text: "summarize"},
+			{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{
+				Filename: "notes", MimeType: mimeType, Data: base64.StdEncoding.EncodeToString([]byte(content))}},
+		}}}
+	}
+
+	if _, err := chatter.Send(document("text/plain", "mail jane@example.com"), &domain.ChatOptions{Model: "test-model"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	last := sent[len(sent)-1]
+	if len(last.MultiContent) > 0 {
+		t.Fatalf("expected the document to be sent as text, got %+v", last.MultiContent)
+	}
+	if strings.Contains(last.Content, "jane@example.com") || !strings.Contains(last.Content, "[EMAIL_1]") {
+		t.Errorf("expected the document to be redacted, got %q", last.Content)
+	}
+
+	// Documents that cannot become text are refused rather than sent unscrubbed
+	sent = nil
+	if _, err := chatter.Send(document("application/octet-stream", "\xff\xfe\x00binary"), &domain.ChatOptions{Model: "test-model"}); err == nil {
+		t.Errorf("expected a binary document to be refused")
+	}
+	if sent != nil {
+		t.Errorf("expected nothing to be sent")
+	}
+
+	// Without redaction the vendor gets the document natively
+	chatter.Redaction = nil
+	if _, err := chatter.Send(document("text/plain", "mail jane@example.com"), &domain.ChatOptions{Model: "test-model"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if last = sent[len(sent)-1]; len(last.MultiContent) != 2 || last.MultiContent[1].Type != chat.ChatMessagePartTypeFile {
+		t.Errorf("expected the native document part, got %+v", last)
+	}
+}
+
+func TestChatter_Send_RedactionDryRun(t *testing.T) {
+	chatter := &Chatter{
+		db:              fsdb.NewDb(t.TempDir()),
+		vendor:          dryrun.NewClient(),
+		model:           "test-model",
+		DryRun:          true,
+		Redaction:       &redact.Config{},
+		redactionVendor: "OpenAI",
+	}
+	request := &domain.ChatRequest{
+		Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "server 10.0.0.7"},
+	}
+
+	session, err := chatter.Send(request, &domain.ChatOptions{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	output := session.GetLastMessage().Content
+	if !strings.Contains(output, "server [IP_1]") {
+		t.Errorf("expected the dry run to show the redacted message, got %q", output)
+	}
+	if !strings.Contains(output, "Redactions (1):\n  [IP_1] <- 10.0.0.7") {
+		t.Errorf("expected the list of redactions, got %q", output)
+	}
+}
+
+func TestChatter_Send_MapReduce(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+
+	var lines []string
+	for i := 0; i < 40; i++ {
+		lines = append(lines, fmt.Sprintf("[00:%02d:00] transcript line number %d", i, i))
+	}
+	input := strings.Join(lines, "\n")
+
+	var mu sync.Mutex
+	var mapCalls int
+	var reduceInput string
+	vendor := &mockVendor{sendFunc: func(_ context.Context, msgs []*chat.ChatCompletionMessage, _ *domain.ChatOptions) (string, error) {
+		content := msgs[len(msgs)-1].Content
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasPrefix(content, "## Part 1 of") {
+			reduceInput = content
+			return "final summary", nil
+		}
+		if !strings.HasPrefix(content, "[00:") {
+			t.Errorf("expected chunk to start on a timestamp line, got %q", content)
+		}
+		mapCalls++
+		return "partial", nil
+	}}
+
+	chatter := &Chatter{db: db, vendor: vendor, model: "test-model"}
+	request := &domain.ChatRequest{
+		Message:   &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: input},
+		MapReduce: &domain.MapReduceConfig{ChunkSize: 100, ChunkOverlap: 10, Workers: 3},
+	}
+
+	session, err := chatter.Send(request, &domain.ChatOptions{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if got := session.GetLastMessage().Content; got != "final summary" {
+		t.Errorf("expected reduce result, got %q", got)
+	}
+	if mapCalls < 2 {
+		t.Errorf("expected several map calls, got %d", mapCalls)
+	}
+	if strings.Count(reduceInput, "partial") != mapCalls {
+		t.Errorf("expected every partial result in the reduce input, got %q", reduceInput)
+	}
+}
+
+func TestChatter_Send_MapReduceSmallInputUnchanged(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	calls := 0
+	vendor := &mockVendor{sendFunc: func(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, error) {
+		calls++
+		return "direct", nil
+	}}
+
+	chatter := &Chatter{db: db, vendor: vendor, model: "test-model"}
+	request := &domain.ChatRequest{
+		Message:   &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "short input"},
+		MapReduce: &domain.MapReduceConfig{},
+	}
+	if _, err := chatter.Send(request, &domain.ChatOptions{Model: "test-model"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected a single direct call, got %d", calls)
+	}
+}
+
+func TestChatter_Send_MapReduceDryRunShowsPlan(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	chatter := &Chatter{db: db, vendor: dryrun.NewClient(), model: "test-model", DryRun: true}
+	request := &domain.ChatRequest{
+		Message:   &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: strings.Repeat("paragraph text here\n\n", 50)},
+		MapReduce: &domain.MapReduceConfig{ChunkSize: 50, ChunkOverlap: 5},
+	}
+
+	session, err := chatter.Send(request, &domain.ChatOptions{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	output := session.GetLastMessage().Content
+	if !strings.Contains(output, "chunk size 50 tokens, overlap 5 tokens") || !strings.Contains(output, "First map request:") {
+		t.Errorf("expected map-reduce plan in dry-run output, got %q", output)
+	}
+}
+
+func TestChatter_Send_FileChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	db := fsdb.NewDb(tempDir)
+	for name, system := range map[string]string{
+		"coder":  "Write code and end with " + domain.FileChangesMarker + " followed by a JSON array.",
+		"writer": "Write prose.",
+	} {
+		dir := filepath.Join(tempDir, "patterns", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "system.md"), []byte(system), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	response := "Added a file.\n" + domain.FileChangesMarker + "\n" +
+		`[{"operation": "create", "path": "a.txt", "content": "a"}]`
+	vendor := &mockVendor{sendFunc: func(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, error) {
+		return response, nil
+	}}
+	var received []domain.FileChange
+	chatter := &Chatter{db: db, vendor: vendor, model: "test-model", FileChanges: func(changes []domain.FileChange) {
+		received = append(received, changes...)
+	}}
+
+	send := func(pattern string) string {
+		request := &domain.ChatRequest{
+			PatternName: pattern,
+			Message:     &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "add a file"},
+		}
+		session, err := chatter.Send(request, &domain.ChatOptions{Model: "test-model"})
+		if err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+		return session.GetLastMessage().Content
+	}
+
+	if got := send("coder"); strings.TrimSpace(got) != "Added a file." {
+		t.Errorf("expected only the summary in the message, got %q", got)
+	}
+	if len(received) != 1 || received[0].Path != "a.txt" {
+		t.Errorf("expected the file change to be handed over, got %+v", received)
+	}
+
+	// Patterns that do not ask for file changes keep the whole message
+	received = nil
+	if got := send("writer"); got != response {
+		t.Errorf("expected the unchanged message, got %q", got)
+	}
+	if received != nil {
+		t.Errorf("expected no file changes, got %+v", received)
+	}
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/util"
+)
+
+const (
+	// DefaultMapReduceContextLength is assumed when neither the request nor the
+	// defaults specify the model context length.
+	DefaultMapReduceContextLength = 8192
+	// charsPerToken is the rough estimate used to convert token budgets to characters.
+	charsPerToken = 4
+)
+
+// chunkPlan describes how an oversized input is split for map-reduce.
+type chunkPlan struct {
+	ChunkSize    int
+	ChunkOverlap int
+	Chunks       []util.TextChunk
+}
+
+func (o *chunkPlan) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Map-reduce plan: %d chunks (chunk size %d tokens, overlap %d tokens)\n",
+		len(o.Chunks), o.ChunkSize, o.ChunkOverlap)
+	for i, chunk := range o.Chunks {
+		fmt.Fprintf(&builder, "  chunk %d: lines %d-%d, ~%d tokens\n",
+			i+1, chunk.StartLine, chunk.EndLine, estimateTokens(chunk.Text))
+	}
+	return builder.String()
+}
+
+func estimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// planChunks returns a plan when the request input does not fit in the chunk
+// budget, or nil when it can be sent as is.
+func (o *Chatter) planChunks(request *domain.ChatRequest, opts *domain.ChatOptions) *chunkPlan {
+	config := request.MapReduce
+	if config == nil || request.Message == nil || len(request.Message.MultiContent) > 0 {
+		return nil
+	}
+
+	chunkSize := config.ChunkSize
+	if chunkSize <= 0 {
+		contextLength := opts.ModelContextLength
+		if contextLength == 0 {
+			contextLength = o.modelContextLength
+		}
+		if contextLength == 0 {
+			contextLength = DefaultMapReduceContextLength
+		}
+		// Leave half of the context for the pattern and the response.
+		chunkSize = contextLength / 2
+	}
+	overlap := min(max(config.ChunkOverlap, 0), chunkSize/2)
+
+	if estimateTokens(request.Message.Content) <= chunkSize {
+		return nil
+	}
+
+	chunker := &util.Chunker{Size: chunkSize * charsPerToken, Overlap: overlap * charsPerToken}
+	chunks := chunker.Split(request.Message.Content)
+	if len(chunks) < 2 {
+		return nil
+	}
+	return &chunkPlan{ChunkSize: chunkSize, ChunkOverlap: overlap, Chunks: chunks}
+}
+
+// sendMapReduce runs the map pattern over every chunk concurrently and then the
+// reduce pattern over the collected partial results. In dry-run mode only the
+// plan and the first map request are shown.
+func (o *Chatter) sendMapReduce(request *domain.ChatRequest, opts *domain.ChatOptions, plan *chunkPlan) (session *fsdb.Session, err error) {
+	config := request.MapReduce
+	mapPattern := config.MapPattern
+	if mapPattern == "" {
+		mapPattern = request.PatternName
+	}
+	reducePattern := config.ReducePattern
+	if reducePattern == "" {
+		reducePattern = request.PatternName
+	}
+
+	// Partial results are collected, never streamed.
+	mapper := *o
+	mapper.Stream = false
+
+	mapRequest := func(chunk util.TextChunk) *domain.ChatRequest {
+		req := *request
+		req.SessionName = ""
+		req.PatternName = mapPattern
+		req.MapReduce = nil
+		req.Message = &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: chunk.Text}
+		return &req
+	}
+
+	if o.DryRun {
+		mapOpts := *opts
+		if session, err = mapper.Send(mapRequest(plan.Chunks[0]), &mapOpts); err != nil {
+			return
+		}
+		last := session.GetLastMessage()
+		last.Content = fmt.Sprintf("%s\nMap pattern: %s\nReduce pattern: %s\n\nFirst map request:\n%s",
+			plan, mapPattern, reducePattern, last.Content)
+		return
+	}
+
+	workers := max(config.Workers, 1)
+	partials := make([]string, len(plan.Chunks))
+	errs := make([]error, len(plan.Chunks))
+	semaphore := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, chunk := range plan.Chunks {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			mapOpts := *opts
+			mapSession, mapErr := mapper.Send(mapRequest(chunk), &mapOpts)
+			if mapErr != nil {
+				errs[i] = fmt.Errorf("map step failed for chunk %d/%d: %w", i+1, len(plan.Chunks), mapErr)
+				return
+			}
+			partials[i] = mapSession.GetLastMessage().Content
+		}()
+	}
+	wg.Wait()
+	for _, mapErr := range errs {
+		if mapErr != nil {
+			return nil, mapErr
+		}
+	}
+
+	var combined strings.Builder
+	for i, partial := range partials {
+		fmt.Fprintf(&combined, "## Part %d of %d\n\n%s\n\n", i+1, len(partials), strings.TrimSpace(partial))
+	}
+
+	reduceRequest := *request
+	reduceRequest.PatternName = reducePattern
+	reduceRequest.MapReduce = nil
+	// Variables were already applied to the input during the map step.
+	reduceRequest.InputHasVars = false
+	reduceRequest.Message = &chat.ChatCompletionMessage{
+		Role:    chat.ChatMessageRoleUser,
+		Content: strings.TrimSpace(combined.String()),
+	}
+	return o.Send(&reduceRequest, opts)
+}
@@ -32,8 +32,15 @@ import (
 	"github.com/danielmiessler/fabric/internal/plugins/template"
 	"github.com/danielmiessler/fabric/internal/tools"
 	"github.com/danielmiessler/fabric/internal/tools/custom_patterns"
+	"github.com/danielmiessler/fabric/internal/tools/images"
 	"github.com/danielmiessler/fabric/internal/tools/jina"
 	"github.com/danielmiessler/fabric/internal/tools/lang"
+	"github.com/danielmiessler/fabric/internal/tools/rag"
+	"github.com/danielmiessler/fabric/internal/tools/redact"
+	"github.com/danielmiessler/fabric/internal/tools/scraper"
+	"github.com/danielmiessler/fabric/internal/tools/speech"
+	"github.com/danielmiessler/fabric/internal/tools/transcription"
+	"github.com/danielmiessler/fabric/internal/tools/websearch"
 	"github.com/danielmiessler/fabric/internal/tools/youtube"
 	"github.com/danielmiessler/fabric/internal/util"
 )
@@ -89,6 +96,12 @@ func NewPluginRegistry(db *fsdb.Db) (ret *PluginRegistry, err error) {
 	ret.TemplateExtensions = template.NewExtensionManager(filepath.Join(homedir, ".config/fabric"))
 
 	ret.Defaults = tools.NeeDefaults(ret.GetModels)
+	ret.RAG = rag.NewRAG(ret.GetEmbedder)
+	ret.Scraper = scraper.NewScraper(ret.Jina)
+	ret.WebSearch = websearch.NewWebSearch()
+	ret.Transcription = transcription.NewTranscription(ret.GetTranscriber)
+	ret.Speech = speech.NewSpeech(ret.GetSpeechSynthesizer)
+	ret.Images = images.NewImages(ret.GetImageGenerator)
 
 	// Create a vendors slice to hold all vendors (order doesn't matter initially)
 	vendors := []ai.Vendor{}
@@ -151,6 +164,12 @@ type PluginRegistry struct {
 	Jina               *jina.Client
 	TemplateExtensions *template.ExtensionManager
 	Strategies         *strategy.StrategiesManager
+	RAG                *rag.RAG
+	Scraper            *scraper.Scraper
+	WebSearch          *websearch.WebSearch
+	Transcription      *transcription.Transcription
+	Speech             *speech.Speech
+	Images             *images.Images
 }
 
 func (o *PluginRegistry) SaveEnvFile() (err error) {
@@ -169,6 +188,12 @@ func (o *PluginRegistry) SaveEnvFile() (err error) {
 	o.YouTube.SetupFillEnvFileContent(&envFileContent)
 	o.Jina.SetupFillEnvFileContent(&envFileContent)
 	o.Language.SetupFillEnvFileContent(&envFileContent)
+	o.RAG.SetupFillEnvFileContent(&envFileContent)
+	o.Scraper.SetupFillEnvFileContent(&envFileContent)
+	o.WebSearch.SetupFillEnvFileContent(&envFileContent)
+	o.Transcription.SetupFillEnvFileContent(&envFileContent)
+	o.Speech.SetupFillEnvFileContent(&envFileContent)
+	o.Images.SetupFillEnvFileContent(&envFileContent)
 
 	err = o.Db.SaveEnv(envFileContent.String())
 	return
@@ -192,7 +217,7 @@ func (o *PluginRegistry) Setup() (err error) {
 			return vendor
 		})...)
 
-	groupsPlugins.AddGroupItems("Tools", o.CustomPatterns, o.Defaults, o.Jina, o.Language, o.PatternsLoader, o.Strategies, o.YouTube)
+	groupsPlugins.AddGroupItems("Tools", o.CustomPatterns, o.Defaults, o.Images, o.Jina, o.Language, o.PatternsLoader, o.RAG, o.Scraper, o.Speech, o.Strategies, o.Transcription, o.WebSearch, o.YouTube)
 
 	for {
 		groupsPlugins.Print(false)
@@ -286,14 +311,54 @@ func (o *PluginRegistry) Configure() (err error) {
 	_ = o.YouTube.Configure()
 	_ = o.Jina.Configure()
 	_ = o.Language.Configure()
+	_ = o.RAG.Configure()
+	_ = o.Scraper.Configure()
+	_ = o.WebSearch.Configure()
+	_ = o.Transcription.Configure()
+	_ = o.Speech.Configure()
+	_ = o.Images.Configure()
 	return
 }
 
+// GetEmbedder returns the configured vendor with the given name if it supports embeddings
+func (o *PluginRegistry) GetEmbedder(vendorName string) ai.Embedder {
+	if embedder, ok := o.VendorManager.FindByName(vendorName).(ai.Embedder); ok {
+		return embedder
+	}
+	return nil
+}
+
+// GetTranscriber returns the configured vendor with the given name if it supports audio transcription
+func (o *PluginRegistry) GetTranscriber(vendorName string) ai.Transcriber {
+	if transcriber, ok := o.VendorManager.FindByName(vendorName).(ai.Transcriber); ok {
+		return transcriber
+	}
+	return nil
+}
+
+// GetSpeechSynthesizer returns the configured vendor with the given name if it supports text-to-speech
+func (o *PluginRegistry) GetSpeechSynthesizer(vendorName string) ai.SpeechSynthesizer {
+	if synthesizer, ok := o.VendorManager.FindByName(vendorName).(ai.SpeechSynthesizer); ok {
+		return synthesizer
+	}
+	return nil
+}
+
+// GetImageGenerator returns the configured vendor with the given name if it supports image generation
+func (o *PluginRegistry) GetImageGenerator(vendorName string) ai.ImageGenerator {
+	if generator, ok := o.VendorManager.FindByName(vendorName).(ai.ImageGenerator); ok {
+		return generator
+	}
+	return nil
+}
+
 func (o *PluginRegistry) GetChatter(model string, modelContextLength int, vendorName string, strategy string, stream bool, dryRun bool) (ret *Chatter, err error) {
 	ret = &Chatter{
-		db:     o.Db,
-		Stream: stream,
-		DryRun: dryRun,
+		db:        o.Db,
+		rag:       o.RAG,
+		webSearch: o.WebSearch,
+		Stream:    stream,
+		DryRun:    dryRun,
 	}
 
 	defaultModel := o.Defaults.Model.Value
@@ -311,12 +376,21 @@ func (o *PluginRegistry) GetChatter(model string, modelContextLength int, vendor
 		ret.modelContextLength = defaultModelContextLength
 	}
 
+	if ret.Redaction, err = redact.LoadConfig(filepath.Join(o.Db.Dir, redact.ConfigFileName)); err != nil {
+		return
+	}
+
 	if dryRun {
 		ret.vendor = dryrun.NewClient()
 		ret.model = model
 		if ret.model == "" {
 			ret.model = defaultModel
 		}
+		// Show the redactions of the vendor the request would go to
+		ret.redactionVendor = vendorName
+		if ret.redactionVendor == "" {
+			ret.redactionVendor = defaultVendor
+		}
 	} else if model == "" {
 		if vendorName != "" {
 			ret.vendor = vendorManager.FindByName(vendorName)
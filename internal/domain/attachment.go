@@ -8,9 +8,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 
+	"github.com/danielmiessler/fabric/internal/chat"
 	"github.com/gabriel-vasile/mimetype"
 )
 
@@ -112,6 +115,61 @@ func (a *Attachment) Base64Content() (ret string, err error) {
 	return
 }
 
+// ChatMessagePart converts the attachment into a chat message part based on its
+// MIME type: images become image parts (URLs are passed through), everything
+// else becomes a document part carrying the base64 encoded content.
+func (a *Attachment) ChatMessagePart() (ret chat.ChatMessagePart, err error) {
+	var mimeType string
+	if mimeType, err = a.ResolveType(); err != nil {
+		return
+	}
+
+	if IsImageMimeType(mimeType) {
+		url := a.URL
+		if url == nil {
+			var base64Image string
+			if base64Image, err = a.Base64Content(); err != nil {
+				return
+			}
+			dataURL := fmt.Sprintf("data:%s;base64,%s", BaseMimeType(mimeType), base64Image)
+			url = &dataURL
+		}
+		ret = chat.ChatMessagePart{
+			Type:     chat.ChatMessagePartTypeImageURL,
+			ImageURL: &chat.ChatMessageImageURL{URL: *url},
+		}
+		return
+	}
+
+	var data string
+	if data, err = a.Base64Content(); err != nil {
+		return
+	}
+	ret = chat.ChatMessagePart{
+		Type: chat.ChatMessagePartTypeFile,
+		File: &chat.ChatMessageFile{
+			Filename: a.FileName(),
+			MimeType: BaseMimeType(mimeType),
+			Data:     data,
+		},
+	}
+	return
+}
+
+// FileName returns the base name of the attachment's path or URL.
+func (a *Attachment) FileName() string {
+	if a.Path != nil {
+		return filepath.Base(*a.Path)
+	}
+	if a.URL != nil {
+		if parsed, err := url.Parse(*a.URL); err == nil && path.Base(parsed.Path) != "/" && path.Base(parsed.Path) != "." {
+			return path.Base(parsed.Path)
+		}
+		return *a.URL
+	}
+	return "attachment"
+}
+
 func NewAttachment(value string) (ret *Attachment, err error) {
 	if isURL(value) {
 		var mimeType string
@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+)
+
+const MimeTypePDF = "application/pdf"
+
+// textMimeTypes lists non text/* MIME types whose content is plain text.
+var textMimeTypes = map[string]struct{}{
+	"application/json":       {},
+	"application/xml":        {},
+	"application/javascript": {},
+	"application/x-yaml":     {},
+	"application/yaml":       {},
+	"application/toml":       {},
+	"application/x-sh":       {},
+	"application/sql":        {},
+}
+
+// BaseMimeType strips parameters such as "; charset=utf-8" and lower-cases the type.
+func BaseMimeType(mimeType string) string {
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+}
+
+func IsImageMimeType(mimeType string) bool {
+	return strings.HasPrefix(BaseMimeType(mimeType), "image/")
+}
+
+func IsTextMimeType(mimeType string) bool {
+	base := BaseMimeType(mimeType)
+	if strings.HasPrefix(base, "text/") {
+		return true
+	}
+	_, ok := textMimeTypes[base]
+	return ok
+}
+
+func IsPDFMimeType(mimeType string) bool {
+	return BaseMimeType(mimeType) == MimeTypePDF
+}
+
+// ExtractDocumentText returns the plain text of a document attachment. Text
+// documents are decoded directly, PDFs are converted with pdftotext (poppler)
+// when it is installed.
+func ExtractDocumentText(file *chat.ChatMessageFile) (ret string, err error) {
+	var content []byte
+	if content, err = base64.StdEncoding.DecodeString(file.Data); err != nil {
+		err = fmt.Errorf("could not decode %s: %w", file.Filename, err)
+		return
+	}
+
+	switch {
+	case IsTextMimeType(file.MimeType) || (!IsPDFMimeType(file.MimeType) && utf8.Valid(content)):
+		ret = string(content)
+	case IsPDFMimeType(file.MimeType):
+		ret, err = extractPDFText(content)
+	default:
+		err = fmt.Errorf("cannot extract text from %s (%s)", file.Filename, file.MimeType)
+	}
+	return
+}
+
+func extractPDFText(content []byte) (ret string, err error) {
+	if _, err = exec.LookPath("pdftotext"); err != nil {
+		err = fmt.Errorf("this vendor does not accept PDF documents and pdftotext was not found for local text extraction: please install poppler-utils")
+		return
+	}
+	cmd := exec.Command("pdftotext", "-layout", "-", "-")
+	cmd.Stdin = bytes.NewReader(content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		err = fmt.Errorf("pdftotext failed: %v: %s", err, stderr.String())
+		return
+	}
+	ret = stdout.String()
+	return
+}
+
+// DocumentTextPart converts a document attachment into a text part that embeds
+// the extracted content, for vendors that cannot take the document natively.
+func DocumentTextPart(file *chat.ChatMessageFile) (ret chat.ChatMessagePart, err error) {
+	var text string
+	if text, err = ExtractDocumentText(file); err != nil {
+		return
+	}
+	ret = chat.ChatMessagePart{
+		Type: chat.ChatMessagePartTypeText,
+		Text: fmt.Sprintf("--- BEGIN DOCUMENT: %s ---\n%s\n--- END DOCUMENT: %s ---", file.Filename, strings.TrimSpace(text), file.Filename),
+	}
+	return
+}
+
+// FlattenDocuments replaces the document parts the vendor does not support with
+// text parts holding their extracted content. Messages that end up with text
+// parts only are collapsed into plain Content, so that vendors without
+// multi-part support still see the documents. The input messages are not modified.
+func FlattenDocuments(msgs []*chat.ChatCompletionMessage, supported func(mimeType string) bool) (ret []*chat.ChatCompletionMessage, err error) {
+	ret = make([]*chat.ChatCompletionMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		if !hasDocuments(msg) {
+			ret = append(ret, msg)
+			continue
+		}
+
+		converted := *msg
+		converted.MultiContent = make([]chat.ChatMessagePart, 0, len(msg.MultiContent))
+		textOnly := true
+		for _, part := range msg.MultiContent {
+			if part.Type == chat.ChatMessagePartTypeFile && part.File != nil && (supported == nil || !supported(part.File.MimeType)) {
+				if part, err = DocumentTextPart(part.File); err != nil {
+					return
+				}
+			}
+			if part.Type != chat.ChatMessagePartTypeText {
+				textOnly = false
+			}
+			converted.MultiContent = append(converted.MultiContent, part)
+		}
+
+		if textOnly {
+			texts := make([]string, 0, len(converted.MultiContent))
+			for _, part := range converted.MultiContent {
+				texts = append(texts, part.Text)
+			}
+			converted.Content = strings.Join(texts, "\n\n")
+			converted.MultiContent = nil
+		}
+		ret = append(ret, &converted)
+	}
+	return
+}
+
+func hasDocuments(msg *chat.ChatCompletionMessage) bool {
+	for _, part := range msg.MultiContent {
+		if part.Type == chat.ChatMessagePartTypeFile {
+			return true
+		}
+	}
+	return false
+}
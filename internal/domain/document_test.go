@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+)
+
+func TestMimeTypeClassification(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		text     bool
+		pdf      bool
+		image    bool
+	}{
+		{"text/plain; charset=utf-8", true, false, false},
+		{"application/json", true, false, false},
+		{"application/pdf", false, true, false},
+		{"image/png", false, false, true},
+		{"application/octet-stream", false, false, false},
+	}
+	for _, tt := range tests {
+		if got := IsTextMimeType(tt.mimeType); got != tt.text {
+			t.Errorf("IsTextMimeType(%q) = %v, want %v", tt.mimeType, got, tt.text)
+		}
+		if got := IsPDFMimeType(tt.mimeType); got != tt.pdf {
+			t.Errorf("IsPDFMimeType(%q) = %v, want %v", tt.mimeType, got, tt.pdf)
+		}
+		if got := IsImageMimeType(tt.mimeType); got != tt.image {
+			t.Errorf("IsImageMimeType(%q) = %v, want %v", tt.mimeType, got, tt.image)
+		}
+	}
+}
+
+func TestFlattenDocuments(t *testing.T) {
+	code := &chat.ChatMessageFile{
+		Filename: "main.go",
+		MimeType: "text/plain",
+		Data:     base64.StdEncoding.EncodeToString([]byte("package main\n")),
+	}
+	msgs := []*chat.ChatCompletionMessage{
+		{Role: chat.ChatMessageRoleSystem, Content: "system"},
+		{Role: chat.ChatMessageRoleUser, MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeText, Text: "review this"},
+			{Type: chat.ChatMessagePartTypeFile, File: code},
+		}},
+	}
+
+	flattened, err := FlattenDocuments(msgs, nil)
+	if err != nil {
+		t.Fatalf("FlattenDocuments failed: %v", err)
+	}
+	if flattened[0] != msgs[0] {
+		t.Error("expected messages without documents to be passed through")
+	}
+	user := flattened[1]
+	if user.MultiContent != nil {
+		t.Fatalf("expected text-only message to be collapsed, got %d parts", len(user.MultiContent))
+	}
+	if !strings.HasPrefix(user.Content, "review this") || !strings.Contains(user.Content, "--- BEGIN DOCUMENT: main.go ---\npackage main") {
+		t.Errorf("unexpected flattened content: %q", user.Content)
+	}
+	if len(msgs[1].MultiContent) != 2 {
+		t.Error("expected input messages to be left untouched")
+	}
+
+	kept, err := FlattenDocuments(msgs, func(string) bool { return true })
+	if err != nil {
+		t.Fatalf("FlattenDocuments failed: %v", err)
+	}
+	if kept[1].MultiContent[1].Type != chat.ChatMessagePartTypeFile {
+		t.Error("expected supported documents to be kept as file parts")
+	}
+}
+
+func TestExtractDocumentText_Binary(t *testing.T) {
+	file := &chat.ChatMessageFile{
+		Filename: "blob.bin",
+		MimeType: "application/octet-stream",
+		Data:     base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe, 0x00, 0x01}),
+	}
+	if _, err := ExtractDocumentText(file); err == nil {
+		t.Error("expected error for binary document")
+	}
+}
+
+func TestAttachment_ChatMessagePart(t *testing.T) {
+	dir := t.TempDir()
+	codePath := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(codePath, []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	attachment, err := NewAttachment(codePath)
+	if err != nil {
+		t.Fatalf("NewAttachment failed: %v", err)
+	}
+	part, err := attachment.ChatMessagePart()
+	if err != nil {
+		t.Fatalf("ChatMessagePart failed: %v", err)
+	}
+	if part.Type != chat.ChatMessagePartTypeFile || part.File == nil {
+		t.Fatalf("expected file part for source code, got %v", part.Type)
+	}
+	if part.File.Filename != "main.go" || part.File.MimeType != "text/plain" {
+		t.Errorf("unexpected file metadata: %+v", part.File)
+	}
+
+	pngPath := filepath.Join(dir, "pixel.png")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+	if err = os.WriteFile(pngPath, png, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if attachment, err = NewAttachment(pngPath); err != nil {
+		t.Fatalf("NewAttachment failed: %v", err)
+	}
+	if part, err = attachment.ChatMessagePart(); err != nil {
+		t.Fatalf("ChatMessagePart failed: %v", err)
+	}
+	if part.Type != chat.ChatMessagePartTypeImageURL || !strings.HasPrefix(part.ImageURL.URL, "data:image/png;base64,") {
+		t.Errorf("expected image data URL part, got %+v", part)
+	}
+}
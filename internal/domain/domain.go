@@ -23,6 +23,21 @@ type ChatRequest struct {
 	InputHasVars          bool
 	NoVariableReplacement bool
 	StrategyName          string
+	RagCollection         string
+	RagTopK               int
+	MapReduce             *MapReduceConfig
+}
+
+// MapReduceConfig enables chunked processing of inputs larger than the model
+// budget: the map pattern runs per chunk, the reduce pattern over the partial
+// results. Empty pattern names fall back to the request pattern. Sizes are in
+// estimated tokens; a zero ChunkSize derives the budget from the model context length.
+type MapReduceConfig struct {
+	MapPattern    string
+	ReducePattern string
+	ChunkSize     int
+	ChunkOverlap  int
+	Workers       int
 }
 
 type ChatOptions struct {
@@ -46,9 +61,6 @@ type ChatOptions struct {
 	SuppressThink       bool
 	ThinkStartTag       string
 	ThinkEndTag         string
-	AudioOutput         bool
-	AudioFormat         string
-	Voice               string
 	Notification        bool
 	NotificationCommand string
 }
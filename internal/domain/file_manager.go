@@ -3,12 +3,12 @@ package domain
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 )
 
-// FileChangesMarker identifies the start of a file changes section in output
+// FileChangesMarker identifies the start of a file changes section in output.
+// Any pattern whose system prompt asks for this marker emits file changes.
 const FileChangesMarker = "__CREATE_CODING_FEATURE_FILE_CHANGES__"
 
 const (
@@ -16,11 +16,28 @@ const (
 	MaxFileSize = 10 * 1024 * 1024
 )
 
+// File change operations
+const (
+	FileCreate = "create" // write Content to a new file
+	FileUpdate = "update" // replace the file with Content
+	FileDelete = "delete" // remove the file
+	FileRename = "rename" // move the file to NewPath, optionally replacing it with Content
+	FilePatch  = "patch"  // apply the unified diff Diff to the file
+)
+
 // FileChange represents a single file change operation to be performed
 type FileChange struct {
-	Operation string `json:"operation"` // "create" or "update"
-	Path      string `json:"path"`      // Relative path from project root
-	Content   string `json:"content"`   // New file content
+	Operation string `json:"operation"`          // create, update, delete, rename or patch
+	Path      string `json:"path"`               // Relative path from project root
+	Content   string `json:"content,omitempty"`  // New file content
+	NewPath   string `json:"new_path,omitempty"` // Target path of rename
+	Diff      string `json:"diff,omitempty"`     // Unified diff of patch
+}
+
+// EmitsFileChanges reports whether a pattern's system prompt asks the model
+// for a file changes section.
+func EmitsFileChanges(systemPrompt string) bool {
+	return strings.Contains(systemPrompt, FileChangesMarker)
 }
 
 // ParseFileChanges extracts and parses the file change marker section from LLM output
@@ -80,28 +97,45 @@ func ParseFileChanges(output string) (changeSummary string, changes []FileChange
 
 	// Validate file changes
 	for i, change := range fileChanges {
-		// Validate operation
-		if change.Operation != "create" && change.Operation != "update" {
-			return changeSummary, nil, fmt.Errorf("invalid operation for file change %d: %s", i, change.Operation)
+		if err = validateFileChange(change); err != nil {
+			return changeSummary, nil, fmt.Errorf("invalid file change %d: %w", i, err)
 		}
+	}
 
-		// Validate path
-		if change.Path == "" {
-			return changeSummary, nil, fmt.Errorf("empty path for file change %d", i)
-		}
+	return changeSummary, fileChanges, nil
+}
 
-		// Check for suspicious paths (directory traversal)
-		if strings.Contains(change.Path, "..") {
-			return changeSummary, nil, fmt.Errorf("suspicious path for file change %d: %s", i, change.Path)
+// validateFileChange checks the operation, paths and size of a change.
+func validateFileChange(change FileChange) error {
+	switch change.Operation {
+	case FileCreate, FileUpdate, FileDelete:
+	case FileRename:
+		if change.NewPath == "" {
+			return fmt.Errorf("rename of %s without new_path", change.Path)
+		}
+	case FilePatch:
+		if strings.TrimSpace(change.Diff) == "" {
+			return fmt.Errorf("patch of %s without diff", change.Path)
 		}
+	default:
+		return fmt.Errorf("invalid operation: %s", change.Operation)
+	}
 
-		// Check file size
-		if len(change.Content) > MaxFileSize {
-			return changeSummary, nil, fmt.Errorf("file content too large for file change %d: %d bytes", i, len(change.Content))
+	if change.Path == "" {
+		return fmt.Errorf("empty path")
+	}
+	// Check for suspicious paths (directory traversal)
+	for _, path := range []string{change.Path, change.NewPath} {
+		if strings.Contains(path, "..") || filepath.IsAbs(path) {
+			return fmt.Errorf("suspicious path: %s", path)
 		}
 	}
 
-	return changeSummary, fileChanges, nil
+	// Check file size
+	if len(change.Content) > MaxFileSize || len(change.Diff) > MaxFileSize {
+		return fmt.Errorf("file content too large: %d bytes", max(len(change.Content), len(change.Diff)))
+	}
+	return nil
 }
 
 // fixInvalidEscapes replaces invalid escape sequences in JSON strings
@@ -170,26 +204,3 @@ func fixInvalidEscapes(jsonStr string) string {
 
 	return result.String()
 }
-
-// ApplyFileChanges applies the parsed file changes to the file system
-func ApplyFileChanges(projectRoot string, changes []FileChange) error {
-	for i, change := range changes {
-		// Get the absolute path
-		absPath := filepath.Join(projectRoot, change.Path)
-
-		// Create directories if necessary
-		dir := filepath.Dir(absPath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s for file change %d: %w", dir, i, err)
-		}
-
-		// Write the file
-		if err := os.WriteFile(absPath, []byte(change.Content), 0644); err != nil {
-			return fmt.Errorf("failed to write file %s for file change %d: %w", absPath, i, err)
-		}
-
-		fmt.Printf("Applied %s operation to %s\n", change.Operation, change.Path)
-	}
-
-	return nil
-}
@@ -1,8 +1,6 @@
 package domain
 
 import (
-	"os"
-	"path/filepath"
 	"testing"
 )
 
@@ -64,10 +62,40 @@ Some text after.`,
 ` + FileChangesMarker + `
 [
 	{
-		"operation": "delete",
+		"operation": "chmod",
 		"path": "test.txt",
 		"content": ""
 	}
+]`,
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "Delete, rename and patch",
+			input: `Some text before.
+` + FileChangesMarker + `
+[
+	{"operation": "delete", "path": "old.txt"},
+	{"operation": "rename", "path": "a.txt", "new_path": "b.txt"},
+	{"operation": "patch", "path": "main.go", "diff": "@@ -1 +1 @@\n-a\n+b\n"}
+]`,
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "Rename without new path",
+			input: FileChangesMarker + `
+[
+	{"operation": "rename", "path": "a.txt"}
+]`,
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "Suspicious rename target",
+			input: FileChangesMarker + `
+[
+	{"operation": "rename", "path": "a.txt", "new_path": "/etc/passwd"}
 ]`,
 			want:    0,
 			wantErr: true,
@@ -116,70 +144,11 @@ Some text after.`,
 	}
 }
 
-func TestApplyFileChanges(t *testing.T) {
-	// Create a temporary directory for testing
-	// Create a temporary directory for testing
-	tempDir, err := os.MkdirTemp("", "file-manager-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
-	// Test file changes
-	changes := []FileChange{
-		{
-			Operation: "create",
-			Path:      "test.txt",
-			Content:   "Hello, World!",
-		},
-		{
-			Operation: "create",
-			Path:      "subdir/nested.txt",
-			Content:   "Nested content",
-		},
-	}
-
-	// Apply the changes
-	if err := ApplyFileChanges(tempDir, changes); err != nil {
-		t.Fatalf("ApplyFileChanges() error = %v", err)
-	}
-
-	// Verify the first file was created correctly
-	content, err := os.ReadFile(filepath.Join(tempDir, "test.txt"))
-	if err != nil {
-		t.Fatalf("Failed to read created file: %v", err)
-	}
-	if string(content) != "Hello, World!" {
-		t.Errorf("File content = %q, want %q", string(content), "Hello, World!")
-	}
-
-	// Verify the nested file was created correctly
-	content, err = os.ReadFile(filepath.Join(tempDir, "subdir/nested.txt"))
-	if err != nil {
-		t.Fatalf("Failed to read created nested file: %v", err)
-	}
-	if string(content) != "Nested content" {
-		t.Errorf("Nested file content = %q, want %q", string(content), "Nested content")
-	}
-
-	// Test updating a file
-	updateChanges := []FileChange{
-		{
-			Operation: "update",
-			Path:      "test.txt",
-			Content:   "Updated content",
-		},
-	}
-
-	// Apply the update
-	if err := ApplyFileChanges(tempDir, updateChanges); err != nil {
-		t.Fatalf("ApplyFileChanges() error = %v", err)
-	}
-	// Verify the file was updated correctly
-	content, err = os.ReadFile(filepath.Join(tempDir, "test.txt"))
-	if err != nil {
-		t.Fatalf("Failed to read updated file: %v", err)
+func TestEmitsFileChanges(t *testing.T) {
+	if !EmitsFileChanges("Output the changes marked by `" + FileChangesMarker + "`") {
+		t.Error("expected a system prompt with the marker to emit file changes")
 	}
-	if string(content) != "Updated content" {
-		t.Errorf("Updated file content = %q, want %q", string(content), "Updated content")
+	if EmitsFileChanges("Summarize the input") {
+		t.Error("expected a system prompt without the marker not to emit file changes")
 	}
 }
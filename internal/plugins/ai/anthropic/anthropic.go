@@ -2,6 +2,7 @@ package anthropic
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -353,7 +354,7 @@ func (an *Client) toMessages(msgs []*chat.ChatCompletionMessage) (ret []anthropi
 	lastRoleWasUser := false
 
 	for _, msg := range msgs {
-		if msg.Content == "" {
+		if msg.Content == "" && len(msg.MultiContent) == 0 {
 			continue // Skip empty messages
 		}
 
@@ -366,9 +367,9 @@ func (an *Client) toMessages(msgs []*chat.ChatCompletionMessage) (ret []anthropi
 				systemContent = msg.Content
 			}
 		case chat.ChatMessageRoleUser:
-			userContent := msg.Content
+			var prefix string
 			if isFirstUserMessage && systemContent != "" {
-				userContent = systemContent + "\\n\\n" + userContent
+				prefix = systemContent
 				isFirstUserMessage = false // System content now consumed
 			}
 			if lastRoleWasUser {
@@ -376,7 +377,7 @@ func (an *Client) toMessages(msgs []*chat.ChatCompletionMessage) (ret []anthropi
 				// This shouldn't happen with current chatter.go logic but is a safeguard.
 				anthropicMessages = append(anthropicMessages, anthropic.NewAssistantMessage(anthropic.NewTextBlock("Okay.")))
 			}
-			anthropicMessages = append(anthropicMessages, anthropic.NewUserMessage(anthropic.NewTextBlock(userContent)))
+			anthropicMessages = append(anthropicMessages, anthropic.NewUserMessage(userContentBlocks(msg, prefix)...))
 			lastRoleWasUser = true
 		case chat.ChatMessageRoleAssistant:
 			// If the first message is an assistant message, and we have system content,
@@ -407,6 +408,86 @@ func (an *Client) toMessages(msgs []*chat.ChatCompletionMessage) (ret []anthropi
 	return anthropicMessages
 }
 
+// userContentBlocks converts a user message into content blocks. Multi-part
+// messages map images to image blocks and PDF or text attachments to document
+// blocks. A non-empty prefix (the accumulated system content) is prepended to
+// the first text.
+func userContentBlocks(msg *chat.ChatCompletionMessage, prefix string) (ret []anthropic.ContentBlockParamUnion) {
+	if len(msg.MultiContent) == 0 {
+		text := msg.Content
+		if prefix != "" {
+			text = prefix + "\\n\\n" + text
+		}
+		return []anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(text)}
+	}
+
+	if prefix != "" {
+		ret = append(ret, anthropic.NewTextBlock(prefix))
+	}
+	for _, part := range msg.MultiContent {
+		switch part.Type {
+		case chat.ChatMessagePartTypeText:
+			if part.Text != "" {
+				ret = append(ret, anthropic.NewTextBlock(part.Text))
+			}
+		case chat.ChatMessagePartTypeImageURL:
+			if mimeType, data, ok := parseDataURL(part.ImageURL.URL); ok {
+				ret = append(ret, anthropic.NewImageBlockBase64(mimeType, data))
+			} else {
+				ret = append(ret, anthropic.NewImageBlock(anthropic.URLImageSourceParam{URL: part.ImageURL.URL}))
+			}
+		case chat.ChatMessagePartTypeFile:
+			if block, ok := documentBlock(part.File); ok {
+				ret = append(ret, block)
+			}
+		}
+	}
+	return
+}
+
+func documentBlock(file *chat.ChatMessageFile) (ret anthropic.ContentBlockParamUnion, ok bool) {
+	switch {
+	case domain.IsPDFMimeType(file.MimeType):
+		ret = anthropic.NewDocumentBlock(anthropic.Base64PDFSourceParam{Data: file.Data})
+	case domain.IsTextMimeType(file.MimeType):
+		content, err := base64.StdEncoding.DecodeString(file.Data)
+		if err != nil {
+			return
+		}
+		ret = anthropic.NewDocumentBlock(anthropic.PlainTextSourceParam{Data: string(content)})
+	default:
+		return
+	}
+	if ret.OfDocument != nil && file.Filename != "" {
+		ret.OfDocument.Title = anthropic.String(file.Filename)
+	}
+	ok = true
+	return
+}
+
+// parseDataURL splits a base64 data URL into its MIME type and payload.
+func parseDataURL(url string) (mimeType, data string, ok bool) {
+	rest, found := strings.CutPrefix(url, "data:")
+	if !found {
+		return
+	}
+	header, payload, found := strings.Cut(rest, ",")
+	if !found || !strings.HasSuffix(header, ";base64") {
+		return
+	}
+	return strings.TrimSuffix(header, ";base64"), payload, true
+}
+
+// SupportsDocument reports the document types sent as native document blocks.
+func (an *Client) SupportsDocument(mimeType string) bool {
+	return domain.IsPDFMimeType(mimeType) || domain.IsTextMimeType(mimeType)
+}
+
+// SupportsNativeSearch reports that --search uses Anthropic's web search tool.
+func (an *Client) SupportsNativeSearch(modelName string) bool {
+	return true
+}
+
 func (an *Client) NeedsRawMode(modelName string) bool {
 	return false
 }
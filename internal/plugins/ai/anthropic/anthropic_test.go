@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/danielmiessler/fabric/internal/chat"
 	"github.com/danielmiessler/fabric/internal/domain"
 )
 
@@ -325,3 +326,44 @@ func TestBuildMessageParams_ExplicitTopP(t *testing.T) {
 		t.Errorf("Expected TopP %f, got %f", opts.TopP, params.TopP.Value)
 	}
 }
+
+func TestToMessages_DocumentAttachments(t *testing.T) {
+	client := NewClient()
+	msgs := []*chat.ChatCompletionMessage{
+		{Role: chat.ChatMessageRoleSystem, Content: "system prompt"},
+		{Role: chat.ChatMessageRoleUser, MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeText, Text: "summarize"},
+			{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{
+				Filename: "report.pdf", MimeType: "application/pdf", Data: "JVBERi0=",
+			}},
+			{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{
+				Filename: "notes.md", MimeType: "text/markdown", Data: "IyBOb3Rlcw==",
+			}},
+			{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: "data:image/png;base64,iVBORw0="}},
+		}},
+	}
+
+	messages := client.toMessages(msgs)
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	blocks := messages[0].Content
+	if len(blocks) != 5 {
+		t.Fatalf("expected 5 content blocks, got %d", len(blocks))
+	}
+	if blocks[0].OfText == nil || blocks[0].OfText.Text != "system prompt" {
+		t.Errorf("expected system prompt as first block")
+	}
+	if blocks[2].OfDocument == nil || blocks[2].OfDocument.Source.OfBase64 == nil {
+		t.Errorf("expected PDF document block")
+	}
+	if blocks[3].OfDocument == nil || blocks[3].OfDocument.Source.OfText == nil || blocks[3].OfDocument.Source.OfText.Data != "# Notes" {
+		t.Errorf("expected plain text document block")
+	}
+	if blocks[4].OfImage == nil || blocks[4].OfImage.Source.OfBase64 == nil {
+		t.Errorf("expected base64 image block")
+	}
+	if !client.SupportsDocument("application/pdf") || client.SupportsDocument("application/zip") {
+		t.Errorf("unexpected document support")
+	}
+}
@@ -35,6 +35,8 @@ func (c *Client) formatMultiContentMessage(msg *chat.ChatCompletionMessage) stri
 			builder.WriteString(fmt.Sprintf("  - Type: %s\n", part.Type))
 			if part.Type == chat.ChatMessagePartTypeImageURL {
 				builder.WriteString(fmt.Sprintf("    Image URL: %s\n", part.ImageURL.URL))
+			} else if part.Type == chat.ChatMessagePartTypeFile {
+				builder.WriteString(fmt.Sprintf("    File: %s (%s, %d base64 bytes)\n", part.File.Filename, part.File.MimeType, len(part.File.Data)))
 			} else {
 				builder.WriteString(fmt.Sprintf("    Text: %s\n", part.Text))
 			}
@@ -47,6 +49,11 @@ func (c *Client) formatMultiContentMessage(msg *chat.ChatCompletionMessage) stri
 	return builder.String()
 }
 
+// SupportsDocument accepts every document so that dry runs show attachments as they were given.
+func (c *Client) SupportsDocument(mimeType string) bool {
+	return true
+}
+
 func (c *Client) formatMessages(msgs []*chat.ChatCompletionMessage) string {
 	var builder strings.Builder
 
@@ -0,0 +1,9 @@
+package ai
+
+import "context"
+
+// Embedder is implemented by vendors that can turn text into embedding vectors.
+// It is used to index and query local document collections.
+type Embedder interface {
+	Embed(ctx context.Context, model string, texts []string) ([][]float32, error)
+}
@@ -0,0 +1,120 @@
+// Package fake provides a deterministic vendor for offline tests. It never
+// touches the network: responses are scripted by substring rules and fall back
+// to echoing the last user message.
+package fake
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins"
+)
+
+const DefaultModel = "fake-model"
+
+// Rule returns Response when every message sent to the vendor, joined
+// together, contains Contains. Model restricts the rule to one model.
+type Rule struct {
+	Model    string
+	Contains string
+	Response string
+}
+
+// Call records one request received by the vendor.
+type Call struct {
+	Model    string
+	Messages []*chat.ChatCompletionMessage
+}
+
+type Client struct {
+	*plugins.PluginBase
+	Models []string
+	Rules  []Rule
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+func NewClient(rules ...Rule) *Client {
+	return &Client{
+		PluginBase: &plugins.PluginBase{Name: "Fake"},
+		Models:     []string{DefaultModel},
+		Rules:      rules,
+	}
+}
+
+func (c *Client) IsConfigured() bool {
+	return true
+}
+
+func (c *Client) Setup() error {
+	return nil
+}
+
+func (c *Client) ListModels() ([]string, error) {
+	return c.Models, nil
+}
+
+func (c *Client) NeedsRawMode(string) bool {
+	return false
+}
+
+func (c *Client) SendStream(msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions, channel chan string) error {
+	defer close(channel)
+	response, err := c.Send(context.Background(), msgs, opts)
+	if err != nil {
+		return err
+	}
+	channel <- response
+	return nil
+}
+
+func (c *Client) Send(_ context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (string, error) {
+	c.mu.Lock()
+	c.calls = append(c.calls, Call{Model: opts.Model, Messages: msgs})
+	c.mu.Unlock()
+
+	var all []string
+	var lastUser string
+	for _, msg := range msgs {
+		text := messageText(msg)
+		all = append(all, text)
+		if msg.Role == chat.ChatMessageRoleUser {
+			lastUser = text
+		}
+	}
+	joined := strings.Join(all, "\n")
+
+	for _, rule := range c.Rules {
+		if rule.Model != "" && rule.Model != opts.Model {
+			continue
+		}
+		if strings.Contains(joined, rule.Contains) {
+			return rule.Response, nil
+		}
+	}
+	return lastUser, nil
+}
+
+// Calls returns the requests received so far.
+func (c *Client) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call(nil), c.calls...)
+}
+
+func messageText(msg *chat.ChatCompletionMessage) string {
+	if len(msg.MultiContent) == 0 {
+		return msg.Content
+	}
+	var parts []string
+	for _, part := range msg.MultiContent {
+		if part.Type == chat.ChatMessagePartTypeText {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,123 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"google.golang.org/genai"
+)
+
+// MaxInlineAudioSize is the largest audio file sent inline. Requests are
+// limited to 20MB and inline data is base64 encoded, which grows it by a
+// third, so files are limited to 3/4 of the limit (15MB).
+const MaxInlineAudioSize int64 = 20 * 1024 * 1024 * 3 / 4
+
+// TranscriptionModels lists Gemini models accepting audio input.
+var TranscriptionModels = []string{"gemini-2.5-flash", "gemini-2.5-pro", "gemini-2.5-flash-lite"}
+
+var audioMIMETypes = map[string]string{
+	".aac":  "audio/aac",
+	".aiff": "audio/aiff",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".mp3":  "audio/mp3",
+	".mp4":  "video/mp4",
+	".mpeg": "audio/mpeg",
+	".mpga": "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+	".webm": "audio/webm",
+}
+
+const (
+	transcriptionPrompt = "Transcribe this audio verbatim. Return only the transcript, without any commentary."
+	// segmentsPrompt asks for the same fields as the OpenAI verbose_json format
+	segmentsPrompt = "Transcribe this audio verbatim. Return a JSON array of segments, one per sentence or phrase, " +
+		`each an object {"start": seconds, "end": seconds, "text": "..."} with start and end in seconds from the beginning of the audio.`
+	speakersPrompt = "Transcribe this audio verbatim and identify the speakers. Return a JSON array of segments, " +
+		"one per speaker turn or sentence, each an object " +
+		`{"start": seconds, "end": seconds, "speaker": "...", "text": "..."} with start and end in seconds from the beginning of the audio. ` +
+		`Label the speakers "Speaker 1", "Speaker 2" and so on in order of appearance, or by name when they introduce themselves.`
+)
+
+func (o *Client) TranscriptionModels() []string {
+	return TranscriptionModels
+}
+
+func (o *Client) MaxTranscriptionFileSize() int64 {
+	return MaxInlineAudioSize
+}
+
+// Transcribe sends the audio file inline and asks the model for a verbatim
+// transcript, as JSON segments when timestamps or speakers are requested.
+func (o *Client) Transcribe(ctx context.Context, filePath string, opts *ai.TranscriptionOptions) (ret *ai.Transcription, err error) {
+	mimeType, ok := audioMIMETypes[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported audio format '%s'", filepath.Ext(filePath))
+	}
+	var data []byte
+	if data, err = os.ReadFile(filePath); err != nil {
+		return
+	}
+
+	model := opts.Model
+	if model == "" {
+		model = TranscriptionModels[0]
+	}
+	prompt := transcriptionPrompt
+	cfg := &genai.GenerateContentConfig{}
+	if opts.Speakers {
+		prompt = speakersPrompt
+		cfg.ResponseMIMEType = "application/json"
+	} else if opts.Segments {
+		prompt = segmentsPrompt
+		cfg.ResponseMIMEType = "application/json"
+	}
+	if opts.Language != "" {
+		prompt += fmt.Sprintf(" The audio is in the language with code %s.", opts.Language)
+	}
+
+	var client *genai.Client
+	if client, err = o.createGenaiClient(ctx); err != nil {
+		return
+	}
+	contents := []*genai.Content{{
+		Role: genai.RoleUser,
+		Parts: []*genai.Part{
+			{Text: prompt},
+			{InlineData: &genai.Blob{MIMEType: mimeType, Data: data}},
+		},
+	}}
+	var response *genai.GenerateContentResponse
+	if response, err = client.Models.GenerateContent(ctx, o.buildModelNameFull(model), contents, cfg); err != nil {
+		return
+	}
+
+	text := strings.TrimSpace(o.extractTextParts(response))
+	if !opts.Segments && !opts.Speakers {
+		return &ai.Transcription{Text: text}, nil
+	}
+	return parseSegmentsJSON(text)
+}
+
+// parseSegmentsJSON reads the segments returned for segmentsPrompt and
+// speakersPrompt.
+func parseSegmentsJSON(text string) (ret *ai.Transcription, err error) {
+	text = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(text), "```json"), "```")
+	var segments []ai.TranscriptionSegment
+	if err = json.Unmarshal([]byte(strings.TrimSpace(text)), &segments); err != nil {
+		return nil, fmt.Errorf("failed to parse transcription segments: %w", err)
+	}
+	ret = &ai.Transcription{Segments: segments}
+	texts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		texts = append(texts, strings.TrimSpace(segment.Text))
+	}
+	ret.Text = strings.Join(texts, " ")
+	return
+}
@@ -0,0 +1,125 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"google.golang.org/genai"
+)
+
+// ImageModels lists the Gemini image models, the Imagen models generate
+// without input images only.
+var ImageModels = []string{"gemini-2.5-flash-image-preview", "imagen-4.0-generate-001", "imagen-4.0-ultra-generate-001",
+	"imagen-4.0-fast-generate-001"}
+
+// imagenAspectRatios maps the OpenAI sizes of --image-size to Imagen aspect ratios.
+var imagenAspectRatios = map[string]string{
+	"1024x1024": "1:1",
+	"1536x1024": "4:3",
+	"1024x1536": "3:4",
+}
+
+func (o *Client) ImageModels() []string {
+	return ImageModels
+}
+
+// GenerateImages creates images with a Gemini image model, which also edits
+// input images, or with an Imagen model.
+func (o *Client) GenerateImages(ctx context.Context, prompt string, opts *ai.ImageOptions) (ret []*ai.GeneratedImage, err error) {
+	model := opts.Model
+	if model == "" {
+		model = ImageModels[0]
+	}
+	count := max(opts.Count, 1)
+
+	var client *genai.Client
+	if client, err = o.createGenaiClient(ctx); err != nil {
+		return
+	}
+
+	if strings.HasPrefix(model, "imagen") {
+		if len(opts.InputImages) > 0 {
+			return nil, fmt.Errorf("model '%s' can not edit images, use %s", model, ImageModels[0])
+		}
+		return o.generateImagenImages(ctx, client, model, prompt, count, opts)
+	}
+
+	parts := []*genai.Part{{Text: prompt}}
+	for _, image := range opts.InputImages {
+		parts = append(parts, &genai.Part{InlineData: &genai.Blob{MIMEType: image.MIMEType, Data: image.Data}})
+	}
+	contents := []*genai.Content{{Role: genai.RoleUser, Parts: parts}}
+	config := &genai.GenerateContentConfig{ResponseModalities: []string{"TEXT", "IMAGE"}}
+
+	// Gemini image models return one image per request
+	for len(ret) < count {
+		var response *genai.GenerateContentResponse
+		if response, err = client.Models.GenerateContent(ctx, o.buildModelNameFull(model), contents, config); err != nil {
+			return nil, fmt.Errorf("image generation failed: %w", err)
+		}
+		images := imageParts(response)
+		if len(images) == 0 {
+			if text := strings.TrimSpace(o.extractTextParts(response)); text != "" {
+				return nil, fmt.Errorf("no image received from %s: %s", model, text)
+			}
+			return nil, fmt.Errorf("no image received from %s", model)
+		}
+		ret = append(ret, images...)
+	}
+	return ret[:count], nil
+}
+
+func (o *Client) generateImagenImages(ctx context.Context, client *genai.Client, model string, prompt string, count int,
+	opts *ai.ImageOptions) (ret []*ai.GeneratedImage, err error) {
+
+	config := &genai.GenerateImagesConfig{
+		NumberOfImages: int32(count),
+		AspectRatio:    imagenAspectRatios[opts.Size],
+	}
+	if opts.Format != "" {
+		config.OutputMIMEType = "image/" + opts.Format
+	}
+	if opts.Compression != 0 {
+		quality := int32(opts.Compression)
+		config.OutputCompressionQuality = &quality
+	}
+
+	var response *genai.GenerateImagesResponse
+	if response, err = client.Models.GenerateImages(ctx, o.buildModelNameFull(model), prompt, config); err != nil {
+		return nil, fmt.Errorf("image generation failed: %w", err)
+	}
+	for _, image := range response.GeneratedImages {
+		if image.Image == nil || len(image.Image.ImageBytes) == 0 {
+			if image.RAIFilteredReason != "" {
+				return nil, fmt.Errorf("image was filtered: %s", image.RAIFilteredReason)
+			}
+			continue
+		}
+		ret = append(ret, &ai.GeneratedImage{
+			Data:          image.Image.ImageBytes,
+			MIMEType:      image.Image.MIMEType,
+			RevisedPrompt: image.EnhancedPrompt,
+		})
+	}
+	if len(ret) == 0 {
+		err = fmt.Errorf("no images received from %s", model)
+	}
+	return
+}
+
+// imageParts returns the inline images of a response.
+func imageParts(response *genai.GenerateContentResponse) (ret []*ai.GeneratedImage) {
+	for _, candidate := range response.Candidates {
+		if candidate.Content == nil {
+			continue
+		}
+		for _, part := range candidate.Content.Parts {
+			if part.InlineData != nil && strings.HasPrefix(part.InlineData.MIMEType, "image/") {
+				ret = append(ret, &ai.GeneratedImage{Data: part.InlineData.Data, MIMEType: part.InlineData.MIMEType})
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,86 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"google.golang.org/genai"
+)
+
+// MaxSpeechInput keeps TTS requests short, long inputs drift in voice and pace.
+const MaxSpeechInput = 4000
+
+const DefaultVoice = "Kore"
+
+// SpeechModels lists the Gemini TTS models.
+var SpeechModels = []string{"gemini-2.5-flash-preview-tts", "gemini-2.5-pro-preview-tts"}
+
+func (o *Client) SpeechModels() []string {
+	return SpeechModels
+}
+
+func (o *Client) SpeechVoices() []string {
+	return GetGeminiVoiceNames()
+}
+
+func (o *Client) MaxSpeechInput() int {
+	return MaxSpeechInput
+}
+
+// Synthesize generates speech with a prebuilt voice of a TTS model.
+func (o *Client) Synthesize(ctx context.Context, text string, opts *ai.SpeechOptions) (ret *ai.Speech, err error) {
+	model := opts.Model
+	if model == "" {
+		model = SpeechModels[0]
+	}
+	if !o.isTTSModel(model) {
+		return nil, fmt.Errorf("model '%s' is not a TTS model, use one of: %s", model, strings.Join(SpeechModels, ", "))
+	}
+	voiceName := opts.Voice
+	if voiceName == "" {
+		voiceName = DefaultVoice
+	}
+	if !IsValidGeminiVoice(voiceName) {
+		return nil, fmt.Errorf("invalid voice '%s'. Valid voices are: %v", voiceName, GetGeminiVoiceNames())
+	}
+
+	var client *genai.Client
+	if client, err = o.createGenaiClient(ctx); err != nil {
+		return
+	}
+
+	contents := []*genai.Content{{
+		Parts: []*genai.Part{{Text: text}},
+	}}
+	config := &genai.GenerateContentConfig{
+		ResponseModalities: []string{"AUDIO"},
+		SpeechConfig: &genai.SpeechConfig{
+			VoiceConfig: &genai.VoiceConfig{
+				PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{
+					VoiceName: voiceName,
+				},
+			},
+		},
+	}
+
+	var response *genai.GenerateContentResponse
+	if response, err = client.Models.GenerateContent(ctx, o.buildModelNameFull(model), contents, config); err != nil {
+		return nil, fmt.Errorf("TTS generation failed: %w", err)
+	}
+
+	if len(response.Candidates) > 0 && response.Candidates[0].Content != nil && len(response.Candidates[0].Content.Parts) > 0 {
+		part := response.Candidates[0].Content.Parts[0]
+		if part.InlineData != nil && len(part.InlineData.Data) > 0 {
+			if part.InlineData.MIMEType != "" && !strings.HasPrefix(part.InlineData.MIMEType, "audio/") {
+				return nil, fmt.Errorf("unexpected data type: %s, expected audio data", part.InlineData.MIMEType)
+			}
+			if len(part.InlineData.Data) < MinAudioDataSize {
+				return nil, fmt.Errorf("audio data too small: %d bytes, minimum required: %d", len(part.InlineData.Data), MinAudioDataSize)
+			}
+			return &ai.Speech{PCM: part.InlineData.Data, SampleRate: DefaultSampleRate, Channels: DefaultChannels}, nil
+		}
+	}
+	return nil, fmt.Errorf("no audio data received from TTS model")
+}
@@ -235,25 +235,16 @@ func TestIsTTSModel(t *testing.T) {
 	}
 }
 
-// Test generateWAVFile method (basic test)
-func TestGenerateWAVFile(t *testing.T) {
-	client := &Client{}
-
-	// Test with minimal PCM data
-	pcmData := []byte{0x00, 0x01, 0x02, 0x03}
-
-	result, err := client.generateWAVFile(pcmData)
-	if err != nil {
-		t.Errorf("generateWAVFile failed: %v", err)
-	}
-
-	// Check that we got some data back
-	if len(result) == 0 {
-		t.Error("generateWAVFile returned empty data")
-	}
-
-	// Check that it starts with RIFF header
-	if len(result) >= 4 && string(result[0:4]) != "RIFF" {
-		t.Error("Generated WAV data doesn't start with RIFF header")
+func TestImageParts(t *testing.T) {
+	response := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: []*genai.Part{
+			{Text: "Here is your cat"},
+			{InlineData: &genai.Blob{MIMEType: "image/png", Data: []byte("png")}},
+			{InlineData: &genai.Blob{MIMEType: "audio/wav", Data: []byte("wav")}},
+		}}}},
+	}
+	images := imageParts(response)
+	if len(images) != 1 || images[0].MIMEType != "image/png" || string(images[0].Data) != "png" {
+		t.Errorf("expected one PNG image, got %+v", images)
 	}
 }
@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// ImageGenerator is implemented by vendors that create images from a prompt:
+// Gemini image and Imagen models, OpenAI and OpenAI-compatible
+// /images/generations endpoints such as a local stable-diffusion server.
+// Input images turn the request into an edit of those images.
+type ImageGenerator interface {
+	GenerateImages(ctx context.Context, prompt string, opts *ImageOptions) ([]*GeneratedImage, error)
+	ImageModels() []string
+}
+
+// ImageGenerationTool is implemented by vendors whose chat models can create
+// an image themselves while answering, like the image generation tool of the
+// OpenAI Responses API. For other vendors --image-file runs an ImageGenerator
+// after the chat.
+type ImageGenerationTool interface {
+	SupportsImageGenerationTool(modelName string) bool
+}
+
+// ImageOptions select the model and the images to generate. Empty values
+// use the vendor's defaults, vendors ignore options they do not support.
+type ImageOptions struct {
+	Model       string       `json:"model,omitempty"`
+	Count       int          `json:"count,omitempty"`
+	Size        string       `json:"size,omitempty"`
+	Quality     string       `json:"quality,omitempty"`
+	Background  string       `json:"background,omitempty"`
+	Compression int          `json:"compression,omitempty"`
+	Format      string       `json:"format,omitempty"`
+	InputImages []InputImage `json:"-"`
+}
+
+// InputImage is an image to edit.
+type InputImage struct {
+	Name     string
+	MIMEType string
+	Data     []byte
+}
+
+// GeneratedImage is an image returned by an ImageGenerator.
+type GeneratedImage struct {
+	Data     []byte
+	MIMEType string
+	// RevisedPrompt is the prompt the vendor actually used, if it rewrote it
+	RevisedPrompt string
+}
+
+// ImageMetadata is written next to every generated image as <image>.json, so
+// the prompt and parameters of an image are not lost.
+type ImageMetadata struct {
+	Prompt        string        `json:"prompt"`
+	RevisedPrompt string        `json:"revised_prompt,omitempty"`
+	Vendor        string        `json:"vendor"`
+	Model         string        `json:"model,omitempty"`
+	Options       *ImageOptions `json:"options,omitempty"`
+	InputImages   []string      `json:"input_images,omitempty"`
+	Index         int           `json:"index,omitempty"`
+	Created       time.Time     `json:"created"`
+}
+
+// WriteImageMetadata writes the sidecar file of the image at imagePath.
+func WriteImageMetadata(imagePath string, metadata *ImageMetadata) (err error) {
+	var data []byte
+	if data, err = json.MarshalIndent(metadata, "", "  "); err != nil {
+		return
+	}
+	return os.WriteFile(imagePath+".json", append(data, '\n'), 0644)
+}
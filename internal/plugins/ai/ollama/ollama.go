@@ -162,6 +162,24 @@ func (o *Client) createChatRequest(msgs []*chat.ChatCompletionMessage, opts *dom
 	return
 }
 
+// Embed returns one embedding vector per input text using the Ollama embed endpoint.
+func (o *Client) Embed(ctx context.Context, model string, texts []string) (ret [][]float32, err error) {
+	if len(texts) == 0 {
+		return
+	}
+
+	var resp *ollamaapi.EmbedResponse
+	if resp, err = o.client.Embed(ctx, &ollamaapi.EmbedRequest{Model: model, Input: texts}); err != nil {
+		return
+	}
+	if len(resp.Embeddings) != len(texts) {
+		err = fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+		return
+	}
+	ret = resp.Embeddings
+	return
+}
+
 func (o *Client) NeedsRawMode(modelName string) bool {
 	ollamaPrefixes := []string{
 		"llama3",
@@ -108,6 +108,11 @@ func (o *Client) convertChatMessage(msg chat.ChatCompletionMessage) openai.ChatC
 					parts = append(parts, openai.TextContentPart(p.Text))
 				case chat.ChatMessagePartTypeImageURL:
 					parts = append(parts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: p.ImageURL.URL}))
+				case chat.ChatMessagePartTypeFile:
+					parts = append(parts, openai.FileContentPart(openai.ChatCompletionContentPartFileFileParam{
+						Filename: openai.String(p.File.Filename),
+						FileData: openai.String(p.File.DataURL()),
+					}))
 				}
 			}
 			return openai.UserMessage(parts)
@@ -150,7 +150,7 @@ func (o *Client) sendResponses(ctx context.Context, msgs []*chat.ChatCompletionM
 	}
 
 	// Extract and save images if requested
-	if err = o.extractAndSaveImages(resp, opts); err != nil {
+	if err = o.extractAndSaveImages(resp, lastUserText(msgs), opts); err != nil {
 		return
 	}
 
@@ -163,6 +163,18 @@ func (o *Client) supportsResponsesAPI() bool {
 	return o.ImplementsResponses
 }
 
+// SupportsNativeSearch reports whether --search can use the web search tool,
+// which is only available through the Responses API.
+func (o *Client) SupportsNativeSearch(modelName string) bool {
+	return o.ImplementsResponses
+}
+
+// SupportsImageGenerationTool reports whether --image-file can use the image
+// generation tool of the Responses API with the model.
+func (o *Client) SupportsImageGenerationTool(modelName string) bool {
+	return o.ImplementsResponses && supportsImageGeneration(modelName)
+}
+
 func (o *Client) NeedsRawMode(modelName string) bool {
 	openaiModelsPrefixes := []string{
 		"o1",
@@ -289,6 +301,13 @@ func convertMessage(msg chat.ChatCompletionMessage) responses.ResponseInputItemU
 					part.OfInputImage.ImageURL = openai.String(p.ImageURL.URL)
 				}
 				parts = append(parts, part)
+			case chat.ChatMessagePartTypeFile:
+				parts = append(parts, responses.ResponseInputContentUnionParam{
+					OfInputFile: &responses.ResponseInputFileParam{
+						Filename: openai.String(p.File.Filename),
+						FileData: openai.String(p.File.DataURL()),
+					},
+				})
 			}
 		}
 		contentList := responses.ResponseInputMessageContentListParam(parts)
@@ -297,6 +316,13 @@ func convertMessage(msg chat.ChatCompletionMessage) responses.ResponseInputItemU
 	return responses.ResponseInputItemParamOfMessage(result.Content, role)
 }
 
+// SupportsDocument reports the document types sent as input files. Only the
+// Responses API accepts them, and only for PDFs; other providers using this
+// client receive extracted text instead.
+func (o *Client) SupportsDocument(mimeType string) bool {
+	return o.supportsResponsesAPI() && domain.IsPDFMimeType(mimeType)
+}
+
 func (o *Client) extractText(resp *responses.Response) (ret string) {
 	var textParts []string
 	var citations []string
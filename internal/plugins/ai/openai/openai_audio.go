@@ -1,153 +1,171 @@
 package openai
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"slices"
-	"sort"
 	"strings"
 
-	debuglog "github.com/danielmiessler/fabric/internal/log"
-
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/shared/constant"
 )
 
 // MaxAudioFileSize defines the maximum allowed size for audio uploads (25MB).
 const MaxAudioFileSize int64 = 25 * 1024 * 1024
 
-// AllowedTranscriptionModels lists the models supported for transcription.
+// DefaultTranscriptionModel is used when no model is given. Local servers
+// usually accept or ignore it.
+const DefaultTranscriptionModel = string(openai.AudioModelWhisper1)
+
+// DiarizeTranscriptionModel returns speaker labels with the diarized_json
+// format. It is the default of OpenAI when speakers are requested.
+const DiarizeTranscriptionModel = "gpt-4o-transcribe-diarize"
+
+// AllowedTranscriptionModels lists the transcription models of OpenAI.
 var AllowedTranscriptionModels = []string{
 	string(openai.AudioModelWhisper1),
 	string(openai.AudioModelGPT4oMiniTranscribe),
 	string(openai.AudioModelGPT4oTranscribe),
+	DiarizeTranscriptionModel,
 }
 
 // allowedAudioExtensions defines the supported input file extensions.
 var allowedAudioExtensions = map[string]struct{}{
+	".flac": {},
 	".mp3":  {},
 	".mp4":  {},
 	".mpeg": {},
 	".mpga": {},
 	".m4a":  {},
+	".ogg":  {},
 	".wav":  {},
 	".webm": {},
 }
 
-// TranscribeFile transcribes the given audio file using the specified model. If the file
-// exceeds the size limit, it can optionally be split into chunks using ffmpeg.
-func (o *Client) TranscribeFile(ctx context.Context, filePath, model string, split bool) (string, error) {
-	if ctx == nil {
-		ctx = context.Background()
+// NewEndpointClient creates a client for an OpenAI-compatible audio server,
+// e.g. a local whisper.cpp or faster-whisper server for /audio/transcriptions
+// at http://localhost:8080/v1 or a Kokoro server for /audio/speech. The API
+// key is optional.
+func NewEndpointClient(name string, baseURL string, apiKey string) (ret *Client) {
+	ret = NewClientCompatibleNoSetupQuestions(name, nil)
+	if apiKey == "" {
+		// The SDK requires a key, local servers ignore it
+		apiKey = "none"
 	}
+	client := openai.NewClient(option.WithAPIKey(apiKey), option.WithBaseURL(baseURL))
+	ret.ApiClient = &client
+	return
+}
 
-	if !slices.Contains(AllowedTranscriptionModels, model) {
-		return "", fmt.Errorf("model '%s' is not supported for transcription", model)
+// TranscriptionModels returns the models of OpenAI. Compatible providers
+// have their own models, which are not known in advance.
+func (o *Client) TranscriptionModels() []string {
+	if o.GetName() == "OpenAI" {
+		return AllowedTranscriptionModels
 	}
+	return nil
+}
+
+func (o *Client) MaxTranscriptionFileSize() int64 {
+	return MaxAudioFileSize
+}
 
+// verboseTranscription is the verbose_json or diarized_json response carrying
+// segments. Whisper servers report avg_logprob, diarizing servers a speaker.
+type verboseTranscription struct {
+	Text     string `json:"text"`
+	Segments []struct {
+		Start      float64  `json:"start"`
+		End        float64  `json:"end"`
+		Text       string   `json:"text"`
+		Speaker    string   `json:"speaker"`
+		AvgLogprob *float64 `json:"avg_logprob"`
+	} `json:"segments"`
+}
+
+// Transcribe transcribes an audio file with the /audio/transcriptions endpoint.
+// Segment timestamps need the verbose_json format, which the gpt-4o models do
+// not support; speaker labels need a diarizing model, or a compatible server
+// adding speakers to its segments.
+func (o *Client) Transcribe(ctx context.Context, filePath string, opts *ai.TranscriptionOptions) (ret *ai.Transcription, err error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
 	if _, ok := allowedAudioExtensions[ext]; !ok {
-		return "", fmt.Errorf("unsupported audio format '%s'", ext)
-	}
-
-	info, err := os.Stat(filePath)
-	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("unsupported audio format '%s'", ext)
 	}
 
-	var files []string
-	var cleanup func()
-	if info.Size() > MaxAudioFileSize {
-		if !split {
-			return "", fmt.Errorf("file %s exceeds 25MB limit; use --split-media-file to enable automatic splitting", filePath)
+	model := opts.Model
+	if model == "" {
+		model = DefaultTranscriptionModel
+		if opts.Speakers && o.GetName() == "OpenAI" {
+			model = DiarizeTranscriptionModel
 		}
-		debuglog.Log("File %s is larger than the size limit... breaking it up into chunks...\n", filePath)
-		if files, cleanup, err = splitAudioFile(filePath, ext, MaxAudioFileSize); err != nil {
-			return "", err
-		}
-		defer cleanup()
-	} else {
-		files = []string{filePath}
 	}
-
-	var builder strings.Builder
-	for i, f := range files {
-		debuglog.Log("Using model %s to transcribe part %d (file name: %s)...\n", model, i+1, f)
-		var chunk *os.File
-		if chunk, err = os.Open(f); err != nil {
-			return "", err
-		}
-		params := openai.AudioTranscriptionNewParams{
-			File:  chunk,
-			Model: openai.AudioModel(model),
-		}
-		var resp *openai.Transcription
-		resp, err = o.ApiClient.Audio.Transcriptions.New(ctx, params)
-		chunk.Close()
-		if err != nil {
-			return "", err
-		}
-		if i > 0 {
-			builder.WriteString(" ")
-		}
-		builder.WriteString(resp.Text)
+	diarize := model == DiarizeTranscriptionModel
+	segments := opts.Segments || opts.Speakers
+	if segments && !diarize && strings.HasPrefix(model, "gpt-4o") {
+		return nil, fmt.Errorf("model '%s' does not return segment timestamps, use %s or %s", model,
+			openai.AudioModelWhisper1, DiarizeTranscriptionModel)
 	}
 
-	return builder.String(), nil
-}
+	var file *os.File
+	if file, err = os.Open(filePath); err != nil {
+		return
+	}
+	defer file.Close()
 
-// splitAudioFile splits the source file into chunks smaller than maxSize using ffmpeg.
-// It returns the list of chunk file paths and a cleanup function.
-func splitAudioFile(src, ext string, maxSize int64) (files []string, cleanup func(), err error) {
-	if _, err = exec.LookPath("ffmpeg"); err != nil {
-		return nil, nil, fmt.Errorf("ffmpeg not found: please install it")
+	params := openai.AudioTranscriptionNewParams{
+		File:  file,
+		Model: openai.AudioModel(model),
+	}
+	if opts.Language != "" {
+		params.Language = openai.String(opts.Language)
+	}
+	if diarize {
+		// The diarizing model needs a chunking strategy for inputs over 30 seconds
+		params.ResponseFormat = "diarized_json"
+		params.ChunkingStrategy.OfAuto = constant.ValueOf[constant.Auto]()
+	} else if segments {
+		params.ResponseFormat = openai.AudioResponseFormatVerboseJSON
+		params.TimestampGranularities = []string{"segment"}
 	}
 
-	var dir string
-	if dir, err = os.MkdirTemp("", "fabric-audio-*"); err != nil {
-		return nil, nil, err
+	var resp *openai.Transcription
+	if resp, err = o.ApiClient.Audio.Transcriptions.New(ctx, params); err != nil {
+		return
 	}
-	cleanup = func() { os.RemoveAll(dir) }
-
-	segmentTime := 600 // start with 10 minutes
-	for {
-		pattern := filepath.Join(dir, "chunk-%03d"+ext)
-		debuglog.Log("Running ffmpeg to split audio into %d-second chunks...\n", segmentTime)
-		cmd := exec.Command("ffmpeg", "-y", "-i", src, "-f", "segment", "-segment_time", fmt.Sprintf("%d", segmentTime), "-c", "copy", pattern)
-		var stderr bytes.Buffer
-		cmd.Stderr = &stderr
-		if err = cmd.Run(); err != nil {
-			return nil, cleanup, fmt.Errorf("ffmpeg failed: %v: %s", err, stderr.String())
+	ret = &ai.Transcription{Text: resp.Text}
+	if segments || diarize {
+		if ret.Segments, err = parseSegments(resp.RawJSON()); err != nil {
+			return nil, err
 		}
+	}
+	return
+}
 
-		if files, err = filepath.Glob(filepath.Join(dir, "chunk-*"+ext)); err != nil {
-			return nil, cleanup, err
-		}
-		sort.Strings(files)
-
-		tooBig := false
-		for _, f := range files {
-			var info os.FileInfo
-			if info, err = os.Stat(f); err != nil {
-				return nil, cleanup, err
-			}
-			if info.Size() > maxSize {
-				tooBig = true
-				break
-			}
-		}
-		if !tooBig {
-			return files, cleanup, nil
-		}
-		for _, f := range files {
-			_ = os.Remove(f)
+// parseSegments reads the segments of a verbose_json or diarized_json
+// response. The confidence of a Whisper segment is its average token
+// probability.
+func parseSegments(raw string) (ret []ai.TranscriptionSegment, err error) {
+	var verbose verboseTranscription
+	if err = json.Unmarshal([]byte(raw), &verbose); err != nil {
+		return nil, fmt.Errorf("failed to parse transcription segments: %w", err)
+	}
+	for _, segment := range verbose.Segments {
+		converted := ai.TranscriptionSegment{
+			Start:   segment.Start,
+			End:     segment.End,
+			Speaker: segment.Speaker,
+			Text:    segment.Text,
 		}
-		if segmentTime <= 1 {
-			return nil, cleanup, fmt.Errorf("unable to split file into acceptable size chunks")
+		if segment.AvgLogprob != nil {
+			converted.Confidence = math.Min(math.Exp(*segment.AvgLogprob), 1)
 		}
-		segmentTime /= 2
+		ret = append(ret, converted)
 	}
+	return
 }
@@ -0,0 +1,48 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+
+	openai "github.com/openai/openai-go"
+)
+
+// DefaultEmbeddingModel is used when no embedding model is configured.
+const DefaultEmbeddingModel = openai.EmbeddingModelTextEmbedding3Small
+
+// Embed returns one embedding vector per input text using the embeddings endpoint.
+func (o *Client) Embed(ctx context.Context, model string, texts []string) (ret [][]float32, err error) {
+	if len(texts) == 0 {
+		return
+	}
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+
+	var resp *openai.CreateEmbeddingResponse
+	if resp, err = o.ApiClient.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Model: model,
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+	}); err != nil {
+		return
+	}
+
+	if len(resp.Data) != len(texts) {
+		err = fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
+		return
+	}
+
+	ret = make([][]float32, len(texts))
+	for _, item := range resp.Data {
+		if item.Index < 0 || item.Index >= int64(len(texts)) || ret[item.Index] != nil {
+			err = fmt.Errorf("invalid embedding index %d for %d inputs", item.Index, len(texts))
+			return nil, err
+		}
+		vector := make([]float32, len(item.Embedding))
+		for i, value := range item.Embedding {
+			vector[i] = float32(value)
+		}
+		ret[item.Index] = vector
+	}
+	return
+}
@@ -0,0 +1,33 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbedIndexes(t *testing.T) {
+	var indexes [2]int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"object":"list","model":"m","data":[{"object":"embedding","index":%d,"embedding":[1]},{"object":"embedding","index":%d,"embedding":[2]}]}`,
+			indexes[0], indexes[1])
+	}))
+	defer server.Close()
+	client := NewEndpointClient("Embeddings Endpoint", server.URL, "")
+
+	indexes = [2]int{1, 0}
+	ret, err := client.Embed(context.Background(), "m", []string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float32{{2}, {1}}, ret, "Vectors are ordered by their index")
+
+	for _, invalid := range [][2]int{{0, 2}, {-1, 0}, {0, 0}} {
+		indexes = invalid
+		_, err = client.Embed(context.Background(), "m", []string{"a", "b"})
+		assert.Error(t, err, "Index %v must be rejected", invalid)
+	}
+}
@@ -4,13 +4,21 @@ package openai
 // using OpenAI's Responses API and Image API.
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/danielmiessler/fabric/internal/chat"
 	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	openai "github.com/openai/openai-go"
 	"github.com/openai/openai-go/packages/param"
 	"github.com/openai/openai-go/responses"
 )
@@ -19,6 +27,17 @@ import (
 const ImageGenerationResponseType = "image_generation_call"
 const ImageGenerationToolType = "image_generation"
 
+// DefaultImageModel is the image model of OpenAI, also used by the image
+// generation tool.
+const DefaultImageModel = string(openai.ImageModelGPTImage1)
+
+// AllowedImageModels lists the image models of OpenAI.
+var AllowedImageModels = []string{
+	string(openai.ImageModelGPTImage1),
+	string(openai.ImageModelDallE3),
+	string(openai.ImageModelDallE2),
+}
+
 // ImageGenerationSupportedModels lists all models that support image generation
 var ImageGenerationSupportedModels = []string{
 	"gpt-4o",
@@ -70,7 +89,7 @@ func (o *Client) addImageGenerationTool(opts *domain.ChatOptions, tools []respon
 		imageGenTool := responses.ToolUnionParam{
 			OfImageGeneration: &responses.ToolImageGenerationParam{
 				Type:         ImageGenerationToolType,
-				Model:        "gpt-image-1",
+				Model:        DefaultImageModel,
 				OutputFormat: outputFormat,
 			},
 		}
@@ -106,8 +125,9 @@ func (o *Client) shouldUseImageGeneration(opts *domain.ChatOptions) bool {
 	return opts.ImageFile != ""
 }
 
-// extractAndSaveImages extracts generated images from the response and saves them
-func (o *Client) extractAndSaveImages(resp *responses.Response, opts *domain.ChatOptions) error {
+// extractAndSaveImages extracts generated images from the response and saves
+// them with a metadata sidecar file
+func (o *Client) extractAndSaveImages(resp *responses.Response, prompt string, opts *domain.ChatOptions) error {
 	if opts.ImageFile == "" {
 		return nil // No image file specified, skip saving
 	}
@@ -136,6 +156,22 @@ func (o *Client) extractAndSaveImages(resp *responses.Response, opts *domain.Cha
 					return fmt.Errorf("failed to save image to %s: %w", opts.ImageFile, err)
 				}
 
+				if err := ai.WriteImageMetadata(opts.ImageFile, &ai.ImageMetadata{
+					Prompt: prompt,
+					Vendor: o.GetName(),
+					Model:  DefaultImageModel,
+					Options: &ai.ImageOptions{
+						Size:        opts.ImageSize,
+						Quality:     opts.ImageQuality,
+						Background:  opts.ImageBackground,
+						Compression: opts.ImageCompression,
+						Format:      getOutputFormatFromExtension(opts.ImageFile),
+					},
+					Created: time.Now(),
+				}); err != nil {
+					return fmt.Errorf("failed to save image metadata: %w", err)
+				}
+
 				fmt.Printf("Image saved to: %s\n", opts.ImageFile)
 				return nil
 			}
@@ -144,3 +180,144 @@ func (o *Client) extractAndSaveImages(resp *responses.Response, opts *domain.Cha
 
 	return nil
 }
+
+// ImageModels returns the models of OpenAI. Compatible providers have their
+// own models, which are not known in advance.
+func (o *Client) ImageModels() []string {
+	if o.GetName() == "OpenAI" {
+		return AllowedImageModels
+	}
+	return nil
+}
+
+// GenerateImages creates images with the /images/generations endpoint, or
+// edits the input images with /images/edits.
+func (o *Client) GenerateImages(ctx context.Context, prompt string, opts *ai.ImageOptions) (ret []*ai.GeneratedImage, err error) {
+	model := opts.Model
+	if model == "" && o.GetName() == "OpenAI" {
+		model = DefaultImageModel
+	}
+	// gpt-image models always return base64 data and reject response_format
+	requestB64 := !strings.HasPrefix(model, "gpt-image")
+
+	var resp *openai.ImagesResponse
+	if len(opts.InputImages) > 0 {
+		params := openai.ImageEditParams{
+			Prompt:     prompt,
+			Model:      openai.ImageModel(model),
+			Size:       openai.ImageEditParamsSize(opts.Size),
+			Quality:    openai.ImageEditParamsQuality(opts.Quality),
+			Background: openai.ImageEditParamsBackground(opts.Background),
+		}
+		images := make([]io.Reader, 0, len(opts.InputImages))
+		for _, image := range opts.InputImages {
+			images = append(images, openai.File(bytes.NewReader(image.Data), image.Name, image.MIMEType))
+		}
+		if len(images) == 1 {
+			params.Image.OfFile = images[0]
+		} else {
+			params.Image.OfFileArray = images
+		}
+		if opts.Count > 1 {
+			params.N = openai.Int(int64(opts.Count))
+		}
+		if requestB64 {
+			params.ResponseFormat = openai.ImageEditParamsResponseFormatB64JSON
+		} else if opts.Format != "" {
+			params.OutputFormat = openai.ImageEditParamsOutputFormat(opts.Format)
+		}
+		if opts.Compression != 0 {
+			params.OutputCompression = openai.Int(int64(opts.Compression))
+		}
+		if resp, err = o.ApiClient.Images.Edit(ctx, params); err != nil {
+			return
+		}
+	} else {
+		params := openai.ImageGenerateParams{
+			Prompt:     prompt,
+			Model:      openai.ImageModel(model),
+			Size:       openai.ImageGenerateParamsSize(opts.Size),
+			Quality:    openai.ImageGenerateParamsQuality(opts.Quality),
+			Background: openai.ImageGenerateParamsBackground(opts.Background),
+		}
+		if opts.Count > 1 {
+			params.N = openai.Int(int64(opts.Count))
+		}
+		if requestB64 {
+			params.ResponseFormat = openai.ImageGenerateParamsResponseFormatB64JSON
+		} else if opts.Format != "" {
+			params.OutputFormat = openai.ImageGenerateParamsOutputFormat(opts.Format)
+		}
+		if opts.Compression != 0 {
+			params.OutputCompression = openai.Int(int64(opts.Compression))
+		}
+		if resp, err = o.ApiClient.Images.Generate(ctx, params); err != nil {
+			return
+		}
+	}
+
+	for _, image := range resp.Data {
+		var data []byte
+		if data, err = imageData(ctx, image); err != nil {
+			return
+		}
+		ret = append(ret, &ai.GeneratedImage{
+			Data:          data,
+			MIMEType:      http.DetectContentType(data),
+			RevisedPrompt: image.RevisedPrompt,
+		})
+	}
+	if len(ret) == 0 {
+		err = fmt.Errorf("no images received from %s", o.GetName())
+	}
+	return
+}
+
+// imageData decodes the base64 data of an image, or downloads it from its
+// URL for servers ignoring the requested response format.
+func imageData(ctx context.Context, image openai.Image) (ret []byte, err error) {
+	if image.B64JSON != "" {
+		if ret, err = base64.StdEncoding.DecodeString(image.B64JSON); err != nil {
+			err = fmt.Errorf("failed to decode image data: %w", err)
+		}
+		return
+	}
+	if image.URL == "" {
+		return nil, fmt.Errorf("image response has neither data nor URL")
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, image.URL, nil); err != nil {
+		return
+	}
+	var resp *http.Response
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download image from %s: %s", image.URL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// lastUserText returns the text of the last user message, the prompt of an
+// image generated by the image generation tool.
+func lastUserText(msgs []*chat.ChatCompletionMessage) string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role != chat.ChatMessageRoleUser {
+			continue
+		}
+		if msgs[i].Content != "" {
+			return msgs[i].Content
+		}
+		var texts []string
+		for _, part := range msgs[i].MultiContent {
+			if part.Type == chat.ChatMessagePartTypeText {
+				texts = append(texts, part.Text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
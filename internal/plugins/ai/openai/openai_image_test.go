@@ -1,12 +1,18 @@
 package openai
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/danielmiessler/fabric/internal/chat"
 	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 	"github.com/openai/openai-go/responses"
 	"github.com/stretchr/testify/assert"
 )
@@ -442,3 +448,57 @@ func TestAddImageGenerationToolWithUserParameters(t *testing.T) {
 		})
 	}
 }
+
+func TestSupportsImageGenerationTool(t *testing.T) {
+	client := NewClient()
+	client.ImplementsResponses = true
+	assert.True(t, client.SupportsImageGenerationTool("gpt-4o"))
+	assert.False(t, client.SupportsImageGenerationTool("gpt-3.5-turbo"))
+
+	client.ImplementsResponses = false
+	assert.False(t, client.SupportsImageGenerationTool("gpt-4o"), "Chat Completions providers have no image tool")
+}
+
+func TestGenerateImages(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]any{"path": r.URL.Path}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			body["path"] = r.URL.Path
+		} else if err := r.ParseMultipartForm(1 << 20); err == nil {
+			body["prompt"] = r.FormValue("prompt")
+			body["images"] = len(r.MultipartForm.File["image"]) + len(r.MultipartForm.File["image[]"])
+		}
+		requests = append(requests, body)
+		w.Header().Set("Content-Type", "application/json")
+		data := base64.StdEncoding.EncodeToString(png)
+		fmt.Fprintf(w, `{"created":1,"data":[{"b64_json":%q,"revised_prompt":"a red cat"},{"b64_json":%q}]}`, data, data)
+	}))
+	defer server.Close()
+
+	client := NewEndpointClient("Images Endpoint", server.URL, "")
+	ret, err := client.GenerateImages(context.Background(), "a cat", &ai.ImageOptions{Count: 2, Size: "512x512"})
+	assert.NoError(t, err)
+	if !assert.Len(t, ret, 2) {
+		return
+	}
+	assert.Equal(t, png, ret[0].Data)
+	assert.Equal(t, "image/png", ret[0].MIMEType)
+	assert.Equal(t, "a red cat", ret[0].RevisedPrompt)
+
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "/images/generations", requests[0]["path"])
+	assert.Equal(t, "b64_json", requests[0]["response_format"], "Endpoints are asked for base64 data")
+	assert.Equal(t, "512x512", requests[0]["size"])
+	assert.EqualValues(t, 2, requests[0]["n"])
+	assert.NotContains(t, requests[0], "model", "No model is sent when none is configured")
+
+	input := []ai.InputImage{{Name: "cat.png", MIMEType: "image/png", Data: png}}
+	_, err = client.GenerateImages(context.Background(), "make it red", &ai.ImageOptions{Model: "gpt-image-1", InputImages: input})
+	assert.NoError(t, err)
+	assert.Equal(t, "/images/edits", requests[1]["path"])
+	assert.Equal(t, "make it red", requests[1]["prompt"])
+	assert.Equal(t, 1, requests[1]["images"])
+}
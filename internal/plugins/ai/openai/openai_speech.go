@@ -0,0 +1,120 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	openai "github.com/openai/openai-go"
+)
+
+// MaxSpeechInput is the input limit of the /audio/speech endpoint in characters.
+const MaxSpeechInput = 4096
+
+const (
+	DefaultSpeechModel = string(openai.SpeechModelGPT4oMiniTTS)
+	DefaultSpeechVoice = string(openai.AudioSpeechNewParamsVoiceAlloy)
+)
+
+// AllowedSpeechModels lists the speech models of OpenAI.
+var AllowedSpeechModels = []string{
+	string(openai.SpeechModelGPT4oMiniTTS),
+	string(openai.SpeechModelTTS1),
+	string(openai.SpeechModelTTS1HD),
+}
+
+// SpeechVoices lists the voices of OpenAI.
+var SpeechVoices = []string{
+	"alloy", "ash", "ballad", "coral", "echo", "fable", "nova", "onyx", "sage", "shimmer", "verse",
+}
+
+// SpeechModels returns the models of OpenAI. Compatible providers have their
+// own models, which are not known in advance.
+func (o *Client) SpeechModels() []string {
+	if o.GetName() == "OpenAI" {
+		return AllowedSpeechModels
+	}
+	return nil
+}
+
+// SpeechVoices returns the voices of OpenAI, see SpeechModels.
+func (o *Client) SpeechVoices() []string {
+	if o.GetName() == "OpenAI" {
+		return SpeechVoices
+	}
+	return nil
+}
+
+func (o *Client) MaxSpeechInput() int {
+	return MaxSpeechInput
+}
+
+// Synthesize requests WAV audio from the /audio/speech endpoint and returns
+// its PCM data.
+func (o *Client) Synthesize(ctx context.Context, text string, opts *ai.SpeechOptions) (ret *ai.Speech, err error) {
+	model := opts.Model
+	if model == "" {
+		model = DefaultSpeechModel
+	}
+	voice := opts.Voice
+	if voice == "" {
+		voice = DefaultSpeechVoice
+	}
+
+	var resp *http.Response
+	if resp, err = o.ApiClient.Audio.Speech.New(ctx, openai.AudioSpeechNewParams{
+		Input:          text,
+		Model:          openai.SpeechModel(model),
+		Voice:          openai.AudioSpeechNewParamsVoice(voice),
+		ResponseFormat: openai.AudioSpeechNewParamsResponseFormatWAV,
+	}); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var data []byte
+	if data, err = io.ReadAll(resp.Body); err != nil {
+		return
+	}
+	return decodeWAV(data)
+}
+
+// decodeWAV reads the PCM data of a 16-bit WAV file. Streamed WAV files have
+// placeholder sizes, so the data chunk runs to the end of the file.
+func decodeWAV(data []byte) (ret *ai.Speech, err error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("speech response is not WAV audio")
+	}
+	ret = &ai.Speech{}
+	for offset := 12; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		body := data[offset+8:]
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, fmt.Errorf("invalid WAV format chunk")
+			}
+			if bits := binary.LittleEndian.Uint16(body[14:16]); bits != 16 {
+				return nil, fmt.Errorf("unsupported WAV sample size of %d bits, expected 16", bits)
+			}
+			ret.Channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			ret.SampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+		case "data":
+			if size > len(body) || size == 0 {
+				size = len(body)
+			}
+			ret.PCM = bytes.Clone(body[:size])
+			if ret.SampleRate == 0 {
+				return nil, fmt.Errorf("WAV data without format chunk")
+			}
+			return
+		}
+		offset += 8 + size + size%2
+	}
+	return nil, fmt.Errorf("WAV audio has no data chunk")
+}
@@ -175,3 +175,38 @@ func TestCitationFormatting(t *testing.T) {
 	citationCount := strings.Count(result, "- [")
 	assert.Equal(t, 2, citationCount, "Expected 2 unique citations")
 }
+
+func TestParseTranscriptionSegments(t *testing.T) {
+	verbose := `{"text":"Hello there.","segments":[{"id":0,"start":0.0,"end":1.5,"text":" Hello there.","avg_logprob":-0.1}]}`
+	segments, err := parseSegments(verbose)
+	assert.NoError(t, err)
+	assert.Len(t, segments, 1)
+	assert.Equal(t, 1.5, segments[0].End)
+	assert.InDelta(t, 0.905, segments[0].Confidence, 0.001)
+	assert.Empty(t, segments[0].Speaker)
+
+	diarized := `{"text":"Hi. Hello.","segments":[{"type":"transcript.text.segment","id":"seg_0","start":0.2,"end":0.9,"text":"Hi.","speaker":"A"},` +
+		`{"type":"transcript.text.segment","id":"seg_1","start":1.1,"end":1.8,"text":"Hello.","speaker":"B"}]}`
+	segments, err = parseSegments(diarized)
+	assert.NoError(t, err)
+	assert.Len(t, segments, 2)
+	assert.Equal(t, "B", segments[1].Speaker)
+	assert.Zero(t, segments[1].Confidence)
+
+	_, err = parseSegments("not json")
+	assert.Error(t, err)
+}
+
+func TestDecodeWAV(t *testing.T) {
+	wav := []byte("RIFF\x00\x00\x00\x00WAVE" +
+		"fmt \x10\x00\x00\x00\x01\x00\x01\x00\xc0\x5d\x00\x00\x80\xbb\x00\x00\x02\x00\x10\x00" +
+		"data\xff\xff\xff\xff\x01\x02\x03\x04")
+	speech, err := decodeWAV(wav)
+	assert.NoError(t, err)
+	assert.Equal(t, 24000, speech.SampleRate)
+	assert.Equal(t, 1, speech.Channels)
+	assert.Equal(t, []byte{1, 2, 3, 4}, speech.PCM)
+
+	_, err = decodeWAV([]byte("ID3 mp3 data"))
+	assert.Error(t, err)
+}
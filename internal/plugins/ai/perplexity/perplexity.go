@@ -214,6 +214,11 @@ func (c *Client) SendStream(msgs []*chat.ChatCompletionMessage, opts *domain.Cha
 	return nil
 }
 
+// SupportsNativeSearch reports that Perplexity models always search the web.
+func (c *Client) SupportsNativeSearch(modelName string) bool {
+	return true
+}
+
 func (c *Client) NeedsRawMode(modelName string) bool {
 	return true
 }
@@ -0,0 +1,8 @@
+package ai
+
+// NativeSearcher is implemented by vendors whose models can search the web
+// themselves when ChatOptions.Search is set. For other vendors the configured
+// search provider runs first and its results are injected into the prompt.
+type NativeSearcher interface {
+	SupportsNativeSearch(modelName string) bool
+}
@@ -0,0 +1,30 @@
+package ai
+
+import "context"
+
+// SpeechSynthesizer is implemented by vendors that turn text into speech:
+// Gemini TTS models, OpenAI speech and OpenAI-compatible /audio/speech
+// endpoints such as a local Kokoro or Piper server.
+//
+// Synthesize is called with at most MaxSpeechInput characters; longer text is
+// split by the caller.
+type SpeechSynthesizer interface {
+	Synthesize(ctx context.Context, text string, opts *SpeechOptions) (*Speech, error)
+	SpeechModels() []string
+	SpeechVoices() []string
+	MaxSpeechInput() int
+}
+
+// SpeechOptions select the model and voice, both default to the vendor's
+// defaults when empty.
+type SpeechOptions struct {
+	Model string
+	Voice string
+}
+
+// Speech is raw 16-bit little-endian PCM audio.
+type Speech struct {
+	PCM        []byte
+	SampleRate int
+	Channels   int
+}
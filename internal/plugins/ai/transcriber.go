@@ -0,0 +1,44 @@
+package ai
+
+import "context"
+
+// Transcriber is implemented by vendors that turn speech into text: OpenAI,
+// Gemini (audio input) and OpenAI-compatible /audio/transcriptions endpoints
+// such as a local whisper.cpp or faster-whisper server.
+//
+// Transcribe is called with files of at most MaxTranscriptionFileSize bytes;
+// larger files are split by the caller.
+type Transcriber interface {
+	Transcribe(ctx context.Context, filePath string, opts *TranscriptionOptions) (*Transcription, error)
+	TranscriptionModels() []string
+	MaxTranscriptionFileSize() int64
+}
+
+// TranscriptionOptions select the model and what the transcription returns.
+type TranscriptionOptions struct {
+	// Model defaults to the first of TranscriptionModels when empty
+	Model string
+	// Language is an optional ISO-639-1 hint, e.g. "en"
+	Language string
+	// Segments requests segment timestamps
+	Segments bool
+	// Speakers requests speaker labels (diarization), which implies segments.
+	// Backends without diarization return segments without speakers.
+	Speakers bool
+}
+
+// Transcription is the result of a transcription, with segments when requested.
+type Transcription struct {
+	Text     string                 `json:"text"`
+	Segments []TranscriptionSegment `json:"segments,omitempty"`
+}
+
+// TranscriptionSegment is a part of a transcription, in seconds from the start.
+// Speaker and Confidence (0 to 1) are set when the backend provides them.
+type TranscriptionSegment struct {
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Speaker    string  `json:"speaker,omitempty"`
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
@@ -16,3 +16,10 @@ type Vendor interface {
 	Send(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, error)
 	NeedsRawMode(modelName string) bool
 }
+
+// DocumentSupporter is implemented by vendors that accept document attachments
+// natively for some MIME types. Documents of other types, or sent to vendors
+// not implementing it, are converted to text locally before sending.
+type DocumentSupporter interface {
+	SupportsDocument(mimeType string) bool
+}
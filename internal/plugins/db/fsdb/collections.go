@@ -0,0 +1,94 @@
+package fsdb
+
+import (
+	"fmt"
+	"sort"
+)
+
+type CollectionsEntity struct {
+	*StorageEntity
+}
+
+// Get loads an indexed collection from its JSON file
+func (o *CollectionsEntity) Get(name string) (ret *Collection, err error) {
+	if !o.Exists(name) {
+		err = fmt.Errorf("collection %s does not exist, index it first with --index-collection", name)
+		return
+	}
+
+	ret = &Collection{}
+	if err = o.LoadAsJson(name, ret); err != nil {
+		return
+	}
+	ret.Name = name
+	return
+}
+
+// GetOrCreate loads an existing collection or returns a new empty one
+func (o *CollectionsEntity) GetOrCreate(name string) (ret *Collection, err error) {
+	if o.Exists(name) {
+		return o.Get(name)
+	}
+	ret = &Collection{Name: name, Files: map[string]string{}}
+	return
+}
+
+func (o *CollectionsEntity) SaveCollection(collection *Collection) (err error) {
+	return o.SaveAsJson(collection.Name, collection)
+}
+
+func (o *CollectionsEntity) PrintCollection(name string) (err error) {
+	var collection *Collection
+	if collection, err = o.Get(name); err != nil {
+		return
+	}
+	fmt.Printf("Collection: %s\n", collection.Name)
+	fmt.Printf("Root: %s\n", collection.Root)
+	fmt.Printf("Embedding: %s\n", collection.EmbeddingModel)
+	fmt.Printf("Files: %d\n", len(collection.Files))
+	fmt.Printf("Chunks: %d\n", len(collection.Chunks))
+	for _, source := range collection.Sources() {
+		fmt.Printf("  %s\n", source)
+	}
+	return
+}
+
+// Collection is a named set of embedded document chunks. Files maps each
+// indexed source path (relative to Root) to the sha256 of its content so that
+// re-indexing only touches files that changed.
+type Collection struct {
+	Name           string             `json:"name"`
+	Root           string             `json:"root"`
+	EmbeddingModel string             `json:"embeddingModel"`
+	Files          map[string]string  `json:"files"`
+	Chunks         []*CollectionChunk `json:"chunks"`
+}
+
+type CollectionChunk struct {
+	Source    string    `json:"source"`
+	StartLine int       `json:"startLine"`
+	EndLine   int       `json:"endLine"`
+	Text      string    `json:"text"`
+	Vector    []float32 `json:"vector"`
+}
+
+// Sources returns the sorted list of indexed file paths
+func (o *Collection) Sources() (ret []string) {
+	for source := range o.Files {
+		ret = append(ret, source)
+	}
+	sort.Strings(ret)
+	return
+}
+
+// RemoveSource drops all chunks and the hash recorded for the given file
+func (o *Collection) RemoveSource(source string) {
+	kept := o.Chunks[:0]
+	for _, chunk := range o.Chunks {
+		if chunk.Source != source {
+			kept = append(kept, chunk)
+		}
+	}
+	o.Chunks = kept
+	delete(o.Files, source)
+}
@@ -29,15 +29,19 @@ func NewDb(dir string) (db *Db) {
 	db.Contexts = &ContextsEntity{
 		&StorageEntity{Label: "Contexts", Dir: db.FilePath("contexts")}}
 
+	db.Collections = &CollectionsEntity{
+		&StorageEntity{Label: "Collections", Dir: db.FilePath("collections"), FileExtension: ".json"}}
+
 	return
 }
 
 type Db struct {
 	Dir string
 
-	Patterns *PatternsEntity
-	Sessions *SessionsEntity
-	Contexts *ContextsEntity
+	Patterns    *PatternsEntity
+	Sessions    *SessionsEntity
+	Contexts    *ContextsEntity
+	Collections *CollectionsEntity
 
 	EnvFilePath string
 }
@@ -75,6 +79,10 @@ func (o *Db) Configure() (err error) {
 		return
 	}
 
+	if err = o.Collections.Configure(); err != nil {
+		return
+	}
+
 	return
 }
 
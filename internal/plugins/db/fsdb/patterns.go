@@ -92,16 +92,21 @@ func (o *PatternsEntity) applyInput(pattern *Pattern, input string) {
 func (o *PatternsEntity) applyVariables(
 	pattern *Pattern, variables map[string]string, input string) (err error) {
 
-	o.ensureInput(pattern)
+	// A filter expression such as {{input|truncate:2000}} places the input too
+	if !template.ReferencesInput(pattern.Pattern) {
+		o.ensureInput(pattern)
+	}
 
 	// Temporarily replace {{input}} with a sentinel token to protect it
 	// from recursive variable resolution
 	withSentinel := strings.ReplaceAll(pattern.Pattern, "{{input}}", inputSentinel)
 
 	// Process all other template variables in the pattern
-	// At this point, our sentinel ensures {{input}} won't be affected
+	// At this point, our sentinel ensures {{input}} won't be affected.
+	// The input is still passed for conditions and filter expressions such as
+	// {{input|truncate:2000}}, whose results are never scanned for variables.
 	var processed string
-	if processed, err = template.ApplyTemplate(withSentinel, variables, ""); err != nil {
+	if processed, err = template.ApplyTemplate(withSentinel, variables, input); err != nil {
 		return
 	}
 
@@ -74,6 +74,17 @@ func TestApplyVariables(t *testing.T) {
 			input: "Review this PR",
 			want:  "You are a code reviewer.\nPlease analyze.\nReview this PR",
 		},
+		{
+			name: "filtered input is not appended again",
+			pattern: &Pattern{
+				Pattern: "{{#if focus}}Focus on {{focus}}.\n{{/if}}{{input|truncate:5}}",
+			},
+			variables: map[string]string{
+				"focus": "security",
+			},
+			input: "{{not a variable}}",
+			want:  "Focus on security.\n{{not",
+		},
 		// ... previous test cases ...
 	}
 
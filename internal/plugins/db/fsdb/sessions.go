@@ -91,6 +91,8 @@ func (o *Session) String() (ret string) {
 					ret += fmt.Sprintf("\n%v: %v", part.Type, *part.ImageURL)
 				case chat.ChatMessagePartTypeText:
 					ret += fmt.Sprintf("\n%v: %v", part.Type, part.Text)
+				case chat.ChatMessagePartTypeFile:
+					ret += fmt.Sprintf("\n%v: %v (%v)", part.Type, part.File.Filename, part.File.MimeType)
 				}
 			}
 		}
@@ -8,13 +8,30 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/kballard/go-shellquote"
 )
 
+// DefaultExtensionTimeout applies to extensions without a timeout.
+const DefaultExtensionTimeout = 30 * time.Second
+
+// DefaultExtensionMaxOutput is the output limit of extensions without max_output (1MB).
+const DefaultExtensionMaxOutput = 1024 * 1024
+
+// DefaultExtensionEnv lists the environment variables passed to every
+// extension. Others must be named in env_allow or set in env.
+var DefaultExtensionEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TMPDIR", "TZ"}
+
 // ExtensionExecutor handles the secure execution of extensions
 // It uses the registry to verify extensions before running them
 type ExtensionExecutor struct {
 	registry *ExtensionRegistry
+
+	// sessions are the running rpc extensions, reused until Shutdown
+	sessionsMu sync.Mutex
+	sessions   map[string]*rpcSession
 }
 
 // NewExtensionExecutor creates a new executor instance
@@ -22,6 +39,7 @@ type ExtensionExecutor struct {
 func NewExtensionExecutor(registry *ExtensionRegistry) *ExtensionExecutor {
 	return &ExtensionExecutor{
 		registry: registry,
+		sessions: map[string]*rpcSession{},
 	}
 }
 
@@ -29,7 +47,11 @@ func NewExtensionExecutor(registry *ExtensionRegistry) *ExtensionExecutor {
 // name: the registered name of the extension
 // operation: the operation to perform
 // value: the input value(s) for the operation
-// In extension_executor.go
+//
+// The executable is started directly with the templated arguments, without a
+// shell unless the extension opts in with shell: true. Every run is bounded
+// by the extension timeout and output limit, sees only the allowed
+// environment and runs in its work_dir or a temporary directory.
 func (e *ExtensionExecutor) Execute(name, operation, value string) (string, error) {
 	// Get and verify extension from registry
 	ext, err := e.registry.GetExtension(name)
@@ -37,42 +59,98 @@ func (e *ExtensionExecutor) Execute(name, operation, value string) (string, erro
 		return "", fmt.Errorf("failed to get extension: %w", err)
 	}
 
-	// Format the command using our template system
-	cmdStr, err := e.formatCommand(ext, operation, value)
+	if ext.Type == ExtensionTypeRPC {
+		session, err := e.session(ext)
+		if err != nil {
+			return "", err
+		}
+		return session.Invoke(operation, value)
+	}
+
+	argv, err := e.formatCommand(ext, operation, value)
 	if err != nil {
 		return "", fmt.Errorf("failed to format command: %w", err)
 	}
 
-	// Split the command string into command and arguments
-	cmdParts := strings.Fields(cmdStr)
-	if len(cmdParts) < 1 {
-		return "", fmt.Errorf("empty command after formatting")
+	timeout, err := ext.GetTimeout()
+	if err != nil {
+		return "", err
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	// Create command with the Executable and formatted arguments
-	cmd := exec.Command("sh", "-c", cmdStr)
-	//cmd := exec.Command(cmdParts[0], cmdParts[1:]...)
-
-	// Set up environment if specified
-	if len(ext.Env) > 0 {
-		cmd.Env = append(os.Environ(), ext.Env...)
+	workDir, err := ext.prepareWorkDir()
+	if err != nil {
+		return "", err
 	}
+	if ext.GetWorkDir() == "" {
+		defer os.RemoveAll(workDir)
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = workDir
+	cmd.Env = ext.buildEnv()
+	// Children of a killed command must not keep its output open
+	cmd.WaitDelay = time.Second
+	debugf("Executing command: %s\n", cmd.String())
 
 	// Execute based on output method
-	outputMethod := ext.GetOutputMethod()
-	if outputMethod == "file" {
-		return e.executeWithFile(cmd, ext)
+	var output string
+	if ext.GetOutputMethod() == "file" {
+		output, err = e.executeWithFile(cmd, ext, workDir)
+	} else {
+		output, err = e.executeStdout(cmd, ext)
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("execution timed out after %v", timeout)
+	}
+	return output, err
+}
+
+// session returns the running rpc extension, starting it on first use or
+// after it stopped.
+func (e *ExtensionExecutor) session(ext *ExtensionDefinition) (*rpcSession, error) {
+	e.sessionsMu.Lock()
+	defer e.sessionsMu.Unlock()
+
+	if session, ok := e.sessions[ext.Name]; ok && session.isRunning() {
+		return session, nil
+	}
+	session, err := startRPCSession(ext)
+	if err != nil {
+		return nil, err
+	}
+	e.sessions[ext.Name] = session
+	return session, nil
+}
+
+// Operations starts an rpc extension if needed and returns the operations it
+// announced.
+func (e *ExtensionExecutor) Operations(ext *ExtensionDefinition) ([]RPCOperation, error) {
+	session, err := e.session(ext)
+	if err != nil {
+		return nil, err
 	}
-	return e.executeStdout(cmd, ext)
+	return session.operations, nil
 }
 
-// formatCommand uses fabric's template system to format the command
-// It creates a variables map for the template system using the input values
-func (e *ExtensionExecutor) formatCommand(ext *ExtensionDefinition, operation string, value string) (string, error) {
+// Shutdown stops all running rpc extensions.
+func (e *ExtensionExecutor) Shutdown() {
+	e.sessionsMu.Lock()
+	defer e.sessionsMu.Unlock()
+	for name, session := range e.sessions {
+		session.Shutdown()
+		delete(e.sessions, name)
+	}
+}
+
+// formatCommand uses fabric's template system to build the argument vector.
+// Each argument is templated on its own, so values cannot add arguments.
+func (e *ExtensionExecutor) formatCommand(ext *ExtensionDefinition, operation string, value string) ([]string, error) {
 	// Get operation config
 	opConfig, exists := ext.Operations[operation]
 	if !exists {
-		return "", fmt.Errorf("operation %s not found for extension %s", operation, ext.Name)
+		return nil, fmt.Errorf("operation %s not found for extension %s", operation, ext.Name)
 	}
 
 	vars := make(map[string]string)
@@ -86,44 +164,70 @@ func (e *ExtensionExecutor) formatCommand(ext *ExtensionDefinition, operation st
 		vars[fmt.Sprintf("%d", i+1)] = val
 	}
 
-	return ApplyTemplate(opConfig.CmdTemplate, vars, "")
+	if ext.Shell && opConfig.CmdTemplate != "" {
+		cmdStr, err := ApplyTemplate(opConfig.CmdTemplate, vars, "")
+		if err != nil {
+			return nil, err
+		}
+		return []string{"sh", "-c", cmdStr}, nil
+	}
+
+	args := opConfig.Args
+	if len(args) == 0 {
+		var err error
+		if args, err = splitCommandTemplate(ext, opConfig.CmdTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	argv := []string{ext.Executable}
+	for _, arg := range args {
+		formatted, err := ApplyTemplate(arg, vars, "")
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(formatted, "~/") {
+			if home, homeErr := os.UserHomeDir(); homeErr == nil {
+				formatted = filepath.Join(home, formatted[2:])
+			}
+		}
+		argv = append(argv, formatted)
+	}
+	return argv, nil
+}
+
+// splitCommandTemplate splits a cmd_template into its argument templates.
+// The template must start with the extension executable.
+func splitCommandTemplate(ext *ExtensionDefinition, cmdTemplate string) ([]string, error) {
+	words, err := shellquote.Split(cmdTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cmd_template: %w", err)
+	}
+	if len(words) == 0 || (words[0] != "{{executable}}" && words[0] != ext.Executable) {
+		return nil, fmt.Errorf("cmd_template must start with {{executable}}; use args, or set shell: true to run it through a shell")
+	}
+	return words[1:], nil
 }
 
 // executeStdout runs the command and captures its stdout
 func (e *ExtensionExecutor) executeStdout(cmd *exec.Cmd, ext *ExtensionDefinition) (string, error) {
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	//debug output
-	fmt.Printf("Executing command: %s\n", cmd.String())
+	stdout := &limitedBuffer{limit: ext.GetMaxOutput()}
+	stderr := &limitedBuffer{limit: ext.GetMaxOutput()}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	if err := cmd.Run(); err != nil {
 		return "", fmt.Errorf("execution failed: %w\nstderr: %s", err, stderr.String())
 	}
+	if stdout.exceeded {
+		return "", fmt.Errorf("output of %s exceeds limit of %d bytes", ext.Name, stdout.limit)
+	}
 
 	return stdout.String(), nil
 }
 
 // executeWithFile runs the command and handles file-based output
-func (e *ExtensionExecutor) executeWithFile(cmd *exec.Cmd, ext *ExtensionDefinition) (string, error) {
-	// Parse timeout - this is now a first-class field
-	timeout, err := time.ParseDuration(ext.Timeout)
-	if err != nil {
-		return "", fmt.Errorf("invalid timeout format: %w", err)
-	}
-
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	// Store the original environment
-	originalEnv := cmd.Env
-	// Create a new command with context. This might reset Env, depending on the Go version.
-	cmd = exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
-	// Restore the environment variables explicitly
-	cmd.Env = originalEnv
-
+func (e *ExtensionExecutor) executeWithFile(cmd *exec.Cmd, ext *ExtensionDefinition, workDir string) (string, error) {
 	fileConfig := ext.GetFileConfig()
 	if fileConfig == nil {
 		return "", fmt.Errorf("no file configuration found")
@@ -131,70 +235,117 @@ func (e *ExtensionExecutor) executeWithFile(cmd *exec.Cmd, ext *ExtensionDefinit
 
 	// Handle path from stdout case
 	if pathFromStdout, ok := fileConfig["path_from_stdout"].(bool); ok && pathFromStdout {
-		return e.handlePathFromStdout(cmd, ext)
+		return e.handlePathFromStdout(cmd, ext, workDir)
 	}
 
 	// Handle fixed file case
-	workDir, _ := fileConfig["work_dir"].(string)
 	outputFile, _ := fileConfig["output_file"].(string)
-
 	if outputFile == "" {
 		return "", fmt.Errorf("no output file specified in configuration")
 	}
 
-	// Set working directory if specified
-	if workDir != "" {
-		cmd.Dir = workDir
-	}
-
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("execution timed out after %v", timeout)
-		}
-		return "", fmt.Errorf("execution failed: %w\nerr: %s", err, stderr.String())
-	}
-
 	// Construct full file path
 	outputPath := outputFile
-	if workDir != "" {
+	if !filepath.IsAbs(outputPath) {
 		outputPath = filepath.Join(workDir, outputFile)
 	}
-
-	content, err := os.ReadFile(outputPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read output file: %w", err)
+	if !isWithinDir(outputPath, workDir) {
+		return "", fmt.Errorf("output file %s is outside the work directory %s", outputFile, workDir)
 	}
 
-	// Handle cleanup if enabled
-	if ext.IsCleanupEnabled() {
-		defer os.Remove(outputPath)
+	stderr := &limitedBuffer{limit: ext.GetMaxOutput()}
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("execution failed: %w\nerr: %s", err, stderr.String())
 	}
 
-	return string(content), nil
+	return readOutputFile(outputPath, ext)
 }
 
 // Helper method to handle path from stdout case
-func (e *ExtensionExecutor) handlePathFromStdout(cmd *exec.Cmd, ext *ExtensionDefinition) (string, error) {
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+func (e *ExtensionExecutor) handlePathFromStdout(cmd *exec.Cmd, ext *ExtensionDefinition, workDir string) (string, error) {
+	stdout := &limitedBuffer{limit: ext.GetMaxOutput()}
+	stderr := &limitedBuffer{limit: ext.GetMaxOutput()}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	if err := cmd.Run(); err != nil {
 		return "", fmt.Errorf("failed to get output path: %w\nerr: %s", err, stderr.String())
 	}
 
 	outputPath := strings.TrimSpace(stdout.String())
-	content, err := os.ReadFile(outputPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read output file: %w", err)
+	if !filepath.IsAbs(outputPath) {
+		outputPath = filepath.Join(workDir, outputPath)
 	}
+	if !isWithinDir(outputPath, workDir) && !isWithinDir(outputPath, os.TempDir()) {
+		return "", fmt.Errorf("output file %s is outside the work directory and the temporary directory", outputPath)
+	}
+
+	return readOutputFile(outputPath, ext)
+}
 
+// readOutputFile reads an output file within the output limit and removes
+// it afterwards when cleanup is enabled.
+func readOutputFile(outputPath string, ext *ExtensionDefinition) (string, error) {
 	if ext.IsCleanupEnabled() {
 		defer os.Remove(outputPath)
 	}
 
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read output file: %w", err)
+	}
+	if info.Size() > int64(ext.GetMaxOutput()) {
+		return "", fmt.Errorf("output file of %s exceeds limit of %d bytes", ext.Name, ext.GetMaxOutput())
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read output file: %w", err)
+	}
 	return string(content), nil
 }
+
+// isWithinDir reports whether path resolves to dir or a file below it.
+func isWithinDir(path string, dir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(absDir); err == nil {
+		absDir = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(absPath)); err == nil {
+		absPath = filepath.Join(resolved, filepath.Base(absPath))
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// limitedBuffer keeps the first limit bytes written and records whether
+// more were written. Writes never fail, so the command is not interrupted.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining < len(p) {
+		b.exceeded = true
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
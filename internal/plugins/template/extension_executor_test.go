@@ -358,3 +358,161 @@ config:
 		}
 	})
 }
+
+func TestExtensionExecutorSafety(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testScript := filepath.Join(tmpDir, "safety-script.sh")
+	scriptContent := `#!/bin/bash
+case "$1" in
+    "args")
+        echo "$#:$2"
+        ;;
+    "sleep")
+        sleep 5
+        ;;
+    "big")
+        head -c 4096 /dev/zero
+        ;;
+    "env")
+        echo "secret=${FABRIC_TEST_SECRET:-unset} allowed=${FABRIC_TEST_ALLOWED:-unset} fixed=${FABRIC_TEST_FIXED:-unset}"
+        ;;
+    "write")
+        echo "written" > "$2"
+        ;;
+esac`
+	if err := os.WriteFile(testScript, []byte(scriptContent), 0755); err != nil {
+		t.Fatalf("Failed to create test script: %v", err)
+	}
+
+	registry := NewExtensionRegistry(tmpDir)
+	executor := NewExtensionExecutor(registry)
+
+	register := func(t *testing.T, name, body string) error {
+		t.Helper()
+		configPath := filepath.Join(tmpDir, name+".yaml")
+		configContent := "name: " + name + "\nexecutable: " + testScript + "\ntype: executable\n" + body
+		if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+			t.Fatalf("Failed to create config: %v", err)
+		}
+		return registry.Register(configPath)
+	}
+
+	t.Run("ArgsAreNotSplitOrInterpreted", func(t *testing.T) {
+		err := register(t, "argv-test", `operations:
+  run:
+    args: ["args", "{{value}}"]`)
+		if err != nil {
+			t.Fatalf("Failed to register extension: %v", err)
+		}
+		output, err := executor.Execute("argv-test", "run", "a b; echo injected $(id)")
+		if err != nil {
+			t.Fatalf("Failed to execute: %v", err)
+		}
+		if expected := "2:a b; echo injected $(id)\n"; output != expected {
+			t.Errorf("Expected output %q, got %q", expected, output)
+		}
+	})
+
+	t.Run("CommandTemplateWithoutShell", func(t *testing.T) {
+		err := register(t, "template-test", `operations:
+  run:
+    cmd_template: "{{executable}} args '{{1}}'"`)
+		if err != nil {
+			t.Fatalf("Failed to register extension: %v", err)
+		}
+		output, err := executor.Execute("template-test", "run", "x' && echo 'injected")
+		if err != nil {
+			t.Fatalf("Failed to execute: %v", err)
+		}
+		if expected := "2:x' && echo 'injected\n"; output != expected {
+			t.Errorf("Expected output %q, got %q", expected, output)
+		}
+	})
+
+	t.Run("ShellRequiresOptIn", func(t *testing.T) {
+		body := `operations:
+  run:
+    cmd_template: "echo hello | {{executable}} args"`
+		if err := register(t, "pipe-test", body); err == nil {
+			t.Fatal("Expected registration error for a shell command line without shell: true")
+		}
+		if err := register(t, "pipe-test", "shell: true\n"+body); err != nil {
+			t.Fatalf("Failed to register extension: %v", err)
+		}
+		output, err := executor.Execute("pipe-test", "run", "")
+		if err != nil {
+			t.Fatalf("Failed to execute: %v", err)
+		}
+		if expected := "1:\n"; output != expected {
+			t.Errorf("Expected output %q, got %q", expected, output)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		err := register(t, "timeout-test", `timeout: 100ms
+operations:
+  run:
+    args: ["sleep"]`)
+		if err != nil {
+			t.Fatalf("Failed to register extension: %v", err)
+		}
+		_, err = executor.Execute("timeout-test", "run", "")
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("Expected timeout error, got %v", err)
+		}
+	})
+
+	t.Run("OutputLimit", func(t *testing.T) {
+		err := register(t, "limit-test", `max_output: 1024
+operations:
+  run:
+    args: ["big"]`)
+		if err != nil {
+			t.Fatalf("Failed to register extension: %v", err)
+		}
+		_, err = executor.Execute("limit-test", "run", "")
+		if err == nil || !strings.Contains(err.Error(), "exceeds limit") {
+			t.Errorf("Expected output limit error, got %v", err)
+		}
+	})
+
+	t.Run("RestrictedEnvironment", func(t *testing.T) {
+		t.Setenv("FABRIC_TEST_SECRET", "s3cr3t")
+		t.Setenv("FABRIC_TEST_ALLOWED", "yes")
+		err := register(t, "env-test", `env_allow: ["FABRIC_TEST_ALLOWED"]
+env: ["FABRIC_TEST_FIXED=1"]
+operations:
+  run:
+    args: ["env"]`)
+		if err != nil {
+			t.Fatalf("Failed to register extension: %v", err)
+		}
+		output, err := executor.Execute("env-test", "run", "")
+		if err != nil {
+			t.Fatalf("Failed to execute: %v", err)
+		}
+		if expected := "secret=unset allowed=yes fixed=1\n"; output != expected {
+			t.Errorf("Expected output %q, got %q", expected, output)
+		}
+	})
+
+	t.Run("OutputFileOutsideWorkDir", func(t *testing.T) {
+		err := register(t, "escape-test", `operations:
+  run:
+    args: ["write", "{{1}}"]
+config:
+  output:
+    method: file
+    file_config:
+      work_dir: "`+tmpDir+`"
+      output_file: "../escape.txt"`)
+		if err != nil {
+			t.Fatalf("Failed to register extension: %v", err)
+		}
+		_, err = executor.Execute("escape-test", "run", "out.txt")
+		if err == nil || !strings.Contains(err.Error(), "outside the work directory") {
+			t.Errorf("Expected work directory error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,577 @@
+package template
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+)
+
+// An extension bundle is a directory, git repository or tarball with the
+// manifest at its root. The executable in the manifest is relative to the
+// bundle. The optional signature file holds a base64 ed25519 signature of
+// the bundle digest, one "<sha256>  <path>" line per file sorted by path.
+const (
+	ExtensionManifestFile  = "extension.yaml"
+	ExtensionSignatureFile = "extension.sig"
+	// TrustedKeysFile lists one base64 ed25519 public key per line,
+	// optionally followed by a name.
+	TrustedKeysFile = "trusted_keys"
+)
+
+// MaxBundleSize limits downloaded and extracted bundles (50MB)
+const MaxBundleSize = 50 * 1024 * 1024
+
+// maxBundleDiffSize limits the unified diff printed for one changed file
+const maxBundleDiffSize = 64 * 1024
+
+// BundleChange is a file that differs between two versions of a bundle.
+type BundleChange struct {
+	Path   string
+	Status string // added, removed or modified
+}
+
+// InstallResult describes an installed or updated bundle.
+type InstallResult struct {
+	Extension *ExtensionDefinition
+	Source    string
+	SignedBy  string
+	Changes   []BundleChange
+	Diff      string
+}
+
+func (r *ExtensionRegistry) installRoot() string {
+	return filepath.Join(r.configDir, "extensions", "installed")
+}
+
+func (r *ExtensionRegistry) installDir(name string) string {
+	return filepath.Join(r.installRoot(), name)
+}
+
+// Install fetches a bundle from a git URL (optionally with #ref), a tarball
+// path or URL, or a local directory, verifies its signature when present
+// and registers it from the managed directory.
+func (r *ExtensionRegistry) Install(source string) (ret *InstallResult, err error) {
+	// Local sources are kept absolute so updates work from any directory
+	if _, statErr := os.Stat(source); statErr == nil {
+		if source, err = filepath.Abs(source); err != nil {
+			return
+		}
+	}
+
+	var staging string
+	if staging, err = os.MkdirTemp("", "fabric-ext-install-*"); err != nil {
+		return
+	}
+	defer os.RemoveAll(staging)
+
+	var bundle *stagedBundle
+	if bundle, err = r.stageBundle(source, staging); err != nil {
+		return
+	}
+	if _, exists := r.registry.Extensions[bundle.ext.Name]; exists {
+		return nil, fmt.Errorf("extension %s is already registered, use --updateextension %s", bundle.ext.Name, bundle.ext.Name)
+	}
+
+	if err = r.installBundle(bundle, source); err != nil {
+		return
+	}
+	ret = &InstallResult{Extension: bundle.ext, Source: source, SignedBy: bundle.signedBy}
+	return
+}
+
+// Update fetches the bundle of an installed extension again from its
+// source, reports the changed files and re-pins the hashes.
+func (r *ExtensionRegistry) Update(name string) (ret *InstallResult, err error) {
+	entry, exists := r.registry.Extensions[name]
+	if !exists {
+		return nil, fmt.Errorf("extension %s not found", name)
+	}
+	if entry.Source == "" {
+		return nil, fmt.Errorf("extension %s was registered from a local config, re-add it with --addextension", name)
+	}
+
+	var staging string
+	if staging, err = os.MkdirTemp("", "fabric-ext-update-*"); err != nil {
+		return
+	}
+	defer os.RemoveAll(staging)
+
+	var bundle *stagedBundle
+	if bundle, err = r.stageBundle(entry.Source, staging); err != nil {
+		return
+	}
+	if bundle.ext.Name != name {
+		return nil, fmt.Errorf("bundle at %s now provides extension %s instead of %s", entry.Source, bundle.ext.Name, name)
+	}
+	if entry.SignedBy != "" && bundle.signedBy == "" {
+		return nil, fmt.Errorf("extension %s was signed by %s but the update is not signed", name, entry.SignedBy)
+	}
+
+	ret = &InstallResult{Extension: bundle.ext, Source: entry.Source, SignedBy: bundle.signedBy}
+	ret.Changes = diffBundleFiles(entry.Files, bundle.files)
+	if ret.Diff, err = diffBundles(r.installDir(name), bundle.dir, ret.Changes); err != nil {
+		return
+	}
+
+	// Keep the installed version until the update is registered
+	previous := r.installDir(name) + ".previous"
+	previousConfig, _ := os.ReadFile(entry.ConfigPath)
+	os.RemoveAll(previous)
+	if err = os.Rename(r.installDir(name), previous); err != nil {
+		return
+	}
+	if err = r.installBundle(bundle, entry.Source); err != nil {
+		os.RemoveAll(r.installDir(name))
+		os.Rename(previous, r.installDir(name))
+		os.WriteFile(entry.ConfigPath, previousConfig, 0644)
+		r.registry.Extensions[name] = entry
+		return
+	}
+	os.RemoveAll(previous)
+	return
+}
+
+// stagedBundle is a fetched and verified bundle waiting to be installed.
+type stagedBundle struct {
+	dir      string
+	ext      *ExtensionDefinition
+	manifest []byte
+	files    map[string]string
+	signedBy string
+}
+
+func (r *ExtensionRegistry) stageBundle(source string, staging string) (ret *stagedBundle, err error) {
+	fetched := filepath.Join(staging, "bundle")
+	if err = fetchBundle(source, fetched); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", source, err)
+	}
+
+	ret = &stagedBundle{}
+	if ret.dir, err = locateManifest(fetched); err != nil {
+		return nil, err
+	}
+	if ret.manifest, err = os.ReadFile(filepath.Join(ret.dir, ExtensionManifestFile)); err != nil {
+		return nil, err
+	}
+	ret.ext = &ExtensionDefinition{}
+	if err = yaml.Unmarshal(ret.manifest, ret.ext); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ExtensionManifestFile, err)
+	}
+	if ret.ext.Name == "" || strings.ContainsAny(ret.ext.Name, " /\\") || ret.ext.Name == "." || ret.ext.Name == ".." {
+		return nil, fmt.Errorf("invalid extension name %q in %s", ret.ext.Name, ExtensionManifestFile)
+	}
+	if _, err = bundlePath(ret.dir, ret.ext.Executable); err != nil {
+		return nil, err
+	}
+
+	if ret.files, err = hashBundle(ret.dir); err != nil {
+		return nil, err
+	}
+	if ret.signedBy, err = verifyBundleSignature(ret.dir, ret.files, filepath.Join(r.configDir, "extensions", TrustedKeysFile)); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// installBundle copies a staged bundle into the managed directory, writes
+// the config with the absolute executable path and registers it.
+func (r *ExtensionRegistry) installBundle(bundle *stagedBundle, source string) (err error) {
+	dir := r.installDir(bundle.ext.Name)
+	if err = copyBundle(bundle.dir, dir); err != nil {
+		return fmt.Errorf("failed to install bundle: %w", err)
+	}
+
+	var manifest yaml.Node
+	if err = yaml.Unmarshal(bundle.manifest, &manifest); err != nil {
+		return
+	}
+	executable, _ := bundlePath(dir, bundle.ext.Executable)
+	if !setYAMLValue(&manifest, "executable", executable) {
+		return fmt.Errorf("%s has no executable", ExtensionManifestFile)
+	}
+	var config []byte
+	if config, err = yaml.Marshal(&manifest); err != nil {
+		return
+	}
+	configPath := filepath.Join(r.installRoot(), bundle.ext.Name+".yaml")
+	if err = os.WriteFile(configPath, config, 0644); err != nil {
+		return
+	}
+
+	if err = r.Register(configPath); err != nil {
+		os.RemoveAll(dir)
+		os.Remove(configPath)
+		return
+	}
+	entry := r.registry.Extensions[bundle.ext.Name]
+	entry.Source = source
+	entry.SignedBy = bundle.signedBy
+	entry.Files = bundle.files
+	return r.saveRegistry()
+}
+
+// fetchBundle places the bundle of source in dest.
+func fetchBundle(source string, dest string) error {
+	lower := strings.ToLower(source)
+	if i := strings.IndexAny(lower, "?#"); i >= 0 && isHTTPURL(lower) {
+		lower = lower[:i]
+	}
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"), strings.HasSuffix(lower, ".tar"):
+		return fetchTarball(source, dest, !strings.HasSuffix(lower, ".tar"))
+	}
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		return copyBundle(source, dest)
+	}
+
+	url, ref, _ := strings.Cut(source, "#")
+	options := &git.CloneOptions{URL: url, Depth: 1, SingleBranch: true}
+	if ref == "" {
+		_, err := git.PlainClone(dest, false, options)
+		return err
+	}
+	var err error
+	for _, name := range []plumbing.ReferenceName{plumbing.NewBranchReferenceName(ref), plumbing.NewTagReferenceName(ref)} {
+		os.RemoveAll(dest)
+		options.ReferenceName = name
+		if _, err = git.PlainClone(dest, false, options); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("could not clone %s at %s: %w", url, ref, err)
+}
+
+func isHTTPURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+func fetchTarball(source string, dest string, compressed bool) (err error) {
+	var reader io.Reader
+	if isHTTPURL(source) {
+		client := &http.Client{Timeout: 60 * time.Second}
+		var resp *http.Response
+		if resp, err = client.Get(source); err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		reader = resp.Body
+	} else {
+		var file *os.File
+		if file, err = os.Open(source); err != nil {
+			return
+		}
+		defer file.Close()
+		reader = file
+	}
+	reader = io.LimitReader(reader, MaxBundleSize+1)
+
+	if compressed {
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(reader); err != nil {
+			return
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	return extractTar(reader, dest)
+}
+
+// extractTar extracts regular files and directories. Links and paths
+// leaving dest are rejected.
+func extractTar(reader io.Reader, dest string) (err error) {
+	var total int64
+	archive := tar.NewReader(reader)
+	for {
+		var header *tar.Header
+		if header, err = archive.Next(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return
+		}
+		// pax_global_header and similar entries carry no files
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		var target string
+		if target, err = bundlePath(dest, header.Name); err != nil {
+			return
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return
+			}
+		case tar.TypeReg:
+			if total += header.Size; total > MaxBundleSize {
+				return fmt.Errorf("bundle exceeds %d bytes", MaxBundleSize)
+			}
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return
+			}
+			if err = writeFile(target, archive, os.FileMode(header.Mode)&0755|0644); err != nil {
+				return
+			}
+		default:
+			return fmt.Errorf("unsupported entry %s in bundle (only files and directories are allowed)", header.Name)
+		}
+	}
+}
+
+func writeFile(path string, reader io.Reader, mode os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, reader); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// bundlePath resolves a relative path inside dir.
+func bundlePath(dir string, name string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q must be relative to the bundle", name)
+	}
+	return filepath.Join(dir, cleaned), nil
+}
+
+// locateManifest returns the bundle root: dir itself, or its only
+// subdirectory as in tarballs with a top-level directory.
+func locateManifest(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, ExtensionManifestFile)); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err == nil && len(entries) == 1 && entries[0].IsDir() {
+		nested := filepath.Join(dir, entries[0].Name())
+		if _, err = os.Stat(filepath.Join(nested, ExtensionManifestFile)); err == nil {
+			return nested, nil
+		}
+	}
+	return "", fmt.Errorf("bundle has no %s at its root", ExtensionManifestFile)
+}
+
+// copyBundle copies the bundle files, leaving out .git.
+func copyBundle(src string, dest string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dest, rel), 0755)
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("unsupported file %s in bundle (only files and directories are allowed)", rel)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return writeFile(filepath.Join(dest, rel), file, info.Mode().Perm())
+	})
+}
+
+// hashBundle returns the SHA-256 of every bundle file by slash separated
+// relative path, without .git and the signature.
+func hashBundle(dir string) (ret map[string]string, err error) {
+	ret = map[string]string{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		if rel == ExtensionSignatureFile {
+			return nil
+		}
+		hash, hashErr := ComputeHash(path)
+		if hashErr != nil {
+			return hashErr
+		}
+		ret[rel] = hash
+		return nil
+	})
+	return
+}
+
+// BundleDigest is the signed text of a bundle.
+func BundleDigest(files map[string]string) []byte {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var b bytes.Buffer
+	for _, path := range paths {
+		fmt.Fprintf(&b, "%s  %s\n", files[path], path)
+	}
+	return b.Bytes()
+}
+
+// SignExtensionBundle writes the signature file of the bundle in dir.
+func SignExtensionBundle(dir string, key ed25519.PrivateKey) error {
+	files, err := hashBundle(dir)
+	if err != nil {
+		return err
+	}
+	signature := ed25519.Sign(key, BundleDigest(files))
+	return os.WriteFile(filepath.Join(dir, ExtensionSignatureFile), []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), 0644)
+}
+
+// verifyBundleSignature checks the signature of a bundle against the trusted
+// keys and returns the name of the signing key. Unsigned bundles return "".
+func verifyBundleSignature(dir string, files map[string]string, trustedKeysPath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ExtensionSignatureFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", ExtensionSignatureFile, err)
+	}
+
+	keys, err := loadTrustedKeys(trustedKeysPath)
+	if err != nil {
+		return "", err
+	}
+	digest := BundleDigest(files)
+	for name, key := range keys {
+		if ed25519.Verify(key, digest, signature) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("bundle signature does not match any key in %s", trustedKeysPath)
+}
+
+// loadTrustedKeys reads the trusted keys by name. Keys without a name are
+// named by their first characters.
+func loadTrustedKeys(path string) (ret map[string]ed25519.PublicKey, err error) {
+	ret = map[string]ed25519.PublicKey{}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return ret, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		encoded, name, _ := strings.Cut(line, " ")
+		key, decodeErr := base64.StdEncoding.DecodeString(encoded)
+		if decodeErr != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid key in %s: %s", path, encoded)
+		}
+		if name = strings.TrimSpace(name); name == "" {
+			name = encoded[:12]
+		}
+		ret[name] = key
+	}
+	return ret, scanner.Err()
+}
+
+// diffBundleFiles compares two sets of file hashes.
+func diffBundleFiles(before map[string]string, after map[string]string) (ret []BundleChange) {
+	for path, hash := range after {
+		if old, exists := before[path]; !exists {
+			ret = append(ret, BundleChange{Path: path, Status: "added"})
+		} else if old != hash {
+			ret = append(ret, BundleChange{Path: path, Status: "modified"})
+		}
+	}
+	for path := range before {
+		if _, exists := after[path]; !exists {
+			ret = append(ret, BundleChange{Path: path, Status: "removed"})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Path < ret[j].Path })
+	return
+}
+
+// diffBundles renders unified diffs of the changed text files.
+func diffBundles(oldDir string, newDir string, changes []BundleChange) (string, error) {
+	var b strings.Builder
+	for _, change := range changes {
+		before, _ := os.ReadFile(filepath.Join(oldDir, filepath.FromSlash(change.Path)))
+		after, _ := os.ReadFile(filepath.Join(newDir, filepath.FromSlash(change.Path)))
+		if bytes.IndexByte(before, 0) >= 0 || bytes.IndexByte(after, 0) >= 0 {
+			fmt.Fprintf(&b, "Binary file %s %s\n", change.Path, change.Status)
+			continue
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(before)),
+			B:        difflib.SplitLines(string(after)),
+			FromFile: "a/" + change.Path,
+			ToFile:   "b/" + change.Path,
+			Context:  3,
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(diff) > maxBundleDiffSize {
+			diff = diff[:maxBundleDiffSize] + "\n... diff truncated\n"
+		}
+		b.WriteString(diff)
+	}
+	return b.String(), nil
+}
+
+// setYAMLValue replaces the value of a top-level key in a YAML document.
+func setYAMLValue(doc *yaml.Node, key string, value string) bool {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return false
+	}
+	mapping := doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1].SetString(value)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+package template
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeBundle(t *testing.T, dir string, greeting string) {
+	t.Helper()
+	files := map[string]string{
+		ExtensionManifestFile: `name: greeter
+executable: bin/greet.sh
+type: executable
+timeout: 5s
+description: Greets
+version: "1.0"
+operations:
+  hello:
+    args: ["{{value}}"]
+`,
+		"bin/greet.sh": "#!/bin/bash\necho \"" + greeting + ", $1\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInstallAndUpdateExtension(t *testing.T) {
+	configDir := t.TempDir()
+	source := t.TempDir()
+	writeBundle(t, source, "Hello")
+
+	registry := NewExtensionRegistry(configDir)
+	result, err := registry.Install(source)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if result.Extension.Name != "greeter" || result.SignedBy != "" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if _, err = registry.Install(source); err == nil {
+		t.Error("Expected error installing the same extension twice")
+	}
+
+	executor := NewExtensionExecutor(registry)
+	output, err := executor.Execute("greeter", "hello", "Ada")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output != "Hello, Ada\n" {
+		t.Errorf("Unexpected output %q", output)
+	}
+
+	// A local modification is reported with the changed file
+	installed := filepath.Join(configDir, "extensions", "installed", "greeter", "bin", "greet.sh")
+	original, _ := os.ReadFile(installed)
+	if err = os.WriteFile(installed, []byte("#!/bin/bash\necho pwned\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_, err = registry.GetExtension("greeter")
+	if err == nil || !strings.Contains(err.Error(), "bin/greet.sh (modified)") {
+		t.Errorf("Expected error naming the modified file, got %v", err)
+	}
+	if err = os.WriteFile(installed, original, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// So is a changed or added helper file that is not the executable
+	helper := filepath.Join(configDir, "extensions", "installed", "greeter", "bin", "helper.sh")
+	if err = os.WriteFile(helper, []byte("#!/bin/bash\necho pwned\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_, err = registry.GetExtension("greeter")
+	if err == nil || !strings.Contains(err.Error(), "bin/helper.sh (added)") {
+		t.Errorf("Expected error naming the added file, got %v", err)
+	}
+	if err = os.Remove(helper); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = registry.GetExtension("greeter"); err != nil {
+		t.Errorf("Expected the restored bundle to verify, got %v", err)
+	}
+
+	// Update picks up the new version with a diff and re-pins the hashes
+	writeBundle(t, source, "Hi")
+	if err = os.WriteFile(filepath.Join(source, "NOTES.md"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = NewExtensionRegistry(configDir).Update("greeter")
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if len(result.Changes) != 2 || result.Changes[0] != (BundleChange{Path: "NOTES.md", Status: "added"}) ||
+		result.Changes[1] != (BundleChange{Path: "bin/greet.sh", Status: "modified"}) {
+		t.Errorf("Unexpected changes: %+v", result.Changes)
+	}
+	if !strings.Contains(result.Diff, "-echo \"Hello, $1\"") || !strings.Contains(result.Diff, "+echo \"Hi, $1\"") {
+		t.Errorf("Unexpected diff:\n%s", result.Diff)
+	}
+
+	registry = NewExtensionRegistry(configDir)
+	output, err = NewExtensionExecutor(registry).Execute("greeter", "hello", "Ada")
+	if err != nil {
+		t.Fatalf("Execute after update failed: %v", err)
+	}
+	if output != "Hi, Ada\n" {
+		t.Errorf("Unexpected output after update %q", output)
+	}
+
+	if err = registry.Remove("greeter"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(configDir, "extensions", "installed", "greeter")); !os.IsNotExist(err) {
+		t.Error("Expected installed files to be removed")
+	}
+}
+
+func TestInstallSignedExtension(t *testing.T) {
+	configDir := t.TempDir()
+	source := t.TempDir()
+	writeBundle(t, source, "Hello")
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = SignExtensionBundle(source, privateKey); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewExtensionRegistry(configDir)
+	if _, err = registry.Install(source); err == nil || !strings.Contains(err.Error(), "does not match any key") {
+		t.Fatalf("Expected untrusted signature error, got %v", err)
+	}
+
+	trustedKeys := filepath.Join(configDir, "extensions", TrustedKeysFile)
+	if err = os.WriteFile(trustedKeys, []byte("# team keys\n"+base64.StdEncoding.EncodeToString(publicKey)+" team-release\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file changed after signing invalidates the signature
+	if err = os.WriteFile(filepath.Join(source, "extra.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = registry.Install(source); err == nil {
+		t.Fatal("Expected signature error for a modified bundle")
+	}
+	os.Remove(filepath.Join(source, "extra.txt"))
+
+	result, err := registry.Install(source)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if result.SignedBy != "team-release" {
+		t.Errorf("Expected signer team-release, got %q", result.SignedBy)
+	}
+
+	// Updates of a signed extension must stay signed
+	os.Remove(filepath.Join(source, ExtensionSignatureFile))
+	if _, err = registry.Update("greeter"); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("Expected unsigned update error, got %v", err)
+	}
+	if _, err = registry.GetExtension("greeter"); err != nil {
+		t.Errorf("Expected installed version to remain usable, got %v", err)
+	}
+}
+
+func writeTarball(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+	for name, content := range files {
+		if err = archive.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = archive.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive.Close()
+	gz.Close()
+}
+
+func TestInstallExtensionFromTarball(t *testing.T) {
+	configDir := t.TempDir()
+	tarball := filepath.Join(t.TempDir(), "greeter.tar.gz")
+	writeTarball(t, tarball, map[string]string{
+		"greeter-1.0/" + ExtensionManifestFile: "name: greeter\nexecutable: greet.sh\ntype: executable\noperations:\n  hello:\n    args: [\"{{value}}\"]\n",
+		"greeter-1.0/greet.sh":                 "#!/bin/bash\necho \"Hello, $1\"\n",
+	})
+
+	registry := NewExtensionRegistry(configDir)
+	if _, err := registry.Install(tarball); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	output, err := NewExtensionExecutor(registry).Execute("greeter", "hello", "tar")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output != "Hello, tar\n" {
+		t.Errorf("Unexpected output %q", output)
+	}
+
+	evil := filepath.Join(t.TempDir(), "evil.tgz")
+	writeTarball(t, evil, map[string]string{"../escape.sh": "x"})
+	if _, err = registry.Install(evil); err == nil || !strings.Contains(err.Error(), "relative to the bundle") {
+		t.Errorf("Expected path traversal error, got %v", err)
+	}
+}
+
+func TestInstallExtensionFromGit(t *testing.T) {
+	dir, repo := newTestRepo(t)
+	writeBundle(t, dir, "Hello")
+	worktree, _ := repo.Worktree()
+	if _, err := worktree.Add("."); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, dir, repo, "README.md", "# greeter\n", "Add greeter")
+
+	registry := NewExtensionRegistry(t.TempDir())
+	result, err := registry.Install("file://" + dir)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if _, exists := registry.registry.Extensions["greeter"].Files[".git/HEAD"]; exists {
+		t.Error("Expected .git to be left out of the bundle")
+	}
+	if result.Extension.Name != "greeter" {
+		t.Errorf("Unexpected extension %q", result.Extension.Name)
+	}
+}
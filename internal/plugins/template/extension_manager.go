@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -31,6 +31,7 @@ func (em *ExtensionManager) ListExtensions() error {
 	if em.registry == nil || em.registry.registry.Extensions == nil {
 		return fmt.Errorf("extension registry not initialized")
 	}
+	defer em.executor.Shutdown()
 
 	for name, entry := range em.registry.registry.Extensions {
 		fmt.Printf("Extension: %s\n", name)
@@ -51,10 +52,10 @@ func (em *ExtensionManager) ListExtensions() error {
 		fmt.Printf("  Description: %s\n", ext.Description)
 		fmt.Printf("  Version: %s\n", ext.Version)
 
-		fmt.Printf("  Operations:\n")
-		for opName, opConfig := range ext.Operations {
-			fmt.Printf("    %s:\n", opName)
-			fmt.Printf("      Command Template: %s\n", opConfig.CmdTemplate)
+		if ext.Type == ExtensionTypeRPC {
+			em.printRPCOperations(ext)
+		} else {
+			printOperations(ext)
 		}
 
 		if fileConfig := ext.GetFileConfig(); fileConfig != nil {
@@ -91,10 +92,6 @@ func (em *ExtensionManager) RegisterExtension(configPath string) error {
 		return fmt.Errorf("failed to register extension: %w", err)
 	}
 
-	if _, err := time.ParseDuration(ext.Timeout); err != nil {
-		return fmt.Errorf("invalid timeout value '%s': must be a duration like '30s' or '1m': %w", ext.Timeout, err)
-	}
-
 	// Print success message with extension details
 	fmt.Printf("Successfully registered extension:\n")
 	fmt.Printf("Name: %s\n", ext.Name)
@@ -104,11 +101,7 @@ func (em *ExtensionManager) RegisterExtension(configPath string) error {
 	fmt.Printf("  Description: %s\n", ext.Description)
 	fmt.Printf("  Version: %s\n", ext.Version)
 
-	fmt.Printf("  Operations:\n")
-	for opName, opConfig := range ext.Operations {
-		fmt.Printf("    %s:\n", opName)
-		fmt.Printf("      Command Template: %s\n", opConfig.CmdTemplate)
-	}
+	printOperations(&ext)
 
 	if fileConfig := ext.GetFileConfig(); fileConfig != nil {
 		fmt.Printf("  File Configuration:\n")
@@ -120,6 +113,92 @@ func (em *ExtensionManager) RegisterExtension(configPath string) error {
 	return nil
 }
 
+// printOperations prints the operations with their arguments and whether
+// they run through a shell.
+func printOperations(ext *ExtensionDefinition) {
+	if ext.Type == ExtensionTypeRPC {
+		fmt.Printf("  Operations: announced by the extension (see --listextensions)\n")
+		return
+	}
+	if ext.Shell {
+		fmt.Printf("  Shell: enabled (cmd_template runs through sh -c)\n")
+	}
+	fmt.Printf("  Operations:\n")
+	for opName, opConfig := range ext.Operations {
+		fmt.Printf("    %s:\n", opName)
+		if len(opConfig.Args) > 0 {
+			fmt.Printf("      Args: %s\n", strings.Join(opConfig.Args, " "))
+		} else {
+			fmt.Printf("      Command Template: %s\n", opConfig.CmdTemplate)
+		}
+	}
+}
+
+// printRPCOperations prints the operations an rpc extension announces in
+// its handshake.
+func (em *ExtensionManager) printRPCOperations(ext *ExtensionDefinition) {
+	operations, err := em.executor.Operations(ext)
+	if err != nil {
+		fmt.Printf("  Operations: unavailable - %v\n", err)
+		return
+	}
+	fmt.Printf("  Operations (discovered):\n")
+	for _, op := range operations {
+		fmt.Printf("    %s:\n", op.Name)
+		if op.Description != "" {
+			fmt.Printf("      Description: %s\n", op.Description)
+		}
+		if len(op.Schema) > 0 {
+			fmt.Printf("      Schema: %s\n", op.Schema)
+		}
+	}
+}
+
+// InstallExtension handles the installextension flag action
+func (em *ExtensionManager) InstallExtension(source string) error {
+	result, err := em.registry.Install(source)
+	if err != nil {
+		return fmt.Errorf("failed to install extension: %w", err)
+	}
+
+	fmt.Printf("Successfully installed extension %s from %s\n", result.Extension.Name, result.Source)
+	printSignature(result)
+	fmt.Printf("  Location: %s\n", em.registry.installDir(result.Extension.Name))
+	fmt.Printf("  Description: %s\n", result.Extension.Description)
+	fmt.Printf("  Version: %s\n", result.Extension.Version)
+	return nil
+}
+
+// UpdateExtension handles the updateextension flag action
+func (em *ExtensionManager) UpdateExtension(name string) error {
+	result, err := em.registry.Update(name)
+	if err != nil {
+		return fmt.Errorf("failed to update extension: %w", err)
+	}
+
+	if len(result.Changes) == 0 {
+		fmt.Printf("Extension %s is up to date, hashes re-pinned\n", name)
+		return nil
+	}
+	fmt.Printf("Updated extension %s from %s (%d files changed, hashes re-pinned)\n", name, result.Source, len(result.Changes))
+	printSignature(result)
+	for _, change := range result.Changes {
+		fmt.Printf("  %-8s %s\n", change.Status, change.Path)
+	}
+	if result.Diff != "" {
+		fmt.Printf("\n%s", result.Diff)
+	}
+	return nil
+}
+
+func printSignature(result *InstallResult) {
+	if result.SignedBy != "" {
+		fmt.Printf("  Signature: verified (%s)\n", result.SignedBy)
+	} else {
+		fmt.Printf("  Signature: none (unsigned bundle)\n")
+	}
+}
+
 // RemoveExtension handles the rmextension flag action
 func (em *ExtensionManager) RemoveExtension(name string) error {
 	if err := em.registry.Remove(name); err != nil {
@@ -133,3 +212,8 @@ func (em *ExtensionManager) RemoveExtension(name string) error {
 func (em *ExtensionManager) ProcessExtension(name, operation, value string) (string, error) {
 	return em.executor.Execute(name, operation, value)
 }
+
+// Shutdown stops the rpc extensions started by this manager.
+func (em *ExtensionManager) Shutdown() {
+	em.executor.Shutdown()
+}
@@ -26,6 +26,20 @@ type ExtensionDefinition struct {
 	Version     string   `yaml:"version"`
 	Env         []string `yaml:"env"`
 
+	// EnvAllow names environment variables passed through from fabric's
+	// environment in addition to DefaultExtensionEnv.
+	EnvAllow []string `yaml:"env_allow"`
+	// WorkDir is the working directory of the command, a temporary
+	// directory removed after the run when empty.
+	WorkDir string `yaml:"work_dir"`
+	// MaxOutput caps the output in bytes, DefaultExtensionMaxOutput when zero.
+	MaxOutput int `yaml:"max_output"`
+	// Shell runs cmd_template through "sh -c". Without it, cmd_template is
+	// split into arguments before substitution and no shell is involved.
+	Shell bool `yaml:"shell"`
+	// Args are the startup arguments of an rpc extension.
+	Args []string `yaml:"args"`
+
 	// Operation-specific commands
 	Operations map[string]OperationConfig `yaml:"operations"`
 
@@ -34,6 +48,9 @@ type ExtensionDefinition struct {
 }
 
 type OperationConfig struct {
+	// Args are the arguments passed to the executable, each one a template.
+	Args []string `yaml:"args"`
+	// CmdTemplate is a command line starting with {{executable}}.
 	CmdTemplate string `yaml:"cmd_template"`
 }
 
@@ -42,6 +59,12 @@ type RegistryEntry struct {
 	ConfigPath     string `yaml:"config_path"`
 	ConfigHash     string `yaml:"config_hash"`
 	ExecutableHash string `yaml:"executable_hash"`
+
+	// Installed bundles only: where the bundle came from, the key that
+	// signed it and the hash of every bundle file.
+	Source   string            `yaml:"source,omitempty"`
+	SignedBy string            `yaml:"signed_by,omitempty"`
+	Files    map[string]string `yaml:"files,omitempty"`
 }
 
 type ExtensionRegistry struct {
@@ -79,6 +102,71 @@ func (e *ExtensionDefinition) IsCleanupEnabled() bool {
 	return false // default to no cleanup
 }
 
+// GetTimeout returns the run time limit, DefaultExtensionTimeout when unset.
+func (e *ExtensionDefinition) GetTimeout() (time.Duration, error) {
+	if e.Timeout == "" {
+		return DefaultExtensionTimeout, nil
+	}
+	timeout, err := time.ParseDuration(e.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout format: %w", err)
+	}
+	return timeout, nil
+}
+
+// GetMaxOutput returns the output limit in bytes.
+func (e *ExtensionDefinition) GetMaxOutput() int {
+	if e.MaxOutput > 0 {
+		return e.MaxOutput
+	}
+	return DefaultExtensionMaxOutput
+}
+
+// GetWorkDir returns the configured working directory, from work_dir or the
+// work_dir of the file output configuration.
+func (e *ExtensionDefinition) GetWorkDir() string {
+	workDir := e.WorkDir
+	if workDir == "" {
+		if fc := e.GetFileConfig(); fc != nil {
+			workDir, _ = fc["work_dir"].(string)
+		}
+	}
+	if strings.HasPrefix(workDir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			workDir = filepath.Join(home, workDir[2:])
+		}
+	}
+	return workDir
+}
+
+// prepareWorkDir returns the configured working directory after checking it
+// exists, or a new temporary directory the caller removes.
+func (e *ExtensionDefinition) prepareWorkDir() (string, error) {
+	workDir := e.GetWorkDir()
+	if workDir == "" {
+		return os.MkdirTemp("", "fabric-ext-"+e.Name+"-*")
+	}
+	info, err := os.Stat(workDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid work_dir: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("invalid work_dir: %s is not a directory", workDir)
+	}
+	return workDir, nil
+}
+
+// buildEnv returns the environment of the command: the allowed variables of
+// fabric's environment followed by the env entries of the extension.
+func (e *ExtensionDefinition) buildEnv() (ret []string) {
+	for _, name := range append(append([]string{}, DefaultExtensionEnv...), e.EnvAllow...) {
+		if value, ok := os.LookupEnv(name); ok {
+			ret = append(ret, name+"="+value)
+		}
+	}
+	return append(ret, e.Env...)
+}
+
 func NewExtensionRegistry(configDir string) *ExtensionRegistry {
 	r := &ExtensionRegistry{
 		configDir: configDir,
@@ -122,6 +210,10 @@ func (r *ExtensionRegistry) Register(configPath string) error {
 		return fmt.Errorf("extension name '%s' contains spaces - names must not contain spaces", ext.Name)
 	}
 
+	if err := r.validateExtensionDefinition(&ext); err != nil {
+		return err
+	}
+
 	// Verify executable exists
 	if _, err := os.Stat(ext.Executable); err != nil {
 		return fmt.Errorf("executable not found: %w", err)
@@ -161,6 +253,9 @@ func (r *ExtensionRegistry) validateExtensionDefinition(ext *ExtensionDefinition
 	if ext.Type == "" {
 		return fmt.Errorf("extension type is required")
 	}
+	if ext.Type != ExtensionTypeExecutable && ext.Type != ExtensionTypeRPC {
+		return fmt.Errorf("unknown extension type %q (supported: %s, %s)", ext.Type, ExtensionTypeExecutable, ExtensionTypeRPC)
+	}
 
 	// Validate timeout format
 	if ext.Timeout != "" {
@@ -169,67 +264,62 @@ func (r *ExtensionRegistry) validateExtensionDefinition(ext *ExtensionDefinition
 		}
 	}
 
+	if ext.MaxOutput < 0 {
+		return fmt.Errorf("max_output cannot be negative")
+	}
+
+	// RPC extensions announce their operations in the handshake
+	if ext.Type == ExtensionTypeRPC {
+		if len(ext.Operations) > 0 || ext.Shell {
+			return fmt.Errorf("rpc extensions discover their operations and do not use operations or shell")
+		}
+		return nil
+	}
+
 	// Validate operations
 	if len(ext.Operations) == 0 {
 		return fmt.Errorf("at least one operation must be defined")
 	}
 	for name, op := range ext.Operations {
-		if op.CmdTemplate == "" {
-			return fmt.Errorf("command template is required for operation %s", name)
+		if (op.CmdTemplate == "") == (len(op.Args) == 0) {
+			return fmt.Errorf("operation %s must define either args or cmd_template", name)
+		}
+		if op.CmdTemplate != "" && !ext.Shell {
+			if _, err := splitCommandTemplate(ext, op.CmdTemplate); err != nil {
+				return fmt.Errorf("operation %s: %w", name, err)
+			}
 		}
 	}
-
 	return nil
 }
 
 func (r *ExtensionRegistry) Remove(name string) error {
-	if _, exists := r.registry.Extensions[name]; !exists {
-		return fmt.Errorf("extension %s not found", name)
-	}
-
-	delete(r.registry.Extensions, name)
-
-	return r.saveRegistry()
-}
-
-func (r *ExtensionRegistry) Verify(name string) error {
-	// Get the registry entry
 	entry, exists := r.registry.Extensions[name]
 	if !exists {
 		return fmt.Errorf("extension %s not found", name)
 	}
 
-	// Load and parse the config file
-	data, err := os.ReadFile(entry.ConfigPath)
-	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
-	}
-
-	// Verify config hash
-	currentConfigHash := ComputeStringHash(string(data))
-	if currentConfigHash != entry.ConfigHash {
-		return fmt.Errorf("config file hash mismatch for %s", name)
-	}
-
-	// Parse to get executable path
-	var ext ExtensionDefinition
-	if err := yaml.Unmarshal(data, &ext); err != nil {
-		return fmt.Errorf("failed to parse config file: %w", err)
+	// Installed bundles are managed by fabric and removed with the extension
+	if entry.Source != "" {
+		if err := os.RemoveAll(r.installDir(name)); err != nil {
+			return fmt.Errorf("failed to remove installed files: %w", err)
+		}
+		os.Remove(entry.ConfigPath)
 	}
 
-	// Verify executable hash
-	currentExecutableHash, err := ComputeHash(ext.Executable)
-	if err != nil {
-		return fmt.Errorf("failed to verify executable: %w", err)
-	}
+	delete(r.registry.Extensions, name)
 
-	if currentExecutableHash != entry.ExecutableHash {
-		return fmt.Errorf("executable hash mismatch for %s", name)
-	}
+	return r.saveRegistry()
+}
 
-	return nil
+func (r *ExtensionRegistry) Verify(name string) error {
+	_, err := r.GetExtension(name)
+	return err
 }
 
+// GetExtension loads an extension after checking that its config, its
+// executable and the files of an installed bundle still match the hashes
+// pinned at registration.
 func (r *ExtensionRegistry) GetExtension(name string) (*ExtensionDefinition, error) {
 	entry, exists := r.registry.Extensions[name]
 	if !exists {
@@ -243,9 +333,9 @@ func (r *ExtensionRegistry) GetExtension(name string) (*ExtensionDefinition, err
 	}
 
 	// Verify config hash
-	currentHash := ComputeStringHash(string(data))
-	if currentHash != entry.ConfigHash {
-		return nil, fmt.Errorf("config file hash mismatch for %s", name)
+	if currentHash := ComputeStringHash(string(data)); currentHash != entry.ConfigHash {
+		return nil, fmt.Errorf("config file hash mismatch for %s: %s changed since registration (pinned %s, now %s)%s",
+			name, entry.ConfigPath, shortHash(entry.ConfigHash), shortHash(currentHash), r.describeBundleChanges(name, entry))
 	}
 
 	// Parse config
@@ -261,12 +351,58 @@ func (r *ExtensionRegistry) GetExtension(name string) (*ExtensionDefinition, err
 	}
 
 	if currentExecHash != entry.ExecutableHash {
-		return nil, fmt.Errorf("executable hash mismatch for %s", name)
+		return nil, fmt.Errorf("executable hash mismatch for %s: %s changed since registration (pinned %s, now %s)%s",
+			name, ext.Executable, shortHash(entry.ExecutableHash), shortHash(currentExecHash), r.describeBundleChanges(name, entry))
+	}
+
+	// Verify every file of an installed bundle, helper scripts and modules
+	// run as part of the executable
+	if entry.Files != nil {
+		changed, err := r.bundleChanges(name, entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify the installed files of %s: %w", name, err)
+		}
+		if len(changed) > 0 {
+			return nil, fmt.Errorf("installed files of %s changed since installation: %s", name, strings.Join(changed, ", "))
+		}
 	}
 
 	return &ext, nil
 }
 
+// bundleChanges lists the files of an installed bundle that differ from the
+// pinned hashes, e.g. "run.sh (modified)".
+func (r *ExtensionRegistry) bundleChanges(name string, entry *RegistryEntry) (ret []string, err error) {
+	var current map[string]string
+	if current, err = hashBundle(r.installDir(name)); err != nil {
+		return
+	}
+	for _, change := range diffBundleFiles(entry.Files, current) {
+		ret = append(ret, change.Path+" ("+change.Status+")")
+	}
+	return
+}
+
+// describeBundleChanges lists the changed files of an installed bundle for
+// an error message, e.g. "; changed files: run.sh (modified)".
+func (r *ExtensionRegistry) describeBundleChanges(name string, entry *RegistryEntry) string {
+	if entry.Files == nil {
+		return ""
+	}
+	changed, err := r.bundleChanges(name, entry)
+	if err != nil || len(changed) == 0 {
+		return ""
+	}
+	return "; changed files: " + strings.Join(changed, ", ")
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
 func (r *ExtensionRegistry) ListExtensions() ([]*ExtensionDefinition, error) {
 	var exts []*ExtensionDefinition
 
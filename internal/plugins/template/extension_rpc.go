@@ -0,0 +1,305 @@
+package template
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Extension types. Executable extensions start a process per call, RPC
+// extensions start once per run and answer line-delimited JSON-RPC 2.0
+// requests on stdin/stdout:
+//
+//	{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocol_version":"1","client":"fabric"}}
+//	{"jsonrpc":"2.0","id":2,"method":"list_operations"}
+//	{"jsonrpc":"2.0","id":3,"method":"invoke","params":{"operation":"lookup","value":"a|b","args":["a","b"]}}
+//	{"jsonrpc":"2.0","id":4,"method":"shutdown"}
+//
+// list_operations answers {"operations":[{"name":..,"description":..,"schema":{..}}]}
+// and invoke answers {"output":".."}. Failures use the JSON-RPC error object.
+const (
+	ExtensionTypeExecutable = "executable"
+	ExtensionTypeRPC        = "rpc"
+)
+
+// RPCProtocolVersion is sent with initialize.
+const RPCProtocolVersion = "1"
+
+// rpcShutdownGrace is how long an RPC extension may take to exit after shutdown.
+const rpcShutdownGrace = 2 * time.Second
+
+// RPCOperation is an operation announced by an RPC extension.
+type RPCOperation struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// rpcSession is a running RPC extension.
+type rpcSession struct {
+	ext        *ExtensionDefinition
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	lines      chan []byte
+	done       chan struct{}
+	stderr     *limitedBuffer
+	workDir    string
+	operations []RPCOperation
+
+	mu     sync.Mutex
+	nextID int
+	closed bool
+}
+
+// startRPCSession starts the extension and performs the handshake.
+func startRPCSession(ext *ExtensionDefinition) (session *rpcSession, err error) {
+	var workDir string
+	if workDir, err = ext.prepareWorkDir(); err != nil {
+		return
+	}
+
+	cmd := exec.Command(ext.Executable, ext.Args...)
+	cmd.Dir = workDir
+	cmd.Env = ext.buildEnv()
+	session = &rpcSession{
+		ext:     ext,
+		cmd:     cmd,
+		lines:   make(chan []byte),
+		done:    make(chan struct{}),
+		stderr:  &limitedBuffer{limit: ext.GetMaxOutput()},
+		workDir: workDir,
+	}
+	cmd.Stderr = session.stderr
+
+	var stdout io.ReadCloser
+	if session.stdin, err = cmd.StdinPipe(); err == nil {
+		stdout, err = cmd.StdoutPipe()
+	}
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		session.removeWorkDir()
+		return nil, fmt.Errorf("failed to start extension %s: %w", ext.Name, err)
+	}
+	debugf("Started RPC extension %s (pid %d)\n", ext.Name, cmd.Process.Pid)
+
+	go session.readLines(stdout)
+
+	if _, err = session.call("initialize", map[string]string{
+		"protocol_version": RPCProtocolVersion,
+		"client":           "fabric",
+	}); err == nil {
+		var result json.RawMessage
+		if result, err = session.call("list_operations", nil); err == nil {
+			var listed struct {
+				Operations []RPCOperation `json:"operations"`
+			}
+			if err = json.Unmarshal(result, &listed); err != nil {
+				err = fmt.Errorf("invalid list_operations result: %w", err)
+			}
+			session.operations = listed.Operations
+		}
+	}
+	if err != nil {
+		session.kill()
+		return nil, fmt.Errorf("extension %s handshake failed: %w", ext.Name, err)
+	}
+	sort.Slice(session.operations, func(i, j int) bool { return session.operations[i].Name < session.operations[j].Name })
+	return session, nil
+}
+
+// readLines forwards stdout lines until the process closes it.
+func (s *rpcSession) readLines(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			select {
+			case s.lines <- line:
+			case <-s.done:
+				return
+			}
+		}
+		if err != nil {
+			close(s.lines)
+			return
+		}
+	}
+}
+
+// Invoke runs an operation and returns its output.
+func (s *rpcSession) Invoke(operation string, value string) (string, error) {
+	if !s.hasOperation(operation) {
+		return "", fmt.Errorf("operation %s not found for extension %s", operation, s.ext.Name)
+	}
+	result, err := s.call("invoke", map[string]any{
+		"operation": operation,
+		"value":     value,
+		"args":      strings.Split(value, "|"),
+	})
+	if err != nil {
+		return "", err
+	}
+	var invoked struct {
+		Output string `json:"output"`
+	}
+	if err = json.Unmarshal(result, &invoked); err != nil {
+		return "", fmt.Errorf("invalid invoke result: %w", err)
+	}
+	if len(invoked.Output) > s.ext.GetMaxOutput() {
+		return "", fmt.Errorf("output of %s exceeds limit of %d bytes", s.ext.Name, s.ext.GetMaxOutput())
+	}
+	return invoked.Output, nil
+}
+
+func (s *rpcSession) hasOperation(name string) bool {
+	for _, op := range s.operations {
+		if op.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// call sends one request and waits for its response within the extension
+// timeout. A timed out or broken extension is killed.
+func (s *rpcSession) call(method string, params any) (json.RawMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, fmt.Errorf("extension %s is not running", s.ext.Name)
+	}
+
+	s.nextID++
+	data, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: s.nextID, Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	debugf("RPC %s -> %s\n", s.ext.Name, data)
+	if _, err = s.stdin.Write(append(data, '\n')); err != nil {
+		s.killLocked()
+		return nil, fmt.Errorf("failed to write to extension %s: %w\nstderr: %s", s.ext.Name, err, s.stderr.String())
+	}
+
+	timeout, err := s.ext.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.killLocked()
+				return nil, fmt.Errorf("extension %s exited\nstderr: %s", s.ext.Name, s.stderr.String())
+			}
+			if len(line) > s.ext.GetMaxOutput()+4096 {
+				s.killLocked()
+				return nil, fmt.Errorf("response of %s exceeds limit of %d bytes", s.ext.Name, s.ext.GetMaxOutput())
+			}
+			var response rpcResponse
+			if err = json.Unmarshal(line, &response); err != nil {
+				s.killLocked()
+				return nil, fmt.Errorf("invalid response from extension %s: %w", s.ext.Name, err)
+			}
+			if response.ID != s.nextID {
+				// Late answer to an earlier request or a notification
+				continue
+			}
+			if response.Error != nil {
+				return nil, fmt.Errorf("%s (code %d)", response.Error.Message, response.Error.Code)
+			}
+			return response.Result, nil
+		case <-timer.C:
+			s.killLocked()
+			return nil, fmt.Errorf("%s timed out after %v", method, timeout)
+		}
+	}
+}
+
+// Shutdown asks the extension to exit and kills it when it does not.
+func (s *rpcSession) Shutdown() {
+	if _, err := s.call("shutdown", nil); err != nil {
+		debugf("RPC extension %s shutdown: %v\n", s.ext.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+	s.stdin.Close()
+
+	done := make(chan struct{})
+	go func() {
+		s.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(rpcShutdownGrace):
+		s.cmd.Process.Kill()
+		<-done
+	}
+	s.removeWorkDir()
+}
+
+func (s *rpcSession) kill() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.killLocked()
+}
+
+func (s *rpcSession) killLocked() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+	s.stdin.Close()
+	s.cmd.Process.Kill()
+	go func() {
+		s.cmd.Wait()
+		s.removeWorkDir()
+	}()
+}
+
+func (s *rpcSession) removeWorkDir() {
+	if s.ext.GetWorkDir() == "" {
+		os.RemoveAll(s.workDir)
+	}
+}
+
+func (s *rpcSession) isRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.closed
+}
@@ -0,0 +1,125 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRPCExtension(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A counter keeping state between calls proves the process is reused
+	testScript := filepath.Join(tmpDir, "rpc-script.sh")
+	scriptContent := `#!/bin/bash
+count=0
+while IFS= read -r line; do
+    id=$(echo "$line" | sed -n 's/.*"id":\([0-9]*\).*/\1/p')
+    case "$line" in
+        *'"method":"initialize"'*)
+            echo '{"jsonrpc":"2.0","id":'$id',"result":{"name":"counter"}}'
+            ;;
+        *'"method":"list_operations"'*)
+            echo '{"jsonrpc":"2.0","id":'$id',"result":{"operations":[{"name":"count","description":"Counts calls","schema":{"type":"string"}},{"name":"fail"},{"name":"hang"}]}}'
+            ;;
+        *'"operation":"count"'*)
+            count=$((count+1))
+            echo '{"jsonrpc":"2.0","id":'$id',"result":{"output":"call '$count'"}}'
+            ;;
+        *'"operation":"fail"'*)
+            echo '{"jsonrpc":"2.0","id":'$id',"error":{"code":-32000,"message":"failed on purpose"}}'
+            ;;
+        *'"operation":"hang"'*)
+            sleep 2
+            ;;
+        *'"method":"shutdown"'*)
+            echo '{"jsonrpc":"2.0","id":'$id',"result":{}}'
+            exit 0
+            ;;
+    esac
+done`
+	if err := os.WriteFile(testScript, []byte(scriptContent), 0755); err != nil {
+		t.Fatalf("Failed to create test script: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "rpc-extension.yaml")
+	configContent := `name: rpc-test
+executable: ` + testScript + `
+type: rpc
+timeout: 500ms`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	registry := NewExtensionRegistry(tmpDir)
+	if err := registry.Register(configPath); err != nil {
+		t.Fatalf("Failed to register extension: %v", err)
+	}
+	executor := NewExtensionExecutor(registry)
+	defer executor.Shutdown()
+
+	ext, err := registry.GetExtension("rpc-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	operations, err := executor.Operations(ext)
+	if err != nil {
+		t.Fatalf("Failed to list operations: %v", err)
+	}
+	if len(operations) != 3 || operations[0].Name != "count" || operations[0].Description != "Counts calls" ||
+		string(operations[0].Schema) != `{"type":"string"}` {
+		t.Errorf("Unexpected operations: %+v", operations)
+	}
+
+	for _, expected := range []string{"call 1", "call 2"} {
+		output, err := executor.Execute("rpc-test", "count", "x|y")
+		if err != nil {
+			t.Fatalf("Failed to execute: %v", err)
+		}
+		if output != expected {
+			t.Errorf("Expected output %q, got %q", expected, output)
+		}
+	}
+
+	if _, err := executor.Execute("rpc-test", "missing", ""); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected unknown operation error, got %v", err)
+	}
+	if _, err := executor.Execute("rpc-test", "fail", ""); err == nil || !strings.Contains(err.Error(), "failed on purpose") {
+		t.Errorf("Expected extension error, got %v", err)
+	}
+	if _, err := executor.Execute("rpc-test", "hang", ""); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+
+	// A killed extension is started again on the next call
+	output, err := executor.Execute("rpc-test", "count", "")
+	if err != nil {
+		t.Fatalf("Failed to execute after restart: %v", err)
+	}
+	if output != "call 1" {
+		t.Errorf("Expected a fresh process, got %q", output)
+	}
+
+	executor.Shutdown()
+	if len(executor.sessions) != 0 {
+		t.Error("Expected no running sessions after shutdown")
+	}
+}
+
+func TestRPCExtensionValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "invalid.yaml")
+	configContent := `name: rpc-invalid
+executable: /bin/cat
+type: rpc
+operations:
+  run:
+    args: ["x"]`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewExtensionRegistry(tmpDir).Register(configPath); err == nil {
+		t.Error("Expected error for rpc extension with operations")
+	}
+}
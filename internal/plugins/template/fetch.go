@@ -1,16 +1,30 @@
 // Package template provides URL fetching operations for the template system.
-// Security Note: This plugin makes outbound HTTP requests. Use with caution
-// and consider implementing URL allowlists in production.
+// Security Note: This plugin makes outbound HTTP requests. Private, loopback
+// and link-local addresses are blocked unless FABRIC_FETCH_ALLOW_PRIVATE is
+// set, and FABRIC_FETCH_ALLOWED_DOMAINS restricts the reachable hosts.
 package template
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 	"unicode/utf8"
+
+	"github.com/danielmiessler/fabric/internal/tools/converter"
 )
 
 const (
@@ -19,26 +33,218 @@ const (
 
 	// UserAgent identifies the client in HTTP requests
 	UserAgent = "Fabric-Fetch/1.0"
+
+	// FetchTimeout bounds a request including redirects
+	FetchTimeout = 30 * time.Second
+
+	// maxFetchRedirects bounds the redirects followed by a request
+	maxFetchRedirects = 10
 )
 
+// Environment variables configuring the fetch plugin, usually set in the .env
+const (
+	FetchAllowedDomainsEnv = "FABRIC_FETCH_ALLOWED_DOMAINS"
+	FetchDeniedDomainsEnv  = "FABRIC_FETCH_DENIED_DOMAINS"
+	FetchAllowPrivateEnv   = "FABRIC_FETCH_ALLOW_PRIVATE"
+	FetchCacheDirEnv       = "FABRIC_FETCH_CACHE_DIR"
+	// FetchHeaderEnvPrefix starts variables holding "host|Name: value"
+	// headers, sent only to that host and its subdomains.
+	FetchHeaderEnvPrefix = "FABRIC_FETCH_HEADER_"
+)
+
+// FetchSettings configure which URLs the plugin may fetch, the cache and
+// extra request headers.
+type FetchSettings struct {
+	// AllowedDomains, when not empty, are the only reachable domains.
+	// Subdomains of an entry match as well.
+	AllowedDomains []string
+	DeniedDomains  []string
+	// AllowPrivate permits loopback, private, link-local and other
+	// non-public addresses.
+	AllowPrivate bool
+	// CacheDir stores responses, caching is disabled when empty.
+	CacheDir string
+	Headers  []FetchHeader
+}
+
+// FetchHeader is a request header sent to Host and its subdomains.
+type FetchHeader struct {
+	Host  string
+	Name  string
+	Value string
+}
+
+// FetchSettingsFromEnv reads the FABRIC_FETCH_* variables. The cache
+// defaults to the user cache directory and is disabled with "none".
+func FetchSettingsFromEnv() *FetchSettings {
+	ret := &FetchSettings{
+		AllowedDomains: splitDomains(os.Getenv(FetchAllowedDomainsEnv)),
+		DeniedDomains:  splitDomains(os.Getenv(FetchDeniedDomainsEnv)),
+	}
+	ret.AllowPrivate, _ = strconv.ParseBool(os.Getenv(FetchAllowPrivateEnv))
+
+	switch cacheDir := os.Getenv(FetchCacheDirEnv); cacheDir {
+	case "none":
+	case "":
+		if userCache, err := os.UserCacheDir(); err == nil {
+			ret.CacheDir = filepath.Join(userCache, "fabric", "fetch")
+		}
+	default:
+		ret.CacheDir = cacheDir
+	}
+
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(key, FetchHeaderEnvPrefix) {
+			continue
+		}
+		host, header, found := strings.Cut(value, "|")
+		name, headerValue, hasValue := strings.Cut(header, ":")
+		if !found || !hasValue || strings.TrimSpace(host) == "" || strings.TrimSpace(name) == "" {
+			debugf("Fetch: ignoring %s, expected \"host|Name: value\"", key)
+			continue
+		}
+		ret.Headers = append(ret.Headers, FetchHeader{
+			Host:  normalizeDomain(host),
+			Name:  strings.TrimSpace(name),
+			Value: strings.TrimSpace(headerValue),
+		})
+	}
+	sort.Slice(ret.Headers, func(i, j int) bool { return ret.Headers[i].Name < ret.Headers[j].Name })
+	return ret
+}
+
+func splitDomains(value string) (ret []string) {
+	for _, domain := range strings.Split(value, ",") {
+		if domain = normalizeDomain(domain); domain != "" {
+			ret = append(ret, domain)
+		}
+	}
+	return
+}
+
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	return strings.TrimPrefix(strings.TrimPrefix(domain, "*"), ".")
+}
+
+// matchesDomain reports whether host is domain or one of its subdomains.
+func matchesDomain(host string, domain string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// checkURL applies the scheme and domain rules to a URL.
+func (s *FetchSettings) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("fetch: unsupported protocol scheme %q", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("fetch: URL %q has no host", u.String())
+	}
+	for _, domain := range s.DeniedDomains {
+		if matchesDomain(host, domain) {
+			return fmt.Errorf("fetch: domain %s is denied", host)
+		}
+	}
+	if len(s.AllowedDomains) > 0 {
+		allowed := false
+		for _, domain := range s.AllowedDomains {
+			allowed = allowed || matchesDomain(host, domain)
+		}
+		if !allowed {
+			return fmt.Errorf("fetch: domain %s is not in the allowed domains", host)
+		}
+	}
+	if !s.AllowPrivate {
+		if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+			return fmt.Errorf("fetch: %s is a private or local address", host)
+		}
+		if ip := net.ParseIP(host); ip != nil && isPrivateIP(ip) {
+			return fmt.Errorf("fetch: %s is a private or local address", host)
+		}
+	}
+	return nil
+}
+
+// headersFor returns the configured headers sent to host.
+func (s *FetchSettings) headersFor(host string) (ret []FetchHeader) {
+	for _, header := range s.Headers {
+		if matchesDomain(host, header.Host) {
+			ret = append(ret, header)
+		}
+	}
+	return
+}
+
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPrivateIP reports addresses that must not be reachable from templates:
+// loopback, private, link-local (including cloud metadata endpoints),
+// shared, multicast and unspecified addresses.
+func isPrivateIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		if ip4[0] == 0 || sharedAddressSpace.Contains(ip4) {
+			return true
+		}
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
 // FetchPlugin provides HTTP fetching capabilities with safety constraints:
 // - Only text content types allowed
 // - Size limited to MaxContentSize
 // - UTF-8 validation
 // - Null byte checking
-type FetchPlugin struct{}
+// - Domain allow and deny lists, private addresses blocked
+// - Responses cached on disk following ETag, Last-Modified and Cache-Control
+type FetchPlugin struct {
+	// Settings replace the FABRIC_FETCH_* environment variables when set.
+	Settings *FetchSettings
+}
 
 // Apply executes fetch operations:
 //   - get:URL: Fetches content from URL, returns text content
+//   - markdown:URL: Fetches a web page and returns its main content as markdown
 func (p *FetchPlugin) Apply(operation string, value string) (string, error) {
 	debugf("Fetch: operation=%q value=%q", operation, value)
 
 	switch operation {
 	case "get":
-		return p.fetch(value)
+		content, _, err := p.fetch(value)
+		return content, err
+	case "markdown":
+		return p.markdown(value)
 	default:
-		return "", fmt.Errorf("fetch: unknown operation %q (supported: get)", operation)
+		return "", fmt.Errorf("fetch: unknown operation %q (supported: get, markdown)", operation)
+	}
+}
+
+func (p *FetchPlugin) settings() *FetchSettings {
+	if p.Settings != nil {
+		return p.Settings
+	}
+	return FetchSettingsFromEnv()
+}
+
+// markdown converts HTML pages with readability, other text is returned as is.
+func (p *FetchPlugin) markdown(urlStr string) (string, error) {
+	content, contentType, err := p.fetch(urlStr)
+	if err != nil {
+		return "", err
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return content, nil
+	}
+	pageURL, _ := url.Parse(urlStr)
+	article, err := converter.HtmlReadabilityMarkdown(content, pageURL)
+	if err != nil {
+		return "", fmt.Errorf("fetch: error extracting readable content: %v", err)
 	}
+	return article.Markdown, nil
 }
 
 // isTextContent checks if the content type is text-based
@@ -56,6 +262,7 @@ func (p *FetchPlugin) isTextContent(contentType string) bool {
 		mediaType == "application/xml" ||
 		mediaType == "application/yaml" ||
 		mediaType == "application/x-yaml" ||
+		mediaType == "application/xhtml+xml" ||
 		strings.HasSuffix(mediaType, "+json") ||
 		strings.HasSuffix(mediaType, "+xml") ||
 		strings.HasSuffix(mediaType, "+yaml")
@@ -80,37 +287,113 @@ func (p *FetchPlugin) validateTextContent(content []byte) error {
 	return nil
 }
 
-// fetch retrieves content from a URL with safety checks
-func (p *FetchPlugin) fetch(urlStr string) (string, error) {
+// newClient returns a client enforcing the settings on every redirect and,
+// unless private addresses are allowed, on every resolved address.
+func (s *FetchSettings) newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !s.AllowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return fmt.Errorf("fetch: %s is a private or local address", host)
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: FetchTimeout,
+		// No proxy: requests must go to the checked address
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("fetch: stopped after %d redirects", maxFetchRedirects)
+			}
+			if err := s.checkURL(req.URL); err != nil {
+				return err
+			}
+			// Configured headers only go to their own hosts
+			for _, header := range s.Headers {
+				req.Header.Del(header.Name)
+			}
+			for _, header := range s.headersFor(req.URL.Hostname()) {
+				req.Header.Set(header.Name, header.Value)
+			}
+			return nil
+		},
+	}
+}
+
+// fetch retrieves content from a URL with safety checks and returns it with
+// its content type.
+func (p *FetchPlugin) fetch(urlStr string) (string, string, error) {
 	debugf("Fetch: requesting URL %q", urlStr)
 
-	client := &http.Client{}
+	settings := p.settings()
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return "", "", fmt.Errorf("fetch: error creating request: %v", err)
+	}
+	if err = settings.checkURL(u); err != nil {
+		return "", "", err
+	}
+
 	req, err := http.NewRequest("GET", urlStr, nil)
 	if err != nil {
-		return "", fmt.Errorf("fetch: error creating request: %v", err)
+		return "", "", fmt.Errorf("fetch: error creating request: %v", err)
 	}
 	req.Header.Set("User-Agent", UserAgent)
+	headers := settings.headersFor(u.Hostname())
+	for _, header := range headers {
+		req.Header.Set(header.Name, header.Value)
+	}
 
-	resp, err := client.Do(req)
+	cache := &fetchCache{dir: settings.CacheDir, key: fetchCacheKey(urlStr, headers)}
+	cached := cache.load()
+	if cached != nil {
+		if cached.isFresh(time.Now()) {
+			debugf("Fetch: serving %q from cache", urlStr)
+			return cached.Body, cached.ContentType, nil
+		}
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := settings.newClient().Do(req)
 	if err != nil {
-		return "", fmt.Errorf("fetch: error fetching URL: %v", err)
+		return "", "", fmt.Errorf("fetch: error fetching URL: %v", err)
 	}
 	defer resp.Body.Close()
 
 	debugf("Fetch: got response status=%q", resp.Status)
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		debugf("Fetch: %q not modified, using cache", urlStr)
+		cached.refresh(resp.Header, time.Now())
+		cache.store(cached)
+		return cached.Body, cached.ContentType, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("fetch: HTTP error: %d - %s", resp.StatusCode, resp.Status)
+		return "", "", fmt.Errorf("fetch: HTTP error: %d - %s", resp.StatusCode, resp.Status)
 	}
 
 	if contentLength := resp.ContentLength; contentLength > MaxContentSize {
-		return "", fmt.Errorf("fetch: content too large: %d bytes (max %d bytes)",
+		return "", "", fmt.Errorf("fetch: content too large: %d bytes (max %d bytes)",
 			contentLength, MaxContentSize)
 	}
 
 	contentType := resp.Header.Get("Content-Type")
 	debugf("Fetch: content-type=%q", contentType)
 	if !p.isTextContent(contentType) {
-		return "", fmt.Errorf("fetch: unsupported content type %q - only text content allowed",
+		return "", "", fmt.Errorf("fetch: unsupported content type %q - only text content allowed",
 			contentType)
 	}
 
@@ -118,17 +401,134 @@ func (p *FetchPlugin) fetch(urlStr string) (string, error) {
 	limitReader := io.LimitReader(resp.Body, MaxContentSize+1)
 	content, err := io.ReadAll(limitReader)
 	if err != nil {
-		return "", fmt.Errorf("fetch: error reading response: %v", err)
+		return "", "", fmt.Errorf("fetch: error reading response: %v", err)
 	}
 
 	if len(content) > MaxContentSize {
-		return "", fmt.Errorf("fetch: content too large: exceeds %d bytes", MaxContentSize)
+		return "", "", fmt.Errorf("fetch: content too large: exceeds %d bytes", MaxContentSize)
 	}
 
 	if err := p.validateTextContent(content); err != nil {
-		return "", err
+		return "", "", err
 	}
 
+	entry := &fetchCacheEntry{URL: urlStr, ContentType: contentType, Body: string(content)}
+	entry.refresh(resp.Header, time.Now())
+	cache.store(entry)
+
 	debugf("Fetch: operation completed successfully, read %d bytes", len(content))
-	return string(content), nil
+	return string(content), contentType, nil
+}
+
+// fetchCache stores one response per URL and header set as JSON.
+type fetchCache struct {
+	dir string
+	key string
+}
+
+type fetchCacheEntry struct {
+	URL          string    `json:"url"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	CacheControl string    `json:"cache_control,omitempty"`
+	Expires      string    `json:"expires,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+	Body         string    `json:"body"`
+}
+
+// fetchCacheKey keeps responses fetched with different headers apart.
+func fetchCacheKey(urlStr string, headers []FetchHeader) string {
+	h := sha256.New()
+	h.Write([]byte(urlStr))
+	for _, header := range headers {
+		fmt.Fprintf(h, "\n%s: %s", header.Name, header.Value)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *fetchCache) path() string {
+	return filepath.Join(c.dir, c.key+".json")
+}
+
+func (c *fetchCache) load() *fetchCacheEntry {
+	if c.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.path())
+	if err != nil {
+		return nil
+	}
+	var entry fetchCacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		debugf("Fetch: ignoring unreadable cache entry %s: %v", c.path(), err)
+		return nil
+	}
+	return &entry
+}
+
+// store saves entries that may be reused: not marked no-store and either
+// fresh for a while or carrying a validator.
+func (c *fetchCache) store(entry *fetchCacheEntry) {
+	if c.dir == "" {
+		return
+	}
+	directives := parseCacheControl(entry.CacheControl)
+	if _, noStore := directives["no-store"]; noStore {
+		os.Remove(c.path())
+		return
+	}
+	if entry.ETag == "" && entry.LastModified == "" && !entry.isFresh(entry.StoredAt) {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err == nil {
+		if err = os.MkdirAll(c.dir, 0o700); err == nil {
+			err = os.WriteFile(c.path(), data, 0o600)
+		}
+	}
+	if err != nil {
+		debugf("Fetch: could not write cache entry: %v", err)
+	}
+}
+
+// refresh takes the caching headers of a 200 or 304 response.
+func (e *fetchCacheEntry) refresh(header http.Header, now time.Time) {
+	e.StoredAt = now
+	if etag := header.Get("ETag"); etag != "" {
+		e.ETag = etag
+	}
+	if lastModified := header.Get("Last-Modified"); lastModified != "" {
+		e.LastModified = lastModified
+	}
+	e.CacheControl = header.Get("Cache-Control")
+	e.Expires = header.Get("Expires")
+}
+
+// isFresh reports whether the entry may be used without asking the server.
+func (e *fetchCacheEntry) isFresh(now time.Time) bool {
+	directives := parseCacheControl(e.CacheControl)
+	if _, noCache := directives["no-cache"]; noCache {
+		return false
+	}
+	if maxAge, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		return err == nil && now.Before(e.StoredAt.Add(time.Duration(seconds)*time.Second))
+	}
+	if e.Expires != "" {
+		expires, err := http.ParseTime(e.Expires)
+		return err == nil && now.Before(expires)
+	}
+	return false
+}
+
+func parseCacheControl(value string) map[string]string {
+	ret := map[string]string{}
+	for _, directive := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if name != "" {
+			ret[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	return ret
 }
@@ -1,6 +1,8 @@
 package template
 
 import (
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -70,3 +72,161 @@ func TestFetchPlugin(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchPluginSecurity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://denied.example/", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		settings    *FetchSettings
+		url         string
+		errContains string
+	}{
+		{name: "loopback blocked", settings: &FetchSettings{}, url: server.URL, errContains: "private or local address"},
+		{name: "metadata endpoint blocked", settings: &FetchSettings{}, url: "http://169.254.169.254/latest/meta-data/", errContains: "private or local address"},
+		{name: "localhost blocked", settings: &FetchSettings{}, url: "http://localhost:9/", errContains: "private or local address"},
+		{name: "allowlist", settings: &FetchSettings{AllowPrivate: true, AllowedDomains: []string{"example.com"}}, url: server.URL, errContains: "not in the allowed domains"},
+		{name: "denylist", settings: &FetchSettings{DeniedDomains: []string{"example.com"}}, url: "https://docs.example.com/", errContains: "is denied"},
+		{name: "redirect checked", settings: &FetchSettings{AllowPrivate: true, DeniedDomains: []string{"denied.example"}}, url: server.URL + "/redirect", errContains: "is denied"},
+		{name: "private allowed", settings: &FetchSettings{AllowPrivate: true}, url: server.URL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&FetchPlugin{Settings: tt.settings}).Apply("get", tt.url)
+			if tt.errContains == "" {
+				if err != nil || got != "ok" {
+					t.Errorf("expected ok, got %q, %v", got, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestFetchPluginCache(t *testing.T) {
+	var requests, revalidations int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/plain")
+		switch r.URL.Path {
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				revalidations++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			fmt.Fprint(w, "tagged")
+		case "/max-age":
+			w.Header().Set("Cache-Control", "max-age=60")
+			fmt.Fprint(w, "fresh")
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("ETag", `"v1"`)
+			fmt.Fprint(w, "volatile")
+		}
+	}))
+	defer server.Close()
+
+	plugin := &FetchPlugin{Settings: &FetchSettings{AllowPrivate: true, CacheDir: t.TempDir()}}
+	fetchTwice := func(path string, expected string) {
+		t.Helper()
+		for i := 0; i < 2; i++ {
+			got, err := plugin.Apply("get", server.URL+path)
+			if err != nil || got != expected {
+				t.Fatalf("fetch %s: got %q, %v", path, got, err)
+			}
+		}
+	}
+
+	fetchTwice("/etag", "tagged")
+	if requests != 2 || revalidations != 1 {
+		t.Errorf("expected a conditional second request, got %d requests and %d revalidations", requests, revalidations)
+	}
+
+	requests = 0
+	fetchTwice("/max-age", "fresh")
+	if requests != 1 {
+		t.Errorf("expected the fresh response to be served from cache, got %d requests", requests)
+	}
+
+	requests = 0
+	fetchTwice("/no-store", "volatile")
+	if requests != 2 {
+		t.Errorf("expected no-store responses to be fetched every time, got %d requests", requests)
+	}
+}
+
+func TestFetchPluginHeadersFromEnv(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Api-Key"))
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	t.Setenv(FetchAllowPrivateEnv, "true")
+	t.Setenv(FetchCacheDirEnv, "none")
+	t.Setenv(FetchHeaderEnvPrefix+"LOCAL", "127.0.0.1|X-Api-Key: secret")
+	t.Setenv(FetchHeaderEnvPrefix+"OTHER", "api.example.com|X-Other: nope")
+
+	settings := FetchSettingsFromEnv()
+	if settings.CacheDir != "" || !settings.AllowPrivate || len(settings.Headers) != 2 {
+		t.Fatalf("unexpected settings: %+v", settings)
+	}
+	if _, err := (&FetchPlugin{}).Apply("get", server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || received[0] != "secret" {
+		t.Errorf("expected the configured header, got %q", received)
+	}
+}
+
+func TestFetchPluginMarkdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/data.json" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"a":1}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>Guide</title><script>track()</script></head><body>
+<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+<article><h1>Guide</h1><p>Install the <strong>tool</strong> with the package manager of your system and run the setup command once to create the configuration directory.</p>
+<p>Afterwards every command reads its defaults from that directory, see the <a href="/config">configuration reference</a> for all settings.</p></article>
+</body></html>`)
+	}))
+	defer server.Close()
+
+	plugin := &FetchPlugin{Settings: &FetchSettings{AllowPrivate: true}}
+	got, err := plugin.Apply("markdown", server.URL+"/guide")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Guide", "**tool**", "[configuration reference](" + server.URL + "/config)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "track()") {
+		t.Errorf("expected scripts to be removed:\n%s", got)
+	}
+
+	// Non-HTML text is returned unchanged
+	if got, err = plugin.Apply("markdown", server.URL+"/data.json"); err != nil || got != `{"a":1}` {
+		t.Errorf("expected JSON unchanged, got %q, %v", got, err)
+	}
+}
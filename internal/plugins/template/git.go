@@ -0,0 +1,395 @@
+// Package template provides git repository operations for the template system.
+// Security Note: This plugin reads the repository containing the current
+// working directory, including uncommitted changes.
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// MaxGitOutputSize defines the maximum size of a git plugin result (1MB)
+const MaxGitOutputSize = MaxFileSize
+
+// DefaultGitLogLimit is the number of commits listed by log without a ref
+const DefaultGitLogLimit = 20
+
+// maxGitLogCommits bounds the walk of log:REF when REF is not an ancestor of HEAD
+const maxGitLogCommits = 1000
+
+// GitPlugin provides read-only access to the git repository of the working
+// directory with the same size limit as the file plugin.
+type GitPlugin struct {
+	// Dir is the directory the repository is searched from, the working
+	// directory when empty.
+	Dir string
+}
+
+// Apply executes git operations:
+//   - branch - Current branch name
+//   - commit[:REF] - Hash, author, date and message of HEAD or REF
+//   - diff[:REF] - Unified diff of the working tree against HEAD or REF
+//   - staged - Unified diff of the staged changes against HEAD
+//   - log[:REF] - Commits since REF (e.g. the last tag), or the last 20 commits
+//   - blame:PATH|START-END - Author, commit and date of each line in the range
+//   - changed[:REF] - Files changed in the working tree, or since REF
+func (p *GitPlugin) Apply(operation string, value string) (ret string, err error) {
+	debugf("Git: operation=%q value=%q", operation, value)
+
+	var repo *git.Repository
+	if repo, err = p.open(); err != nil {
+		return
+	}
+
+	switch operation {
+	case "branch":
+		ret, err = gitBranch(repo)
+	case "commit":
+		ret, err = gitCommit(repo, value)
+	case "diff":
+		ret, err = gitDiff(repo, value, false)
+	case "staged":
+		ret, err = gitDiff(repo, "", true)
+	case "log":
+		ret, err = gitLog(repo, value)
+	case "blame":
+		ret, err = gitBlame(repo, value)
+	case "changed":
+		ret, err = gitChanged(repo, value)
+	default:
+		return "", fmt.Errorf("git: unknown operation %q (supported: branch, commit, diff, staged, log, blame, changed)", operation)
+	}
+	if err != nil {
+		return "", fmt.Errorf("git: %v", err)
+	}
+	if len(ret) > MaxGitOutputSize {
+		return "", fmt.Errorf("git: %s output size %d exceeds limit of %d bytes", operation, len(ret), MaxGitOutputSize)
+	}
+	return
+}
+
+func (p *GitPlugin) open() (*git.Repository, error) {
+	dir := p.Dir
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return nil, fmt.Errorf("git: %v", err)
+		}
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("git: could not open repository at %s: %v", dir, err)
+	}
+	return repo, nil
+}
+
+func gitBranch(repo *git.Repository) (string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	if !head.Name().IsBranch() {
+		return "HEAD (detached at " + head.Hash().String()[:7] + ")", nil
+	}
+	return head.Name().Short(), nil
+}
+
+func resolveCommit(repo *git.Repository, ref string) (*object.Commit, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve %q: %v", ref, err)
+	}
+	return repo.CommitObject(*hash)
+}
+
+func gitCommit(repo *git.Repository, ref string) (string, error) {
+	commit, err := resolveCommit(repo, ref)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("commit %s\nAuthor: %s <%s>\nDate:   %s\n\n%s",
+		commit.Hash, commit.Author.Name, commit.Author.Email,
+		commit.Author.When.Format("2006-01-02 15:04:05 -0700"), strings.TrimSpace(commit.Message)), nil
+}
+
+func gitLog(repo *git.Repository, since string) (string, error) {
+	head, err := resolveCommit(repo, "")
+	if err != nil {
+		return "", err
+	}
+	var stop plumbing.Hash
+	limit := DefaultGitLogLimit
+	if since != "" {
+		var from *object.Commit
+		if from, err = resolveCommit(repo, since); err != nil {
+			return "", err
+		}
+		stop = from.Hash
+		limit = maxGitLogCommits
+	}
+
+	iter, err := repo.Log(&git.LogOptions{From: head.Hash})
+	if err != nil {
+		return "", err
+	}
+	defer iter.Close()
+
+	var lines []string
+	for len(lines) < limit {
+		commit, nextErr := iter.Next()
+		if nextErr == io.EOF {
+			break
+		} else if nextErr != nil {
+			return "", nextErr
+		}
+		if commit.Hash == stop {
+			break
+		}
+		subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+		lines = append(lines, fmt.Sprintf("%s %s (%s, %s)", commit.Hash.String()[:7], subject,
+			commit.Author.Name, commit.Author.When.Format("2006-01-02")))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func gitBlame(repo *git.Repository, value string) (string, error) {
+	path, lineRange, _ := strings.Cut(value, "|")
+	if path == "" {
+		return "", fmt.Errorf("blame requires PATH|START-END")
+	}
+	commit, err := resolveCommit(repo, "")
+	if err != nil {
+		return "", err
+	}
+	result, err := git.Blame(commit, filepath.ToSlash(path))
+	if err != nil {
+		return "", err
+	}
+
+	start, end := 1, len(result.Lines)
+	if lineRange != "" {
+		first, last, found := strings.Cut(lineRange, "-")
+		if start, err = strconv.Atoi(first); err != nil || start < 1 {
+			return "", fmt.Errorf("invalid blame range %q", lineRange)
+		}
+		end = start
+		if found {
+			if end, err = strconv.Atoi(last); err != nil || end < start {
+				return "", fmt.Errorf("invalid blame range %q", lineRange)
+			}
+		}
+	}
+	if end > len(result.Lines) {
+		end = len(result.Lines)
+	}
+
+	var b strings.Builder
+	for i := start; i <= end; i++ {
+		line := result.Lines[i-1]
+		fmt.Fprintf(&b, "%s %s %s %d| %s\n", line.Hash.String()[:7], line.AuthorName,
+			line.Date.Format("2006-01-02"), i, line.Text)
+	}
+	return b.String(), nil
+}
+
+// gitChanged lists changed files with a status letter, either the working
+// tree changes against HEAD or everything changed since ref.
+func gitChanged(repo *git.Repository, ref string) (string, error) {
+	changes, err := collectChanges(repo, ref, false)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, path := range sortedKeys(changes) {
+		lines = append(lines, changes[path]+" "+path)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// collectChanges returns the changed paths with a status letter (A, M, D or
+// ? for untracked). With staged only index changes against HEAD count.
+func collectChanges(repo *git.Repository, ref string, staged bool) (ret map[string]string, err error) {
+	ret = map[string]string{}
+
+	var worktree *git.Worktree
+	if worktree, err = repo.Worktree(); err != nil {
+		return
+	}
+	var status git.Status
+	if status, err = worktree.Status(); err != nil {
+		return
+	}
+	for path, fileStatus := range status {
+		code := fileStatus.Worktree
+		if staged || code == git.Unmodified {
+			code = fileStatus.Staging
+		}
+		if staged && fileStatus.Staging == git.Untracked {
+			continue
+		}
+		if code != git.Unmodified {
+			ret[path] = statusLetter(code)
+		}
+	}
+
+	if ref == "" {
+		return
+	}
+
+	// Changes between ref and HEAD, working tree changes keep precedence
+	var from, head *object.Commit
+	if from, err = resolveCommit(repo, ref); err != nil {
+		return
+	}
+	if head, err = resolveCommit(repo, ""); err != nil {
+		return
+	}
+	var fromTree, headTree *object.Tree
+	if fromTree, err = from.Tree(); err != nil {
+		return
+	}
+	if headTree, err = head.Tree(); err != nil {
+		return
+	}
+	var treeChanges object.Changes
+	if treeChanges, err = object.DiffTree(fromTree, headTree); err != nil {
+		return
+	}
+	for _, change := range treeChanges {
+		path, letter := change.To.Name, "M"
+		if change.From.Name == "" {
+			letter = "A"
+		} else if change.To.Name == "" {
+			path, letter = change.From.Name, "D"
+		}
+		if _, exists := ret[path]; !exists {
+			ret[path] = letter
+		}
+	}
+	return
+}
+
+func statusLetter(code git.StatusCode) string {
+	switch code {
+	case git.Added:
+		return "A"
+	case git.Deleted:
+		return "D"
+	case git.Renamed:
+		return "R"
+	case git.Untracked:
+		return "?"
+	default:
+		return "M"
+	}
+}
+
+// gitDiff renders a unified diff of the working tree (or of the index when
+// staged is set) against ref.
+func gitDiff(repo *git.Repository, ref string, staged bool) (string, error) {
+	changes, err := collectChanges(repo, ref, staged)
+	if err != nil {
+		return "", err
+	}
+	base, err := resolveCommit(repo, ref)
+	if err != nil {
+		return "", err
+	}
+	baseTree, err := base.Tree()
+	if err != nil {
+		return "", err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, path := range sortedKeys(changes) {
+		if changes[path] == "?" {
+			continue
+		}
+		before, _ := treeFileContent(baseTree, path)
+		var after []byte
+		if staged {
+			after, _ = indexFileContent(repo, path)
+		} else {
+			after, _ = os.ReadFile(filepath.Join(worktree.Filesystem.Root(), path))
+		}
+		if bytes.Equal(before, after) {
+			continue
+		}
+		if bytes.IndexByte(before, 0) >= 0 || bytes.IndexByte(after, 0) >= 0 {
+			fmt.Fprintf(&b, "Binary file %s differs\n", path)
+			continue
+		}
+		diff, diffErr := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(before)),
+			B:        difflib.SplitLines(string(after)),
+			FromFile: "a/" + path,
+			ToFile:   "b/" + path,
+			Context:  3,
+		})
+		if diffErr != nil {
+			return "", diffErr
+		}
+		b.WriteString(diff)
+		if b.Len() > MaxGitOutputSize {
+			break
+		}
+	}
+	return b.String(), nil
+}
+
+func treeFileContent(tree *object.Tree, path string) ([]byte, error) {
+	file, err := tree.File(path)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := file.Contents()
+	return []byte(contents), err
+}
+
+func indexFileContent(repo *git.Repository, path string) ([]byte, error) {
+	index, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := index.Entry(path)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := repo.BlobObject(entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
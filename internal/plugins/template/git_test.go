@@ -0,0 +1,121 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func newTestRepo(t *testing.T) (string, *git.Repository) {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	return dir, repo
+}
+
+func commitFile(t *testing.T, dir string, repo *git.Repository, name, content, message string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = worktree.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	signature := &object.Signature{Name: "Ada", Email: "ada@example.com", When: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	if _, err = worktree.Commit(message, &git.CommitOptions{Author: signature}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitPlugin(t *testing.T) {
+	dir, repo := newTestRepo(t)
+	commitFile(t, dir, repo, "main.go", "package main\n\nfunc main() {}\n", "Initial commit")
+	head, _ := repo.Head()
+	if _, err := repo.CreateTag("v1.0.0", head.Hash(), nil); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, dir, repo, "README.md", "# Demo\n", "Add readme")
+
+	// Unstaged change, staged change and untracked file
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() { run() }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("todo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Demo\n\nStaged.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	worktree, _ := repo.Worktree()
+	if _, err := worktree.Add("README.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	plugin := &GitPlugin{Dir: dir}
+	tests := []struct {
+		operation string
+		value     string
+		contains  []string
+		excludes  []string
+		wantErr   bool
+	}{
+		{operation: "branch", contains: []string{"master"}},
+		{operation: "commit", contains: []string{"Author: Ada <ada@example.com>", "Add readme"}},
+		{operation: "commit", value: "v1.0.0", contains: []string{"Initial commit"}},
+		{operation: "log", value: "v1.0.0", contains: []string{"Add readme (Ada, 2024-05-01)"}, excludes: []string{"Initial commit"}},
+		{operation: "log", contains: []string{"Add readme", "Initial commit"}},
+		{operation: "changed", contains: []string{"M README.md", "M main.go", "? notes.txt"}},
+		{operation: "changed", value: "v1.0.0", contains: []string{"M README.md", "M main.go"}},
+		{operation: "diff", contains: []string{"--- a/main.go", "+func main() { run() }", "+Staged."}, excludes: []string{"notes.txt"}},
+		{operation: "staged", contains: []string{"+Staged."}, excludes: []string{"main.go"}},
+		{operation: "diff", value: "v1.0.0", contains: []string{"+++ b/README.md", "+# Demo"}},
+		{operation: "blame", value: "main.go|1-1", contains: []string{"Ada 2024-05-01 1| package main"}, excludes: []string{"3|"}},
+		{operation: "blame", value: "main.go|x", wantErr: true},
+		{operation: "log", value: "no-such-ref", wantErr: true},
+		{operation: "unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.operation+":"+tt.value, func(t *testing.T) {
+			got, err := plugin.Apply(tt.operation, tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %q in:\n%s", want, got)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("did not expect %q in:\n%s", unwanted, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGitPlugin_NotARepository(t *testing.T) {
+	plugin := &GitPlugin{Dir: t.TempDir()}
+	if _, err := plugin.Apply("branch", ""); err == nil {
+		t.Error("expected error outside a repository")
+	}
+}
@@ -0,0 +1,139 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Plugin handles the {{plugin:namespace:operation:value}} calls of one namespace.
+type Plugin interface {
+	Apply(operation string, value string) (string, error)
+}
+
+// PluginInfo describes a registered plugin.
+type PluginInfo struct {
+	Namespace   string
+	Description string
+	Operations  []string
+	Plugin      Plugin
+}
+
+var (
+	pluginsMu          sync.RWMutex
+	registeredPlugins  = map[string]*PluginInfo{}
+	disabledNamespaces = map[string]bool{}
+)
+
+func init() {
+	for _, info := range []*PluginInfo{
+		{Namespace: "text", Description: "String manipulation",
+			Operations: []string{"upper", "lower", "title", "trim"}, Plugin: textPlugin},
+		{Namespace: "datetime", Description: "Dates and times",
+			Operations: []string{"now", "time", "unix", "startofhour", "endofhour", "today", "full", "month", "year",
+				"startofweek", "endofweek", "startofmonth", "endofmonth", "rel"}, Plugin: datetimePlugin},
+		{Namespace: "file", Description: "Local file access (size limited)",
+			Operations: []string{"read", "tail", "exists", "size", "modified"}, Plugin: filePlugin},
+		{Namespace: "fetch", Description: "HTTP fetching of text content and readable pages",
+			Operations: []string{"get", "markdown"}, Plugin: fetchPlugin},
+		{Namespace: "git", Description: "Read-only git repository information (size limited)",
+			Operations: []string{"branch", "commit", "diff", "staged", "log", "blame", "changed"}, Plugin: gitPlugin},
+		{Namespace: "sys", Description: "System information and environment variables",
+			Operations: []string{"hostname", "user", "os", "arch", "env", "pwd", "home"}, Plugin: sysPlugin},
+	} {
+		if err := RegisterPlugin(info); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterPlugin makes a plugin available under its namespace.
+func RegisterPlugin(info *PluginInfo) error {
+	if info.Namespace == "" || strings.Contains(info.Namespace, ":") {
+		return fmt.Errorf("invalid template plugin namespace %q", info.Namespace)
+	}
+	if info.Plugin == nil {
+		return fmt.Errorf("template plugin %s has no implementation", info.Namespace)
+	}
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if _, exists := registeredPlugins[info.Namespace]; exists {
+		return fmt.Errorf("template plugin namespace %s is already registered", info.Namespace)
+	}
+	registeredPlugins[info.Namespace] = info
+	return nil
+}
+
+// RegisteredPlugins returns all plugins sorted by namespace.
+func RegisteredPlugins() (ret []*PluginInfo) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	for _, info := range registeredPlugins {
+		ret = append(ret, info)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Namespace < ret[j].Namespace })
+	return
+}
+
+// SetDisabledPlugins replaces the set of disabled namespaces. Calls into a
+// disabled namespace fail instead of running the plugin.
+func SetDisabledPlugins(namespaces []string) error {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	disabled := map[string]bool{}
+	for _, namespace := range namespaces {
+		if _, exists := registeredPlugins[namespace]; !exists {
+			return fmt.Errorf("unknown template plugin namespace: %s", namespace)
+		}
+		disabled[namespace] = true
+	}
+	disabledNamespaces = disabled
+	return nil
+}
+
+// IsPluginEnabled reports whether calls into namespace are allowed.
+func IsPluginEnabled(namespace string) bool {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	return !disabledNamespaces[namespace]
+}
+
+// ListPlugins prints the registered plugins with their operations and policy.
+func ListPlugins(w io.Writer, shellCompleteList bool) {
+	plugins := RegisteredPlugins()
+	if shellCompleteList {
+		for _, info := range plugins {
+			fmt.Fprintln(w, info.Namespace)
+		}
+		return
+	}
+
+	fmt.Fprint(w, "Template plugins ({{plugin:namespace:operation:value}}):\n\n")
+	for _, info := range plugins {
+		status := "enabled"
+		if !IsPluginEnabled(info.Namespace) {
+			status = "disabled"
+		}
+		fmt.Fprintf(w, "%-10s %-9s %s\n", info.Namespace, status, info.Description)
+		fmt.Fprintf(w, "%-20s operations: %s\n", "", strings.Join(info.Operations, ", "))
+	}
+}
+
+// lookupPlugin returns the plugin of an enabled namespace.
+func lookupPlugin(namespace string) (Plugin, error) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	info, exists := registeredPlugins[namespace]
+	if !exists {
+		return nil, fmt.Errorf("unknown plugin namespace: %s", namespace)
+	}
+	if disabledNamespaces[namespace] {
+		return nil, fmt.Errorf("plugin namespace %s is disabled by configuration", namespace)
+	}
+	return info.Plugin, nil
+}
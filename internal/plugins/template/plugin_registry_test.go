@@ -0,0 +1,58 @@
+package template
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type reversePlugin struct{}
+
+func (p *reversePlugin) Apply(operation string, value string) (string, error) {
+	runes := []rune(value)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes), nil
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	if err := RegisterPlugin(&PluginInfo{Namespace: "reverse", Operations: []string{"all"}, Plugin: &reversePlugin{}}); err != nil {
+		t.Fatalf("RegisterPlugin failed: %v", err)
+	}
+	got, err := ApplyTemplate("{{plugin:reverse:all:abc}}", nil, "")
+	if err != nil || got != "cba" {
+		t.Fatalf("expected registered plugin to run, got %q, %v", got, err)
+	}
+
+	if err := RegisterPlugin(&PluginInfo{Namespace: "reverse", Plugin: &reversePlugin{}}); err == nil {
+		t.Error("expected duplicate namespace to be rejected")
+	}
+	if err := RegisterPlugin(&PluginInfo{Namespace: "a:b", Plugin: &reversePlugin{}}); err == nil {
+		t.Error("expected namespace with colon to be rejected")
+	}
+}
+
+func TestSetDisabledPlugins(t *testing.T) {
+	defer SetDisabledPlugins(nil)
+
+	if err := SetDisabledPlugins([]string{"sys", "file"}); err != nil {
+		t.Fatalf("SetDisabledPlugins failed: %v", err)
+	}
+	if _, err := ApplyTemplate("{{plugin:sys:os}}", nil, ""); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("expected disabled namespace error, got %v", err)
+	}
+	if got, err := ApplyTemplate("{{plugin:text:upper:ok}}", nil, ""); err != nil || got != "OK" {
+		t.Errorf("expected other namespaces to keep working, got %q, %v", got, err)
+	}
+
+	var out bytes.Buffer
+	ListPlugins(&out, false)
+	if !strings.Contains(out.String(), "sys        disabled") {
+		t.Errorf("expected listing to show the policy, got:\n%s", out.String())
+	}
+
+	if err := SetDisabledPlugins([]string{"nope"}); err == nil {
+		t.Error("expected unknown namespace to be rejected")
+	}
+}
@@ -0,0 +1,396 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file implements the block and filter syntax rendered before the
+// variable and plugin substitution of ApplyTemplate:
+//
+//	{{#if focus}}Focus on {{focus}}.{{else}}Cover everything.{{/if}}
+//	{{#unless brief}}...{{/unless}}
+//	{{#each topics}}- {{this}} ({{@index}}){{/each}}
+//	{{audience|default:"engineers"|upper}}
+//	{{input|truncate:2000}}
+//
+// Tags without "#", "/", "else" or a "|" are left for the variable pass, so
+// existing templates render exactly as before. Values produced here that
+// contain "{{" are inserted after the variable pass, so input text is never
+// scanned for further tags.
+
+var tagPattern = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+const valuePlaceholder = "__FABRIC_TEMPLATE_VALUE_%d__"
+
+type nodeKind int
+
+const (
+	nodeText nodeKind = iota
+	nodeExpr
+	nodeIf
+	nodeUnless
+	nodeEach
+)
+
+type node struct {
+	kind     nodeKind
+	text     string // nodeText: raw text, nodeExpr: expression
+	name     string // block variable
+	children []*node
+	orElse   []*node
+}
+
+// renderer keeps the rendered values until they are substituted back.
+type renderer struct {
+	variables map[string]string
+	input     string
+	values    []string
+}
+
+// isSyntaxTag reports whether a tag belongs to the block and filter syntax.
+func isSyntaxTag(tag string) bool {
+	tag = strings.TrimSpace(tag)
+	if strings.HasPrefix(tag, "plugin:") || strings.HasPrefix(tag, "ext:") {
+		return false
+	}
+	return strings.HasPrefix(tag, "#") || strings.HasPrefix(tag, "/") ||
+		tag == "else" || strings.Contains(tag, "|")
+}
+
+// hasSyntax reports whether content uses any block or filter tag.
+func hasSyntax(content string) bool {
+	for _, match := range tagPattern.FindAllStringSubmatch(content, -1) {
+		if isSyntaxTag(match[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderSyntax renders blocks and filter expressions. The returned content
+// contains placeholders that restore replaces with the rendered values.
+func (o *renderer) renderSyntax(content string) (ret string, err error) {
+	var nodes []*node
+	if nodes, err = parseNodes(content); err != nil {
+		return
+	}
+	var b strings.Builder
+	if err = o.render(&b, nodes, nil); err != nil {
+		return
+	}
+	ret = b.String()
+	return
+}
+
+func (o *renderer) restore(content string) string {
+	for i := len(o.values) - 1; i >= 0; i-- {
+		content = strings.ReplaceAll(content, fmt.Sprintf(valuePlaceholder, i), o.values[i])
+	}
+	return content
+}
+
+func (o *renderer) placeholder(value string) string {
+	if !strings.Contains(value, "{{") {
+		return value
+	}
+	o.values = append(o.values, value)
+	return fmt.Sprintf(valuePlaceholder, len(o.values)-1)
+}
+
+func parseNodes(content string) (ret []*node, err error) {
+	type frame struct {
+		block  *node
+		inElse bool
+	}
+	root := &node{}
+	stack := []*frame{{block: root}}
+	appendNode := func(n *node) {
+		top := stack[len(stack)-1]
+		if top.inElse {
+			top.block.orElse = append(top.block.orElse, n)
+		} else {
+			top.block.children = append(top.block.children, n)
+		}
+	}
+
+	last := 0
+	for _, loc := range tagPattern.FindAllStringSubmatchIndex(content, -1) {
+		tag := strings.TrimSpace(content[loc[2]:loc[3]])
+		if !isSyntaxTag(tag) {
+			continue
+		}
+		if loc[0] > last {
+			appendNode(&node{kind: nodeText, text: content[last:loc[0]]})
+		}
+		last = loc[1]
+
+		switch {
+		case strings.HasPrefix(tag, "#"):
+			keyword, name, _ := strings.Cut(tag[1:], " ")
+			name = strings.TrimSpace(name)
+			n := &node{name: name}
+			switch keyword {
+			case "if":
+				n.kind = nodeIf
+			case "unless":
+				n.kind = nodeUnless
+			case "each":
+				n.kind = nodeEach
+			default:
+				return nil, fmt.Errorf("unknown template block {{%s}}", tag)
+			}
+			if name == "" {
+				return nil, fmt.Errorf("template block {{%s}} needs a variable name", tag)
+			}
+			appendNode(n)
+			stack = append(stack, &frame{block: n})
+		case tag == "else":
+			top := stack[len(stack)-1]
+			if top.block == root || top.inElse {
+				return nil, fmt.Errorf("unexpected {{else}}")
+			}
+			top.inElse = true
+		case strings.HasPrefix(tag, "/"):
+			top := stack[len(stack)-1]
+			if top.block == root || blockKeyword(top.block.kind) != tag[1:] {
+				return nil, fmt.Errorf("unexpected {{%s}}", tag)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			appendNode(&node{kind: nodeExpr, text: tag})
+		}
+	}
+	if len(stack) > 1 {
+		top := stack[len(stack)-1].block
+		return nil, fmt.Errorf("unclosed {{#%s %s}}", blockKeyword(top.kind), top.name)
+	}
+	if last < len(content) {
+		appendNode(&node{kind: nodeText, text: content[last:]})
+	}
+	return root.children, nil
+}
+
+func blockKeyword(kind nodeKind) string {
+	switch kind {
+	case nodeIf:
+		return "if"
+	case nodeUnless:
+		return "unless"
+	case nodeEach:
+		return "each"
+	}
+	return ""
+}
+
+// render writes nodes to b. scope holds the loop variables "this" and
+// "@index" inside {{#each}}; plain tags naming them are substituted here.
+func (o *renderer) render(b *strings.Builder, nodes []*node, scope map[string]string) (err error) {
+	for _, n := range nodes {
+		switch n.kind {
+		case nodeText:
+			text := n.text
+			if scope != nil {
+				text = tagPattern.ReplaceAllStringFunc(text, func(tag string) string {
+					if value, ok := scope[strings.TrimSpace(tag[2:len(tag)-2])]; ok {
+						return o.placeholder(value)
+					}
+					return tag
+				})
+			}
+			b.WriteString(text)
+		case nodeExpr:
+			var value string
+			if value, err = o.evaluate(n.text, scope); err != nil {
+				return
+			}
+			b.WriteString(o.placeholder(value))
+		case nodeIf, nodeUnless:
+			value, _ := o.lookup(n.name, scope)
+			branch := n.children
+			if isTruthy(value) == (n.kind == nodeUnless) {
+				branch = n.orElse
+			}
+			if err = o.render(b, branch, scope); err != nil {
+				return
+			}
+		case nodeEach:
+			value, _ := o.lookup(n.name, scope)
+			items := parseList(value)
+			if len(items) == 0 {
+				if err = o.render(b, n.orElse, scope); err != nil {
+					return
+				}
+				continue
+			}
+			for i, item := range items {
+				itemScope := map[string]string{}
+				for k, v := range scope {
+					itemScope[k] = v
+				}
+				itemScope["this"] = item
+				itemScope["@index"] = strconv.Itoa(i)
+				if err = o.render(b, n.children, itemScope); err != nil {
+					return
+				}
+			}
+		}
+	}
+	return
+}
+
+func (o *renderer) lookup(name string, scope map[string]string) (string, bool) {
+	if value, ok := scope[name]; ok {
+		return value, true
+	}
+	if name == "input" {
+		return o.input, true
+	}
+	value, ok := o.variables[name]
+	return value, ok
+}
+
+// evaluate resolves "name|filter|filter:arg".
+func (o *renderer) evaluate(expr string, scope map[string]string) (ret string, err error) {
+	parts := splitUnquoted(expr, '|')
+	name := strings.TrimSpace(parts[0])
+	value, found := o.lookup(name, scope)
+
+	for _, part := range parts[1:] {
+		filter, arg, hasArg := strings.Cut(strings.TrimSpace(part), ":")
+		if hasArg {
+			if arg, err = unquote(strings.TrimSpace(arg)); err != nil {
+				return "", fmt.Errorf("filter %s in {{%s}}: %v", filter, expr, err)
+			}
+		}
+		if filter == "default" {
+			if value == "" {
+				value = arg
+				found = true
+			}
+			continue
+		}
+		if !found {
+			return "", fmt.Errorf("missing required variable: %s", name)
+		}
+		if value, err = applyFilter(filter, arg, hasArg, value); err != nil {
+			return "", fmt.Errorf("filter %s in {{%s}}: %v", filter, expr, err)
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("missing required variable: %s", name)
+	}
+	return value, nil
+}
+
+func applyFilter(filter string, arg string, hasArg bool, value string) (string, error) {
+	switch filter {
+	case "truncate":
+		limit, err := strconv.Atoi(arg)
+		if err != nil || limit < 0 {
+			return "", fmt.Errorf("expected a character count, got %q", arg)
+		}
+		if utf8.RuneCountInString(value) > limit {
+			value = string([]rune(value)[:limit])
+		}
+		return value, nil
+	case "join":
+		separator := ", "
+		if hasArg {
+			separator = arg
+		}
+		return strings.Join(parseList(value), separator), nil
+	default:
+		if hasArg {
+			return "", fmt.Errorf("unknown filter (supported: default, truncate, join, upper, lower, title, trim)")
+		}
+		// An empty value has nothing to transform, and the text plugin
+		// rejects empty input.
+		if value == "" {
+			return "", nil
+		}
+		return textPlugin.Apply(filter, value)
+	}
+}
+
+// isTruthy treats missing, blank, "false", "0", "no" and "off" values as false.
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "false", "0", "no", "off":
+		return false
+	}
+	return true
+}
+
+// parseList reads a JSON array or a comma separated list.
+func parseList(value string) (ret []string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	if strings.HasPrefix(value, "[") {
+		var items []any
+		if err := json.Unmarshal([]byte(value), &items); err == nil {
+			for _, item := range items {
+				if s, ok := item.(string); ok {
+					ret = append(ret, s)
+				} else {
+					data, _ := json.Marshal(item)
+					ret = append(ret, string(data))
+				}
+			}
+			return
+		}
+	}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+// splitUnquoted splits s on sep, ignoring separators inside double quotes.
+func splitUnquoted(s string, sep rune) (ret []string) {
+	var current strings.Builder
+	inQuotes, escaped := false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == sep && !inQuotes:
+			ret = append(ret, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	return append(ret, current.String())
+}
+
+func unquote(arg string) (string, error) {
+	if strings.HasPrefix(arg, `"`) {
+		return strconv.Unquote(arg)
+	}
+	return arg, nil
+}
+
+// ReferencesInput reports whether content places the input, either as
+// {{input}} or through a filter expression such as {{input|truncate:2000}}.
+func ReferencesInput(content string) bool {
+	for _, match := range tagPattern.FindAllStringSubmatch(content, -1) {
+		name, _, _ := strings.Cut(match[1], "|")
+		if strings.TrimSpace(name) == "input" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyTemplate_Syntax(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		vars        map[string]string
+		input       string
+		want        string
+		errContains string
+	}{
+		{
+			name:     "if with variable set",
+			template: "{{#if focus}}Focus on {{focus}}.{{else}}Cover everything.{{/if}}",
+			vars:     map[string]string{"focus": "security"},
+			want:     "Focus on security.",
+		},
+		{
+			name:     "if with variable missing takes else branch",
+			template: "{{#if focus}}Focus on {{focus}}.{{else}}Cover everything.{{/if}}",
+			want:     "Cover everything.",
+		},
+		{
+			name:     "unless with false value",
+			template: "{{#unless brief}}Be thorough.{{/unless}}",
+			vars:     map[string]string{"brief": "false"},
+			want:     "Be thorough.",
+		},
+		{
+			name:     "default filter",
+			template: `Audience: {{audience|default:"engineers"}}`,
+			want:     "Audience: engineers",
+		},
+		{
+			name:     "filter chain reuses text plugin",
+			template: "{{input|upper|truncate:5}}",
+			input:    "hello world",
+			want:     "HELLO",
+		},
+		{
+			name:     "each over comma list",
+			template: "{{#each topics}}- {{this}} ({{@index}})\n{{/each}}",
+			vars:     map[string]string{"topics": "go, rust"},
+			want:     "- go (0)\n- rust (1)\n",
+		},
+		{
+			name:     "each over JSON list with else",
+			template: `{{#each topics}}[{{this|upper}}]{{else}}none{{/each}}|{{#each empty}}x{{else}}none{{/each}}`,
+			vars:     map[string]string{"topics": `["a","b"]`, "empty": "[]"},
+			want:     "[A][B]|none",
+		},
+		{
+			name:     "nested blocks",
+			template: "{{#if show}}{{#each items}}{{#if this}}{{this}};{{/if}}{{/each}}{{/if}}",
+			vars:     map[string]string{"show": "yes", "items": "a,b"},
+			want:     "a;b;",
+		},
+		{
+			name:     "join filter",
+			template: `{{topics|join:" / "}}`,
+			vars:     map[string]string{"topics": "a,b,c"},
+			want:     "a / b / c",
+		},
+		{
+			name:     "filtered values are not scanned for tags",
+			template: "{{input|trim}}",
+			input:    "  {{missing}}  ",
+			want:     "{{missing}}",
+		},
+		{
+			name:     "filter inside plugin call",
+			template: "{{plugin:text:upper:{{name|trim}}}}",
+			vars:     map[string]string{"name": " bob "},
+			want:     "BOB",
+		},
+		{
+			name:        "missing variable without default",
+			template:    "{{name|upper}}",
+			errContains: "missing required variable: name",
+		},
+		{
+			name:        "unknown filter",
+			template:    "{{name|reverse}}",
+			vars:        map[string]string{"name": "x"},
+			errContains: "unknown text operation",
+		},
+		{
+			name:        "unclosed block",
+			template:    "{{#if a}}text",
+			errContains: "unclosed {{#if a}}",
+		},
+		{
+			name:        "mismatched close",
+			template:    "{{#if a}}text{{/each}}",
+			errContains: "unexpected {{/each}}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyTemplate(tt.template, tt.vars, tt.input)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferencesInput(t *testing.T) {
+	if !ReferencesInput("a {{ input | truncate:10 }}") {
+		t.Error("expected filtered input to be detected")
+	}
+	if ReferencesInput("{{inputs}} {{plugin:text:upper:input}}") {
+		t.Error("did not expect other tags to count as input")
+	}
+}
@@ -16,6 +16,7 @@ var (
 	filePlugin     = &FilePlugin{}
 	fetchPlugin    = &FetchPlugin{}
 	sysPlugin      = &SysPlugin{}
+	gitPlugin      = &GitPlugin{}
 )
 
 var extensionManager *ExtensionManager
@@ -30,6 +31,14 @@ func init() {
 	// Extensions will work if registry exists, otherwise they'll just fail gracefully
 }
 
+// ShutdownExtensions stops the rpc extensions started while applying
+// templates. It is called once at the end of a run.
+func ShutdownExtensions() {
+	if extensionManager != nil {
+		extensionManager.Shutdown()
+	}
+}
+
 var pluginPattern = regexp.MustCompile(`\{\{plugin:([^:]+):([^:]+)(?::([^}]+))?\}\}`)
 var extensionPattern = regexp.MustCompile(`\{\{ext:([^:]+):([^:]+)(?::([^}]+))?\}\}`)
 
@@ -38,10 +47,16 @@ func debugf(format string, a ...interface{}) {
 }
 
 func ApplyTemplate(content string, variables map[string]string, input string) (string, error) {
-
-	var missingVars []string
 	r := regexp.MustCompile(`\{\{([^{}]+)\}\}`)
 
+	syntax := &renderer{variables: variables, input: input}
+	if hasSyntax(content) {
+		var err error
+		if content, err = syntax.renderSyntax(content); err != nil {
+			return "", err
+		}
+	}
+
 	debugf("Starting template processing\n")
 	for strings.Contains(content, "{{") {
 		matches := r.FindAllStringSubmatch(content, -1)
@@ -49,51 +64,27 @@ func ApplyTemplate(content string, variables map[string]string, input string) (s
 			break
 		}
 
-		replaced := false
 		for _, match := range matches {
 			fullMatch := match[0]
 			varName := match[1]
 
 			// Check if this is a plugin call
 			if strings.HasPrefix(varName, "plugin:") {
-				pluginMatches := pluginPattern.FindStringSubmatch(fullMatch)
-				if len(pluginMatches) >= 3 {
+				if pluginMatches := pluginPattern.FindStringSubmatch(fullMatch); len(pluginMatches) >= 3 {
 					namespace := pluginMatches[1]
 					operation := pluginMatches[2]
-					value := ""
-					if len(pluginMatches) == 4 {
-						value = pluginMatches[3]
-					}
+					value := pluginMatches[3]
 
 					debugf("\nPlugin call:\n")
 					debugf("  Namespace: %s\n", namespace)
 					debugf("  Operation: %s\n", operation)
 					debugf("  Value: %s\n", value)
 
-					var result string
-					var err error
-
-					switch namespace {
-					case "text":
-						debugf("Executing text plugin\n")
-						result, err = textPlugin.Apply(operation, value)
-					case "datetime":
-						debugf("Executing datetime plugin\n")
-						result, err = datetimePlugin.Apply(operation, value)
-					case "file":
-						debugf("Executing file plugin\n")
-						result, err = filePlugin.Apply(operation, value)
-						debugf("File plugin result: %#v\n", result)
-					case "fetch":
-						debugf("Executing fetch plugin\n")
-						result, err = fetchPlugin.Apply(operation, value)
-					case "sys":
-						debugf("Executing sys plugin\n")
-						result, err = sysPlugin.Apply(operation, value)
-					default:
-						return "", fmt.Errorf("unknown plugin namespace: %s", namespace)
+					plugin, err := lookupPlugin(namespace)
+					if err != nil {
+						return "", err
 					}
-
+					result, err := plugin.Apply(operation, value)
 					if err != nil {
 						debugf("Plugin error: %v\n", err)
 						return "", fmt.Errorf("plugin %s error: %v", namespace, err)
@@ -109,10 +100,7 @@ func ApplyTemplate(content string, variables map[string]string, input string) (s
 			if pluginMatches := extensionPattern.FindStringSubmatch(fullMatch); len(pluginMatches) >= 3 {
 				name := pluginMatches[1]
 				operation := pluginMatches[2]
-				value := ""
-				if len(pluginMatches) == 4 {
-					value = pluginMatches[3]
-				}
+				value := pluginMatches[3]
 
 				debugf("\nExtension call:\n")
 				debugf("  Name: %s\n", name)
@@ -125,7 +113,6 @@ func ApplyTemplate(content string, variables map[string]string, input string) (s
 				}
 
 				content = strings.ReplaceAll(content, fullMatch, result)
-				replaced = true
 				continue
 			}
 
@@ -133,112 +120,17 @@ func ApplyTemplate(content string, variables map[string]string, input string) (s
 			debugf("Processing variable: %s\n", varName)
 			if varName == "input" {
 				debugf("Replacing {{input}}\n")
-				replaced = true
 				content = strings.ReplaceAll(content, fullMatch, input)
+			} else if val, ok := variables[varName]; ok {
+				debugf("Replacing variable %s with value: %s\n", varName, val)
+				content = strings.ReplaceAll(content, fullMatch, val)
 			} else {
-				if val, ok := variables[varName]; !ok {
-					debugf("Missing variable: %s\n", varName)
-					missingVars = append(missingVars, varName)
-					return "", fmt.Errorf("missing required variable: %s", varName)
-				} else {
-					debugf("Replacing variable %s with value: %s\n", varName, val)
-					content = strings.ReplaceAll(content, fullMatch, val)
-					replaced = true
-				}
-			}
-			if !replaced {
-				return "", fmt.Errorf("template processing stuck - potential infinite loop")
-			}
-		}
-	}
-
-	debugf("Starting template processing\n")
-	for strings.Contains(content, "{{") {
-		matches := r.FindAllStringSubmatch(content, -1)
-		if len(matches) == 0 {
-			break
-		}
-
-		replaced := false
-		for _, match := range matches {
-			fullMatch := match[0]
-			varName := match[1]
-
-			// Check if this is a plugin call
-			if strings.HasPrefix(varName, "plugin:") {
-				pluginMatches := pluginPattern.FindStringSubmatch(fullMatch)
-				if len(pluginMatches) >= 3 {
-					namespace := pluginMatches[1]
-					operation := pluginMatches[2]
-					value := ""
-					if len(pluginMatches) == 4 {
-						value = pluginMatches[3]
-					}
-
-					debugf("\nPlugin call:\n")
-					debugf("  Namespace: %s\n", namespace)
-					debugf("  Operation: %s\n", operation)
-					debugf("  Value: %s\n", value)
-
-					var result string
-					var err error
-
-					switch namespace {
-					case "text":
-						debugf("Executing text plugin\n")
-						result, err = textPlugin.Apply(operation, value)
-					case "datetime":
-						debugf("Executing datetime plugin\n")
-						result, err = datetimePlugin.Apply(operation, value)
-					case "file":
-						debugf("Executing file plugin\n")
-						result, err = filePlugin.Apply(operation, value)
-						debugf("File plugin result: %#v\n", result)
-					case "fetch":
-						debugf("Executing fetch plugin\n")
-						result, err = fetchPlugin.Apply(operation, value)
-					case "sys":
-						debugf("Executing sys plugin\n")
-						result, err = sysPlugin.Apply(operation, value)
-					default:
-						return "", fmt.Errorf("unknown plugin namespace: %s", namespace)
-					}
-
-					if err != nil {
-						debugf("Plugin error: %v\n", err)
-						return "", fmt.Errorf("plugin %s error: %v", namespace, err)
-					}
-
-					debugf("Plugin result: %s\n", result)
-					content = strings.ReplaceAll(content, fullMatch, result)
-					debugf("Content after replacement: %s\n", content)
-					continue
-				}
-			}
-
-			// Handle regular variables and input
-			debugf("Processing variable: %s\n", varName)
-			if varName == "input" {
-				debugf("Replacing {{input}}\n")
-				replaced = true
-				content = strings.ReplaceAll(content, fullMatch, input)
-			} else {
-				if val, ok := variables[varName]; !ok {
-					debugf("Missing variable: %s\n", varName)
-					missingVars = append(missingVars, varName)
-					return "", fmt.Errorf("missing required variable: %s", varName)
-				} else {
-					debugf("Replacing variable %s with value: %s\n", varName, val)
-					content = strings.ReplaceAll(content, fullMatch, val)
-					replaced = true
-				}
-			}
-			if !replaced {
-				return "", fmt.Errorf("template processing stuck - potential infinite loop")
+				debugf("Missing variable: %s\n", varName)
+				return "", fmt.Errorf("missing required variable: %s", varName)
 			}
 		}
 	}
 
 	debugf("Template processing complete\n")
-	return content, nil
+	return syntax.restore(content), nil
 }
@@ -0,0 +1,242 @@
+// Package batch runs a pattern over many inputs with a pool of workers.
+//
+// Inputs come either from a file glob (one item per file) or from a JSONL file
+// with one item per line. Results are written next to a manifest so that a
+// re-run skips the items that already completed successfully.
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+
+	// ManifestFileName is the manifest written into a batch output directory.
+	ManifestFileName = "manifest.jsonl"
+)
+
+// Item is one unit of work. Variables are merged over the pattern variables
+// given on the command line.
+type Item struct {
+	ID        string            `json:"id"`
+	Input     string            `json:"input"`
+	Variables map[string]string `json:"variables,omitempty"`
+	Source    string            `json:"-"`
+}
+
+// Result is recorded in the manifest for every processed item.
+type Result struct {
+	ID       string  `json:"id"`
+	Source   string  `json:"source,omitempty"`
+	Status   string  `json:"status"`
+	Output   string  `json:"output,omitempty"`
+	File     string  `json:"file,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_seconds"`
+}
+
+var unsafeIDChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// LoadItems reads batch items from a JSONL file (".jsonl" extension) or from
+// all files matching a glob. A directory is treated as all files inside it.
+func LoadItems(spec string) (ret []*Item, err error) {
+	if strings.EqualFold(filepath.Ext(spec), ".jsonl") {
+		return loadJSONL(spec)
+	}
+
+	pattern := spec
+	if info, statErr := os.Stat(spec); statErr == nil && info.IsDir() {
+		pattern = filepath.Join(spec, "*")
+	}
+
+	var matches []string
+	if matches, err = filepath.Glob(pattern); err != nil {
+		err = fmt.Errorf("invalid batch glob %s: %w", spec, err)
+		return
+	}
+	sort.Strings(matches)
+
+	base := globBase(pattern)
+	seen := map[string]bool{}
+	for _, match := range matches {
+		var info os.FileInfo
+		if info, err = os.Stat(match); err != nil {
+			return
+		}
+		if info.IsDir() {
+			continue
+		}
+		var content []byte
+		if content, err = os.ReadFile(match); err != nil {
+			return
+		}
+		rel, relErr := filepath.Rel(base, match)
+		if relErr != nil {
+			rel = filepath.Base(match)
+		}
+		id := uniqueID(sanitizeID(strings.TrimSuffix(rel, filepath.Ext(rel))), seen)
+		ret = append(ret, &Item{ID: id, Input: string(content), Source: match})
+	}
+	if len(ret) == 0 {
+		err = fmt.Errorf("no input files match %s", spec)
+	}
+	return
+}
+
+func loadJSONL(path string) (ret []*Item, err error) {
+	var file *os.File
+	if file, err = os.Open(path); err != nil {
+		return
+	}
+	defer file.Close()
+
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		item := &Item{}
+		if err = json.Unmarshal([]byte(line), item); err != nil {
+			err = fmt.Errorf("%s:%d: invalid batch item: %w", path, lineNumber, err)
+			return
+		}
+		if item.ID == "" {
+			item.ID = fmt.Sprintf("line-%d", lineNumber)
+		}
+		item.ID = sanitizeID(item.ID)
+		if seen[item.ID] {
+			err = fmt.Errorf("%s:%d: duplicate batch item id %s", path, lineNumber, item.ID)
+			return
+		}
+		seen[item.ID] = true
+		item.Source = fmt.Sprintf("%s:%d", path, lineNumber)
+		ret = append(ret, item)
+	}
+	err = scanner.Err()
+	return
+}
+
+// globBase returns the directory part of a glob before its first wildcard.
+func globBase(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+func sanitizeID(id string) string {
+	id = unsafeIDChars.ReplaceAllString(filepath.ToSlash(id), "_")
+	return strings.Trim(id, "_")
+}
+
+func uniqueID(id string, seen map[string]bool) string {
+	candidate := id
+	for i := 2; seen[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", id, i)
+	}
+	seen[candidate] = true
+	return candidate
+}
+
+// Runner processes items concurrently. Workers bounds the parallelism and
+// RatePerMinute, when positive, bounds how often Process is started.
+type Runner struct {
+	Workers       int
+	RatePerMinute int
+	Process       func(ctx context.Context, item *Item) (string, error)
+	Output        *Output
+	// Progress is called after each item, from a single goroutine at a time.
+	Progress func(done, total int, result *Result)
+}
+
+// Run processes all items not already completed in the output manifest and
+// returns the number of processed and skipped items.
+func (o *Runner) Run(ctx context.Context, items []*Item) (processed, skipped int, err error) {
+	var pending []*Item
+	for _, item := range items {
+		if o.Output.IsCompleted(item.ID) {
+			skipped++
+		} else {
+			pending = append(pending, item)
+		}
+	}
+
+	workers := max(o.Workers, 1)
+	jobs := make(chan *Item)
+
+	var throttle <-chan time.Time
+	if o.RatePerMinute > 0 {
+		ticker := time.NewTicker(time.Minute / time.Duration(o.RatePerMinute))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var writeErr error
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				start := time.Now()
+				output, processErr := o.Process(ctx, item)
+				result := &Result{ID: item.ID, Source: item.Source, Status: StatusOK, Duration: time.Since(start).Seconds()}
+				if processErr != nil {
+					result.Status = StatusError
+					result.Error = processErr.Error()
+				}
+
+				mu.Lock()
+				if recordErr := o.Output.Record(result, output); recordErr != nil && writeErr == nil {
+					writeErr = recordErr
+				}
+				processed++
+				if o.Progress != nil {
+					o.Progress(processed, len(pending), result)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i, item := range pending {
+		if throttle != nil && i > 0 {
+			select {
+			case <-throttle:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- item
+	}
+	close(jobs)
+	wg.Wait()
+
+	if writeErr != nil {
+		err = writeErr
+	} else {
+		err = ctx.Err()
+	}
+	return
+}
@@ -0,0 +1,136 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLoadItems_Glob(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "first")
+	writeFile(t, filepath.Join(dir, "b.txt"), "second")
+	writeFile(t, filepath.Join(dir, "c.md"), "ignored")
+
+	items, err := LoadItems(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		t.Fatalf("LoadItems failed: %v", err)
+	}
+	if len(items) != 2 || items[0].ID != "a" || items[0].Input != "first" || items[1].ID != "b" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+
+	if _, err = LoadItems(filepath.Join(dir, "*.none")); err == nil {
+		t.Error("expected error for glob without matches")
+	}
+}
+
+func TestLoadItems_JSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inputs.jsonl")
+	writeFile(t, path, `{"id":"one","input":"hello","variables":{"lang":"fr"}}
+
+{"input":"no id"}
+`)
+
+	items, err := LoadItems(path)
+	if err != nil {
+		t.Fatalf("LoadItems failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if items[0].Variables["lang"] != "fr" {
+		t.Errorf("expected per-line variables, got %v", items[0].Variables)
+	}
+	if items[1].ID != "line-3" {
+		t.Errorf("expected generated id line-3, got %s", items[1].ID)
+	}
+
+	writeFile(t, path, `{"id":"x","input":"1"}
+{"id":"x","input":"2"}`)
+	if _, err = LoadItems(path); err == nil {
+		t.Error("expected error for duplicate ids")
+	}
+}
+
+func TestRunner_ResumesCompletedItems(t *testing.T) {
+	outDir := filepath.Join(t.TempDir(), "out")
+	items := []*Item{{ID: "a", Input: "1"}, {ID: "b", Input: "2"}, {ID: "c", Input: "fail"}}
+
+	var calls atomic.Int32
+	process := func(_ context.Context, item *Item) (string, error) {
+		calls.Add(1)
+		if item.Input == "fail" {
+			return "", errors.New("boom")
+		}
+		return "result " + item.Input, nil
+	}
+
+	output, err := NewOutput(outDir, "")
+	if err != nil {
+		t.Fatalf("NewOutput failed: %v", err)
+	}
+	runner := &Runner{Workers: 2, Process: process, Output: output}
+	processed, skipped, err := runner.Run(context.Background(), items)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if processed != 3 || skipped != 0 {
+		t.Fatalf("expected 3 processed, got %d processed %d skipped", processed, skipped)
+	}
+	content, err := os.ReadFile(filepath.Join(outDir, "a.md"))
+	if err != nil || string(content) != "result 1" {
+		t.Fatalf("expected per-item output file, got %q (%v)", content, err)
+	}
+
+	// A fresh run only retries the failed item.
+	if output, err = NewOutput(outDir, ""); err != nil {
+		t.Fatalf("NewOutput failed: %v", err)
+	}
+	calls.Store(0)
+	runner = &Runner{Workers: 2, Process: process, Output: output}
+	if processed, skipped, err = runner.Run(context.Background(), items); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if processed != 1 || skipped != 2 || calls.Load() != 1 {
+		t.Errorf("expected only the failed item to be retried, got %d processed %d skipped %d calls", processed, skipped, calls.Load())
+	}
+}
+
+func TestOutput_JSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	output, err := NewOutput(path, "")
+	if err != nil {
+		t.Fatalf("NewOutput failed: %v", err)
+	}
+	if err = output.Record(&Result{ID: "a", Status: StatusOK}, "answer"); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err = output.Record(&Result{ID: "b", Status: StatusError, Error: "boom"}, ""); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), `"output":"answer"`) || !strings.Contains(string(content), `"error":"boom"`) {
+		t.Errorf("unexpected JSONL content: %s", content)
+	}
+
+	reloaded, err := NewOutput(path, "")
+	if err != nil {
+		t.Fatalf("NewOutput failed: %v", err)
+	}
+	if !reloaded.IsCompleted("a") || reloaded.IsCompleted("b") {
+		t.Error("expected only successful items to be completed after reload")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
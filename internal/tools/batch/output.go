@@ -0,0 +1,121 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Output writes batch results either to a JSONL file (one Result per line,
+// output included) or to a directory with one file per item plus a manifest.
+// In both cases the JSONL file doubles as the manifest used for resuming.
+type Output struct {
+	Path      string
+	Extension string
+
+	manifestPath string
+	toDirectory  bool
+	completed    map[string]bool
+	results      map[string]*Result
+}
+
+// NewOutput prepares the output location and loads the results of previous runs.
+// Paths ending in ".jsonl" are written as a single JSONL file, anything else is
+// used as a directory.
+func NewOutput(path string, extension string) (ret *Output, err error) {
+	ret = &Output{Path: path, Extension: extension, completed: map[string]bool{}, results: map[string]*Result{}}
+	if ret.Extension == "" {
+		ret.Extension = ".md"
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		ret.manifestPath = path
+		if dir := filepath.Dir(path); dir != "" {
+			if err = os.MkdirAll(dir, 0755); err != nil {
+				return
+			}
+		}
+	} else {
+		ret.toDirectory = true
+		ret.manifestPath = filepath.Join(path, ManifestFileName)
+		if err = os.MkdirAll(path, 0755); err != nil {
+			return
+		}
+	}
+
+	err = ret.loadManifest()
+	return
+}
+
+func (o *Output) loadManifest() (err error) {
+	var file *os.File
+	if file, err = os.Open(o.manifestPath); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var result Result
+		if json.Unmarshal(scanner.Bytes(), &result) != nil {
+			// A partially written last line from an interrupted run is ignored.
+			continue
+		}
+		// Later lines win, so a retried item that succeeded is completed.
+		o.completed[result.ID] = result.Status == StatusOK
+		o.results[result.ID] = &result
+	}
+	return scanner.Err()
+}
+
+// IsCompleted reports whether a previous run already processed the item successfully.
+func (o *Output) IsCompleted(id string) bool {
+	return o.completed[id]
+}
+
+// Result returns the latest recorded result of an item, or nil.
+func (o *Output) Result(id string) *Result {
+	return o.results[id]
+}
+
+// Record appends the result to the manifest and, for directory outputs,
+// writes the item output to its own file.
+func (o *Output) Record(result *Result, output string) (err error) {
+	if result.Status == StatusOK {
+		if o.toDirectory {
+			fileName := filepath.Join(o.Path, filepath.FromSlash(result.ID)+o.Extension)
+			if err = os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+				return
+			}
+			if err = os.WriteFile(fileName, []byte(output), 0644); err != nil {
+				return fmt.Errorf("could not write output for %s: %w", result.ID, err)
+			}
+			result.File = fileName
+		} else {
+			result.Output = output
+		}
+	}
+
+	var line []byte
+	if line, err = json.Marshal(result); err != nil {
+		return
+	}
+	var file *os.File
+	if file, err = os.OpenFile(o.manifestPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+		return
+	}
+	defer file.Close()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		return
+	}
+	o.completed[result.ID] = result.Status == StatusOK
+	o.results[result.ID] = result
+	return
+}
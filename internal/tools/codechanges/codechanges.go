@@ -0,0 +1,253 @@
+// Package codechanges applies the file changes emitted by patterns such as
+// create_coding_feature to a git working tree. Changes are resolved into a
+// Plan first, which can be shown as a unified diff for approval. Applying a
+// plan records a snapshot of the touched files, so Undo restores them.
+package codechanges
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/tools/githelper"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Edit is the change of one file. Renamed files are one edit at their new
+// path with From set to the old path.
+type Edit struct {
+	// Path is the slash separated path relative to the worktree root
+	Path string
+	// From is the old path of a renamed file
+	From string
+
+	// Existed and Before are the state before the changes, Exists and After
+	// the state after them
+	Existed bool
+	Before  string
+	Exists  bool
+	After   string
+	Mode    fs.FileMode
+}
+
+// Operation describes the edit: create, update, delete or rename.
+func (o *Edit) Operation() string {
+	switch {
+	case o.From != "":
+		return domain.FileRename
+	case !o.Exists:
+		return domain.FileDelete
+	case !o.Existed:
+		return domain.FileCreate
+	default:
+		return domain.FileUpdate
+	}
+}
+
+// Plan is a set of file changes resolved against a worktree.
+type Plan struct {
+	Worktree *githelper.Worktree
+	Edits    []*Edit
+}
+
+// NewPlan resolves changes against the worktree. It refuses paths outside
+// the worktree, inside .git or ignored by git, and changes that do not apply,
+// such as deleting a missing file or a patch whose lines are not found.
+// Several changes of the same file are combined.
+func NewPlan(worktree *githelper.Worktree, changes []domain.FileChange) (ret *Plan, err error) {
+	ret = &Plan{Worktree: worktree}
+	edits := map[string]*Edit{}
+	renamed := map[string]bool{}
+
+	current := func(path string) (edit *Edit, err error) {
+		if renamed[path] {
+			return nil, fmt.Errorf("%s was renamed by an earlier change", path)
+		}
+		if edit = edits[path]; edit != nil {
+			return
+		}
+		if edit, err = ret.load(path); err != nil {
+			return
+		}
+		edits[path] = edit
+		ret.Edits = append(ret.Edits, edit)
+		return
+	}
+
+	for i, change := range changes {
+		var path string
+		if path, err = ret.checkPath(change.Path); err != nil {
+			return nil, fmt.Errorf("file change %d: %w", i+1, err)
+		}
+		var edit *Edit
+		if edit, err = current(path); err != nil {
+			return nil, fmt.Errorf("file change %d: %w", i+1, err)
+		}
+
+		switch change.Operation {
+		case domain.FileCreate, domain.FileUpdate:
+			edit.Exists, edit.After = true, change.Content
+		case domain.FileDelete:
+			if !edit.Exists {
+				return nil, fmt.Errorf("file change %d: cannot delete %s, it does not exist", i+1, path)
+			}
+			edit.Exists, edit.After = false, ""
+		case domain.FilePatch:
+			if edit.Existed && !edit.Exists {
+				return nil, fmt.Errorf("file change %d: cannot patch %s, it is deleted", i+1, path)
+			}
+			edit.Exists = true
+			if edit.After, err = ApplyPatch(edit.After, change.Diff); err != nil {
+				return nil, fmt.Errorf("file change %d: patch of %s: %w", i+1, path, err)
+			}
+		case domain.FileRename:
+			if !edit.Exists {
+				return nil, fmt.Errorf("file change %d: cannot rename %s, it does not exist", i+1, path)
+			}
+			var target string
+			if target, err = ret.checkPath(change.NewPath); err != nil {
+				return nil, fmt.Errorf("file change %d: %w", i+1, err)
+			}
+			if edits[target] != nil || renamed[target] {
+				return nil, fmt.Errorf("file change %d: %s is changed by another change", i+1, target)
+			}
+			var targetEdit *Edit
+			if targetEdit, err = ret.load(target); err != nil {
+				return nil, fmt.Errorf("file change %d: %w", i+1, err)
+			}
+			if targetEdit.Existed {
+				return nil, fmt.Errorf("file change %d: cannot rename %s to %s, it exists", i+1, path, target)
+			}
+			edit.Path, edit.From = target, path
+			if change.Content != "" {
+				edit.After = change.Content
+			}
+			delete(edits, path)
+			edits[target] = edit
+			renamed[path] = true
+		}
+	}
+	return
+}
+
+// load reads the current state of a file.
+func (o *Plan) load(path string) (ret *Edit, err error) {
+	ret = &Edit{Path: path, Mode: 0644}
+	absPath := o.absPath(path)
+	var info os.FileInfo
+	if info, err = os.Stat(absPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ret, nil
+		}
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	var data []byte
+	if data, err = os.ReadFile(absPath); err != nil {
+		return
+	}
+	ret.Existed, ret.Exists, ret.Before, ret.After, ret.Mode = true, true, string(data), string(data), info.Mode().Perm()
+	return
+}
+
+// checkPath returns the cleaned slash path of a change, or an error if the
+// change must not touch it.
+func (o *Plan) checkPath(path string) (ret string, err error) {
+	ret = filepath.ToSlash(filepath.Clean(filepath.FromSlash(path)))
+	if ret == "." || filepath.IsAbs(path) || ret == ".." || strings.HasPrefix(ret, "../") {
+		return "", fmt.Errorf("refusing to change %s: it is outside the git worktree %s", path, o.Worktree.Root)
+	}
+	if first := strings.SplitN(ret, "/", 2)[0]; first == ".git" {
+		return "", fmt.Errorf("refusing to change %s: it is inside the .git directory", path)
+	}
+	if o.Worktree.IsIgnored(ret, false) {
+		return "", fmt.Errorf("refusing to change %s: it is ignored by .gitignore", path)
+	}
+	if err = o.checkSymlinks(ret); err != nil {
+		return "", err
+	}
+	return
+}
+
+// checkSymlinks refuses paths whose existing part resolves outside the worktree.
+func (o *Plan) checkSymlinks(path string) (err error) {
+	var root string
+	if root, err = filepath.EvalSymlinks(o.Worktree.Root); err != nil {
+		return
+	}
+	existing := o.absPath(path)
+	for {
+		if _, statErr := os.Lstat(existing); statErr == nil {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	var resolved string
+	if resolved, err = filepath.EvalSymlinks(existing); err != nil {
+		return
+	}
+	if rel, relErr := filepath.Rel(root, resolved); relErr != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("refusing to change %s: it links outside the git worktree %s", path, o.Worktree.Root)
+	}
+	return
+}
+
+func (o *Plan) absPath(path string) string {
+	return filepath.Join(o.Worktree.Root, filepath.FromSlash(path))
+}
+
+// Changed returns the edits that change anything.
+func (o *Plan) Changed() (ret []*Edit) {
+	for _, edit := range o.Edits {
+		if edit.From != "" || edit.Existed != edit.Exists || edit.Exists && edit.Before != edit.After {
+			ret = append(ret, edit)
+		}
+	}
+	return
+}
+
+// Diff returns the changes as a unified diff like git diff.
+func (o *Plan) Diff() string {
+	var b strings.Builder
+	for _, edit := range o.Changed() {
+		from, to := "a/"+edit.Path, "b/"+edit.Path
+		if edit.From != "" {
+			from = "a/" + edit.From
+			fmt.Fprintf(&b, "rename from %s\nrename to %s\n", edit.From, edit.Path)
+		}
+		if !edit.Existed && edit.From == "" {
+			from = "/dev/null"
+		}
+		if !edit.Exists {
+			to = "/dev/null"
+		}
+		diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(edit.Before),
+			B:        difflib.SplitLines(edit.After),
+			FromFile: from,
+			ToFile:   to,
+			Context:  3,
+		})
+		b.WriteString(diff)
+	}
+	return b.String()
+}
+
+// Summary lists the changed files with their operation.
+func (o *Plan) Summary() string {
+	var lines []string
+	for _, edit := range o.Changed() {
+		if edit.From != "" {
+			lines = append(lines, fmt.Sprintf("%s %s -> %s", edit.Operation(), edit.From, edit.Path))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s %s", edit.Operation(), edit.Path))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
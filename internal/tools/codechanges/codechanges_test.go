@@ -0,0 +1,235 @@
+package codechanges
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/tools/githelper"
+	"github.com/go-git/go-git/v5"
+)
+
+// newWorktree creates a git repository with the given files.
+func newWorktree(t *testing.T, files map[string]string) *githelper.Worktree {
+	t.Helper()
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	worktree, err := githelper.OpenWorktree(dir)
+	if err != nil {
+		t.Fatalf("OpenWorktree() error = %v", err)
+	}
+	return worktree
+}
+
+func readFile(t *testing.T, worktree *githelper.Worktree, name string) (string, bool) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(worktree.Root, filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return "", false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), true
+}
+
+func TestApplyAndUndo(t *testing.T) {
+	worktree := newWorktree(t, map[string]string{
+		"main.go":    "package main\n\nfunc main() {\n\tprintln(\"a\")\n}\n",
+		"old.txt":    "old\n",
+		"docs/a.md":  "# A\n",
+		"update.txt": "before\n",
+	})
+
+	changes := []domain.FileChange{
+		{Operation: domain.FileCreate, Path: "subdir/nested.txt", Content: "Nested content"},
+		{Operation: domain.FileUpdate, Path: "update.txt", Content: "after\n"},
+		{Operation: domain.FileDelete, Path: "old.txt"},
+		{Operation: domain.FileRename, Path: "docs/a.md", NewPath: "docs/b.md"},
+		{Operation: domain.FilePatch, Path: "main.go", Diff: "@@ -4 +4 @@\n-\tprintln(\"a\")\n+\tprintln(\"b\")\n"},
+	}
+	plan, err := NewPlan(worktree, changes)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+
+	diff := plan.Diff()
+	for _, want := range []string{"--- /dev/null\n+++ b/subdir/nested.txt", "--- a/old.txt\n+++ /dev/null",
+		"rename from docs/a.md\nrename to docs/b.md", "+\tprintln(\"b\")"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Diff() does not contain %q:\n%s", want, diff)
+		}
+	}
+	if summary := plan.Summary(); !strings.Contains(summary, "rename docs/a.md -> docs/b.md") {
+		t.Errorf("Summary() = %q", summary)
+	}
+
+	applied, err := plan.Apply()
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if len(applied) != 5 {
+		t.Errorf("Apply() changed %d files, want 5", len(applied))
+	}
+	if content, _ := readFile(t, worktree, "subdir/nested.txt"); content != "Nested content" {
+		t.Errorf("nested.txt = %q", content)
+	}
+	if content, _ := readFile(t, worktree, "main.go"); !strings.Contains(content, `println("b")`) {
+		t.Errorf("main.go was not patched: %q", content)
+	}
+	if _, exists := readFile(t, worktree, "old.txt"); exists {
+		t.Errorf("old.txt was not deleted")
+	}
+	if _, exists := readFile(t, worktree, "docs/a.md"); exists {
+		t.Errorf("docs/a.md was not renamed")
+	}
+	if content, _ := readFile(t, worktree, "docs/b.md"); content != "# A\n" {
+		t.Errorf("docs/b.md = %q", content)
+	}
+
+	if _, err = Undo(worktree); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	for name, want := range map[string]string{"old.txt": "old\n", "docs/a.md": "# A\n", "update.txt": "before\n"} {
+		if content, _ := readFile(t, worktree, name); content != want {
+			t.Errorf("after Undo() %s = %q, want %q", name, content, want)
+		}
+	}
+	for _, name := range []string{"subdir/nested.txt", "docs/b.md"} {
+		if _, exists := readFile(t, worktree, name); exists {
+			t.Errorf("after Undo() %s still exists", name)
+		}
+	}
+	if _, err = Undo(worktree); err == nil {
+		t.Errorf("Undo() without snapshots should fail")
+	}
+}
+
+func TestUndoRefusesModifiedFiles(t *testing.T) {
+	worktree := newWorktree(t, map[string]string{"a.txt": "a\n"})
+	plan, err := NewPlan(worktree, []domain.FileChange{{Operation: domain.FileUpdate, Path: "a.txt", Content: "b\n"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(worktree.Root, "a.txt"), []byte("edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Undo(worktree); err == nil || !strings.Contains(err.Error(), "a.txt") {
+		t.Errorf("Undo() error = %v, want a refusal naming a.txt", err)
+	}
+}
+
+func TestNewPlanRefusals(t *testing.T) {
+	worktree := newWorktree(t, map[string]string{
+		".gitignore": "secrets/\n*.log\n",
+		"a.txt":      "a\n",
+		"b.txt":      "b\n",
+	})
+
+	tests := []struct {
+		name   string
+		change domain.FileChange
+	}{
+		{"outside", domain.FileChange{Operation: domain.FileCreate, Path: "../escape.txt", Content: "x"}},
+		{"absolute", domain.FileChange{Operation: domain.FileCreate, Path: "/tmp/escape.txt", Content: "x"}},
+		{"git dir", domain.FileChange{Operation: domain.FileCreate, Path: ".git/config", Content: "x"}},
+		{"ignored dir", domain.FileChange{Operation: domain.FileCreate, Path: "secrets/key", Content: "x"}},
+		{"ignored file", domain.FileChange{Operation: domain.FileUpdate, Path: "debug.log", Content: "x"}},
+		{"delete missing", domain.FileChange{Operation: domain.FileDelete, Path: "missing.txt"}},
+		{"rename onto existing", domain.FileChange{Operation: domain.FileRename, Path: "a.txt", NewPath: "b.txt"}},
+		{"patch not applying", domain.FileChange{Operation: domain.FilePatch, Path: "a.txt", Diff: "@@ -1 +1 @@\n-z\n+y\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPlan(worktree, []domain.FileChange{tt.change}); err == nil {
+				t.Errorf("NewPlan() should refuse %+v", tt.change)
+			}
+		})
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		diff    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "exact",
+			content: "a\nb\nc\n",
+			diff:    "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+			want:    "a\nB\nc\n",
+		},
+		{
+			name:    "wrong line numbers",
+			content: "x\nx\nx\na\nb\nc\n",
+			diff:    "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+			want:    "x\nx\nx\na\nB\nc\n",
+		},
+		{
+			name:    "trailing whitespace",
+			content: "a  \nb\n",
+			diff:    "@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+			want:    "a\nc\n",
+		},
+		{
+			name:    "removed comment line",
+			content: "select 1;\n-- comment\nselect 2;\n",
+			diff:    "@@ -1,3 +1,2 @@\n select 1;\n--- comment\n select 2;\n",
+			want:    "select 1;\nselect 2;\n",
+		},
+		{
+			name:    "new file",
+			content: "",
+			diff:    "--- /dev/null\n+++ b/f\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+			want:    "one\ntwo\n",
+		},
+		{
+			name:    "two hunks",
+			content: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			diff:    "@@ -1,2 +1,2 @@\n-1\n+one\n 2\n@@ -7,2 +7,2 @@\n 7\n-8\n+eight\n",
+			want:    "one\n2\n3\n4\n5\n6\n7\neight\n",
+		},
+		{
+			name:    "not found",
+			content: "a\n",
+			diff:    "@@ -1 +1 @@\n-b\n+c\n",
+			wantErr: true,
+		},
+		{
+			name:    "no hunks",
+			content: "a\n",
+			diff:    "just text",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyPatch(tt.content, tt.diff)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyPatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package codechanges
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// hunk is a part of a unified diff: the lines it expects at OldStart and the
+// lines replacing them.
+type hunk struct {
+	OldStart int
+	Old      []string
+	New      []string
+
+	// line counts of the header, to tell removed lines like "-- comment"
+	// from the file headers of a following diff
+	oldCount, newCount int
+}
+
+func (o *hunk) complete() bool {
+	return len(o.Old) >= o.oldCount && len(o.New) >= o.newCount
+}
+
+func headerCount(value string) int {
+	if value == "" {
+		return 1
+	}
+	ret, _ := strconv.Atoi(value)
+	return ret
+}
+
+// ApplyPatch applies a unified diff to content. Models often get line numbers
+// wrong, so each hunk is searched nearest to its line number, after the
+// previous hunk, and matched ignoring trailing whitespace if it does not match
+// exactly.
+func ApplyPatch(content string, diff string) (ret string, err error) {
+	var hunks []hunk
+	if hunks, err = parseHunks(diff); err != nil {
+		return
+	}
+
+	lines, newline := splitLines(content)
+	position := 0
+	for i, h := range hunks {
+		start := -1
+		if len(h.Old) == 0 {
+			// pure insertion, e.g. into a new file
+			start = min(max(h.OldStart, position), len(lines))
+		} else {
+			expected := max(h.OldStart-1, position)
+			if start = findLines(lines, h.Old, position, expected, exactLine); start < 0 {
+				start = findLines(lines, h.Old, position, expected, trimmedLine)
+			}
+		}
+		if start < 0 {
+			return "", fmt.Errorf("hunk %d does not apply: the lines to change were not found", i+1)
+		}
+
+		replaced := make([]string, 0, len(lines)-len(h.Old)+len(h.New))
+		replaced = append(replaced, lines[:start]...)
+		replaced = append(replaced, h.New...)
+		replaced = append(replaced, lines[start+len(h.Old):]...)
+		lines = replaced
+		position = start + len(h.New)
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+	if content == "" {
+		newline = true
+	}
+	ret = strings.Join(lines, "\n")
+	if newline {
+		ret += "\n"
+	}
+	return
+}
+
+// parseHunks reads the hunks of a unified diff, skipping file headers.
+func parseHunks(diff string) (ret []hunk, err error) {
+	var current *hunk
+	for _, line := range strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n") {
+		if match := hunkHeader.FindStringSubmatch(line); match != nil {
+			start, _ := strconv.Atoi(match[1])
+			ret = append(ret, hunk{OldStart: start, oldCount: headerCount(match[2]), newCount: headerCount(match[4])})
+			current = &ret[len(ret)-1]
+			continue
+		}
+		if current == nil {
+			// headers like diff --git, index, --- and +++ before the first hunk
+			continue
+		}
+		switch {
+		case current.complete() && (strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "--- ") ||
+			strings.HasPrefix(line, "diff ")):
+			current = nil
+		case strings.HasPrefix(line, "+"):
+			current.New = append(current.New, line[1:])
+		case strings.HasPrefix(line, "-"):
+			current.Old = append(current.Old, line[1:])
+		case strings.HasPrefix(line, " "):
+			current.Old = append(current.Old, line[1:])
+			current.New = append(current.New, line[1:])
+		case strings.HasPrefix(line, `\`):
+			// \ No newline at end of file
+		case line == "":
+			// an empty context line whose leading space got lost
+			current.Old = append(current.Old, "")
+			current.New = append(current.New, "")
+		default:
+			return nil, fmt.Errorf("invalid line in hunk %d: %q", len(ret), line)
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no hunks found in the diff")
+	}
+	// the final newline of the diff is not an empty context line
+	for i := range ret {
+		ret[i].Old, ret[i].New = trimTrailingEmpty(ret[i].Old, ret[i].New)
+	}
+	return
+}
+
+// trimTrailingEmpty removes empty lines the hunks end with in both versions.
+func trimTrailingEmpty(before []string, after []string) ([]string, []string) {
+	for len(before) > 0 && len(after) > 0 && before[len(before)-1] == "" && after[len(after)-1] == "" {
+		before, after = before[:len(before)-1], after[:len(after)-1]
+	}
+	return before, after
+}
+
+func exactLine(a, b string) bool { return a == b }
+
+func trimmedLine(a, b string) bool {
+	return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t")
+}
+
+// findLines returns the index of want in lines at or after from, nearest to
+// expected, or -1.
+func findLines(lines []string, want []string, from int, expected int, equal func(a, b string) bool) int {
+	matches := func(start int) bool {
+		if start < from || start+len(want) > len(lines) {
+			return false
+		}
+		for i := range want {
+			if !equal(lines[start+i], want[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	for distance := 0; distance <= len(lines); distance++ {
+		if matches(expected - distance) {
+			return expected - distance
+		}
+		if matches(expected + distance) {
+			return expected + distance
+		}
+	}
+	return -1
+}
+
+// splitLines splits content into lines and reports whether it ends with a newline.
+func splitLines(content string) (lines []string, newline bool) {
+	if content == "" {
+		return nil, false
+	}
+	newline = strings.HasSuffix(content, "\n")
+	lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	return
+}
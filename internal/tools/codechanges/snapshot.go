@@ -0,0 +1,258 @@
+package codechanges
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/tools/githelper"
+)
+
+// MaxSnapshots is the number of applied change sets that can be undone.
+const MaxSnapshots = 20
+
+const manifestFile = "manifest.json"
+
+// snapshotEntry is the state of one file before changes were applied, and the
+// hash of its content after them to detect later modifications.
+type snapshotEntry struct {
+	Path    string      `json:"path"`
+	Existed bool        `json:"existed"`
+	Mode    fs.FileMode `json:"mode,omitempty"`
+	Backup  string      `json:"backup,omitempty"`
+	Exists  bool        `json:"exists"`
+	Hash    string      `json:"hash,omitempty"`
+}
+
+type snapshotManifest struct {
+	Created time.Time       `json:"created"`
+	Files   []snapshotEntry `json:"files"`
+}
+
+// SnapshotsDir returns the directory in which the snapshots of a worktree are
+// kept. It is inside .git, so git never shows it as a change.
+func SnapshotsDir(worktree *githelper.Worktree) string {
+	return filepath.Join(worktree.GitDir, "fabric", "undo")
+}
+
+// Apply records a snapshot of the files the plan changes and then changes
+// them. If writing fails the snapshot is restored. It returns the changed
+// edits.
+func (o *Plan) Apply() (ret []*Edit, err error) {
+	if ret = o.Changed(); len(ret) == 0 {
+		return
+	}
+
+	var snapshot string
+	if snapshot, err = o.snapshot(ret); err != nil {
+		return nil, fmt.Errorf("failed to record the undo snapshot: %w", err)
+	}
+
+	if err = o.write(ret); err != nil {
+		if _, restoreErr := restore(o.Worktree, snapshot, false); restoreErr != nil {
+			return nil, fmt.Errorf("%w, restoring the previous state failed as well: %v", err, restoreErr)
+		}
+		os.RemoveAll(snapshot)
+		return nil, err
+	}
+	pruneSnapshots(SnapshotsDir(o.Worktree), MaxSnapshots)
+	return
+}
+
+// snapshot saves the current state of the files edits touch.
+func (o *Plan) snapshot(edits []*Edit) (ret string, err error) {
+	base := SnapshotsDir(o.Worktree)
+	if err = os.MkdirAll(base, 0755); err != nil {
+		return
+	}
+	if ret, err = os.MkdirTemp(base, time.Now().UTC().Format("20060102T150405.000000000")+"-"); err != nil {
+		return
+	}
+	filesDir := filepath.Join(ret, "files")
+	if err = os.Mkdir(filesDir, 0755); err != nil {
+		return
+	}
+
+	manifest := snapshotManifest{Created: time.Now()}
+	add := func(path string, existed bool, before string, mode fs.FileMode, exists bool, after string) error {
+		entry := snapshotEntry{Path: path, Existed: existed, Exists: exists}
+		if existed {
+			entry.Mode = mode
+			entry.Backup = fmt.Sprintf("%d", len(manifest.Files))
+			if err := os.WriteFile(filepath.Join(filesDir, entry.Backup), []byte(before), 0600); err != nil {
+				return err
+			}
+		}
+		if exists {
+			entry.Hash = hash(after)
+		}
+		manifest.Files = append(manifest.Files, entry)
+		return nil
+	}
+	for _, edit := range edits {
+		if edit.From != "" {
+			if err = add(edit.From, true, edit.Before, edit.Mode, false, ""); err != nil {
+				return
+			}
+			if err = add(edit.Path, false, "", 0, true, edit.After); err != nil {
+				return
+			}
+			continue
+		}
+		if err = add(edit.Path, edit.Existed, edit.Before, edit.Mode, edit.Exists, edit.After); err != nil {
+			return
+		}
+	}
+
+	var data []byte
+	if data, err = json.MarshalIndent(manifest, "", "  "); err != nil {
+		return
+	}
+	err = os.WriteFile(filepath.Join(ret, manifestFile), data, 0644)
+	return
+}
+
+// write changes the files of the edits.
+func (o *Plan) write(edits []*Edit) (err error) {
+	for _, edit := range edits {
+		if edit.Exists {
+			path := o.absPath(edit.Path)
+			if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return
+			}
+			if err = os.WriteFile(path, []byte(edit.After), edit.Mode); err != nil {
+				return
+			}
+		}
+		if edit.From != "" {
+			if err = os.Remove(o.absPath(edit.From)); err != nil {
+				return
+			}
+		} else if !edit.Exists {
+			if err = os.Remove(o.absPath(edit.Path)); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Undo restores the files changed by the most recently applied plan of the
+// worktree and removes its snapshot. It refuses to overwrite files that were
+// modified after the changes were applied. It returns the restored paths.
+func Undo(worktree *githelper.Worktree) (ret []string, err error) {
+	var snapshots []string
+	if snapshots, err = listSnapshots(SnapshotsDir(worktree)); err != nil {
+		return
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("there are no applied file changes to undo in %s", worktree.Root)
+	}
+	snapshot := snapshots[len(snapshots)-1]
+	if ret, err = restore(worktree, snapshot, true); err != nil {
+		return
+	}
+	err = os.RemoveAll(snapshot)
+	return
+}
+
+// restore brings back the files of a snapshot. With check it first verifies
+// that the files are still as the changes left them.
+func restore(worktree *githelper.Worktree, snapshot string, check bool) (ret []string, err error) {
+	var data []byte
+	if data, err = os.ReadFile(filepath.Join(snapshot, manifestFile)); err != nil {
+		return
+	}
+	var manifest snapshotManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid undo snapshot %s: %w", snapshot, err)
+	}
+
+	if check {
+		var modified []string
+		for _, entry := range manifest.Files {
+			current, readErr := os.ReadFile(filepath.Join(worktree.Root, filepath.FromSlash(entry.Path)))
+			exists := readErr == nil
+			if readErr != nil && !errors.Is(readErr, fs.ErrNotExist) {
+				return nil, readErr
+			}
+			if exists != entry.Exists || exists && hash(string(current)) != entry.Hash {
+				modified = append(modified, entry.Path)
+			}
+		}
+		if len(modified) > 0 {
+			return nil, fmt.Errorf("cannot undo, these files were modified after the changes were applied: %s",
+				strings.Join(modified, ", "))
+		}
+	}
+
+	for _, entry := range manifest.Files {
+		path := filepath.Join(worktree.Root, filepath.FromSlash(entry.Path))
+		if !entry.Existed {
+			if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return
+			}
+			err = nil
+			ret = append(ret, entry.Path)
+			continue
+		}
+		var content []byte
+		if content, err = os.ReadFile(filepath.Join(snapshot, "files", entry.Backup)); err != nil {
+			return
+		}
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return
+		}
+		if err = os.WriteFile(path, content, entry.Mode); err != nil {
+			return
+		}
+		if err = os.Chmod(path, entry.Mode); err != nil {
+			return
+		}
+		ret = append(ret, entry.Path)
+	}
+	return
+}
+
+// listSnapshots returns the snapshot directories, oldest first.
+func listSnapshots(base string) (ret []string, err error) {
+	var entries []os.DirEntry
+	if entries, err = os.ReadDir(base); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ret = append(ret, filepath.Join(base, entry.Name()))
+		}
+	}
+	sort.Strings(ret)
+	return
+}
+
+// pruneSnapshots removes the oldest snapshots beyond keep.
+func pruneSnapshots(base string, keep int) {
+	snapshots, err := listSnapshots(base)
+	if err != nil {
+		return
+	}
+	for len(snapshots) > keep {
+		os.RemoveAll(snapshots[0])
+		snapshots = snapshots[1:]
+	}
+}
+
+func hash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,310 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-shiori/go-readability"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ReadableArticle is the main content of a web page as markdown with the
+// metadata found by readability.
+type ReadableArticle struct {
+	Title         string
+	Byline        string
+	SiteName      string
+	Excerpt       string
+	Language      string
+	PublishedTime *time.Time
+	Markdown      string
+}
+
+// HtmlReadabilityMarkdown extracts the main content of a web page like
+// HtmlReadability and renders it as markdown. Relative links and images are
+// resolved against pageURL when it is set.
+func HtmlReadabilityMarkdown(page string, pageURL *url.URL) (ret *ReadableArticle, err error) {
+	var article readability.Article
+	if article, err = readability.FromReader(bytes.NewBufferString(page), pageURL); err != nil {
+		return
+	}
+	ret = &ReadableArticle{
+		Title:         strings.TrimSpace(article.Title),
+		Byline:        strings.TrimSpace(article.Byline),
+		SiteName:      strings.TrimSpace(article.SiteName),
+		Excerpt:       strings.TrimSpace(article.Excerpt),
+		Language:      article.Language,
+		PublishedTime: article.PublishedTime,
+	}
+	if article.Node != nil {
+		ret.Markdown = HtmlToMarkdown(article.Node, pageURL)
+	}
+	if ret.Title != "" && !strings.HasPrefix(ret.Markdown, "# ") {
+		ret.Markdown = strings.TrimSpace("# " + ret.Title + "\n\n" + ret.Markdown)
+	}
+	return
+}
+
+// HtmlToMarkdown renders an HTML tree as markdown: headings, paragraphs,
+// lists, links, images, emphasis, code, quotes and tables.
+func HtmlToMarkdown(node *html.Node, base *url.URL) string {
+	w := &markdownWriter{base: base}
+	return strings.TrimSpace(blankLines.ReplaceAllString(w.blocks(node), "\n\n"))
+}
+
+var (
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+type markdownWriter struct {
+	base *url.URL
+}
+
+func isBlock(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer, atom.Aside,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Ul, atom.Ol, atom.Li, atom.Pre,
+		atom.Blockquote, atom.Table, atom.Hr, atom.Figure, atom.Figcaption, atom.Dl, atom.Dt, atom.Dd,
+		atom.Body, atom.Html, atom.Nav, atom.Details, atom.Summary:
+		return true
+	}
+	return false
+}
+
+func isSkipped(n *html.Node) bool {
+	if n.Type == html.CommentNode {
+		return true
+	}
+	if n.Type != html.ElementNode {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Svg, atom.Template, atom.Head, atom.Form, atom.Button, atom.Iframe:
+		return true
+	}
+	return false
+}
+
+// blocks renders the children of n, grouping inline runs into paragraphs.
+func (w *markdownWriter) blocks(n *html.Node) string {
+	var parts []string
+	var run strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(run.String()); text != "" {
+			parts = append(parts, text)
+		}
+		run.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if isSkipped(child) {
+			continue
+		}
+		if isBlock(child) {
+			flush()
+			if block := strings.TrimSpace(w.block(child)); block != "" {
+				parts = append(parts, block)
+			}
+			continue
+		}
+		run.WriteString(w.inline(child))
+	}
+	flush()
+	return strings.Join(parts, "\n\n")
+}
+
+func (w *markdownWriter) block(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		return strings.Repeat("#", level) + " " + strings.TrimSpace(w.inlineChildren(n))
+	case atom.Ul, atom.Ol:
+		return w.list(n, n.DataAtom == atom.Ol)
+	case atom.Pre:
+		return "```\n" + strings.TrimRight(textContent(n), "\n") + "\n```"
+	case atom.Blockquote:
+		return prefixLines(w.blocks(n), "> ", "> ")
+	case atom.Hr:
+		return "---"
+	case atom.Table:
+		return w.table(n)
+	default:
+		return w.blocks(n)
+	}
+}
+
+func (w *markdownWriter) list(n *html.Node, ordered bool) string {
+	var items []string
+	index := 1
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", index)
+			index++
+		}
+		content := strings.TrimSpace(w.blocks(child))
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+func (w *markdownWriter) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if child.DataAtom == atom.Tr {
+				var cells []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						text := strings.TrimSpace(w.inlineChildren(cell))
+						cells = append(cells, strings.ReplaceAll(text, "|", "\\|"))
+					}
+				}
+				rows = append(rows, cells)
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+		}
+	}
+	return b.String()
+}
+
+func (w *markdownWriter) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if !isSkipped(child) {
+			b.WriteString(w.inline(child))
+		}
+	}
+	return b.String()
+}
+
+func (w *markdownWriter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return whitespace.ReplaceAllString(n.Data, " ")
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "  \n"
+	case atom.A:
+		text := strings.TrimSpace(w.inlineChildren(n))
+		href := w.resolve(attr(n, "href"))
+		if href == "" || strings.HasPrefix(href, "javascript:") || text == "" {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	case atom.Img:
+		src := w.resolve(attr(n, "src"))
+		if src == "" {
+			return ""
+		}
+		return "![" + attr(n, "alt") + "](" + src + ")"
+	case atom.Strong, atom.B:
+		return wrapInline(w.inlineChildren(n), "**")
+	case atom.Em, atom.I:
+		return wrapInline(w.inlineChildren(n), "_")
+	case atom.Code, atom.Kbd, atom.Samp:
+		return wrapInline(textContent(n), "`")
+	default:
+		if isBlock(n) {
+			return " " + w.inlineChildren(n) + " "
+		}
+		return w.inlineChildren(n)
+	}
+}
+
+// wrapInline wraps text in marker, keeping surrounding spaces outside.
+func wrapInline(text string, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	trailing := text[len(strings.TrimRight(text, " ")):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+func (w *markdownWriter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || w.base == nil {
+		return ref
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return w.base.ResolveReference(parsed).String()
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			b.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// prefixLines puts first before the first line and rest before the others.
+func prefixLines(text string, first string, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			lines[i] = strings.TrimRight(prefix, " ")
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
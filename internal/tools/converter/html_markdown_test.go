@@ -0,0 +1,75 @@
+package converter
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestHtmlToMarkdown(t *testing.T) {
+	base, _ := url.Parse("https://example.com/docs/page.html")
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "Headings and paragraphs",
+			html:     "<h2>Setup</h2><p>Run   the <b>installer</b>\nfirst.</p>",
+			expected: "## Setup\n\nRun the **installer** first.",
+		},
+		{
+			name:     "Links and images are resolved",
+			html:     `<p>See <a href="../guide">the guide</a> <img src="/logo.png" alt="logo"></p>`,
+			expected: "See [the guide](https://example.com/guide) ![logo](https://example.com/logo.png)",
+		},
+		{
+			name:     "Nested lists",
+			html:     "<ul><li>One<ul><li>Nested</li></ul></li><li>Two</li></ul><ol><li>First</li><li>Second</li></ol>",
+			expected: "- One\n\n  - Nested\n- Two\n\n1. First\n2. Second",
+		},
+		{
+			name:     "Code and quotes",
+			html:     "<p>Call <code>run()</code></p><pre><code>x := 1\ny := 2\n</code></pre><blockquote><p>Quoted</p></blockquote>",
+			expected: "Call `run()`\n\n```\nx := 1\ny := 2\n```\n\n> Quoted",
+		},
+		{
+			name:     "Tables",
+			html:     "<table><tr><th>Name</th><th>Value</th></tr><tr><td>a|b</td><td>1</td></tr></table>",
+			expected: "| Name | Value |\n| --- | --- |\n| a\\|b | 1 |",
+		},
+		{
+			name:     "Scripts are dropped",
+			html:     "<p>Text</p><script>alert(1)</script>",
+			expected: "Text",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tc.html))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, HtmlToMarkdown(doc, base))
+		})
+	}
+}
+
+func TestHtmlReadabilityMarkdown(t *testing.T) {
+	page := `<html><head><title>Release Notes</title></head><body>
+<nav><a href="/">Home</a></nav>
+<article><h1>Release Notes</h1>
+<p>Version 2 adds <em>streaming</em> output to every command, and the configuration format is now shared between the command line and the server.</p>
+<p>Upgrading requires no changes for most users. Read the <a href="/migrate">migration guide</a> when you use custom plugins or extensions.</p>
+</article></body></html>`
+	base, _ := url.Parse("https://example.com/news/")
+
+	article, err := HtmlReadabilityMarkdown(page, base)
+	assert.NoError(t, err)
+	assert.Equal(t, "Release Notes", article.Title)
+	assert.True(t, strings.HasPrefix(article.Markdown, "# Release Notes"), article.Markdown)
+	assert.Contains(t, article.Markdown, "_streaming_")
+	assert.Contains(t, article.Markdown, "[migration guide](https://example.com/migrate)")
+}
@@ -0,0 +1,230 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	KindContains    = "contains"
+	KindNotContains = "not_contains"
+	KindRegex       = "regex"
+	KindMaxLength   = "max_length"
+	KindJSONSchema  = "json_schema"
+	KindRubric      = "rubric"
+)
+
+// AssertionResult is the outcome of one assertion.
+type AssertionResult struct {
+	Kind    string  `json:"kind"`
+	Passed  bool    `json:"passed"`
+	Message string  `json:"message,omitempty"`
+	Score   float64 `json:"score,omitempty"`
+}
+
+// Kind returns the assertion type.
+func (o *Assertion) Kind() string {
+	switch {
+	case o.Contains != "":
+		return KindContains
+	case o.NotContains != "":
+		return KindNotContains
+	case o.Regex != "":
+		return KindRegex
+	case o.MaxLength > 0:
+		return KindMaxLength
+	case o.JSONSchema != nil:
+		return KindJSONSchema
+	case o.Rubric != "":
+		return KindRubric
+	}
+	return ""
+}
+
+func (o *Assertion) validate() (err error) {
+	set := 0
+	for _, isSet := range []bool{o.Contains != "", o.NotContains != "", o.Regex != "",
+		o.MaxLength > 0, o.JSONSchema != nil, o.Rubric != ""} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("each assertion must set exactly one of contains, not_contains, regex, max_length, json_schema or rubric")
+	}
+	if o.Regex != "" {
+		if _, err = regexp.Compile(o.Regex); err != nil {
+			return fmt.Errorf("invalid regex %q: %w", o.Regex, err)
+		}
+	}
+	return
+}
+
+// check evaluates the deterministic assertions. Rubrics are handled by the judge.
+func (o *Assertion) check(output string) (ret *AssertionResult) {
+	ret = &AssertionResult{Kind: o.Kind(), Passed: true}
+	switch ret.Kind {
+	case KindContains:
+		if !strings.Contains(output, o.Contains) {
+			ret.Passed = false
+			ret.Message = fmt.Sprintf("output does not contain %q", o.Contains)
+		}
+	case KindNotContains:
+		if strings.Contains(output, o.NotContains) {
+			ret.Passed = false
+			ret.Message = fmt.Sprintf("output contains %q", o.NotContains)
+		}
+	case KindRegex:
+		if !regexp.MustCompile(o.Regex).MatchString(output) {
+			ret.Passed = false
+			ret.Message = fmt.Sprintf("output does not match /%s/", o.Regex)
+		}
+	case KindMaxLength:
+		if length := utf8.RuneCountInString(output); length > o.MaxLength {
+			ret.Passed = false
+			ret.Message = fmt.Sprintf("output is %d characters, limit is %d", length, o.MaxLength)
+		}
+	case KindJSONSchema:
+		var value any
+		if err := json.Unmarshal([]byte(extractJSON(output)), &value); err != nil {
+			ret.Passed = false
+			ret.Message = fmt.Sprintf("output is not valid JSON: %v", err)
+		} else if problems := validateSchema(o.JSONSchema, value, "$"); len(problems) > 0 {
+			ret.Passed = false
+			ret.Message = strings.Join(problems, "; ")
+		}
+	}
+	return
+}
+
+// extractJSON strips a surrounding markdown code fence, which models add
+// even when asked for bare JSON.
+func extractJSON(output string) string {
+	trimmed := strings.TrimSpace(output)
+	if strings.HasPrefix(trimmed, "```") {
+		trimmed = strings.TrimPrefix(trimmed, "```json")
+		trimmed = strings.TrimPrefix(trimmed, "```")
+		trimmed = strings.TrimSuffix(strings.TrimSpace(trimmed), "```")
+	}
+	return strings.TrimSpace(trimmed)
+}
+
+// validateSchema checks value against the commonly used subset of JSON Schema:
+// type, enum, required, properties, additionalProperties (false), items,
+// minItems/maxItems and minLength/maxLength.
+func validateSchema(schema map[string]any, value any, path string) (problems []string) {
+	if expected, ok := schema["type"]; ok && !matchesType(expected, value) {
+		return []string{fmt.Sprintf("%s: expected type %v, got %s", path, expected, jsonType(value))}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if _, present := v[fmt.Sprint(name)]; !present {
+					problems = append(problems, fmt.Sprintf("%s: missing required property %q", path, name))
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if propSchema, ok := properties[key].(map[string]any); ok {
+				problems = append(problems, validateSchema(propSchema, v[key], path+"."+key)...)
+			} else if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				problems = append(problems, fmt.Sprintf("%s: unexpected property %q", path, key))
+			}
+		}
+	case []any:
+		if min, ok := schemaInt(schema, "minItems"); ok && len(v) < min {
+			problems = append(problems, fmt.Sprintf("%s: expected at least %d items, got %d", path, min, len(v)))
+		}
+		if max, ok := schemaInt(schema, "maxItems"); ok && len(v) > max {
+			problems = append(problems, fmt.Sprintf("%s: expected at most %d items, got %d", path, max, len(v)))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				problems = append(problems, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if min, ok := schemaInt(schema, "minLength"); ok && length < min {
+			problems = append(problems, fmt.Sprintf("%s: expected at least %d characters, got %d", path, min, length))
+		}
+		if max, ok := schemaInt(schema, "maxLength"); ok && length > max {
+			problems = append(problems, fmt.Sprintf("%s: expected at most %d characters, got %d", path, max, length))
+		}
+	}
+	return
+}
+
+func matchesType(expected any, value any) bool {
+	if list, ok := expected.([]any); ok {
+		for _, t := range list {
+			if matchesType(t, value) {
+				return true
+			}
+		}
+		return false
+	}
+	actual := jsonType(value)
+	switch expected {
+	case "number":
+		return actual == "number" || actual == "integer"
+	default:
+		return expected == actual
+	}
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func schemaInt(schema map[string]any, key string) (int, bool) {
+	switch v := schema[key].(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,167 @@
+package eval
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai/fake"
+)
+
+const testSuite = `pattern: extract_ideas
+cases:
+  - name: ideas
+    input: "the article about gardens"
+    assertions:
+      - contains: "IDEAS"
+      - regex: "^# "
+      - max_length: 100
+      - rubric: "Lists ideas about gardens"
+  - name: json
+    input_file: input.txt
+    assertions:
+      - json_schema:
+          type: object
+          required: [title, tags]
+          properties:
+            title: {type: string}
+            tags: {type: array, items: {type: string}, minItems: 1}
+`
+
+func writeSuite(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "input.txt"), []byte("give me json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "ideas.yaml")
+	if err := os.WriteFile(path, []byte(testSuite), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func fakeRunner(vendor *fake.Client, models ...string) *Runner {
+	send := func(model string, content string) (string, error) {
+		msgs := []*chat.ChatCompletionMessage{{Role: chat.ChatMessageRoleUser, Content: content}}
+		return vendor.Send(context.Background(), msgs, &domain.ChatOptions{Model: model})
+	}
+	return &Runner{
+		Models: models,
+		Run: func(model string, _ *Suite, c *Case) (string, error) {
+			return send(model, c.Input)
+		},
+		JudgeModel: "judge",
+		Judge: func(model string) (CompleteFunc, error) {
+			return func(prompt string) (string, error) { return send(model, prompt) }, nil
+		},
+	}
+}
+
+func TestLoadSuite(t *testing.T) {
+	suite, err := LoadSuite(writeSuite(t))
+	if err != nil {
+		t.Fatalf("LoadSuite failed: %v", err)
+	}
+	if suite.Name != "ideas" || suite.Pattern != "extract_ideas" {
+		t.Errorf("unexpected suite name/pattern: %q/%q", suite.Name, suite.Pattern)
+	}
+	if suite.Cases[1].Input != "give me json" {
+		t.Errorf("expected input file to be loaded, got %q", suite.Cases[1].Input)
+	}
+}
+
+func TestLoadSuite_RejectsAmbiguousAssertion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.yaml")
+	content := "cases:\n  - input: x\n    assertions:\n      - contains: a\n        regex: b\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSuite(path); err == nil {
+		t.Error("expected error for assertion setting two kinds")
+	}
+}
+
+func TestRunner_RunSuite(t *testing.T) {
+	suite, err := LoadSuite(writeSuite(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vendor := fake.NewClient(
+		fake.Rule{Model: "judge", Contains: "Lists ideas", Response: `Sure: {"pass": true, "score": 0.9, "reason": "ok"}`},
+		fake.Rule{Model: "good", Contains: "gardens", Response: "# IDEAS\n- plant trees"},
+		fake.Rule{Model: "good", Contains: "json", Response: "```json\n{\"title\": \"t\", \"tags\": [\"a\"]}\n```"},
+		fake.Rule{Model: "bad", Contains: "json", Response: `{"title": 3}`},
+	)
+	report, err := fakeRunner(vendor, "good", "bad").RunSuite(suite)
+	if err != nil {
+		t.Fatalf("RunSuite failed: %v", err)
+	}
+
+	good, bad := report.Models[0], report.Models[1]
+	if good.Passed != 2 || good.PassRate != 1 {
+		t.Errorf("expected all cases to pass for good model, got %d/%d", good.Passed, good.Total)
+	}
+	if bad.Passed != 0 {
+		t.Errorf("expected no case to pass for bad model, got %d", bad.Passed)
+	}
+	if rubric := good.Cases[0].Assertions[3]; rubric.Kind != KindRubric || !rubric.Passed || rubric.Score != 0.9 {
+		t.Errorf("unexpected rubric result: %+v", rubric)
+	}
+	schema := bad.Cases[1].Assertions[0]
+	if !strings.Contains(schema.Message, `missing required property "tags"`) || !strings.Contains(schema.Message, "$.title: expected type string") {
+		t.Errorf("unexpected schema failure message: %q", schema.Message)
+	}
+}
+
+func TestReport_CompareAndSave(t *testing.T) {
+	suite, err := LoadSuite(writeSuite(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reportsDir := t.TempDir()
+
+	before := fake.NewClient(fake.Rule{Contains: "gardens", Response: "# IDEAS\n- plant trees"})
+	previous, err := fakeRunner(before, "m").RunSuite(suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous.CreatedAt = previous.CreatedAt.Add(-time.Hour)
+	if _, err = previous.Save(reportsDir); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	after := fake.NewClient(fake.Rule{Contains: "gardens", Response: "no ideas here"})
+	current, err := fakeRunner(after, "m").RunSuite(suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadLatestReport(reportsDir, suite.Name)
+	if err != nil || loaded == nil {
+		t.Fatalf("LoadLatestReport failed: %v", err)
+	}
+	current.Compare(loaded)
+
+	delta := current.Comparison.Models[0]
+	if len(delta.Regressed) != 0 || len(delta.Changed) != 1 || delta.Changed[0].Case != "ideas" {
+		t.Errorf("unexpected delta: %+v", delta)
+	}
+	if !strings.Contains(delta.Changed[0].Diff, "+no ideas here") {
+		t.Errorf("expected unified diff of the output, got %q", delta.Changed[0].Diff)
+	}
+
+	mdPath, err := current.Save(reportsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, _ := os.ReadFile(mdPath)
+	if !strings.Contains(string(md), "## Changes since") {
+		t.Errorf("expected changes section in markdown report, got:\n%s", md)
+	}
+}
@@ -0,0 +1,66 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const judgePrompt = `You are grading the output of a language model against a rubric.
+
+Answer with a single JSON object and nothing else:
+{"pass": true or false, "score": a number between 0 and 1, "reason": "one sentence"}
+
+# RUBRIC
+
+%s
+
+# INPUT GIVEN TO THE MODEL
+
+%s
+
+# OUTPUT TO GRADE
+
+%s
+`
+
+type judgeVerdict struct {
+	Pass   bool    `json:"pass"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// judge asks the judge model whether output satisfies the rubric.
+func judge(complete CompleteFunc, rubric string, input string, output string) (ret *AssertionResult) {
+	ret = &AssertionResult{Kind: KindRubric}
+	if complete == nil {
+		ret.Message = "no judge model configured, use --judge-model"
+		return
+	}
+
+	response, err := complete(fmt.Sprintf(judgePrompt, rubric, input, output))
+	if err != nil {
+		ret.Message = fmt.Sprintf("judge failed: %v", err)
+		return
+	}
+
+	var verdict judgeVerdict
+	if err = json.Unmarshal([]byte(jsonObject(response)), &verdict); err != nil {
+		ret.Message = fmt.Sprintf("could not parse judge verdict %q: %v", strings.TrimSpace(response), err)
+		return
+	}
+	ret.Passed = verdict.Pass
+	ret.Score = verdict.Score
+	ret.Message = verdict.Reason
+	return
+}
+
+// jsonObject returns the outermost {...} of a response, ignoring any prose around it.
+func jsonObject(response string) string {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return response
+	}
+	return response[start : end+1]
+}
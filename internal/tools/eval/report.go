@@ -0,0 +1,265 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const reportTimeFormat = "20060102-150405"
+
+// Report holds the results of one suite run.
+type Report struct {
+	Suite      string         `json:"suite"`
+	Pattern    string         `json:"pattern"`
+	JudgeModel string         `json:"judge_model,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Models     []*ModelResult `json:"models"`
+	Comparison *Comparison    `json:"comparison,omitempty"`
+}
+
+// ModelResult holds the results of a suite for one model.
+type ModelResult struct {
+	Model    string        `json:"model"`
+	Passed   int           `json:"passed"`
+	Total    int           `json:"total"`
+	PassRate float64       `json:"pass_rate"`
+	Cases    []*CaseResult `json:"cases"`
+}
+
+// CaseResult holds the output of one case and its assertion results.
+type CaseResult struct {
+	Name       string             `json:"name"`
+	Passed     bool               `json:"passed"`
+	Output     string             `json:"output"`
+	Error      string             `json:"error,omitempty"`
+	Assertions []*AssertionResult `json:"assertions,omitempty"`
+	Duration   float64            `json:"duration_seconds"`
+}
+
+// Comparison describes the changes since a previous run of the same suite.
+type Comparison struct {
+	PreviousRun time.Time     `json:"previous_run"`
+	Models      []*ModelDelta `json:"models"`
+}
+
+// ModelDelta compares the results of one model with the previous run.
+type ModelDelta struct {
+	Model            string        `json:"model"`
+	PreviousPassRate float64       `json:"previous_pass_rate"`
+	PassRate         float64       `json:"pass_rate"`
+	Regressed        []string      `json:"regressed,omitempty"`
+	Fixed            []string      `json:"fixed,omitempty"`
+	Changed          []*OutputDiff `json:"changed,omitempty"`
+}
+
+// OutputDiff is a unified diff between the previous and current output of a case.
+type OutputDiff struct {
+	Case string `json:"case"`
+	Diff string `json:"diff"`
+}
+
+func (o *ModelResult) add(result *CaseResult) {
+	o.Cases = append(o.Cases, result)
+	o.Total++
+	if result.Passed {
+		o.Passed++
+	}
+	o.PassRate = float64(o.Passed) / float64(o.Total)
+}
+
+func (o *ModelResult) findCase(name string) *CaseResult {
+	for _, c := range o.Cases {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (o *Report) findModel(model string) *ModelResult {
+	for _, m := range o.Models {
+		if m.Model == model {
+			return m
+		}
+	}
+	return nil
+}
+
+// Compare records the differences with a previous report of the same suite.
+// Models missing from the previous report are skipped.
+func (o *Report) Compare(previous *Report) {
+	if previous == nil {
+		return
+	}
+	comparison := &Comparison{PreviousRun: previous.CreatedAt}
+	for _, current := range o.Models {
+		before := previous.findModel(current.Model)
+		if before == nil {
+			continue
+		}
+		delta := &ModelDelta{Model: current.Model, PreviousPassRate: before.PassRate, PassRate: current.PassRate}
+		for _, c := range current.Cases {
+			old := before.findCase(c.Name)
+			if old == nil {
+				continue
+			}
+			if old.Passed && !c.Passed {
+				delta.Regressed = append(delta.Regressed, c.Name)
+			} else if !old.Passed && c.Passed {
+				delta.Fixed = append(delta.Fixed, c.Name)
+			}
+			if old.Output != c.Output {
+				diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+					A:        difflib.SplitLines(old.Output),
+					B:        difflib.SplitLines(c.Output),
+					FromFile: "previous",
+					ToFile:   "current",
+					Context:  2,
+				})
+				delta.Changed = append(delta.Changed, &OutputDiff{Case: c.Name, Diff: diff})
+			}
+		}
+		comparison.Models = append(comparison.Models, delta)
+	}
+	o.Comparison = comparison
+}
+
+// Save writes the report as JSON and markdown into dir/<suite>/ and returns
+// the path of the markdown file.
+func (o *Report) Save(dir string) (ret string, err error) {
+	suiteDir := filepath.Join(dir, o.Suite)
+	if err = os.MkdirAll(suiteDir, 0o755); err != nil {
+		return
+	}
+	base := filepath.Join(suiteDir, o.CreatedAt.Format(reportTimeFormat))
+
+	var data []byte
+	if data, err = json.MarshalIndent(o, "", "  "); err != nil {
+		return
+	}
+	if err = os.WriteFile(base+".json", data, 0o644); err != nil {
+		return
+	}
+	ret = base + ".md"
+	err = os.WriteFile(ret, []byte(o.Markdown()), 0o644)
+	return
+}
+
+// LoadLatestReport returns the most recent saved report of a suite, or nil if
+// the suite has not been run before.
+func LoadLatestReport(dir string, suite string) (ret *Report, err error) {
+	var files []string
+	if files, err = filepath.Glob(filepath.Join(dir, suite, "*.json")); err != nil || len(files) == 0 {
+		return
+	}
+	sort.Strings(files)
+
+	var data []byte
+	if data, err = os.ReadFile(files[len(files)-1]); err != nil {
+		return
+	}
+	ret = &Report{}
+	err = json.Unmarshal(data, ret)
+	return
+}
+
+// Markdown renders the report for humans.
+func (o *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Eval: %s\n\n", o.Suite)
+	fmt.Fprintf(&b, "Pattern: `%s`  \nRun: %s\n", o.Pattern, o.CreatedAt.Format(time.RFC3339))
+	if o.JudgeModel != "" {
+		fmt.Fprintf(&b, "Judge: `%s`\n", o.JudgeModel)
+	}
+
+	b.WriteString("\n| Model | Passed | Pass rate |")
+	if o.Comparison != nil {
+		b.WriteString(" Change |")
+	}
+	b.WriteString("\n|---|---|---|")
+	if o.Comparison != nil {
+		b.WriteString("---|")
+	}
+	b.WriteString("\n")
+	for _, m := range o.Models {
+		fmt.Fprintf(&b, "| %s | %d/%d | %.0f%% |", m.Model, m.Passed, m.Total, m.PassRate*100)
+		if o.Comparison != nil {
+			if delta := o.Comparison.findModel(m.Model); delta != nil {
+				fmt.Fprintf(&b, " %+.0f%% |", (delta.PassRate-delta.PreviousPassRate)*100)
+			} else {
+				b.WriteString(" new |")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	for _, m := range o.Models {
+		fmt.Fprintf(&b, "\n## %s\n\n", m.Model)
+		for _, c := range m.Cases {
+			status := "PASS"
+			if !c.Passed {
+				status = "FAIL"
+			}
+			fmt.Fprintf(&b, "- **%s** %s", status, c.Name)
+			if c.Error != "" {
+				fmt.Fprintf(&b, ": error: %s", c.Error)
+			}
+			b.WriteString("\n")
+			for _, a := range c.Assertions {
+				if !a.Passed || a.Kind == KindRubric {
+					fmt.Fprintf(&b, "  - %s: %s\n", a.Kind, assertionSummary(a))
+				}
+			}
+		}
+	}
+
+	if o.Comparison != nil {
+		fmt.Fprintf(&b, "\n## Changes since %s\n", o.Comparison.PreviousRun.Format(time.RFC3339))
+		for _, delta := range o.Comparison.Models {
+			fmt.Fprintf(&b, "\n### %s\n\n", delta.Model)
+			if len(delta.Regressed) > 0 {
+				fmt.Fprintf(&b, "Regressed: %s\n\n", strings.Join(delta.Regressed, ", "))
+			}
+			if len(delta.Fixed) > 0 {
+				fmt.Fprintf(&b, "Fixed: %s\n\n", strings.Join(delta.Fixed, ", "))
+			}
+			if len(delta.Changed) == 0 {
+				b.WriteString("No output changes.\n")
+			}
+			for _, diff := range delta.Changed {
+				fmt.Fprintf(&b, "Output of %s changed:\n\n```diff\n%s```\n\n", diff.Case, diff.Diff)
+			}
+		}
+	}
+	return b.String()
+}
+
+func (o *Comparison) findModel(model string) *ModelDelta {
+	for _, d := range o.Models {
+		if d.Model == model {
+			return d
+		}
+	}
+	return nil
+}
+
+func assertionSummary(a *AssertionResult) string {
+	status := "passed"
+	if !a.Passed {
+		status = "failed"
+	}
+	if a.Kind == KindRubric {
+		status = fmt.Sprintf("%s (score %.2f)", status, a.Score)
+	}
+	if a.Message != "" {
+		return status + ", " + a.Message
+	}
+	return status
+}
@@ -0,0 +1,90 @@
+package eval
+
+import (
+	"fmt"
+	"time"
+)
+
+// RunFunc sends the case input through the suite pattern using model and
+// returns the output.
+type RunFunc func(model string, suite *Suite, c *Case) (string, error)
+
+// CompleteFunc sends a single prompt to a model and returns the response.
+type CompleteFunc func(prompt string) (string, error)
+
+// Runner runs suites against one or more models.
+type Runner struct {
+	Models []string
+	Run    RunFunc
+
+	// JudgeModel grades rubric assertions unless the suite names its own.
+	JudgeModel string
+	// Judge returns a CompleteFunc for a judge model.
+	Judge func(model string) (CompleteFunc, error)
+
+	// Progress is called after each case, it may be nil.
+	Progress func(model string, result *CaseResult)
+}
+
+// RunSuite runs every case of suite once per model.
+func (o *Runner) RunSuite(suite *Suite) (ret *Report, err error) {
+	if len(o.Models) == 0 {
+		err = fmt.Errorf("no models to evaluate")
+		return
+	}
+
+	judgeModel := suite.JudgeModel
+	if judgeModel == "" {
+		judgeModel = o.JudgeModel
+	}
+	var judgeFunc CompleteFunc
+	if judgeModel != "" && o.Judge != nil {
+		if judgeFunc, err = o.Judge(judgeModel); err != nil {
+			return
+		}
+	}
+
+	ret = &Report{
+		Suite:      suite.Name,
+		Pattern:    suite.Pattern,
+		JudgeModel: judgeModel,
+		CreatedAt:  time.Now().UTC(),
+	}
+	for _, model := range o.Models {
+		modelResult := &ModelResult{Model: model}
+		for _, c := range suite.Cases {
+			result := o.runCase(model, suite, c, judgeFunc)
+			modelResult.add(result)
+			if o.Progress != nil {
+				o.Progress(model, result)
+			}
+		}
+		ret.Models = append(ret.Models, modelResult)
+	}
+	return
+}
+
+func (o *Runner) runCase(model string, suite *Suite, c *Case, judgeFunc CompleteFunc) (ret *CaseResult) {
+	ret = &CaseResult{Name: c.Name}
+	start := time.Now()
+	output, err := o.Run(model, suite, c)
+	ret.Duration = time.Since(start).Seconds()
+	if err != nil {
+		ret.Error = err.Error()
+		return
+	}
+	ret.Output = output
+
+	ret.Passed = true
+	for _, assertion := range c.Assertions {
+		var result *AssertionResult
+		if assertion.Kind() == KindRubric {
+			result = judge(judgeFunc, assertion.Rubric, c.Input, output)
+		} else {
+			result = assertion.check(output)
+		}
+		ret.Assertions = append(ret.Assertions, result)
+		ret.Passed = ret.Passed && result.Passed
+	}
+	return
+}
@@ -0,0 +1,151 @@
+// Package eval runs regression suites against patterns.
+//
+// A suite is a YAML file describing test cases for one pattern: the input,
+// the pattern variables and a list of assertions checked against the model
+// output. Assertions are either deterministic (contains, regex, JSON schema,
+// maximum length) or judged by a second model against a rubric.
+package eval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is the content of one suite file.
+type Suite struct {
+	// Name defaults to the file name without extension.
+	Name string `yaml:"name"`
+	// Pattern defaults to Name.
+	Pattern string `yaml:"pattern"`
+	// JudgeModel overrides the judge model given on the command line.
+	JudgeModel string  `yaml:"judge_model"`
+	Cases      []*Case `yaml:"cases"`
+
+	Path string `yaml:"-"`
+}
+
+// Case is one input run through the pattern.
+type Case struct {
+	Name       string            `yaml:"name"`
+	Input      string            `yaml:"input"`
+	InputFile  string            `yaml:"input_file"`
+	Variables  map[string]string `yaml:"variables"`
+	Assertions []*Assertion      `yaml:"assertions"`
+}
+
+// Assertion sets exactly one of its fields.
+type Assertion struct {
+	Contains    string         `yaml:"contains,omitempty"`
+	NotContains string         `yaml:"not_contains,omitempty"`
+	Regex       string         `yaml:"regex,omitempty"`
+	MaxLength   int            `yaml:"max_length,omitempty"`
+	JSONSchema  map[string]any `yaml:"json_schema,omitempty"`
+	Rubric      string         `yaml:"rubric,omitempty"`
+}
+
+// LoadSuite reads a suite file. Input files are resolved relative to the suite.
+func LoadSuite(path string) (ret *Suite, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return
+	}
+
+	ret = &Suite{}
+	if err = yaml.Unmarshal(data, ret); err != nil {
+		err = fmt.Errorf("could not parse suite %s: %w", path, err)
+		return
+	}
+	ret.Path = path
+	if ret.Name == "" {
+		ret.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if ret.Pattern == "" {
+		ret.Pattern = ret.Name
+	}
+	if len(ret.Cases) == 0 {
+		err = fmt.Errorf("suite %s has no cases", path)
+		return
+	}
+
+	for i, c := range ret.Cases {
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case-%d", i+1)
+		}
+		if c.InputFile != "" {
+			inputPath := c.InputFile
+			if !filepath.IsAbs(inputPath) {
+				inputPath = filepath.Join(filepath.Dir(path), inputPath)
+			}
+			var input []byte
+			if input, err = os.ReadFile(inputPath); err != nil {
+				err = fmt.Errorf("suite %s, case %q: %w", path, c.Name, err)
+				return
+			}
+			c.Input = string(input)
+		}
+		for _, a := range c.Assertions {
+			if err = a.validate(); err != nil {
+				err = fmt.Errorf("suite %s, case %q: %w", path, c.Name, err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// FindSuites resolves the suites to run. Each argument is a suite file, a
+// directory of suites, or a pattern name looked up in suitesDir. Without
+// arguments every suite in suitesDir is returned.
+func FindSuites(suitesDir string, args []string) (ret []string, err error) {
+	if len(args) == 0 {
+		return suiteFiles(suitesDir)
+	}
+
+	for _, arg := range args {
+		if info, statErr := os.Stat(arg); statErr == nil {
+			if info.IsDir() {
+				var files []string
+				if files, err = suiteFiles(arg); err != nil {
+					return
+				}
+				ret = append(ret, files...)
+			} else {
+				ret = append(ret, arg)
+			}
+			continue
+		}
+
+		found := false
+		for _, ext := range []string{".yaml", ".yml"} {
+			candidate := filepath.Join(suitesDir, arg+ext)
+			if _, statErr := os.Stat(candidate); statErr == nil {
+				ret = append(ret, candidate)
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = fmt.Errorf("no suite found for %q (looked for a file and in %s)", arg, suitesDir)
+			return
+		}
+	}
+	return
+}
+
+func suiteFiles(dir string) (ret []string, err error) {
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		var matches []string
+		if matches, err = filepath.Glob(filepath.Join(dir, pattern)); err != nil {
+			return
+		}
+		ret = append(ret, matches...)
+	}
+	if len(ret) == 0 {
+		err = fmt.Errorf("no suites found in %s", dir)
+	}
+	return
+}
@@ -0,0 +1,76 @@
+package githelper
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// Worktree is the working tree of a local git repository.
+type Worktree struct {
+	// Root is the absolute top-level directory of the working tree
+	Root string
+	// GitDir is the absolute path of the repository's .git directory
+	GitDir string
+
+	ignore gitignore.Matcher
+}
+
+// OpenWorktree opens the working tree containing dir. It fails when dir is
+// not inside a git working tree.
+func OpenWorktree(dir string) (ret *Worktree, err error) {
+	if dir, err = filepath.Abs(dir); err != nil {
+		return
+	}
+	var repo *git.Repository
+	if repo, err = git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true}); err != nil {
+		return nil, fmt.Errorf("%s is not inside a git working tree: %w", dir, err)
+	}
+	var worktree *git.Worktree
+	if worktree, err = repo.Worktree(); err != nil {
+		return nil, fmt.Errorf("%s is not inside a git working tree: %w", dir, err)
+	}
+
+	ret = &Worktree{Root: worktree.Filesystem.Root()}
+	if storage, ok := repo.Storer.(*filesystem.Storage); ok {
+		ret.GitDir = storage.Filesystem().Root()
+	} else {
+		ret.GitDir = filepath.Join(ret.Root, git.GitDirName)
+	}
+	if ret.ignore, err = NewIgnoreMatcher(ret.Root); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// IsIgnored reports whether the slash or OS separated path relative to Root is
+// ignored by git.
+func (o *Worktree) IsIgnored(relPath string, isDir bool) bool {
+	return o.ignore.Match(SplitPath(relPath), isDir)
+}
+
+// NewIgnoreMatcher reads the .gitignore files below root, .git/info/exclude
+// and the global excludes file of the user. It works for directories that are
+// not a git repository as well.
+func NewIgnoreMatcher(root string) (ret gitignore.Matcher, err error) {
+	var patterns []gitignore.Pattern
+	if patterns, err = gitignore.ReadPatterns(osfs.New(root), nil); err != nil {
+		return nil, fmt.Errorf("failed to read the .gitignore files of %s: %w", root, err)
+	}
+	// a broken global configuration should not prevent reading the project
+	if global, globalErr := gitignore.LoadGlobalPatterns(osfs.New("/")); globalErr == nil {
+		patterns = append(global, patterns...)
+	}
+	return gitignore.NewMatcher(patterns), nil
+}
+
+// SplitPath splits a relative path into its components for gitignore matching.
+func SplitPath(relPath string) []string {
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	return strings.Split(strings.TrimPrefix(relPath, "./"), "/")
+}
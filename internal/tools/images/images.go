@@ -0,0 +1,213 @@
+// Package images creates images with a vendor implementing ai.ImageGenerator
+// (Gemini, OpenAI) or any OpenAI-compatible /images/generations endpoint, e.g.
+// a local stable-diffusion server, and saves them with a metadata sidecar
+// file recording prompt and parameters.
+package images
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/ai/openai"
+)
+
+// EndpointVendor selects the configured OpenAI-compatible endpoint
+const EndpointVendor = "endpoint"
+
+// DefaultVendors are tried in order when nothing else is selected
+var DefaultVendors = []string{"OpenAI", "Gemini"}
+
+func NewImages(getGenerator func(vendorName string) ai.ImageGenerator) (ret *Images) {
+
+	label := "Images"
+	ret = &Images{getGenerator: getGenerator}
+
+	ret.PluginBase = &plugins.PluginBase{
+		Name:             label,
+		SetupDescription: "Images - Image generation vendor, model and local endpoint for --image-file",
+		EnvNamePrefix:    plugins.BuildEnvVariablePrefix(label),
+	}
+
+	ret.Vendor = ret.AddSetupQuestionCustom("Vendor", false,
+		"Enter the default image generation vendor (e.g. OpenAI, Gemini, or endpoint for the URL below)")
+	ret.Model = ret.AddSetupQuestionCustom("Model", false,
+		"Enter the default image model (e.g. gpt-image-1, gemini-2.5-flash-image-preview)")
+	ret.EndpointURL = ret.AddSetupQuestionCustom("Endpoint URL", false,
+		"Enter the base URL of an OpenAI-compatible image server (e.g. http://localhost:7860/v1)")
+	ret.EndpointApiKey = ret.AddSetupQuestionCustom("Endpoint API Key", false,
+		"Enter the API key of the image server, if it needs one")
+
+	return
+}
+
+type Images struct {
+	*plugins.PluginBase
+	Vendor         *plugins.SetupQuestion
+	Model          *plugins.SetupQuestion
+	EndpointURL    *plugins.SetupQuestion
+	EndpointApiKey *plugins.SetupQuestion
+
+	getGenerator func(vendorName string) ai.ImageGenerator
+}
+
+// Generator resolves an image generation backend by vendor name. An empty
+// name uses the configured vendor, then the first configured of
+// DefaultVendors. The configured model is returned when the configured vendor
+// is used. The name of the resolved vendor is returned for the metadata.
+func (o *Images) Generator(vendorName string) (ret ai.ImageGenerator, resolvedName string, model string, err error) {
+	if vendorName == "" && o.Vendor.Value != "" {
+		vendorName = o.Vendor.Value
+		model = o.Model.Value
+	}
+
+	if strings.EqualFold(vendorName, EndpointVendor) {
+		if o.EndpointURL.Value == "" {
+			err = fmt.Errorf("no image generation endpoint is configured, run fabric --setup and configure Images")
+			return
+		}
+		ret = openai.NewEndpointClient("Images Endpoint", o.EndpointURL.Value, o.EndpointApiKey.Value)
+		resolvedName = EndpointVendor
+		return
+	}
+
+	candidates := DefaultVendors
+	if vendorName != "" {
+		candidates = []string{vendorName}
+	}
+	for _, candidate := range candidates {
+		if o.getGenerator != nil {
+			if ret = o.getGenerator(candidate); ret != nil {
+				resolvedName = candidate
+				return
+			}
+		}
+	}
+	if vendorName == "" {
+		err = fmt.Errorf("no image generation vendor is configured, configure %s or the Images endpoint with fabric --setup",
+			strings.Join(DefaultVendors, " or "))
+	} else {
+		err = fmt.Errorf("vendor %s is not configured or does not support image generation", vendorName)
+	}
+	return
+}
+
+// FileNames returns the files of count images written to path: path itself
+// for one image, numbered files like cat-1.png, cat-2.png otherwise.
+func FileNames(path string, count int) (ret []string) {
+	if count <= 1 {
+		return []string{path}
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; i <= count; i++ {
+		ret = append(ret, fmt.Sprintf("%s-%d%s", base, i, ext))
+	}
+	return
+}
+
+// Save writes the generated images to path, numbered as by FileNames, each
+// with a <image>.json sidecar holding metadata. Images in another format than
+// the extension of path are converted for PNG and JPEG, other formats keep
+// their data and get a matching extension. The written files are returned.
+func Save(generated []*ai.GeneratedImage, path string, metadata ai.ImageMetadata) (ret []string, err error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+	if metadata.Created.IsZero() {
+		metadata.Created = time.Now()
+	}
+
+	for i, fileName := range FileNames(path, len(generated)) {
+		var data []byte
+		if data, fileName, err = convert(generated[i], fileName); err != nil {
+			return
+		}
+		if _, statErr := os.Stat(fileName); statErr == nil {
+			return ret, fmt.Errorf("image file already exists: %s", fileName)
+		}
+		if err = os.WriteFile(fileName, data, 0644); err != nil {
+			return ret, fmt.Errorf("failed to save image to %s: %w", fileName, err)
+		}
+		ret = append(ret, fileName)
+
+		imageMetadata := metadata
+		imageMetadata.RevisedPrompt = generated[i].RevisedPrompt
+		if len(generated) > 1 {
+			imageMetadata.Index = i + 1
+		}
+		if err = ai.WriteImageMetadata(fileName, &imageMetadata); err != nil {
+			return ret, fmt.Errorf("failed to save image metadata: %w", err)
+		}
+	}
+	return
+}
+
+// FormatFromExtension returns the image format of a file name: png, jpeg or webp.
+func FormatFromExtension(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".webp":
+		return "webp"
+	default:
+		return "png"
+	}
+}
+
+// convert returns the image data in the format of fileName. When the image can
+// not be converted, the data is kept and the extension of fileName changed.
+func convert(generated *ai.GeneratedImage, fileName string) (ret []byte, retFileName string, err error) {
+	ret, retFileName = generated.Data, fileName
+
+	mimeType := generated.MIMEType
+	if mimeType == "" {
+		mimeType = http.DetectContentType(generated.Data)
+	}
+	actual := strings.TrimPrefix(mimeType, "image/")
+	wanted := FormatFromExtension(fileName)
+	if actual == wanted || !strings.HasPrefix(mimeType, "image/") {
+		return
+	}
+
+	// the standard library decodes and encodes PNG and JPEG only
+	convertible := func(format string) bool { return format == "png" || format == "jpeg" }
+	if !convertible(actual) || !convertible(wanted) {
+		retFileName = strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "." + extension(actual)
+		fmt.Fprintf(os.Stderr, "Received a %s image, saving it as %s\n", actual, retFileName)
+		return
+	}
+
+	var decoded image.Image
+	if decoded, _, err = image.Decode(bytes.NewReader(generated.Data)); err != nil {
+		return nil, "", fmt.Errorf("failed to decode %s image: %w", actual, err)
+	}
+	var buf bytes.Buffer
+	if wanted == "png" {
+		err = png.Encode(&buf, decoded)
+	} else {
+		err = jpeg.Encode(&buf, decoded, &jpeg.Options{Quality: 90})
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to convert image to %s: %w", wanted, err)
+	}
+	return buf.Bytes(), fileName, nil
+}
+
+func extension(format string) string {
+	if format == "jpeg" {
+		return "jpg"
+	}
+	return format
+}
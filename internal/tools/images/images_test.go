@@ -0,0 +1,136 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+type fakeGenerator struct{}
+
+func (o *fakeGenerator) GenerateImages(_ context.Context, _ string, _ *ai.ImageOptions) ([]*ai.GeneratedImage, error) {
+	return nil, nil
+}
+
+func (o *fakeGenerator) ImageModels() []string { return nil }
+
+func pngImage(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerator(t *testing.T) {
+	fake := &fakeGenerator{}
+	o := NewImages(func(vendorName string) ai.ImageGenerator {
+		if vendorName == "Gemini" {
+			return fake
+		}
+		return nil
+	})
+
+	if ret, name, _, err := o.Generator(""); err != nil || ret != fake || name != "Gemini" {
+		t.Errorf("expected the first configured default vendor, got %s, err %v", name, err)
+	}
+	if _, _, _, err := o.Generator("OpenAI"); err == nil {
+		t.Error("expected error for vendor without image generation")
+	}
+
+	o.Vendor.Value = "Gemini"
+	o.Model.Value = "imagen-4.0-generate-001"
+	if _, _, model, err := o.Generator(""); err != nil || model != "imagen-4.0-generate-001" {
+		t.Errorf("expected configured model, got %q, err %v", model, err)
+	}
+
+	if _, _, _, err := o.Generator(EndpointVendor); err == nil {
+		t.Error("expected error for endpoint without URL")
+	}
+	o.EndpointURL.Value = "http://localhost:7860/v1"
+	if ret, name, _, err := o.Generator(EndpointVendor); err != nil || ret == nil || name != EndpointVendor {
+		t.Errorf("expected endpoint generator, got err %v", err)
+	}
+}
+
+func TestFileNames(t *testing.T) {
+	if got := FileNames("out/cat.png", 1); len(got) != 1 || got[0] != "out/cat.png" {
+		t.Errorf("expected the path for one image, got %q", got)
+	}
+	got := FileNames("out/cat.png", 3)
+	want := []string{"out/cat-1.png", "out/cat-2.png", "out/cat-3.png"}
+	if len(got) != len(want) {
+		t.Fatalf("FileNames() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("file %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	data := pngImage(t)
+	generated := []*ai.GeneratedImage{
+		{Data: data, MIMEType: "image/png", RevisedPrompt: "a red cat"},
+		{Data: data, MIMEType: "image/png"},
+	}
+	metadata := ai.ImageMetadata{Prompt: "a cat", Vendor: "OpenAI", Model: "gpt-image-1",
+		Options: &ai.ImageOptions{Count: 2}, InputImages: []string{"cat.png"}}
+
+	fileNames, err := Save(generated, filepath.Join(dir, "images", "cat.jpg"), metadata)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if len(fileNames) != 2 || filepath.Base(fileNames[1]) != "cat-2.jpg" {
+		t.Fatalf("unexpected files %q", fileNames)
+	}
+
+	written, err := os.ReadFile(fileNames[0])
+	if err != nil {
+		t.Fatalf("failed to read image: %v", err)
+	}
+	if mimeType := http.DetectContentType(written); mimeType != "image/jpeg" {
+		t.Errorf("expected PNG to be converted to JPEG, got %s", mimeType)
+	}
+
+	var sidecar ai.ImageMetadata
+	sidecarData, err := os.ReadFile(fileNames[0] + ".json")
+	if err != nil {
+		t.Fatalf("failed to read metadata: %v", err)
+	}
+	if err = json.Unmarshal(sidecarData, &sidecar); err != nil {
+		t.Fatalf("invalid metadata: %v", err)
+	}
+	if sidecar.Prompt != "a cat" || sidecar.RevisedPrompt != "a red cat" || sidecar.Index != 1 ||
+		sidecar.Options.Count != 2 || len(sidecar.InputImages) != 1 || sidecar.Created.IsZero() {
+		t.Errorf("unexpected metadata %+v", sidecar)
+	}
+
+	if _, err = Save(generated[:1], fileNames[0], metadata); err == nil {
+		t.Error("expected error for existing file")
+	}
+}
+
+func TestSaveKeepsUnconvertibleFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cat.png")
+	fileNames, err := Save([]*ai.GeneratedImage{{Data: []byte("RIFF0000WEBPVP8 "), MIMEType: "image/webp"}}, path, ai.ImageMetadata{})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if len(fileNames) != 1 || filepath.Ext(fileNames[0]) != ".webp" {
+		t.Errorf("expected the WebP image to keep its format, got %q", fileNames)
+	}
+}
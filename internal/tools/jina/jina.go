@@ -32,6 +32,11 @@ func NewClient() (ret *Client) {
 	return
 }
 
+// HasApiKey reports whether an API key is configured.
+func (jc *Client) HasApiKey() bool {
+	return jc.ApiKey.Value != ""
+}
+
 // ScrapeURL return the main content of a webpage in clean, LLM-friendly text.
 func (jc *Client) ScrapeURL(url string) (ret string, err error) {
 	return jc.request(fmt.Sprintf("https://r.jina.ai/%s", url))
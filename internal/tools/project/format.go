@@ -0,0 +1,127 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Output formats of a project
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// FileItem is a file or directory of the JSON format read by the
+// create_coding_feature pattern.
+type FileItem struct {
+	Type     string     `json:"type"`
+	Name     string     `json:"name"`
+	Content  string     `json:"content,omitempty"`
+	Contents []FileItem `json:"contents,omitempty"`
+}
+
+type reportItem struct {
+	Type        string    `json:"type"`
+	Directories int       `json:"directories"`
+	Files       int       `json:"files"`
+	Tokens      int       `json:"tokens,omitempty"`
+	Omitted     []Omitted `json:"omitted,omitempty"`
+}
+
+type instructionsItem struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Details string `json:"details"`
+}
+
+// Format returns the project in the json or markdown format.
+func (o *Project) Format(format string) (ret string, err error) {
+	switch format {
+	case FormatJSON, "":
+		var data []byte
+		if data, err = o.JSON(); err != nil {
+			return
+		}
+		ret = string(data)
+	case FormatMarkdown, "md":
+		ret = o.Markdown()
+	default:
+		err = fmt.Errorf("invalid project format '%s'. Supported formats: %s, %s", format, FormatJSON, FormatMarkdown)
+	}
+	return
+}
+
+// JSON returns the project as the directory tree, report and instructions
+// objects read by the create_coding_feature pattern.
+func (o *Project) JSON() ([]byte, error) {
+	root := FileItem{Type: "directory", Name: o.Root, Contents: []FileItem{}}
+	for _, file := range o.Files {
+		addFileToDirectory(&root, file.Path, file.Content)
+	}
+	data := []any{
+		root,
+		reportItem{Type: "report", Directories: o.Directories + 1, Files: len(o.Files), Tokens: o.Tokens, Omitted: o.Omitted},
+		instructionsItem{Type: "instructions", Name: "code_change_instructions", Details: o.Instructions},
+	}
+	return json.MarshalIndent(data, "", "  ")
+}
+
+// addFileToDirectory adds a file to the correct directory in the structure
+func addFileToDirectory(root *FileItem, relPath string, content string) {
+	parts := strings.Split(relPath, "/")
+	current := root
+	for _, dirName := range parts[:len(parts)-1] {
+		found := false
+		// Look for existing directory
+		for j, item := range current.Contents {
+			if item.Type == "directory" && item.Name == dirName {
+				current = &current.Contents[j]
+				found = true
+				break
+			}
+		}
+		// Create directory if not found
+		if !found {
+			current.Contents = append(current.Contents, FileItem{Type: "directory", Name: dirName, Contents: []FileItem{}})
+			current = &current.Contents[len(current.Contents)-1]
+		}
+	}
+	current.Contents = append(current.Contents, FileItem{Type: "file", Name: parts[len(parts)-1], Content: content})
+}
+
+var backtickRuns = regexp.MustCompile("`{3,}")
+
+// Markdown returns the project as a compact markdown bundle: the
+// instructions, then every file in a fenced code block under its path.
+func (o *Project) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Project %s\n\n", o.Root)
+	if o.Instructions != "" {
+		fmt.Fprintf(&b, "## Instructions\n\n%s\n\n", strings.TrimSpace(o.Instructions))
+	}
+	fmt.Fprintf(&b, "## Files\n\n%d files, ~%d tokens\n", len(o.Files), o.Tokens)
+	for _, file := range o.Files {
+		fence := "```"
+		for _, run := range backtickRuns.FindAllString(file.Content, -1) {
+			if len(run) >= len(fence) {
+				fence = strings.Repeat("`", len(run)+1)
+			}
+		}
+		language := strings.TrimPrefix(path.Ext(file.Path), ".")
+		content := file.Content
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n%s%s\n%s%s\n", file.Path, fence, language, content, fence)
+	}
+	if len(o.Omitted) > 0 {
+		b.WriteString("\n## Omitted files\n\n")
+		for _, omitted := range o.Omitted {
+			fmt.Fprintf(&b, "- %s (%s)\n", omitted.Path, omitted.Reason)
+		}
+	}
+	return b.String()
+}
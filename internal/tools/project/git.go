@@ -0,0 +1,96 @@
+package project
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// recentChanges scores the files below root changed in the working tree or
+// in the last commits of its repository, newer changes score higher. Paths are
+// relative to root. Directories that are not in a repository score nothing.
+func recentChanges(root string, commits int) (ret map[string]int) {
+	ret = map[string]int{}
+	if commits < 0 {
+		return
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return
+	}
+	repo, err := git.PlainOpenWithOptions(absRoot, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return
+	}
+
+	score := func(repoPath string, value int) {
+		relPath, relErr := filepath.Rel(absRoot, filepath.Join(worktree.Filesystem.Root(), filepath.FromSlash(repoPath)))
+		if relErr != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return
+		}
+		relPath = filepath.ToSlash(relPath)
+		ret[relPath] = max(ret[relPath], value)
+	}
+
+	if status, statusErr := worktree.Status(); statusErr == nil {
+		for path, fileStatus := range status {
+			if fileStatus.Worktree != git.Unmodified || fileStatus.Staging != git.Unmodified {
+				score(path, commits+10)
+			}
+		}
+	}
+
+	history, err := repo.Log(&git.LogOptions{})
+	if err != nil {
+		// a repository without commits
+		return
+	}
+	i := 0
+	err = history.ForEach(func(commit *object.Commit) error {
+		if i >= commits {
+			return storer.ErrStop
+		}
+		for _, path := range changedFiles(commit) {
+			score(path, commits-i)
+		}
+		i++
+		return nil
+	})
+	if err != nil && !errors.Is(err, storer.ErrStop) {
+		debuglog.Log("Failed to read the git history of %s: %v\n", root, err)
+	}
+	return
+}
+
+// changedFiles returns the paths a commit changed compared to its first parent.
+func changedFiles(commit *object.Commit) (ret []string) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return
+	}
+	var parentTree *object.Tree
+	if parent, parentErr := commit.Parent(0); parentErr == nil {
+		if parentTree, err = parent.Tree(); err != nil {
+			return
+		}
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return
+	}
+	for _, change := range changes {
+		if change.To.Name != "" {
+			ret = append(ret, change.To.Name)
+		}
+	}
+	return
+}
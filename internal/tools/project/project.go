@@ -0,0 +1,363 @@
+// Package project builds the context of a code project for a chat request:
+// the files of a directory that git does not ignore, filtered by glob and
+// language, without binaries and limited to a token budget. When not every
+// file fits, the files most relevant to the instructions and the recently
+// changed ones are kept.
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danielmiessler/fabric/internal/tools/githelper"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+const (
+	// DefaultMaxTokens is the token budget of the file contents
+	DefaultMaxTokens = 100000
+	// MaxFileBytes skips larger files, which are mostly generated or data
+	MaxFileBytes = 1 << 20
+	// DefaultGitHistory is the number of recent commits whose files are preferred
+	DefaultGitHistory = 20
+
+	// charsPerToken is the rough estimate used to convert the token budget to characters
+	charsPerToken = 4
+	// binarySniffBytes is how much of a file is checked for binary content
+	binarySniffBytes = 8000
+)
+
+// Reasons files are left out of a project
+const (
+	OmittedBinary = "binary"
+	OmittedSize   = "too large"
+	OmittedBudget = "token budget"
+)
+
+// Languages maps the names accepted by Options.Languages to file extensions.
+var Languages = map[string][]string{
+	"c":          {".c", ".h"},
+	"cpp":        {".cpp", ".cc", ".cxx", ".hpp", ".hh", ".h"},
+	"csharp":     {".cs"},
+	"css":        {".css", ".scss", ".sass", ".less"},
+	"go":         {".go"},
+	"html":       {".html", ".htm"},
+	"java":       {".java"},
+	"javascript": {".js", ".jsx", ".mjs", ".cjs"},
+	"json":       {".json"},
+	"kotlin":     {".kt", ".kts"},
+	"markdown":   {".md", ".markdown"},
+	"php":        {".php"},
+	"python":     {".py", ".pyi"},
+	"ruby":       {".rb"},
+	"rust":       {".rs"},
+	"scala":      {".scala"},
+	"shell":      {".sh", ".bash", ".zsh"},
+	"sql":        {".sql"},
+	"swift":      {".swift"},
+	"toml":       {".toml"},
+	"typescript": {".ts", ".tsx", ".mts", ".cts"},
+	"yaml":       {".yaml", ".yml"},
+}
+
+// Options select the files of a project.
+type Options struct {
+	// Include keeps only files matching one of these gitignore style globs
+	Include []string
+	// Exclude leaves out files matching one of these gitignore style globs
+	Exclude []string
+	// Languages keeps only files of these languages, names of Languages or
+	// extensions like ".proto"
+	Languages []string
+	// MaxDepth limits the directory depth, 0 means no limit
+	MaxDepth int
+	// MaxTokens is the token budget of the file contents, 0 means
+	// DefaultMaxTokens and a negative value no limit
+	MaxTokens int
+	// Instructions are sent with the files and rank them by relevance
+	Instructions string
+	// GitHistory is the number of recent commits whose files are preferred,
+	// 0 means DefaultGitHistory and a negative value none
+	GitHistory int
+}
+
+// File is a file included in a project.
+type File struct {
+	// Path is the slash separated path relative to the project root
+	Path    string
+	Content string
+	Tokens  int
+	// Score ranks the file by relevance and recent changes
+	Score int
+}
+
+// Omitted is a file left out of a project.
+type Omitted struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// Project is the selected content of a project directory.
+type Project struct {
+	// Root is the directory as given
+	Root         string
+	Instructions string
+	Directories  int
+	// Files are sorted by path
+	Files   []*File
+	Omitted []Omitted
+	Tokens  int
+}
+
+// Scan reads the files of the project in root that git does not ignore and
+// that match the options.
+func Scan(root string, opts *Options) (ret *Project, err error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	var info os.FileInfo
+	if info, err = os.Stat(root); err != nil {
+		return
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	var absRoot string
+	if absRoot, err = filepath.Abs(root); err != nil {
+		return
+	}
+
+	var ignored func(relPath string, isDir bool) bool
+	if ignored, err = ignoreFunc(absRoot); err != nil {
+		return
+	}
+	var selected func(relPath string) bool
+	if selected, err = selectFunc(opts); err != nil {
+		return
+	}
+	excluded := patternsMatcher(opts.Exclude)
+
+	ret = &Project{Root: root, Instructions: opts.Instructions}
+	var candidates []*File
+	err = filepath.WalkDir(absRoot, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		relPath, relErr := filepath.Rel(absRoot, path)
+		if relErr != nil {
+			return relErr
+		}
+		if relPath == "." {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+		isDir := entry.IsDir()
+		tooDeep := opts.MaxDepth > 0 && strings.Count(relPath, "/")+1 > opts.MaxDepth
+		if entry.Name() == ".git" || tooDeep || ignored(relPath, isDir) ||
+			excluded != nil && excluded.Match(githelper.SplitPath(relPath), isDir) {
+			if isDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isDir {
+			ret.Directories++
+			return nil
+		}
+		if !entry.Type().IsRegular() || !selected(relPath) {
+			return nil
+		}
+
+		fileInfo, infoErr := entry.Info()
+		if infoErr != nil {
+			return infoErr
+		}
+		if fileInfo.Size() > MaxFileBytes {
+			ret.Omitted = append(ret.Omitted, Omitted{Path: relPath, Reason: OmittedSize})
+			return nil
+		}
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return fmt.Errorf("error reading file %s: %w", path, readErr)
+		}
+		if IsBinary(content) {
+			ret.Omitted = append(ret.Omitted, Omitted{Path: relPath, Reason: OmittedBinary})
+			return nil
+		}
+		candidates = append(candidates, &File{Path: relPath, Content: string(content), Tokens: EstimateTokens(relPath) + EstimateTokens(string(content))})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret.selectFiles(candidates, opts)
+	return
+}
+
+// selectFiles keeps the highest scored files that fit in the token budget.
+func (o *Project) selectFiles(candidates []*File, opts *Options) {
+	maxTokens := opts.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = DefaultMaxTokens
+	}
+
+	total := 0
+	for _, file := range candidates {
+		total += file.Tokens
+	}
+	if maxTokens > 0 && total > maxTokens {
+		terms := relevanceTerms(opts.Instructions)
+		history := opts.GitHistory
+		if history == 0 {
+			history = DefaultGitHistory
+		}
+		recent := recentChanges(o.Root, history)
+		for _, file := range candidates {
+			file.Score = relevance(file, terms) + recent[file.Path]
+		}
+		// stable keeps the path order among files of the same score
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	}
+
+	for _, file := range candidates {
+		if maxTokens > 0 && o.Tokens+file.Tokens > maxTokens {
+			o.Omitted = append(o.Omitted, Omitted{Path: file.Path, Reason: OmittedBudget})
+			continue
+		}
+		o.Files = append(o.Files, file)
+		o.Tokens += file.Tokens
+	}
+	sort.Slice(o.Files, func(i, j int) bool { return o.Files[i].Path < o.Files[j].Path })
+	sort.Slice(o.Omitted, func(i, j int) bool { return o.Omitted[i].Path < o.Omitted[j].Path })
+}
+
+// ignoreFunc returns a matcher of the paths below root that git ignores. The
+// .gitignore files of the whole worktree apply if root is inside one.
+func ignoreFunc(root string) (ret func(relPath string, isDir bool) bool, err error) {
+	if worktree, openErr := githelper.OpenWorktree(root); openErr == nil {
+		var prefix string
+		if prefix, err = filepath.Rel(worktree.Root, root); err != nil {
+			return
+		}
+		return func(relPath string, isDir bool) bool {
+			return worktree.IsIgnored(filepath.Join(prefix, relPath), isDir)
+		}, nil
+	}
+
+	var matcher gitignore.Matcher
+	if matcher, err = githelper.NewIgnoreMatcher(root); err != nil {
+		return
+	}
+	return func(relPath string, isDir bool) bool {
+		return matcher.Match(githelper.SplitPath(relPath), isDir)
+	}, nil
+}
+
+// selectFunc returns a matcher of the file paths selected by the include
+// globs and languages of the options.
+func selectFunc(opts *Options) (ret func(relPath string) bool, err error) {
+	extensions := map[string]bool{}
+	for _, language := range opts.Languages {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if strings.HasPrefix(language, ".") {
+			extensions[language] = true
+			continue
+		}
+		languageExtensions, ok := Languages[language]
+		if !ok {
+			var names []string
+			for name := range Languages {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown language '%s', use an extension like .proto or one of: %s", language, strings.Join(names, ", "))
+		}
+		for _, extension := range languageExtensions {
+			extensions[extension] = true
+		}
+	}
+	included := patternsMatcher(opts.Include)
+
+	return func(relPath string) bool {
+		if len(extensions) > 0 && !extensions[strings.ToLower(filepath.Ext(relPath))] {
+			return false
+		}
+		return included == nil || included.Match(githelper.SplitPath(relPath), false)
+	}, nil
+}
+
+// patternsMatcher matches gitignore style globs, nil without globs.
+func patternsMatcher(globs []string) gitignore.Matcher {
+	var patterns []gitignore.Pattern
+	for _, glob := range globs {
+		if glob = strings.TrimSpace(glob); glob != "" {
+			patterns = append(patterns, gitignore.ParsePattern(glob, nil))
+		}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	return gitignore.NewMatcher(patterns)
+}
+
+// IsBinary reports whether content looks like binary data rather than text.
+func IsBinary(content []byte) bool {
+	sniff := content
+	if len(sniff) > binarySniffBytes {
+		sniff = sniff[:binarySniffBytes]
+		// do not fail on a multi-byte character cut off at the end
+		for i := 0; i < utf8.UTFMax && !utf8.Valid(sniff); i++ {
+			sniff = sniff[:len(sniff)-1]
+		}
+	}
+	return bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(sniff)
+}
+
+// EstimateTokens estimates the number of tokens of text.
+func EstimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true, "from": true,
+	"into": true, "all": true, "are": true, "add": true, "use": true, "make": true, "should": true,
+	"when": true, "not": true, "new": true, "file": true, "files": true, "code": true,
+}
+
+// relevanceTerms returns the lower case words of the instructions that are
+// worth searching for.
+func relevanceTerms(instructions string) (ret []string) {
+	seen := map[string]bool{}
+	for _, word := range wordPattern.FindAllString(strings.ToLower(instructions), -1) {
+		if len(word) < 3 || stopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		ret = append(ret, word)
+	}
+	return
+}
+
+// relevance scores a file by the terms in its path, which weigh most, and in
+// its content.
+func relevance(file *File, terms []string) (ret int) {
+	path := strings.ToLower(file.Path)
+	content := strings.ToLower(file.Content)
+	for _, term := range terms {
+		if strings.Contains(path, term) {
+			ret += 20
+		}
+		ret += min(strings.Count(content, term), 10)
+	}
+	return
+}
@@ -0,0 +1,234 @@
+package project
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func paths(files []*File) (ret []string) {
+	for _, file := range files {
+		ret = append(ret, file.Path)
+	}
+	return
+}
+
+func TestScanFilters(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitignore":          "build/\n*.log\n",
+		"main.go":             "package main\n",
+		"internal/util.go":    "package internal\n",
+		"internal/util.py":    "print(1)\n",
+		"build/out.go":        "package build\n",
+		"debug.log":           "log\n",
+		"image.png":           "\x89PNG\r\n\x1a\n\x00\x00",
+		"node_modules/x/a.js": "x\n",
+		"docs/readme.md":      "# Docs\n",
+	})
+
+	tests := []struct {
+		name    string
+		opts    *Options
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "gitignore and binaries",
+			opts: &Options{},
+			want: []string{".gitignore", "docs/readme.md", "internal/util.go", "internal/util.py", "main.go", "node_modules/x/a.js"},
+		},
+		{
+			name: "language",
+			opts: &Options{Languages: []string{"go"}},
+			want: []string{"internal/util.go", "main.go"},
+		},
+		{
+			name: "extension",
+			opts: &Options{Languages: []string{".py", "markdown"}},
+			want: []string{"docs/readme.md", "internal/util.py"},
+		},
+		{
+			name: "include and exclude globs",
+			opts: &Options{Include: []string{"internal/**"}, Exclude: []string{"*.py"}},
+			want: []string{"internal/util.go"},
+		},
+		{
+			name: "exclude directory",
+			opts: &Options{Exclude: []string{"node_modules", ".gitignore"}, Languages: []string{"javascript", "go"}},
+			want: []string{"internal/util.go", "main.go"},
+		},
+		{
+			name: "depth",
+			opts: &Options{MaxDepth: 1, Languages: []string{"go"}},
+			want: []string{"main.go"},
+		},
+		{
+			name:    "unknown language",
+			opts:    &Options{Languages: []string{"cobolish"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, err := Scan(dir, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := strings.Join(paths(project.Files), ","); got != strings.Join(tt.want, ",") {
+				t.Errorf("Scan() files = %s, want %s", got, strings.Join(tt.want, ","))
+			}
+		})
+	}
+
+	project, _ := Scan(dir, nil)
+	if len(project.Omitted) != 1 || project.Omitted[0].Path != "image.png" || project.Omitted[0].Reason != OmittedBinary {
+		t.Errorf("expected image.png to be omitted as binary, got %+v", project.Omitted)
+	}
+}
+
+func TestScanSubdirectoryOfRepository(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{
+		".gitignore":        "*.gen.go\n",
+		"app/main.go":       "package main\n",
+		"app/types.gen.go":  "package main\n",
+		"other/ignored.txt": "x\n",
+	})
+
+	project, err := Scan(filepath.Join(dir, "app"), nil)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if got := strings.Join(paths(project.Files), ","); got != "main.go" {
+		t.Errorf("expected the .gitignore of the repository to apply, got %s", got)
+	}
+}
+
+func TestScanBudgetPrefersRelevantAndRecentFiles(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filler := strings.Repeat("lorem ipsum ", 40)
+	writeFiles(t, dir, map[string]string{
+		"a.go":       filler,
+		"b.go":       filler,
+		"c.go":       filler,
+		"payment.go": filler + "charge the card",
+	})
+	worktree, _ := repo.Worktree()
+	if _, err = worktree.Add("."); err != nil {
+		t.Fatal(err)
+	}
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	if _, err = worktree.Commit("initial", &git.CommitOptions{Author: signature}); err != nil {
+		t.Fatal(err)
+	}
+	// an uncommitted change
+	writeFiles(t, dir, map[string]string{"c.go": filler + "changed"})
+
+	project, err := Scan(dir, &Options{MaxTokens: 300, Instructions: "Fix the payment charge"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if got := strings.Join(paths(project.Files), ","); got != "c.go,payment.go" {
+		t.Errorf("expected the relevant and the changed file, got %s", got)
+	}
+	if project.Tokens > 300 {
+		t.Errorf("expected at most 300 tokens, got %d", project.Tokens)
+	}
+	if len(project.Omitted) != 2 || project.Omitted[0].Reason != OmittedBudget {
+		t.Errorf("expected two files omitted for the budget, got %+v", project.Omitted)
+	}
+
+	project, _ = Scan(dir, &Options{MaxTokens: -1})
+	if len(project.Files) != 4 {
+		t.Errorf("expected all files without a budget, got %d", len(project.Files))
+	}
+}
+
+func TestFormats(t *testing.T) {
+	project := &Project{
+		Root:         ".",
+		Instructions: "Add tests",
+		Directories:  1,
+		Files: []*File{
+			{Path: "README.md", Content: "# Readme\n```sh\nmake\n```\n"},
+			{Path: "src/main.go", Content: "package main"},
+		},
+		Omitted: []Omitted{{Path: "logo.png", Reason: OmittedBinary}},
+		Tokens:  20,
+	}
+
+	jsonOutput, err := project.Format(FormatJSON)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	var items []map[string]any
+	if err = json.Unmarshal([]byte(jsonOutput), &items); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(items) != 3 || items[0]["type"] != "directory" || items[1]["type"] != "report" || items[2]["details"] != "Add tests" {
+		t.Errorf("unexpected JSON structure: %s", jsonOutput)
+	}
+	if items[1]["files"] != float64(2) || items[1]["directories"] != float64(2) {
+		t.Errorf("unexpected report: %v", items[1])
+	}
+	if !strings.Contains(jsonOutput, `"name": "src"`) || !strings.Contains(jsonOutput, `"reason": "binary"`) {
+		t.Errorf("expected the src directory and the omitted file: %s", jsonOutput)
+	}
+
+	markdown, err := project.Format(FormatMarkdown)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	for _, want := range []string{"## Instructions\n\nAdd tests", "### src/main.go\n\n```go\npackage main\n```", "````md\n# Readme", "- logo.png (binary)"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown does not contain %q:\n%s", want, markdown)
+		}
+	}
+
+	if _, err = project.Format("xml"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	if IsBinary([]byte("plain text, ünïcode")) {
+		t.Errorf("text detected as binary")
+	}
+	if !IsBinary([]byte{0x7f, 'E', 'L', 'F', 0, 1}) {
+		t.Errorf("binary not detected")
+	}
+	long := []byte(strings.Repeat("a", binarySniffBytes-1) + "ü")
+	if IsBinary(long) {
+		t.Errorf("text with a character cut off at the sniff limit detected as binary")
+	}
+}
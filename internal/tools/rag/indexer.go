@@ -0,0 +1,166 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/util"
+)
+
+// embedBatchSize bounds the number of chunks sent to the embedder at once.
+const embedBatchSize = 64
+
+// IndexedExtensions lists the file types picked up when indexing a directory.
+var IndexedExtensions = map[string]struct{}{
+	".md": {}, ".markdown": {}, ".txt": {}, ".rst": {}, ".adoc": {}, ".org": {},
+	".go": {}, ".py": {}, ".js": {}, ".jsx": {}, ".ts": {}, ".tsx": {}, ".java": {},
+	".kt": {}, ".c": {}, ".h": {}, ".cpp": {}, ".hpp": {}, ".cs": {}, ".rb": {},
+	".rs": {}, ".php": {}, ".swift": {}, ".scala": {}, ".sh": {}, ".sql": {},
+	".yaml": {}, ".yml": {}, ".toml": {}, ".json": {}, ".html": {}, ".css": {},
+}
+
+var skippedDirs = map[string]struct{}{
+	".git": {}, "node_modules": {}, "vendor": {}, "__pycache__": {}, ".venv": {}, "dist": {}, "build": {},
+}
+
+// IndexStats summarizes what an indexing run changed.
+type IndexStats struct {
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int
+	Chunks    int
+}
+
+func (o IndexStats) String() string {
+	return fmt.Sprintf("%d added, %d updated, %d removed, %d unchanged, %d chunks total",
+		o.Added, o.Updated, o.Removed, o.Unchanged, o.Chunks)
+}
+
+// Indexer (re)builds collections. Files whose sha256 did not change since the
+// last run keep their existing chunks and vectors.
+type Indexer struct {
+	Embedder       ai.Embedder
+	EmbeddingModel string
+	Chunker        *util.Chunker
+}
+
+func (o *Indexer) Index(ctx context.Context, collection *fsdb.Collection, root string) (stats IndexStats, err error) {
+	if root, err = filepath.Abs(root); err != nil {
+		return
+	}
+
+	if collection.Files == nil {
+		collection.Files = map[string]string{}
+	}
+	if collection.EmbeddingModel != "" && collection.EmbeddingModel != o.EmbeddingModel {
+		// Vectors from different models are not comparable, start over.
+		collection.Files = map[string]string{}
+		collection.Chunks = nil
+	}
+	collection.Root = root
+	collection.EmbeddingModel = o.EmbeddingModel
+
+	seen := map[string]bool{}
+	var pending []*fsdb.CollectionChunk
+
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() {
+			if _, skip := skippedDirs[entry.Name()]; skip && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, ok := IndexedExtensions[strings.ToLower(filepath.Ext(path))]; !ok {
+			return nil
+		}
+
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return fmt.Errorf("could not read %s: %w", path, readErr)
+		}
+		if !utf8.Valid(content) {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+
+		hash := fmt.Sprintf("%x", sha256.Sum256(content))
+		previous, known := collection.Files[rel]
+		if known && previous == hash {
+			stats.Unchanged++
+			return nil
+		}
+		if known {
+			collection.RemoveSource(rel)
+			stats.Updated++
+		} else {
+			stats.Added++
+		}
+		collection.Files[rel] = hash
+
+		for _, chunk := range o.Chunker.Split(string(content)) {
+			pending = append(pending, &fsdb.CollectionChunk{
+				Source:    rel,
+				StartLine: chunk.StartLine,
+				EndLine:   chunk.EndLine,
+				Text:      chunk.Text,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	for _, source := range collection.Sources() {
+		if !seen[source] {
+			collection.RemoveSource(source)
+			stats.Removed++
+		}
+	}
+
+	if err = o.embed(ctx, pending); err != nil {
+		return
+	}
+	collection.Chunks = append(collection.Chunks, pending...)
+	stats.Chunks = len(collection.Chunks)
+	return
+}
+
+func (o *Indexer) embed(ctx context.Context, chunks []*fsdb.CollectionChunk) (err error) {
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(chunks))
+		texts := make([]string, 0, end-start)
+		for _, chunk := range chunks[start:end] {
+			texts = append(texts, embeddingText(chunk))
+		}
+		var vectors [][]float32
+		if vectors, err = o.Embedder.Embed(ctx, "", texts); err != nil {
+			return fmt.Errorf("embedding failed: %w", err)
+		}
+		for i, vector := range vectors {
+			chunks[start+i].Vector = vector
+		}
+	}
+	return
+}
+
+// embeddingText prefixes the chunk with its source so that file names
+// contribute to retrieval.
+func embeddingText(chunk *fsdb.CollectionChunk) string {
+	return chunk.Source + "\n" + chunk.Text
+}
@@ -0,0 +1,77 @@
+package rag
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	LocalEmbeddingModel      = "local-hash"
+	LocalEmbeddingDimensions = 512
+)
+
+// LocalEmbedder is an offline embedder based on feature hashing of word
+// unigrams and bigrams. It is deterministic and dependency free, which makes
+// it a reasonable default for small collections and for tests.
+type LocalEmbedder struct {
+	Dimensions int
+}
+
+func NewLocalEmbedder(dimensions int) *LocalEmbedder {
+	return &LocalEmbedder{Dimensions: dimensions}
+}
+
+func (o *LocalEmbedder) Embed(_ context.Context, _ string, texts []string) (ret [][]float32, err error) {
+	ret = make([][]float32, len(texts))
+	for i, text := range texts {
+		ret[i] = o.embed(text)
+	}
+	return
+}
+
+func (o *LocalEmbedder) embed(text string) []float32 {
+	vector := make([]float32, o.Dimensions)
+	words := tokenize(text)
+	for i, word := range words {
+		o.add(vector, word)
+		if i > 0 {
+			o.add(vector, words[i-1]+" "+word)
+		}
+	}
+	normalize(vector)
+	return vector
+}
+
+func (o *LocalEmbedder) add(vector []float32, feature string) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(feature))
+	sum := h.Sum32()
+	sign := float32(1)
+	if sum&1 == 1 {
+		sign = -1
+	}
+	vector[(sum>>1)%uint32(o.Dimensions)] += sign
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func normalize(vector []float32) {
+	var sum float64
+	for _, value := range vector {
+		sum += float64(value) * float64(value)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vector {
+		vector[i] /= norm
+	}
+}
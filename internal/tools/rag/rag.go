@@ -0,0 +1,104 @@
+// Package rag provides retrieval-augmented context from local document collections.
+//
+// A collection is built by walking a directory, splitting every supported file
+// into overlapping chunks and embedding each chunk. At chat time the input is
+// embedded as well and the most similar chunks are injected, with source
+// citations, into the system message.
+//
+// Embeddings are produced by a configured vendor implementing ai.Embedder
+// (OpenAI, Ollama, ...). Without a configured vendor a local hashing embedder
+// is used, which needs no network access but only captures lexical overlap.
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/util"
+)
+
+const (
+	DefaultChunkSize    = 1500
+	DefaultChunkOverlap = 200
+	DefaultTopK         = 5
+)
+
+func NewRAG(getEmbedder func(vendorName string) ai.Embedder) (ret *RAG) {
+
+	label := "RAG"
+	ret = &RAG{getEmbedder: getEmbedder}
+
+	ret.PluginBase = &plugins.PluginBase{
+		Name:             label,
+		SetupDescription: "RAG - Embedding settings for local document collections (--index-collection, --rag)",
+		EnvNamePrefix:    plugins.BuildEnvVariablePrefix(label),
+	}
+
+	ret.EmbeddingVendor = ret.AddSetupQuestionCustom("Embedding Vendor", false,
+		"Enter the vendor used for embeddings (e.g. OpenAI, Ollama; leave empty for the offline local embedder)")
+	ret.EmbeddingModel = ret.AddSetupQuestionCustom("Embedding Model", false,
+		"Enter the embedding model (e.g. text-embedding-3-small, nomic-embed-text)")
+	ret.ChunkSize = ret.AddSetupQuestionCustom("Chunk Size", false,
+		"Enter the maximum chunk size in characters")
+	ret.ChunkOverlap = ret.AddSetupQuestionCustom("Chunk Overlap", false,
+		"Enter the chunk overlap in characters")
+
+	return
+}
+
+type RAG struct {
+	*plugins.PluginBase
+	EmbeddingVendor *plugins.SetupQuestion
+	EmbeddingModel  *plugins.SetupQuestion
+	ChunkSize       *plugins.SetupQuestion
+	ChunkOverlap    *plugins.SetupQuestion
+
+	getEmbedder func(vendorName string) ai.Embedder
+}
+
+// Embedder resolves the configured embedding backend and the model label that
+// is stored with a collection, so that a collection is never queried with
+// vectors from a different model.
+func (o *RAG) Embedder() (embedder ai.Embedder, model string, err error) {
+	vendorName := o.EmbeddingVendor.Value
+	if vendorName == "" {
+		return NewLocalEmbedder(LocalEmbeddingDimensions), LocalEmbeddingModel, nil
+	}
+	if o.getEmbedder != nil {
+		embedder = o.getEmbedder(vendorName)
+	}
+	if embedder == nil {
+		err = fmt.Errorf("vendor %s is not configured or does not support embeddings", vendorName)
+		return
+	}
+	model = fmt.Sprintf("%s|%s", vendorName, o.EmbeddingModel.Value)
+	embedder = &modelEmbedder{embedder: embedder, model: o.EmbeddingModel.Value}
+	return
+}
+
+func (o *RAG) Chunker() *util.Chunker {
+	return &util.Chunker{
+		Size:    parseIntOrDefault(o.ChunkSize.Value, DefaultChunkSize),
+		Overlap: parseIntOrDefault(o.ChunkOverlap.Value, DefaultChunkOverlap),
+	}
+}
+
+// modelEmbedder pins the configured model so callers do not need to know it.
+type modelEmbedder struct {
+	embedder ai.Embedder
+	model    string
+}
+
+func (o *modelEmbedder) Embed(ctx context.Context, _ string, texts []string) ([][]float32, error) {
+	return o.embedder.Embed(ctx, o.model, texts)
+}
+
+func parseIntOrDefault(value string, def int) int {
+	if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+		return parsed
+	}
+	return def
+}
@@ -0,0 +1,99 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/util"
+)
+
+func TestLocalEmbedder_Similarity(t *testing.T) {
+	embedder := NewLocalEmbedder(LocalEmbeddingDimensions)
+	vectors, err := embedder.Embed(context.Background(), "", []string{
+		"kubernetes deployment rollout",
+		"rolling out a kubernetes deployment",
+		"banana bread recipe",
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if cosine(vectors[0], vectors[1]) <= cosine(vectors[0], vectors[2]) {
+		t.Errorf("expected related texts to be more similar than unrelated ones")
+	}
+}
+
+func TestIndexer_IncrementalAndRetrieve(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "deploy.md", "# Deploy\n\nRun the kubernetes rollout with kubectl apply.")
+	writeFile(t, root, "cooking.txt", "Banana bread needs ripe bananas and flour.")
+	writeFile(t, root, "image.png", "not indexed")
+
+	indexer := &Indexer{
+		Embedder:       NewLocalEmbedder(LocalEmbeddingDimensions),
+		EmbeddingModel: LocalEmbeddingModel,
+		Chunker:        &util.Chunker{Size: DefaultChunkSize, Overlap: DefaultChunkOverlap},
+	}
+	collection := &fsdb.Collection{Name: "docs"}
+
+	stats, err := indexer.Index(context.Background(), collection, root)
+	if err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	if stats.Added != 2 || len(collection.Files) != 2 {
+		t.Fatalf("expected 2 added files, got %+v", stats)
+	}
+
+	stats, err = indexer.Index(context.Background(), collection, root)
+	if err != nil {
+		t.Fatalf("re-Index failed: %v", err)
+	}
+	if stats.Unchanged != 2 || stats.Added != 0 || stats.Updated != 0 {
+		t.Errorf("expected all files unchanged, got %+v", stats)
+	}
+
+	writeFile(t, root, "cooking.txt", "Sourdough needs a starter.")
+	if err = os.Remove(filepath.Join(root, "deploy.md")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "sub/ops.md", "Kubernetes rollout troubleshooting guide.")
+
+	if stats, err = indexer.Index(context.Background(), collection, root); err != nil {
+		t.Fatalf("third Index failed: %v", err)
+	}
+	if stats.Updated != 1 || stats.Removed != 1 || stats.Added != 1 {
+		t.Errorf("unexpected stats after changes: %+v", stats)
+	}
+	for _, chunk := range collection.Chunks {
+		if chunk.Source == "deploy.md" {
+			t.Errorf("chunks of removed file were kept")
+		}
+	}
+
+	results, err := Retrieve(context.Background(), indexer.Embedder, collection, "how do I do a kubernetes rollout?", 1)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Source != "sub/ops.md" {
+		t.Fatalf("expected sub/ops.md as best match, got %+v", results)
+	}
+
+	formatted := FormatContext("docs", results)
+	if !strings.Contains(formatted, "[1] sub/ops.md (lines 1-1)") {
+		t.Errorf("expected citation in formatted context, got %q", formatted)
+	}
+}
+
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,80 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+// ScoredChunk is a retrieved chunk with its cosine similarity to the query.
+type ScoredChunk struct {
+	*fsdb.CollectionChunk
+	Score float64
+}
+
+// Retrieve returns the topK chunks most similar to the query.
+func Retrieve(ctx context.Context, embedder ai.Embedder, collection *fsdb.Collection, query string, topK int) (ret []ScoredChunk, err error) {
+	if strings.TrimSpace(query) == "" || len(collection.Chunks) == 0 {
+		return
+	}
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+
+	var vectors [][]float32
+	if vectors, err = embedder.Embed(ctx, "", []string{query}); err != nil {
+		err = fmt.Errorf("could not embed query: %w", err)
+		return
+	}
+	if len(vectors) != 1 {
+		err = fmt.Errorf("expected one query embedding, got %d", len(vectors))
+		return
+	}
+
+	for _, chunk := range collection.Chunks {
+		ret = append(ret, ScoredChunk{CollectionChunk: chunk, Score: cosine(vectors[0], chunk.Vector)})
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Score > ret[j].Score })
+	if len(ret) > topK {
+		ret = ret[:topK]
+	}
+	return
+}
+
+// FormatContext renders retrieved chunks as a system message section with
+// numbered source citations the model can refer to.
+func FormatContext(collectionName string, chunks []ScoredChunk) string {
+	if len(chunks) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# RETRIEVED CONTEXT (collection: %s)\n\n", collectionName)
+	builder.WriteString("Use the following excerpts when relevant and cite them by their number, e.g. [1].\n\n")
+	for i, chunk := range chunks {
+		fmt.Fprintf(&builder, "[%d] %s (lines %d-%d)\n", i+1, chunk.Source, chunk.StartLine, chunk.EndLine)
+		builder.WriteString(chunk.Text)
+		builder.WriteString("\n\n")
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,109 @@
+package redact
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Built-in detector names usable in the detectors list of the configuration.
+const (
+	DetectorAPIKeys = "api_keys"
+	DetectorEmails  = "emails"
+	DetectorPhones  = "phones"
+	DetectorIPs     = "ips"
+)
+
+// DefaultDetectors are used when a configuration lists none.
+var DefaultDetectors = []string{DetectorAPIKeys, DetectorEmails, DetectorPhones, DetectorIPs}
+
+// detector finds one kind of sensitive value. When a pattern has a capture
+// group only the first group is replaced, so "api_key=VALUE" keeps its name.
+type detector struct {
+	kind     string
+	patterns []*regexp.Regexp
+	valid    func(string) bool
+	// bounded rejects matches inside words, e.g. "d::" in "std::vector"
+	bounded bool
+}
+
+var builtinDetectors = map[string]*detector{
+	DetectorAPIKeys: {
+		kind: "API_KEY",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]+?-----END [A-Z ]*PRIVATE KEY-----`),
+			regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`),
+			regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+			regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}\b`),
+			regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+			regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`),
+			regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+			regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+			regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+			regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`),
+			regexp.MustCompile(`(?i)\b(?:api[_-]?key|apikey|secret|token|passw(?:or)?d|access[_-]?key)["']?\s*[:=]\s*["']?([^\s"',;]{8,})`),
+		},
+	},
+	DetectorEmails: {
+		kind: "EMAIL",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+		},
+	},
+	DetectorIPs: {
+		kind:    "IP",
+		bounded: true,
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`),
+			regexp.MustCompile(`(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`),
+		},
+		valid: func(value string) bool {
+			// Times like 12:30:45, version strings and a bare "::" are not addresses
+			return net.ParseIP(value) != nil && strings.ContainsAny(value, "0123456789abcdefABCDEF") &&
+				(strings.Count(value, ":") >= 2 || strings.Count(value, ".") == 3)
+		},
+	},
+	DetectorPhones: {
+		kind: "PHONE",
+		patterns: []*regexp.Regexp{
+			// International numbers, with or without separators
+			regexp.MustCompile(`\+\d{1,3}(?:[ .-]?\(?\d{1,4}\)?){2,5}\d`),
+			// National numbers need separators to not match plain numbers
+			regexp.MustCompile(`(?:\(\d{2,4}\)\s?|\b\d{2,4}[ .-])\d{3,4}[ .-]\d{3,4}\b`),
+		},
+		valid: func(value string) bool {
+			digits := 0
+			for _, r := range value {
+				if r >= '0' && r <= '9' {
+					digits++
+				}
+			}
+			return digits >= 7 && digits <= 15
+		},
+	},
+}
+
+// customDetector builds a detector from a user rule; the placeholder kind is
+// the upper-cased rule name.
+func customDetector(rule CustomRule) (*detector, error) {
+	pattern, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	kind := strings.ToUpper(nonWord.ReplaceAllString(rule.Name, "_"))
+	if kind == "" {
+		kind = "CUSTOM"
+	}
+	return &detector{kind: kind, patterns: []*regexp.Regexp{pattern}}, nil
+}
+
+var nonWord = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// inWord reports whether text[start:end] touches a word character.
+func inWord(text string, start int, end int) bool {
+	return start > 0 && isWordByte(text[start-1]) || end < len(text) && isWordByte(text[end])
+}
@@ -0,0 +1,310 @@
+// Package redact scrubs secrets and personal data from messages before they
+// are sent to a vendor.
+//
+// Rules come from redaction.yaml in the fabric config directory. Every match
+// is replaced by a placeholder such as [EMAIL_1]; the same value always gets
+// the same placeholder within a request, so the model can still refer to it.
+// Placeholders in the response can be restored to the original values.
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+)
+
+// ConfigFileName is the name of the redaction rules file in the config directory.
+const ConfigFileName = "redaction.yaml"
+
+// CustomRule redacts matches of a regular expression. When the pattern has a
+// capture group only the first group is redacted.
+type CustomRule struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+// Rules select the detectors of a vendor. Unset fields of a vendor entry
+// inherit the top-level rules; custom rules are added to the top-level ones.
+type Rules struct {
+	Enabled   *bool        `yaml:"enabled,omitempty"`
+	Restore   *bool        `yaml:"restore,omitempty"`
+	Detectors []string     `yaml:"detectors,omitempty"`
+	Custom    []CustomRule `yaml:"custom,omitempty"`
+}
+
+// Config is the content of redaction.yaml.
+type Config struct {
+	Rules   `yaml:",inline"`
+	Vendors map[string]Rules `yaml:"vendors,omitempty"`
+}
+
+// LoadConfig reads the rules file. A missing file returns nil, which disables
+// redaction.
+func LoadConfig(path string) (ret *Config, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read redaction rules: %w", err)
+	}
+	ret = &Config{}
+	if err = yaml.Unmarshal(data, ret); err != nil {
+		return nil, fmt.Errorf("failed to parse redaction rules %s: %w", path, err)
+	}
+	// Validate the rules of every vendor up front
+	for _, vendor := range append([]string{""}, ret.vendorNames()...) {
+		if _, err = ret.ForVendor(vendor); err != nil {
+			return nil, fmt.Errorf("invalid redaction rules in %s: %w", path, err)
+		}
+	}
+	return
+}
+
+// ForceEnabled returns a copy of the rules with redaction switched on by default,
+// or the default rules when there is no configuration. Vendors disabled
+// explicitly stay disabled.
+func (o *Config) ForceEnabled() *Config {
+	enabled := true
+	ret := &Config{}
+	if o != nil {
+		*ret = *o
+	}
+	ret.Rules.Enabled = &enabled
+	return ret
+}
+
+func (o *Config) vendorNames() (ret []string) {
+	for name := range o.Vendors {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return
+}
+
+// ForVendor builds a redactor with the rules of the vendor, or returns nil
+// when redaction is disabled for it.
+func (o *Config) ForVendor(vendorName string) (ret *Redactor, err error) {
+	if o == nil {
+		return nil, nil
+	}
+	rules := o.Rules
+	for name, vendorRules := range o.Vendors {
+		if !strings.EqualFold(name, vendorName) {
+			continue
+		}
+		if vendorRules.Enabled != nil {
+			rules.Enabled = vendorRules.Enabled
+		}
+		if vendorRules.Restore != nil {
+			rules.Restore = vendorRules.Restore
+		}
+		if vendorRules.Detectors != nil {
+			rules.Detectors = vendorRules.Detectors
+		}
+		rules.Custom = append(append([]CustomRule(nil), rules.Custom...), vendorRules.Custom...)
+	}
+	if rules.Enabled != nil && !*rules.Enabled {
+		return nil, nil
+	}
+
+	ret = &Redactor{
+		Restore:      rules.Restore == nil || *rules.Restore,
+		placeholders: map[string]string{},
+		originals:    map[string]string{},
+		counters:     map[string]int{},
+	}
+	// Custom rules run first so they win over the generic detectors
+	for _, rule := range rules.Custom {
+		var custom *detector
+		if custom, err = customDetector(rule); err != nil {
+			return nil, fmt.Errorf("custom rule %q: %w", rule.Name, err)
+		}
+		ret.detectors = append(ret.detectors, custom)
+	}
+	detectors := rules.Detectors
+	if detectors == nil {
+		detectors = DefaultDetectors
+	}
+	for _, name := range detectors {
+		builtin, ok := builtinDetectors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown detector %q, expected one of %s", name, strings.Join(DefaultDetectors, ", "))
+		}
+		ret.detectors = append(ret.detectors, builtin)
+	}
+	return
+}
+
+// Redaction is one value replaced by a placeholder.
+type Redaction struct {
+	Placeholder string
+	Kind        string
+	Original    string
+}
+
+// Redactor replaces sensitive values and remembers them for restoring.
+type Redactor struct {
+	Restore bool
+
+	detectors    []*detector
+	placeholders map[string]string // original -> placeholder
+	originals    map[string]string // placeholder -> original
+	counters     map[string]int
+	redactions   []Redaction
+}
+
+// Redact replaces every detected value in text by its placeholder.
+func (o *Redactor) Redact(text string) string {
+	for _, d := range o.detectors {
+		for _, pattern := range d.patterns {
+			text = o.replace(text, d, pattern)
+		}
+	}
+	return text
+}
+
+func (o *Redactor) replace(text string, d *detector, pattern *regexp.Regexp) string {
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	// Placeholders of earlier detectors must stay intact
+	protected := placeholderPattern.FindAllStringIndex(text, -1)
+	overlapsPlaceholder := func(start, end int) bool {
+		for _, span := range protected {
+			if start < span[1] && end > span[0] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		// Only the first capture group is secret, e.g. the value of "token=..."
+		if len(match) >= 4 && match[2] >= 0 {
+			start, end = match[2], match[3]
+		}
+		value := text[start:end]
+		if value == "" || start < last || overlapsPlaceholder(start, end) ||
+			(d.bounded && inWord(text, start, end)) || (d.valid != nil && !d.valid(value)) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(o.placeholder(d.kind, value))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+var placeholderPattern = regexp.MustCompile(`\[[A-Z0-9_]+_\d+\]`)
+
+func (o *Redactor) placeholder(kind string, value string) string {
+	if placeholder, ok := o.placeholders[value]; ok {
+		return placeholder
+	}
+	o.counters[kind]++
+	placeholder := fmt.Sprintf("[%s_%d]", kind, o.counters[kind])
+	o.placeholders[value] = placeholder
+	o.originals[placeholder] = value
+	o.redactions = append(o.redactions, Redaction{Placeholder: placeholder, Kind: kind, Original: value})
+	return placeholder
+}
+
+// RedactMessages returns copies of the messages with the text content redacted;
+// the given messages are left untouched. Other parts such as documents are not
+// redacted, they have to be converted to text first.
+func (o *Redactor) RedactMessages(msgs []*chat.ChatCompletionMessage) []*chat.ChatCompletionMessage {
+	ret := make([]*chat.ChatCompletionMessage, len(msgs))
+	for i, msg := range msgs {
+		redacted := *msg
+		redacted.Content = o.Redact(msg.Content)
+		if len(msg.MultiContent) > 0 {
+			redacted.MultiContent = make([]chat.ChatMessagePart, len(msg.MultiContent))
+			for j, part := range msg.MultiContent {
+				if part.Type == chat.ChatMessagePartTypeText {
+					part.Text = o.Redact(part.Text)
+				}
+				redacted.MultiContent[j] = part
+			}
+		}
+		ret[i] = &redacted
+	}
+	return ret
+}
+
+// RestoreText replaces the placeholders in text by the original values.
+func (o *Redactor) RestoreText(text string) string {
+	if len(o.originals) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, len(o.originals)*2)
+	for placeholder, original := range o.originals {
+		pairs = append(pairs, placeholder, original)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Redactions lists the replaced values in the order they were found.
+func (o *Redactor) Redactions() []Redaction {
+	return o.redactions
+}
+
+// FormatRedactions renders the redactions for --dry-run.
+func FormatRedactions(redactions []Redaction) string {
+	if len(redactions) == 0 {
+		return "Redactions: none\n"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Redactions (%d):\n", len(redactions))
+	for _, redaction := range redactions {
+		fmt.Fprintf(&b, "  %s <- %s\n", redaction.Placeholder, strings.ReplaceAll(redaction.Original, "\n", "\\n"))
+	}
+	return b.String()
+}
+
+// StreamRestorer restores placeholders in streamed chunks, holding back a
+// trailing partial placeholder until the next chunk completes it.
+type StreamRestorer struct {
+	redactor *Redactor
+	pending  string
+}
+
+// maxPlaceholderLength bounds how much text is held back
+const maxPlaceholderLength = 64
+
+func (o *Redactor) NewStreamRestorer() *StreamRestorer {
+	return &StreamRestorer{redactor: o}
+}
+
+// Write returns the restored text that can be printed now.
+func (o *StreamRestorer) Write(chunk string) string {
+	text := o.pending + chunk
+	o.pending = ""
+	if i := strings.LastIndex(text, "["); i >= 0 && !strings.Contains(text[i:], "]") &&
+		len(text)-i < maxPlaceholderLength && partialPlaceholder.MatchString(text[i:]) {
+		o.pending = text[i:]
+		text = text[:i]
+	}
+	return o.redactor.RestoreText(text)
+}
+
+// Flush returns the held back text.
+func (o *StreamRestorer) Flush() string {
+	text := o.pending
+	o.pending = ""
+	return o.redactor.RestoreText(text)
+}
+
+var partialPlaceholder = regexp.MustCompile(`^\[[A-Z0-9_]*$`)
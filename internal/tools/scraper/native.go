@@ -0,0 +1,326 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/tools/converter"
+)
+
+const (
+	// DefaultUserAgent identifies the native scraper to web servers and robots.txt
+	DefaultUserAgent = "Fabric-Scraper/1.0 (+https://github.com/danielmiessler/fabric)"
+	// DefaultTimeout bounds each request
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxPages bounds the pages fetched for one URL
+	DefaultMaxPages = 10
+	// MaxPageSize limits each downloaded page (5MB)
+	MaxPageSize = 5 * 1024 * 1024
+)
+
+// Native scrapes web pages without an external service: the main content of
+// each page is extracted with readability and rendered as markdown.
+type Native struct {
+	Client    *http.Client
+	UserAgent string
+	// MaxDepth is how many levels of same-site links are followed, 0 only
+	// fetches the page itself. Pagination links (rel="next") are always
+	// followed within MaxPages.
+	MaxDepth int
+	MaxPages int
+
+	robots map[string]*robotsRules
+}
+
+// NewNative creates a native scraper with the default timeout and user agent.
+func NewNative(maxDepth int, maxPages int) *Native {
+	return &Native{
+		Client:    &http.Client{Timeout: DefaultTimeout},
+		UserAgent: DefaultUserAgent,
+		MaxDepth:  maxDepth,
+		MaxPages:  maxPages,
+	}
+}
+
+// Page is one scraped page.
+type Page struct {
+	URL      string
+	Title    string
+	Author   string
+	SiteName string
+	Date     *time.Time
+	Markdown string
+
+	links []string
+	next  []string
+}
+
+// ScrapeURL returns the main content of the page at rawURL as markdown,
+// followed by its next pages and linked same-site pages up to the limits.
+func (n *Native) ScrapeURL(rawURL string) (ret string, err error) {
+	var pages []*Page
+	if pages, err = n.Scrape(rawURL); err != nil {
+		return
+	}
+	parts := make([]string, 0, len(pages))
+	for _, page := range pages {
+		parts = append(parts, page.Format())
+	}
+	ret = strings.Join(parts, "\n\n---\n\n")
+	return
+}
+
+// Scrape fetches the pages reachable from rawURL in breadth-first order.
+// Only the first page must succeed, later failures are skipped.
+func (n *Native) Scrape(rawURL string) (ret []*Page, err error) {
+	var start *url.URL
+	if start, err = url.Parse(rawURL); err != nil || (start.Scheme != "http" && start.Scheme != "https") || start.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: expected an http or https URL", rawURL)
+	}
+	start.Fragment = ""
+
+	maxPages := n.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+
+	type queued struct {
+		url   string
+		depth int
+	}
+	queue := []queued{{url: start.String()}}
+	seen := map[string]bool{start.String(): true}
+	enqueue := func(link string, depth int) {
+		if !seen[link] {
+			seen[link] = true
+			queue = append(queue, queued{url: link, depth: depth})
+		}
+	}
+
+	for len(queue) > 0 && len(ret) < maxPages {
+		item := queue[0]
+		queue = queue[1:]
+
+		page, fetchErr := n.fetchPage(item.url)
+		if fetchErr != nil {
+			if len(ret) == 0 {
+				return nil, fetchErr
+			}
+			debuglog.Debug(debuglog.Detailed, "Scraper: skipping %s: %v\n", item.url, fetchErr)
+			continue
+		}
+		ret = append(ret, page)
+
+		// Pagination continues the same document at the same depth
+		for _, link := range page.next {
+			enqueue(link, item.depth)
+		}
+		if item.depth < n.MaxDepth {
+			for _, link := range page.links {
+				if sameSite(start, link) {
+					enqueue(link, item.depth+1)
+				}
+			}
+		}
+	}
+	return
+}
+
+// Format renders the page with its metadata in the layout of Jina Reader.
+func (p *Page) Format() string {
+	var b strings.Builder
+	if p.Title != "" {
+		fmt.Fprintf(&b, "Title: %s\n\n", p.Title)
+	}
+	fmt.Fprintf(&b, "URL Source: %s\n\n", p.URL)
+	if p.Author != "" {
+		fmt.Fprintf(&b, "Author: %s\n\n", p.Author)
+	}
+	if p.Date != nil {
+		fmt.Fprintf(&b, "Published Time: %s\n\n", p.Date.Format(time.RFC3339))
+	}
+	b.WriteString("Markdown Content:\n")
+	b.WriteString(p.Markdown)
+	return b.String()
+}
+
+func (n *Native) userAgent() string {
+	if n.UserAgent != "" {
+		return n.UserAgent
+	}
+	return DefaultUserAgent
+}
+
+func (n *Native) client() *http.Client {
+	if n.Client != nil {
+		return n.Client
+	}
+	return &http.Client{Timeout: DefaultTimeout}
+}
+
+func (n *Native) get(pageURL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", n.userAgent())
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.5")
+	resp, err := n.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	return resp, nil
+}
+
+// allowed checks the robots.txt of the page's site, fetched once per site.
+func (n *Native) allowed(pageURL *url.URL) bool {
+	site := pageURL.Scheme + "://" + pageURL.Host
+	if n.robots == nil {
+		n.robots = map[string]*robotsRules{}
+	}
+	rules, cached := n.robots[site]
+	if !cached {
+		rules = n.fetchRobots(site)
+		n.robots[site] = rules
+	}
+	return rules.Allowed(pageURL.RequestURI())
+}
+
+// fetchRobots treats a missing robots.txt as allowing everything and an
+// unreachable or failing one as disallowing everything.
+func (n *Native) fetchRobots(site string) *robotsRules {
+	resp, err := n.get(site + "/robots.txt")
+	if err != nil {
+		debuglog.Debug(debuglog.Detailed, "Scraper: robots.txt of %s unavailable: %v\n", site, err)
+		return disallowAll()
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return disallowAll()
+	case resp.StatusCode != http.StatusOK:
+		return &robotsRules{}
+	}
+	return parseRobots(io.LimitReader(resp.Body, 512*1024), n.userAgent())
+}
+
+func (n *Native) fetchPage(pageURL string) (ret *Page, err error) {
+	var parsed *url.URL
+	if parsed, err = url.Parse(pageURL); err != nil {
+		return
+	}
+	if !n.allowed(parsed) {
+		return nil, fmt.Errorf("%s is disallowed by robots.txt", pageURL)
+	}
+
+	var resp *http.Response
+	if resp, err = n.get(pageURL); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching %s: HTTP %d", pageURL, resp.StatusCode)
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(resp.Body, MaxPageSize+1)); err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if len(body) > MaxPageSize {
+		return nil, fmt.Errorf("page %s exceeds %d bytes", pageURL, MaxPageSize)
+	}
+
+	// The final URL after redirects resolves relative links
+	finalURL := resp.Request.URL
+	ret = &Page{URL: finalURL.String()}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml" || mediaType == "":
+	case strings.HasPrefix(mediaType, "text/"):
+		ret.Markdown = string(body)
+		return
+	default:
+		return nil, fmt.Errorf("unsupported content type %q at %s", mediaType, pageURL)
+	}
+
+	var article *converter.ReadableArticle
+	if article, err = converter.HtmlReadabilityMarkdown(string(body), finalURL); err != nil {
+		return nil, fmt.Errorf("error extracting content of %s: %w", pageURL, err)
+	}
+	ret.Title = article.Title
+	ret.Author = article.Byline
+	ret.SiteName = article.SiteName
+	ret.Date = article.PublishedTime
+	ret.Markdown = article.Markdown
+	ret.links, ret.next = extractLinks(body, finalURL)
+	return
+}
+
+// extractLinks returns the absolute http(s) links of a page and its
+// rel="next" pagination links.
+func extractLinks(body []byte, base *url.URL) (links []string, next []string) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && (node.DataAtom == atom.A || node.DataAtom == atom.Link) {
+			var href, rel string
+			for _, attr := range node.Attr {
+				switch attr.Key {
+				case "href":
+					href = attr.Val
+				case "rel":
+					rel = strings.ToLower(attr.Val)
+				}
+			}
+			if link := resolveLink(base, href); link != "" {
+				if strings.Contains(" "+rel+" ", " next ") {
+					next = append(next, link)
+				} else if node.DataAtom == atom.A && !strings.Contains(rel, "nofollow") {
+					links = append(links, link)
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return
+}
+
+func resolveLink(base *url.URL, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	resolved.Fragment = ""
+	return resolved.String()
+}
+
+// sameSite reports whether link is on the host of start.
+func sameSite(start *url.URL, link string) bool {
+	parsed, err := url.Parse(link)
+	return err == nil && strings.EqualFold(parsed.Host, start.Host)
+}
@@ -0,0 +1,224 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const articleTemplate = `<!DOCTYPE html>
+<html><head>
+<title>%[1]s</title>
+<meta name="author" content="Jane Doe">
+<meta property="article:published_time" content="2024-03-05T10:00:00Z">
+%[3]s
+</head><body>
+<nav><a href="/nav">Navigation</a></nav>
+<article>
+<h1>%[1]s</h1>
+<p>%[2]s This paragraph is long enough for readability to keep it as the main content of the page, so it repeats itself a little. This paragraph is long enough for readability to keep it as the main content of the page.</p>
+<p>Read <a href="/related">the related post</a> or <a href="https://elsewhere.example/">another site</a>.</p>
+</article>
+</body></html>`
+
+type testSite struct {
+	*httptest.Server
+	mu        sync.Mutex
+	requested []string
+	agents    []string
+}
+
+func newTestSite(t *testing.T, robots string) *testSite {
+	site := &testSite{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		if robots == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, robots)
+	})
+	page := func(title string, body string, head string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			site.mu.Lock()
+			site.requested = append(site.requested, r.URL.Path)
+			site.agents = append(site.agents, r.UserAgent())
+			site.mu.Unlock()
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprintf(w, articleTemplate, title, body, head)
+		}
+	}
+	mux.HandleFunc("/article", page("First Page", "Alpha content.", `<link rel="next" href="/article?page=2">`))
+	mux.HandleFunc("/related", page("Related Post", "Beta content.", ""))
+	mux.HandleFunc("/private/secret", page("Secret", "Hidden content.", ""))
+	mux.HandleFunc("/notes.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "plain notes")
+	})
+	mux.HandleFunc("/nav", page("Navigation", "Nav content.", ""))
+	site.Server = httptest.NewServer(mux)
+	t.Cleanup(site.Close)
+	return site
+}
+
+func (s *testSite) paths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requested...)
+}
+
+func TestNativeScrapeURLExtractsMarkdownAndMetadata(t *testing.T) {
+	site := newTestSite(t, "")
+	native := NewNative(0, 1)
+
+	result, err := native.ScrapeURL(site.URL + "/article")
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "Title: First Page")
+	assert.Contains(t, result, "URL Source: "+site.URL+"/article")
+	assert.Contains(t, result, "Author: Jane Doe")
+	assert.Contains(t, result, "Published Time: 2024-03-05T10:00:00Z")
+	assert.Contains(t, result, "Markdown Content:\n# First Page")
+	assert.Contains(t, result, "Alpha content.")
+	assert.Contains(t, result, "[the related post]("+site.URL+"/related)")
+	assert.NotContains(t, result, "<p>")
+
+	site.mu.Lock()
+	defer site.mu.Unlock()
+	assert.Equal(t, []string{DefaultUserAgent}, site.agents)
+}
+
+func TestNativeFollowsPaginationAndSameSiteLinks(t *testing.T) {
+	site := newTestSite(t, "")
+
+	pages, err := NewNative(0, 10).Scrape(site.URL + "/article")
+	require.NoError(t, err)
+	// rel="next" is followed without depth, /article?page=2 serves the same handler
+	require.Len(t, pages, 2)
+	assert.Equal(t, site.URL+"/article?page=2", pages[1].URL)
+	assert.NotContains(t, site.paths(), "/related")
+
+	site = newTestSite(t, "")
+	pages, err = NewNative(1, 10).Scrape(site.URL + "/article")
+	require.NoError(t, err)
+	var urls []string
+	for _, page := range pages {
+		urls = append(urls, page.URL)
+	}
+	assert.Contains(t, urls, site.URL+"/related")
+	assert.Contains(t, urls, site.URL+"/nav")
+	for _, u := range urls {
+		assert.True(t, strings.HasPrefix(u, site.URL), "left the site: %s", u)
+	}
+}
+
+func TestNativeMaxPages(t *testing.T) {
+	site := newTestSite(t, "")
+
+	pages, err := NewNative(3, 2).Scrape(site.URL + "/article")
+	require.NoError(t, err)
+	assert.Len(t, pages, 2)
+}
+
+func TestNativeRespectsRobots(t *testing.T) {
+	site := newTestSite(t, "User-agent: *\nDisallow: /private\nDisallow: /related\n\nUser-agent: OtherBot\nDisallow: /\n")
+
+	_, err := NewNative(0, 1).ScrapeURL(site.URL + "/private/secret")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disallowed by robots.txt")
+
+	pages, err := NewNative(1, 10).Scrape(site.URL + "/article")
+	require.NoError(t, err)
+	for _, page := range pages {
+		assert.NotEqual(t, site.URL+"/related", page.URL)
+	}
+	assert.NotContains(t, site.paths(), "/related")
+	assert.NotContains(t, site.paths(), "/private/secret")
+}
+
+func TestNativePlainText(t *testing.T) {
+	site := newTestSite(t, "")
+
+	result, err := NewNative(0, 1).ScrapeURL(site.URL + "/notes.txt")
+	require.NoError(t, err)
+	assert.Contains(t, result, "Markdown Content:\nplain notes")
+}
+
+func TestNativeErrors(t *testing.T) {
+	site := newTestSite(t, "")
+
+	_, err := NewNative(0, 1).ScrapeURL(site.URL + "/missing")
+	assert.ErrorContains(t, err, "HTTP 404")
+
+	_, err = NewNative(0, 1).ScrapeURL("ftp://example.com/file")
+	assert.ErrorContains(t, err, "invalid URL")
+}
+
+func TestParseRobots(t *testing.T) {
+	robots := `# comment
+User-agent: Fabric-Scraper
+User-agent: Another
+Disallow: /tmp/
+Allow: /tmp/public
+Disallow: /*.pdf$
+
+User-agent: *
+Disallow: /
+`
+	rules := parseRobots(strings.NewReader(robots), DefaultUserAgent)
+	assert.True(t, rules.Allowed("/index.html"))
+	assert.False(t, rules.Allowed("/tmp/file"))
+	assert.True(t, rules.Allowed("/tmp/public/file"))
+	assert.False(t, rules.Allowed("/docs/paper.pdf"))
+	assert.True(t, rules.Allowed("/docs/paper.pdf?download=1"))
+
+	rules = parseRobots(strings.NewReader(robots), "SomeOtherBot/1.0")
+	assert.False(t, rules.Allowed("/index.html"))
+
+	rules = parseRobots(strings.NewReader("User-agent: *\nDisallow:\n"), DefaultUserAgent)
+	assert.True(t, rules.Allowed("/anything"))
+}
+
+func TestScraperBackendSelection(t *testing.T) {
+	jina := &fakeScraper{}
+	s := NewScraper(jina)
+
+	_, err := s.URLScraper()
+	assert.ErrorContains(t, err, "not configured", "Jina AI is not the default without an API key")
+
+	s.Backend.Value = "jina"
+	backend, err := s.URLScraper()
+	require.NoError(t, err)
+	assert.Same(t, jina, backend, "Jina AI works without an API key when chosen")
+
+	s.Backend.Value = ""
+	jina.apiKey = true
+	backend, err = s.URLScraper()
+	require.NoError(t, err)
+	assert.Same(t, jina, backend)
+
+	s.Backend.Value = "native"
+	s.MaxDepth.Value = "2"
+	s.MaxPages.Value = "5"
+	backend, err = s.URLScraper()
+	require.NoError(t, err)
+	native, ok := backend.(*Native)
+	require.True(t, ok)
+	assert.Equal(t, 2, native.MaxDepth)
+	assert.Equal(t, 5, native.MaxPages)
+
+	s.Backend.Value = "other"
+	_, err = s.URLScraper()
+	assert.ErrorContains(t, err, "unknown scraping backend")
+}
+
+type fakeScraper struct{ apiKey bool }
+
+func (f *fakeScraper) ScrapeURL(string) (string, error) { return "", nil }
+func (f *fakeScraper) HasApiKey() bool                  { return f.apiKey }
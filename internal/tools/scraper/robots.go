@@ -0,0 +1,129 @@
+package scraper
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// robotsRules are the Allow and Disallow rules of robots.txt that apply to
+// one user agent.
+type robotsRules struct {
+	rules []robotsRule
+}
+
+// disallowAll blocks every path of a site.
+func disallowAll() *robotsRules {
+	return &robotsRules{rules: []robotsRule{{allow: false, length: 1, pattern: robotsPattern("/")}}}
+}
+
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// parseRobots reads robots.txt and keeps the group of the first user agent
+// token contained in userAgent, or the "*" group when none matches.
+func parseRobots(reader io.Reader, userAgent string) *robotsRules {
+	type group struct {
+		agents []string
+		rules  []robotsRule
+	}
+	var groups []*group
+	var current *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			// An empty Disallow allows everything
+			if value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: robotsPattern(value),
+			})
+		default:
+			inAgents = false
+		}
+	}
+
+	userAgent = strings.ToLower(userAgent)
+	var fallback *group
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if fallback == nil {
+					fallback = g
+				}
+			} else if agent != "" && strings.Contains(userAgent, agent) {
+				return &robotsRules{rules: g.rules}
+			}
+		}
+	}
+	if fallback != nil {
+		return &robotsRules{rules: fallback.rules}
+	}
+	return &robotsRules{}
+}
+
+// robotsPattern converts a path pattern with "*" and a trailing "$" to a
+// regular expression anchored at the start.
+func robotsPattern(value string) *regexp.Regexp {
+	anchored := strings.HasSuffix(value, "$")
+	value = strings.TrimSuffix(value, "$")
+	parts := strings.Split(value, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// Allowed reports whether path (with query) may be fetched. The longest
+// matching rule wins, Allow wins ties.
+func (r *robotsRules) Allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	allowed, length := true, -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > length || (rule.length == length && rule.allow) {
+			allowed, length = rule.allow, rule.length
+		}
+	}
+	return allowed
+}
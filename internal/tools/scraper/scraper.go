@@ -0,0 +1,84 @@
+// Package scraper turns web pages into markdown for --scrape_url.
+//
+// Two backends implement URLScraper: the Jina AI reader service and a native
+// scraper that fetches pages itself, respects robots.txt, extracts the main
+// content with readability and can follow pagination and same-site links.
+package scraper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/plugins"
+)
+
+const (
+	BackendJina   = "jina"
+	BackendNative = "native"
+)
+
+// URLScraper returns the main content of a web page as LLM-friendly text.
+type URLScraper interface {
+	ScrapeURL(url string) (string, error)
+}
+
+func NewScraper(jina URLScraper) (ret *Scraper) {
+
+	label := "Web Scraper"
+	ret = &Scraper{jina: jina}
+
+	ret.PluginBase = &plugins.PluginBase{
+		Name:             label,
+		SetupDescription: "Web Scraper - Backend for --scrape_url (Jina AI or native)",
+		EnvNamePrefix:    plugins.BuildEnvVariablePrefix(label),
+	}
+
+	ret.Backend = ret.AddSetupQuestionCustom("Backend", false,
+		"Enter the scraping backend: jina (Jina AI service, default) or native (local, respects robots.txt)")
+	ret.MaxDepth = ret.AddSetupQuestionCustom("Max Depth", false,
+		"Enter how many levels of same-site links the native backend follows (0 scrapes only the page)")
+	ret.MaxPages = ret.AddSetupQuestionCustom("Max Pages", false,
+		"Enter the maximum number of pages the native backend fetches per URL")
+
+	return
+}
+
+type Scraper struct {
+	*plugins.PluginBase
+	Backend  *plugins.SetupQuestion
+	MaxDepth *plugins.SetupQuestion
+	MaxPages *plugins.SetupQuestion
+
+	jina URLScraper
+}
+
+// URLScraper resolves the configured backend. Without one, Jina AI is used
+// once its API key is configured.
+func (o *Scraper) URLScraper() (ret URLScraper, err error) {
+	switch backend := strings.ToLower(strings.TrimSpace(o.Backend.Value)); backend {
+	case "":
+		// pages are only sent to Jina AI once it is set up or chosen
+		if keyed, ok := o.jina.(interface{ HasApiKey() bool }); ok && !keyed.HasApiKey() {
+			err = fmt.Errorf("scraping functionality is not configured. Please set up Jina to enable scraping, " +
+				"or choose a backend in the Web Scraper step of fabric --setup: WEB_SCRAPER_BACKEND=native scrapes locally, " +
+				"WEB_SCRAPER_BACKEND=jina uses Jina AI without an API key")
+			return
+		}
+		ret = o.jina
+	case BackendJina:
+		ret = o.jina
+	case BackendNative:
+		ret = NewNative(parseInt(o.MaxDepth.Value, 0), parseInt(o.MaxPages.Value, DefaultMaxPages))
+	default:
+		err = fmt.Errorf("unknown scraping backend %q, expected %s or %s", backend, BackendJina, BackendNative)
+	}
+	return
+}
+
+func parseInt(value string, def int) int {
+	if parsed, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && parsed >= 0 {
+		return parsed
+	}
+	return def
+}
@@ -0,0 +1,86 @@
+package speech
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+const bitsPerSample = 16
+
+// WriteFile writes speech to an audio file in the format of its extension,
+// WAV when there is none. WAV is written directly, other formats like mp3 are
+// encoded with ffmpeg. Existing files are not overwritten.
+func WriteFile(speech *ai.Speech, fileName string) (err error) {
+	if _, statErr := os.Stat(fileName); statErr == nil {
+		return fmt.Errorf("file %s already exists, not overwriting. Rename the existing file or choose a different name", fileName)
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+	if format == "" || format == "wav" {
+		var data []byte
+		if data, err = EncodeWAV(speech); err != nil {
+			return
+		}
+		return os.WriteFile(fileName, data, 0644)
+	}
+
+	if _, err = exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found: please install it to write %s files, or use a .wav output file", format)
+	}
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-loglevel", "error", "-n",
+		"-f", "s16le", "-ar", strconv.Itoa(speech.SampleRate), "-ac", strconv.Itoa(speech.Channels), "-i", "pipe:0",
+		fileName)
+	cmd.Stdin = bytes.NewReader(speech.PCM)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg failed to encode %s: %v: %s", format, err, stderr.String())
+	}
+	return
+}
+
+// EncodeWAV writes speech as a WAV file.
+func EncodeWAV(speech *ai.Speech) (ret []byte, err error) {
+	if len(speech.PCM) == 0 {
+		return nil, fmt.Errorf("empty PCM data provided")
+	}
+	if speech.SampleRate <= 0 || speech.Channels <= 0 {
+		return nil, fmt.Errorf("invalid audio format: %d Hz, %d channels", speech.SampleRate, speech.Channels)
+	}
+
+	byteRate := speech.SampleRate * speech.Channels * bitsPerSample / 8
+	blockAlign := speech.Channels * bitsPerSample / 8
+	dataLen := uint32(len(speech.PCM))
+
+	buf := bytes.NewBuffer(make([]byte, 0, 44+len(speech.PCM)))
+
+	// RIFF header
+	buf.WriteString("RIFF")
+	binary.Write(buf, binary.LittleEndian, 36+dataLen)
+	buf.WriteString("WAVE")
+
+	// fmt chunk
+	buf.WriteString("fmt ")
+	binary.Write(buf, binary.LittleEndian, uint32(16))                // subchunk1Size
+	binary.Write(buf, binary.LittleEndian, uint16(1))                 // audioFormat = PCM
+	binary.Write(buf, binary.LittleEndian, uint16(speech.Channels))   // numChannels
+	binary.Write(buf, binary.LittleEndian, uint32(speech.SampleRate)) // sampleRate
+	binary.Write(buf, binary.LittleEndian, uint32(byteRate))          // byteRate
+	binary.Write(buf, binary.LittleEndian, uint16(blockAlign))        // blockAlign
+	binary.Write(buf, binary.LittleEndian, uint16(bitsPerSample))     // bitsPerSample
+
+	// data chunk
+	buf.WriteString("data")
+	binary.Write(buf, binary.LittleEndian, dataLen)
+	buf.Write(speech.PCM)
+
+	return buf.Bytes(), nil
+}
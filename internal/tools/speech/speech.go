@@ -0,0 +1,128 @@
+// Package speech turns the output of a pattern into an audio file with a
+// vendor implementing ai.SpeechSynthesizer (Gemini, OpenAI) or any
+// OpenAI-compatible /audio/speech endpoint, e.g. a local Kokoro server. Long
+// text is split into chunks, which are synthesized one after another and
+// concatenated.
+package speech
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/ai/openai"
+)
+
+// EndpointVendor selects the configured OpenAI-compatible endpoint
+const EndpointVendor = "endpoint"
+
+// DefaultVendors are tried in order when nothing else is selected
+var DefaultVendors = []string{"OpenAI", "Gemini"}
+
+func NewSpeech(getSynthesizer func(vendorName string) ai.SpeechSynthesizer) (ret *Speech) {
+
+	label := "Speech"
+	ret = &Speech{getSynthesizer: getSynthesizer}
+
+	ret.PluginBase = &plugins.PluginBase{
+		Name:             label,
+		SetupDescription: "Speech - Text-to-speech vendor, voice and local endpoint for --speak",
+		EnvNamePrefix:    plugins.BuildEnvVariablePrefix(label),
+	}
+
+	ret.Vendor = ret.AddSetupQuestionCustom("Vendor", false,
+		"Enter the default text-to-speech vendor (e.g. OpenAI, Gemini, or endpoint for the URL below)")
+	ret.Model = ret.AddSetupQuestionCustom("Model", false,
+		"Enter the default text-to-speech model (e.g. gpt-4o-mini-tts, gemini-2.5-flash-preview-tts)")
+	ret.Voice = ret.AddSetupQuestionCustom("Voice", false,
+		"Enter the default voice (e.g. alloy for OpenAI, Kore for Gemini)")
+	ret.EndpointURL = ret.AddSetupQuestionCustom("Endpoint URL", false,
+		"Enter the base URL of an OpenAI-compatible text-to-speech server (e.g. http://localhost:8880/v1)")
+	ret.EndpointApiKey = ret.AddSetupQuestionCustom("Endpoint API Key", false,
+		"Enter the API key of the text-to-speech server, if it needs one")
+
+	return
+}
+
+type Speech struct {
+	*plugins.PluginBase
+	Vendor         *plugins.SetupQuestion
+	Model          *plugins.SetupQuestion
+	Voice          *plugins.SetupQuestion
+	EndpointURL    *plugins.SetupQuestion
+	EndpointApiKey *plugins.SetupQuestion
+
+	getSynthesizer func(vendorName string) ai.SpeechSynthesizer
+}
+
+// Synthesizer resolves a text-to-speech backend by vendor name. An empty name
+// uses the configured vendor, then the first configured of DefaultVendors.
+// It also returns the name of the resolved vendor. The returned options hold
+// the configured model and voice when the configured vendor is used.
+func (o *Speech) Synthesizer(vendorName string) (ret ai.SpeechSynthesizer, resolvedName string, opts ai.SpeechOptions, err error) {
+	if vendorName == "" && o.Vendor.Value != "" {
+		vendorName = o.Vendor.Value
+		opts = ai.SpeechOptions{Model: o.Model.Value, Voice: o.Voice.Value}
+	}
+
+	if strings.EqualFold(vendorName, EndpointVendor) {
+		if o.EndpointURL.Value == "" {
+			err = fmt.Errorf("no text-to-speech endpoint is configured, run fabric --setup and configure Speech")
+			return
+		}
+		ret = openai.NewEndpointClient("Speech Endpoint", o.EndpointURL.Value, o.EndpointApiKey.Value)
+		resolvedName = EndpointVendor
+		return
+	}
+
+	candidates := DefaultVendors
+	if vendorName != "" {
+		candidates = []string{vendorName}
+	}
+	for _, candidate := range candidates {
+		if o.getSynthesizer != nil {
+			if ret = o.getSynthesizer(candidate); ret != nil {
+				resolvedName = candidate
+				return
+			}
+		}
+	}
+	if vendorName == "" {
+		err = fmt.Errorf("no text-to-speech vendor is configured, configure %s or the Speech endpoint with fabric --setup",
+			strings.Join(DefaultVendors, " or "))
+	} else {
+		err = fmt.Errorf("vendor %s is not configured or does not support text-to-speech", vendorName)
+	}
+	return
+}
+
+// Speak synthesizes text of any length. The text is cleaned of markdown and
+// split into chunks of at most MaxSpeechInput characters, whose audio is
+// concatenated.
+func Speak(ctx context.Context, synthesizer ai.SpeechSynthesizer, text string, opts *ai.SpeechOptions) (ret *ai.Speech, err error) {
+	chunks := SplitText(SpeakableText(text), synthesizer.MaxSpeechInput())
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no text to speak")
+	}
+
+	for i, chunk := range chunks {
+		debuglog.Log("Synthesizing speech part %d of %d (%d characters)...\n", i+1, len(chunks), len(chunk))
+		var part *ai.Speech
+		if part, err = synthesizer.Synthesize(ctx, chunk, opts); err != nil {
+			return
+		}
+		if ret == nil {
+			ret = part
+			continue
+		}
+		if part.SampleRate != ret.SampleRate || part.Channels != ret.Channels {
+			return nil, fmt.Errorf("speech parts have different formats (%d Hz, %d channels and %d Hz, %d channels)",
+				ret.SampleRate, ret.Channels, part.SampleRate, part.Channels)
+		}
+		ret.PCM = append(ret.PCM, part.PCM...)
+	}
+	return
+}
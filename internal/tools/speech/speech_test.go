@@ -0,0 +1,151 @@
+package speech
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+type fakeSynthesizer struct {
+	maxInput   int
+	sampleRate int
+	texts      []string
+}
+
+func (o *fakeSynthesizer) Synthesize(_ context.Context, text string, _ *ai.SpeechOptions) (*ai.Speech, error) {
+	o.texts = append(o.texts, text)
+	rate := o.sampleRate
+	if rate == 0 {
+		rate = 24000
+	}
+	return &ai.Speech{PCM: []byte{byte(len(o.texts)), 0}, SampleRate: rate, Channels: 1}, nil
+}
+
+func (o *fakeSynthesizer) SpeechModels() []string { return nil }
+
+func (o *fakeSynthesizer) SpeechVoices() []string { return nil }
+
+func (o *fakeSynthesizer) MaxSpeechInput() int { return o.maxInput }
+
+func TestSpeakableText(t *testing.T) {
+	input := "# Summary\n\nThis is **important** and `code`.\n\n- See [the docs](https://example.com)\n\n```go\nfmt.Println()\n```\n\n\n\nDone."
+	want := "Summary\n\nThis is important and code.\n\nSee the docs\n\nDone."
+	if got := SpeakableText(input); got != want {
+		t.Errorf("SpeakableText() = %q, want %q", got, want)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	text := "First paragraph is short.\n\nSecond one has two sentences. This is the second sentence!\n\nThird."
+	chunks := SplitText(text, 40)
+	want := []string{"First paragraph is short.", "Second one has two sentences.", "This is the second sentence!", "Third."}
+	if len(chunks) != len(want) {
+		t.Fatalf("SplitText() = %q, want %q", chunks, want)
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Errorf("chunk %d = %q, want %q", i, chunks[i], want[i])
+		}
+	}
+
+	if chunks = SplitText(text, 0); len(chunks) != 1 || chunks[0] != text {
+		t.Errorf("expected one chunk without limit, got %q", chunks)
+	}
+
+	long := strings.Repeat("word ", 30) + strings.Repeat("x", 25)
+	for _, chunk := range SplitText(long, 20) {
+		if utf8.RuneCountInString(chunk) > 20 {
+			t.Errorf("chunk %q exceeds the limit", chunk)
+		}
+	}
+
+	if chunks = SplitText("  \n\n ", 10); len(chunks) != 0 {
+		t.Errorf("expected no chunks for blank text, got %q", chunks)
+	}
+}
+
+func TestSpeak(t *testing.T) {
+	fake := &fakeSynthesizer{maxInput: 30}
+	result, err := Speak(context.Background(), fake, "## One\n\nFirst paragraph here.\n\nSecond paragraph here.", &ai.SpeechOptions{})
+	if err != nil {
+		t.Fatalf("Speak failed: %v", err)
+	}
+	if len(fake.texts) != 2 || fake.texts[0] != "One\n\nFirst paragraph here." {
+		t.Errorf("unexpected chunks: %q", fake.texts)
+	}
+	if string(result.PCM) != "\x01\x00\x02\x00" {
+		t.Errorf("expected concatenated PCM, got %v", result.PCM)
+	}
+
+	if _, err = Speak(context.Background(), fake, "```\ncode only\n```", &ai.SpeechOptions{}); err == nil {
+		t.Error("expected error for text without speakable content")
+	}
+}
+
+func TestSynthesizer(t *testing.T) {
+	fake := &fakeSynthesizer{}
+	o := NewSpeech(func(vendorName string) ai.SpeechSynthesizer {
+		if vendorName == "Gemini" {
+			return fake
+		}
+		return nil
+	})
+
+	if ret, name, _, err := o.Synthesizer(""); err != nil || ret != fake || name != "Gemini" {
+		t.Errorf("expected the first configured default vendor, got %s, err %v", name, err)
+	}
+	if _, _, _, err := o.Synthesizer("OpenAI"); err == nil {
+		t.Error("expected error for vendor without text-to-speech")
+	}
+
+	o.Vendor.Value = "Gemini"
+	o.Voice.Value = "Puck"
+	if _, _, opts, err := o.Synthesizer(""); err != nil || opts.Voice != "Puck" {
+		t.Errorf("expected configured voice, got %+v, err %v", opts, err)
+	}
+
+	if _, _, _, err := o.Synthesizer(EndpointVendor); err == nil {
+		t.Error("expected error for endpoint without URL")
+	}
+	o.EndpointURL.Value = "http://localhost:8880/v1"
+	if ret, _, _, err := o.Synthesizer(EndpointVendor); err != nil || ret == nil {
+		t.Errorf("expected endpoint synthesizer, got err %v", err)
+	}
+}
+
+func TestEncodeWAV(t *testing.T) {
+	data, err := EncodeWAV(&ai.Speech{PCM: []byte{0x00, 0x01, 0x02, 0x03}, SampleRate: 24000, Channels: 1})
+	if err != nil {
+		t.Fatalf("EncodeWAV failed: %v", err)
+	}
+	if len(data) != 48 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		t.Fatalf("unexpected WAV data: %v", data)
+	}
+	if rate := binary.LittleEndian.Uint32(data[24:28]); rate != 24000 {
+		t.Errorf("unexpected sample rate %d", rate)
+	}
+
+	if _, err = EncodeWAV(&ai.Speech{SampleRate: 24000, Channels: 1}); err == nil {
+		t.Error("expected error for empty PCM data")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "speech.wav")
+	speech := &ai.Speech{PCM: []byte{0x00, 0x01}, SampleRate: 24000, Channels: 1}
+	if err := WriteFile(speech, path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 46 {
+		t.Errorf("unexpected file: %v, %v", info, err)
+	}
+	if err := WriteFile(speech, path); err == nil {
+		t.Error("expected error for existing file")
+	}
+}
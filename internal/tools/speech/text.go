@@ -0,0 +1,103 @@
+package speech
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	codeBlockRegex = regexp.MustCompile("(?s)```.*?```")
+	imageRegex     = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkRegex      = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	headingRegex   = regexp.MustCompile(`(?m)^\s*#{1,6}\s+`)
+	bulletRegex    = regexp.MustCompile(`(?m)^(\s*)[-*+]\s+`)
+	emphasisRegex  = regexp.MustCompile("\\*+|`+|__")
+	blankRegex     = regexp.MustCompile(`\n{3,}`)
+	sentenceRegex  = regexp.MustCompile(`[.!?…]+["')\]]*\s+`)
+)
+
+// SpeakableText removes the markdown that pattern outputs are written in, so
+// it is not read out: code blocks, link targets, headings, bullets and
+// emphasis markers.
+func SpeakableText(text string) string {
+	text = codeBlockRegex.ReplaceAllString(text, "")
+	text = imageRegex.ReplaceAllString(text, "$1")
+	text = linkRegex.ReplaceAllString(text, "$1")
+	text = headingRegex.ReplaceAllString(text, "")
+	text = bulletRegex.ReplaceAllString(text, "$1")
+	text = emphasisRegex.ReplaceAllString(text, "")
+	text = blankRegex.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// SplitText splits text into chunks of at most maxChars characters at
+// paragraph boundaries, then sentence boundaries, then words. A maxChars of 0
+// or less returns the text as one chunk.
+func SplitText(text string, maxChars int) (ret []string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if maxChars <= 0 || utf8.RuneCountInString(text) <= maxChars {
+		return []string{text}
+	}
+
+	var current strings.Builder
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			ret = append(ret, chunk)
+		}
+		current.Reset()
+	}
+	add := func(piece string, separator string) {
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+len(separator)+utf8.RuneCountInString(piece) > maxChars {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString(separator)
+		}
+		current.WriteString(piece)
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph == "" {
+			continue
+		}
+		if utf8.RuneCountInString(paragraph) <= maxChars {
+			add(paragraph, "\n\n")
+			continue
+		}
+		flush()
+		for _, sentence := range splitSentences(paragraph) {
+			if utf8.RuneCountInString(sentence) <= maxChars {
+				add(sentence, " ")
+				continue
+			}
+			for _, word := range strings.Fields(sentence) {
+				for utf8.RuneCountInString(word) > maxChars {
+					runes := []rune(word)
+					add(string(runes[:maxChars]), " ")
+					word = string(runes[maxChars:])
+				}
+				add(word, " ")
+			}
+		}
+		flush()
+	}
+	flush()
+	return
+}
+
+// splitSentences splits a paragraph after sentence-ending punctuation.
+func splitSentences(paragraph string) (ret []string) {
+	start := 0
+	for _, match := range sentenceRegex.FindAllStringIndex(paragraph, -1) {
+		ret = append(ret, strings.TrimSpace(paragraph[start:match[1]]))
+		start = match[1]
+	}
+	if rest := strings.TrimSpace(paragraph[start:]); rest != "" {
+		ret = append(ret, rest)
+	}
+	return
+}
@@ -0,0 +1,126 @@
+package transcription
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+// Output formats of --transcribe-format
+const (
+	FormatText       = "text"
+	FormatTimestamps = "timestamps"
+	FormatSRT        = "srt"
+	FormatVTT        = "vtt"
+	FormatSpeakers   = "speakers"
+	FormatJSON       = "json"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FormatText, FormatTimestamps, FormatSRT, FormatVTT, FormatSpeakers, FormatJSON}
+
+// NeedsSegments reports whether a format needs segment timestamps.
+func NeedsSegments(format string) bool {
+	return format != "" && format != FormatText
+}
+
+// NeedsSpeakers reports whether a format needs speaker labels.
+func NeedsSpeakers(format string) bool {
+	return format == FormatSpeakers
+}
+
+// Format renders a transcription. The timestamps format uses the
+// "[HH:MM:SS] text" lines of YouTube transcripts, the speakers format joins
+// the consecutive segments of a speaker into one turn. Speaker labels are
+// added to every format with segments when the backend returned them.
+func Format(transcription *ai.Transcription, format string) (ret string, err error) {
+	if NeedsSegments(format) && len(transcription.Segments) == 0 && transcription.Text != "" {
+		return "", fmt.Errorf("the transcription has no segments, format %s needs a backend returning timestamps", format)
+	}
+	if NeedsSpeakers(format) && len(transcription.Segments) > 0 && !hasSpeakers(transcription) {
+		return "", fmt.Errorf("the transcription has no speaker labels, format %s needs a backend supporting diarization", format)
+	}
+
+	var b strings.Builder
+	switch format {
+	case "", FormatText:
+		return transcription.Text, nil
+	case FormatTimestamps:
+		for _, segment := range transcription.Segments {
+			fmt.Fprintf(&b, "[%s] %s\n", formatTime(segment.Start, "", false), speakerText(segment))
+		}
+	case FormatSRT:
+		for i, segment := range transcription.Segments {
+			fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, formatTime(segment.Start, ",", true),
+				formatTime(segment.End, ",", true), speakerText(segment))
+		}
+	case FormatVTT:
+		b.WriteString("WEBVTT\n\n")
+		for _, segment := range transcription.Segments {
+			text := strings.TrimSpace(segment.Text)
+			if segment.Speaker != "" {
+				text = fmt.Sprintf("<v %s>%s", segment.Speaker, text)
+			}
+			fmt.Fprintf(&b, "%s --> %s\n%s\n\n", formatTime(segment.Start, ".", true),
+				formatTime(segment.End, ".", true), text)
+		}
+	case FormatSpeakers:
+		for _, turn := range speakerTurns(transcription.Segments) {
+			fmt.Fprintf(&b, "[%s] %s\n\n", formatTime(turn.Start, "", false), speakerText(turn))
+		}
+	case FormatJSON:
+		var data []byte
+		if data, err = json.MarshalIndent(transcription, "", "  "); err != nil {
+			return
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("unknown transcription format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// formatTime renders seconds as HH:MM:SS, with milliseconds after the
+// separator when withMillis is set.
+func formatTime(seconds float64, separator string, withMillis bool) string {
+	millis := int64(math.Round(math.Max(seconds, 0) * 1000))
+	ret := fmt.Sprintf("%02d:%02d:%02d", millis/3600000, millis/60000%60, millis/1000%60)
+	if withMillis {
+		ret += fmt.Sprintf("%s%03d", separator, millis%1000)
+	}
+	return ret
+}
+
+// speakerText returns the text of a segment, prefixed by its speaker.
+func speakerText(segment ai.TranscriptionSegment) string {
+	if segment.Speaker == "" {
+		return strings.TrimSpace(segment.Text)
+	}
+	return segment.Speaker + ": " + strings.TrimSpace(segment.Text)
+}
+
+func hasSpeakers(transcription *ai.Transcription) bool {
+	for _, segment := range transcription.Segments {
+		if segment.Speaker != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// speakerTurns joins consecutive segments of the same speaker.
+func speakerTurns(segments []ai.TranscriptionSegment) (ret []ai.TranscriptionSegment) {
+	for _, segment := range segments {
+		segment.Text = strings.TrimSpace(segment.Text)
+		if last := len(ret) - 1; last >= 0 && ret[last].Speaker == segment.Speaker {
+			ret[last].End = segment.End
+			ret[last].Text += " " + segment.Text
+			continue
+		}
+		ret = append(ret, segment)
+	}
+	return
+}
@@ -0,0 +1,103 @@
+package transcription
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+)
+
+// Chunk is a part of a split audio file starting Offset seconds into the original.
+type Chunk struct {
+	Path   string
+	Offset float64
+}
+
+// SplitAudioFile splits the source file into chunks smaller than maxSize using
+// ffmpeg and returns them with their offsets and a cleanup function.
+func SplitAudioFile(src string, maxSize int64) (chunks []Chunk, cleanup func(), err error) {
+	if _, err = exec.LookPath("ffmpeg"); err != nil {
+		return nil, nil, fmt.Errorf("ffmpeg not found: please install it")
+	}
+	ext := strings.ToLower(filepath.Ext(src))
+
+	var dir string
+	if dir, err = os.MkdirTemp("", "fabric-audio-*"); err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	segmentTime := 600 // start with 10 minutes
+	for {
+		pattern := filepath.Join(dir, "chunk-%03d"+ext)
+		debuglog.Log("Running ffmpeg to split audio into %d-second chunks...\n", segmentTime)
+		cmd := exec.Command("ffmpeg", "-y", "-i", src, "-f", "segment", "-segment_time", fmt.Sprintf("%d", segmentTime), "-c", "copy", pattern)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err = cmd.Run(); err != nil {
+			return nil, cleanup, fmt.Errorf("ffmpeg failed: %v: %s", err, stderr.String())
+		}
+
+		var files []string
+		if files, err = filepath.Glob(filepath.Join(dir, "chunk-*"+ext)); err != nil {
+			return nil, cleanup, err
+		}
+		sort.Strings(files)
+
+		tooBig := false
+		for _, f := range files {
+			var info os.FileInfo
+			if info, err = os.Stat(f); err != nil {
+				return nil, cleanup, err
+			}
+			if info.Size() > maxSize {
+				tooBig = true
+				break
+			}
+		}
+		if !tooBig {
+			return chunkOffsets(files, segmentTime), cleanup, nil
+		}
+		for _, f := range files {
+			_ = os.Remove(f)
+		}
+		if segmentTime <= 1 {
+			return nil, cleanup, fmt.Errorf("unable to split file into acceptable size chunks")
+		}
+		segmentTime /= 2
+	}
+}
+
+// chunkOffsets adds up the chunk durations. With stream copy ffmpeg cuts at
+// packet boundaries, so the measured durations are more exact than the segment
+// time, which is only used when ffprobe is not available.
+func chunkOffsets(files []string, segmentTime int) (ret []Chunk) {
+	offset := 0.0
+	for _, f := range files {
+		ret = append(ret, Chunk{Path: f, Offset: offset})
+		duration, err := ProbeDuration(f)
+		if err != nil {
+			debuglog.Debug(debuglog.Detailed, "Transcription: using the segment time as duration of %s: %v\n", f, err)
+			duration = float64(segmentTime)
+		}
+		offset += duration
+	}
+	return
+}
+
+// ProbeDuration returns the duration in seconds of an audio or video file
+// with ffprobe.
+func ProbeDuration(file string) (ret float64, err error) {
+	var out []byte
+	if out, err = exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1", file).Output(); err != nil {
+		return
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+}
@@ -0,0 +1,151 @@
+// Package transcription turns audio and video files into text with a vendor
+// implementing ai.Transcriber (OpenAI, Gemini) or any OpenAI-compatible
+// /audio/transcriptions endpoint, e.g. a local whisper.cpp or faster-whisper
+// server. Files above the size limit of the backend are split with ffmpeg and
+// the segments of the chunks are merged with corrected time offsets.
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/ai/openai"
+)
+
+const (
+	// EndpointVendor selects the configured OpenAI-compatible endpoint
+	EndpointVendor = "endpoint"
+	// DefaultVendor transcribes when nothing else is selected
+	DefaultVendor = "OpenAI"
+)
+
+func NewTranscription(getTranscriber func(vendorName string) ai.Transcriber) (ret *Transcription) {
+
+	label := "Transcription"
+	ret = &Transcription{getTranscriber: getTranscriber}
+
+	ret.PluginBase = &plugins.PluginBase{
+		Name:             label,
+		SetupDescription: "Transcription - Vendor and local Whisper-compatible endpoint for --transcribe-file",
+		EnvNamePrefix:    plugins.BuildEnvVariablePrefix(label),
+	}
+
+	ret.Vendor = ret.AddSetupQuestionCustom("Vendor", false,
+		"Enter the default transcription vendor (e.g. OpenAI, Gemini, Groq, or endpoint for the URL below)")
+	ret.Model = ret.AddSetupQuestionCustom("Model", false,
+		"Enter the default transcription model (e.g. whisper-1, gemini-2.5-flash)")
+	ret.EndpointURL = ret.AddSetupQuestionCustom("Endpoint URL", false,
+		"Enter the base URL of an OpenAI-compatible transcription server (e.g. http://localhost:8080/v1)")
+	ret.EndpointApiKey = ret.AddSetupQuestionCustom("Endpoint API Key", false,
+		"Enter the API key of the transcription server, if it needs one")
+
+	return
+}
+
+type Transcription struct {
+	*plugins.PluginBase
+	Vendor         *plugins.SetupQuestion
+	Model          *plugins.SetupQuestion
+	EndpointURL    *plugins.SetupQuestion
+	EndpointApiKey *plugins.SetupQuestion
+
+	getTranscriber func(vendorName string) ai.Transcriber
+}
+
+// Transcriber resolves a transcription backend by vendor name. An empty name
+// uses the configured vendor, then OpenAI. The returned model is the
+// configured default model when the configured vendor is used.
+func (o *Transcription) Transcriber(vendorName string) (ret ai.Transcriber, model string, err error) {
+	if vendorName == "" {
+		vendorName = o.Vendor.Value
+		model = o.Model.Value
+	}
+	if vendorName == "" {
+		vendorName = DefaultVendor
+	}
+
+	if strings.EqualFold(vendorName, EndpointVendor) {
+		if o.EndpointURL.Value == "" {
+			err = fmt.Errorf("no transcription endpoint is configured, run fabric --setup and configure Transcription")
+			return
+		}
+		ret = openai.NewEndpointClient("Transcription Endpoint", o.EndpointURL.Value, o.EndpointApiKey.Value)
+		return
+	}
+
+	if o.getTranscriber != nil {
+		ret = o.getTranscriber(vendorName)
+	}
+	if ret == nil {
+		err = fmt.Errorf("vendor %s is not configured or does not support audio transcription", vendorName)
+	}
+	return
+}
+
+// TranscribeFile transcribes a file of any size. Files above the limit of the
+// backend are split into chunks with ffmpeg when split is set.
+func TranscribeFile(ctx context.Context, transcriber ai.Transcriber, filePath string, opts *ai.TranscriptionOptions,
+	split bool) (ret *ai.Transcription, err error) {
+
+	var info os.FileInfo
+	if info, err = os.Stat(filePath); err != nil {
+		return
+	}
+
+	chunks := []Chunk{{Path: filePath}}
+	if maxSize := transcriber.MaxTranscriptionFileSize(); maxSize > 0 && info.Size() > maxSize {
+		if !split {
+			return nil, fmt.Errorf("file %s exceeds the %dMB limit; use --split-media-file to enable automatic splitting",
+				filePath, maxSize/(1024*1024))
+		}
+		debuglog.Log("File %s is larger than the size limit... breaking it up into chunks...\n", filePath)
+		var cleanup func()
+		chunks, cleanup, err = SplitAudioFile(filePath, maxSize)
+		if cleanup != nil {
+			defer cleanup()
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	if opts.Speakers && len(chunks) > 1 {
+		debuglog.Log("Speaker labels are assigned per chunk and may differ between the %d chunks\n", len(chunks))
+	}
+
+	parts := make([]*ai.Transcription, 0, len(chunks))
+	for i, chunk := range chunks {
+		debuglog.Log("Transcribing part %d (file name: %s)...\n", i+1, chunk.Path)
+		var part *ai.Transcription
+		if part, err = transcriber.Transcribe(ctx, chunk.Path, opts); err != nil {
+			return
+		}
+		parts = append(parts, part)
+	}
+	ret = Merge(parts, chunks)
+	return
+}
+
+// Merge joins the transcriptions of consecutive chunks, shifting the segments
+// of each chunk by its offset. Speakers and confidences are kept as returned.
+func Merge(parts []*ai.Transcription, chunks []Chunk) (ret *ai.Transcription) {
+	ret = &ai.Transcription{}
+	texts := make([]string, 0, len(parts))
+	for i, part := range parts {
+		if text := strings.TrimSpace(part.Text); text != "" {
+			texts = append(texts, text)
+		}
+		for _, segment := range part.Segments {
+			segment.Start += chunks[i].Offset
+			segment.End += chunks[i].Offset
+			ret.Segments = append(ret.Segments, segment)
+		}
+	}
+	ret.Text = strings.Join(texts, " ")
+	return
+}
@@ -0,0 +1,201 @@
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+type fakeTranscriber struct {
+	maxSize int64
+	calls   []string
+}
+
+func (o *fakeTranscriber) Transcribe(_ context.Context, filePath string, _ *ai.TranscriptionOptions) (*ai.Transcription, error) {
+	o.calls = append(o.calls, filePath)
+	return &ai.Transcription{
+		Text:     "hello world",
+		Segments: []ai.TranscriptionSegment{{Start: 0, End: 1.5, Text: " hello world"}},
+	}, nil
+}
+
+func (o *fakeTranscriber) TranscriptionModels() []string { return []string{"fake-model"} }
+
+func (o *fakeTranscriber) MaxTranscriptionFileSize() int64 { return o.maxSize }
+
+func sampleTranscription() *ai.Transcription {
+	return &ai.Transcription{
+		Text: "Hello there. General Kenobi.",
+		Segments: []ai.TranscriptionSegment{
+			{Start: 0, End: 2.5, Text: " Hello there."},
+			{Start: 3661.25, End: 3663, Text: " General Kenobi."},
+		},
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatText, "Hello there. General Kenobi."},
+		{FormatTimestamps, "[00:00:00] Hello there.\n[01:01:01] General Kenobi."},
+		{FormatSRT, "1\n00:00:00,000 --> 00:00:02,500\nHello there.\n\n2\n01:01:01,250 --> 01:01:03,000\nGeneral Kenobi."},
+		{FormatVTT, "WEBVTT\n\n00:00:00.000 --> 00:00:02.500\nHello there.\n\n01:01:01.250 --> 01:01:03.000\nGeneral Kenobi."},
+	}
+	for _, tt := range tests {
+		got, err := Format(sampleTranscription(), tt.format)
+		if err != nil {
+			t.Fatalf("Format(%s) failed: %v", tt.format, err)
+		}
+		if got != tt.want {
+			t.Errorf("Format(%s) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestFormat_Speakers(t *testing.T) {
+	transcription := &ai.Transcription{
+		Text: "Hi. How are you? Fine, thanks.",
+		Segments: []ai.TranscriptionSegment{
+			{Start: 0, End: 1, Speaker: "A", Text: " Hi.", Confidence: 0.9},
+			{Start: 1, End: 2.5, Speaker: "A", Text: " How are you?"},
+			{Start: 3, End: 4, Speaker: "B", Text: " Fine, thanks."},
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatSpeakers, "[00:00:00] A: Hi. How are you?\n\n[00:00:03] B: Fine, thanks."},
+		{FormatTimestamps, "[00:00:00] A: Hi.\n[00:00:01] A: How are you?\n[00:00:03] B: Fine, thanks."},
+		{FormatVTT, "WEBVTT\n\n00:00:00.000 --> 00:00:01.000\n<v A>Hi.\n\n00:00:01.000 --> 00:00:02.500\n<v A>How are you?\n\n" +
+			"00:00:03.000 --> 00:00:04.000\n<v B>Fine, thanks."},
+	}
+	for _, tt := range tests {
+		got, err := Format(transcription, tt.format)
+		if err != nil {
+			t.Fatalf("Format(%s) failed: %v", tt.format, err)
+		}
+		if got != tt.want {
+			t.Errorf("Format(%s) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	if _, err := Format(sampleTranscription(), FormatSpeakers); err == nil {
+		t.Error("expected error for speakers format without speaker labels")
+	}
+}
+
+func TestFormat_JSON(t *testing.T) {
+	transcription := &ai.Transcription{
+		Text:     "Hi.",
+		Segments: []ai.TranscriptionSegment{{Start: 0, End: 1, Speaker: "A", Text: "Hi.", Confidence: 0.5}},
+	}
+	got, err := Format(transcription, FormatJSON)
+	if err != nil {
+		t.Fatalf("Format(json) failed: %v", err)
+	}
+
+	var parsed ai.Transcription
+	if err = json.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("invalid JSON %q: %v", got, err)
+	}
+	if len(parsed.Segments) != 1 || parsed.Segments[0] != transcription.Segments[0] {
+		t.Errorf("unexpected segments: %+v", parsed.Segments)
+	}
+}
+
+func TestFormat_Errors(t *testing.T) {
+	if _, err := Format(&ai.Transcription{Text: "no segments"}, FormatSRT); err == nil {
+		t.Error("expected error for srt without segments")
+	}
+	if _, err := Format(sampleTranscription(), "docx"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	parts := []*ai.Transcription{
+		{Text: "first part ", Segments: []ai.TranscriptionSegment{{Start: 1, End: 2, Text: "first part"}}},
+		{Text: "second part", Segments: []ai.TranscriptionSegment{{Start: 1, End: 2, Speaker: "B", Text: "second part"}}},
+	}
+	merged := Merge(parts, []Chunk{{Path: "a", Offset: 0}, {Path: "b", Offset: 600}})
+
+	if merged.Text != "first part second part" {
+		t.Errorf("unexpected text: %q", merged.Text)
+	}
+	if len(merged.Segments) != 2 || merged.Segments[1].Start != 601 || merged.Segments[1].End != 602 ||
+		merged.Segments[1].Speaker != "B" {
+		t.Errorf("unexpected segments: %+v", merged.Segments)
+	}
+	if parts[1].Segments[0].Start != 1 {
+		t.Error("Merge must not modify the parts")
+	}
+}
+
+func TestTranscriber(t *testing.T) {
+	fake := &fakeTranscriber{}
+	var requested []string
+	o := NewTranscription(func(vendorName string) ai.Transcriber {
+		requested = append(requested, vendorName)
+		if vendorName == "Gemini" || vendorName == DefaultVendor {
+			return fake
+		}
+		return nil
+	})
+
+	if _, model, err := o.Transcriber(""); err != nil || model != "" || requested[0] != DefaultVendor {
+		t.Errorf("expected default vendor, got model %q, requested %v, err %v", model, requested, err)
+	}
+
+	o.Vendor.Value = "Gemini"
+	o.Model.Value = "gemini-2.5-flash"
+	if ret, model, err := o.Transcriber(""); err != nil || ret != fake || model != "gemini-2.5-flash" {
+		t.Errorf("expected configured vendor and model, got model %q, err %v", model, err)
+	}
+
+	if _, model, err := o.Transcriber(DefaultVendor); err != nil || model != "" {
+		t.Errorf("an explicit vendor must not use the configured model, got %q, err %v", model, err)
+	}
+
+	if _, _, err := o.Transcriber("Anthropic"); err == nil {
+		t.Error("expected error for vendor without transcription")
+	}
+
+	if _, _, err := o.Transcriber(EndpointVendor); err == nil {
+		t.Error("expected error for endpoint without URL")
+	}
+	o.EndpointURL.Value = "http://localhost:8080/v1"
+	if ret, _, err := o.Transcriber(EndpointVendor); err != nil || ret == nil {
+		t.Errorf("expected endpoint transcriber, got err %v", err)
+	}
+}
+
+func TestTranscribeFile_TooLarge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audio.mp3")
+	if err := os.WriteFile(path, make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeTranscriber{maxSize: 1024}
+	if _, err := TranscribeFile(context.Background(), fake, path, &ai.TranscriptionOptions{}, false); err == nil ||
+		!strings.Contains(err.Error(), "--split-media-file") {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+
+	fake.maxSize = 0
+	result, err := TranscribeFile(context.Background(), fake, path, &ai.TranscriptionOptions{}, false)
+	if err != nil {
+		t.Fatalf("TranscribeFile failed: %v", err)
+	}
+	if result.Text != "hello world" || len(fake.calls) != 1 {
+		t.Errorf("unexpected result %+v, calls %v", result, fake.calls)
+	}
+}
@@ -0,0 +1,139 @@
+package vision
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+)
+
+// Screenshot modes of --screenshot
+const (
+	ScreenshotScreen = "screen"
+	ScreenshotWindow = "window"
+	ScreenshotRegion = "region"
+)
+
+// captureTool is a screenshot command, the file to write is appended to
+// Command. Requires lists the programs it runs.
+type captureTool struct {
+	Requires []string
+	Command  []string
+}
+
+// captureTools returns the screenshot tools for a mode in order of preference.
+func captureTools(mode string, wayland bool) (ret []captureTool, err error) {
+	switch mode {
+	case ScreenshotScreen:
+		if wayland {
+			ret = []captureTool{
+				{[]string{"grim"}, []string{"grim"}},
+				{[]string{"gnome-screenshot"}, []string{"gnome-screenshot", "-f"}},
+				{[]string{"spectacle"}, []string{"spectacle", "-b", "-n", "-f", "-o"}},
+			}
+		} else {
+			ret = []captureTool{
+				{[]string{"maim"}, []string{"maim"}},
+				{[]string{"import"}, []string{"import", "-window", "root"}},
+				{[]string{"scrot"}, []string{"scrot", "-o"}},
+				{[]string{"gnome-screenshot"}, []string{"gnome-screenshot", "-f"}},
+				{[]string{"spectacle"}, []string{"spectacle", "-b", "-n", "-f", "-o"}},
+			}
+		}
+	case ScreenshotWindow:
+		if wayland {
+			ret = []captureTool{
+				{[]string{"grim", "swaymsg", "jq"}, []string{"sh", "-c",
+					`grim -g "$(swaymsg -t get_tree | jq -r '.. | select(.focused?) | .rect | "\(.x),\(.y) \(.width)x\(.height)"')" "$1"`, "--"}},
+				{[]string{"gnome-screenshot"}, []string{"gnome-screenshot", "-w", "-f"}},
+				{[]string{"spectacle"}, []string{"spectacle", "-b", "-n", "-a", "-o"}},
+			}
+		} else {
+			ret = []captureTool{
+				{[]string{"maim", "xdotool"}, []string{"sh", "-c", `maim -i "$(xdotool getactivewindow)" "$1"`, "--"}},
+				{[]string{"import", "xdotool"}, []string{"sh", "-c", `import -window "$(xdotool getactivewindow)" "$1"`, "--"}},
+				{[]string{"scrot"}, []string{"scrot", "-o", "-u"}},
+				{[]string{"gnome-screenshot"}, []string{"gnome-screenshot", "-w", "-f"}},
+				{[]string{"spectacle"}, []string{"spectacle", "-b", "-n", "-a", "-o"}},
+			}
+		}
+	case ScreenshotRegion:
+		if wayland {
+			ret = []captureTool{
+				{[]string{"grim", "slurp"}, []string{"sh", "-c", `grim -g "$(slurp)" "$1"`, "--"}},
+				{[]string{"gnome-screenshot"}, []string{"gnome-screenshot", "-a", "-f"}},
+				{[]string{"spectacle"}, []string{"spectacle", "-b", "-n", "-r", "-o"}},
+			}
+		} else {
+			ret = []captureTool{
+				{[]string{"maim"}, []string{"maim", "-s"}},
+				{[]string{"import"}, []string{"import"}},
+				{[]string{"scrot"}, []string{"scrot", "-o", "-s"}},
+				{[]string{"gnome-screenshot"}, []string{"gnome-screenshot", "-a", "-f"}},
+				{[]string{"spectacle"}, []string{"spectacle", "-b", "-n", "-r", "-o"}},
+			}
+		}
+	default:
+		err = fmt.Errorf("invalid screenshot mode '%s'. Supported modes: %s, %s, %s",
+			mode, ScreenshotScreen, ScreenshotWindow, ScreenshotRegion)
+	}
+	return
+}
+
+// isWayland reports whether the desktop session runs on Wayland.
+func isWayland() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != "" || strings.EqualFold(os.Getenv("XDG_SESSION_TYPE"), "wayland")
+}
+
+// Screenshot captures the screen, the active window or a region selected with
+// the mouse to a PNG file with the first installed X11 or Wayland tool.
+func Screenshot(mode string, file string) (err error) {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("screenshots are only supported on Linux")
+	}
+	wayland := isWayland()
+	var tools []captureTool
+	if tools, err = captureTools(mode, wayland); err != nil {
+		return
+	}
+
+	for _, tool := range tools {
+		if !installed(tool.Requires) {
+			continue
+		}
+		debuglog.Log("Capturing the %s with %s...\n", mode, tool.Requires[0])
+		cmd := exec.Command(tool.Command[0], append(tool.Command[1:], file)...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err = cmd.Run(); err != nil {
+			return fmt.Errorf("%s failed: %v: %s", tool.Requires[0], err, stderr.String())
+		}
+		if info, statErr := os.Stat(file); statErr != nil || info.Size() == 0 {
+			return fmt.Errorf("%s did not capture a screenshot", tool.Requires[0])
+		}
+		return
+	}
+
+	var names []string
+	for _, tool := range tools {
+		names = append(names, strings.Join(tool.Requires, "+"))
+	}
+	session := "X11"
+	if wayland {
+		session = "Wayland"
+	}
+	return fmt.Errorf("no screenshot tool found for a %s %s, install one of: %s", session, mode, strings.Join(names, ", "))
+}
+
+func installed(programs []string) bool {
+	for _, program := range programs {
+		if _, err := exec.LookPath(program); err != nil {
+			return false
+		}
+	}
+	return true
+}
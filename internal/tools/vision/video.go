@@ -0,0 +1,131 @@
+// Package vision turns video files and the screen into images for vision
+// models: video attachments are sampled into frames with ffmpeg, and
+// screenshots are captured with the X11 or Wayland tools of the desktop.
+package vision
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/tools/transcription"
+)
+
+const (
+	// DefaultFPS is the default number of frames sampled per second of video
+	DefaultFPS = 1.0
+	// DefaultMaxFrames keeps a video within the image limits of the vendors
+	DefaultMaxFrames = 20
+	// maxFrameWidth limits the frame width, larger images only cost tokens
+	maxFrameWidth = 1024
+)
+
+// Frame is an image sampled from a video Time seconds into it.
+type Frame struct {
+	Path string
+	Time float64
+}
+
+// IsVideo reports whether the MIME type is a video.
+func IsVideo(mimeType string) bool {
+	return strings.HasPrefix(strings.ToLower(mimeType), "video/")
+}
+
+// SampleRate returns the frame rate to sample a video of the given duration
+// with: fps, lowered to spread maxFrames over the whole video when fps would
+// sample more. An unknown duration keeps fps.
+func SampleRate(duration float64, fps float64, maxFrames int) float64 {
+	if fps <= 0 {
+		fps = DefaultFPS
+	}
+	if duration > 0 && maxFrames > 0 && duration*fps > float64(maxFrames) {
+		return float64(maxFrames) / duration
+	}
+	return fps
+}
+
+// ExtractFrames samples up to maxFrames JPEG frames at fps frames per second
+// from src, a video file or URL, into dir.
+func ExtractFrames(src string, dir string, fps float64, maxFrames int) (ret []Frame, err error) {
+	if _, err = exec.LookPath("ffmpeg"); err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: please install it to attach videos")
+	}
+	if maxFrames <= 0 {
+		maxFrames = DefaultMaxFrames
+	}
+
+	duration, probeErr := transcription.ProbeDuration(src)
+	if probeErr != nil {
+		debuglog.Debug(debuglog.Detailed, "Vision: unknown duration of %s, sampling from the start: %v\n", src, probeErr)
+	}
+	rate := SampleRate(duration, fps, maxFrames)
+
+	pattern := filepath.Join(dir, "frame-%04d.jpg")
+	filter := fmt.Sprintf("fps=%s,scale='min(%d,iw)':-2", strconv.FormatFloat(rate, 'f', -1, 64), maxFrameWidth)
+	debuglog.Log("Sampling up to %d frames from %s at %.3g frames per second...\n", maxFrames, src, rate)
+	cmd := exec.Command("ffmpeg", "-nostdin", "-v", "error", "-i", src, "-vf", filter,
+		"-frames:v", strconv.Itoa(maxFrames), "-q:v", "3", pattern)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %v: %s", err, stderr.String())
+	}
+
+	var files []string
+	if files, err = filepath.Glob(filepath.Join(dir, "frame-*.jpg")); err != nil {
+		return
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no frames could be extracted from %s", src)
+	}
+	sort.Strings(files)
+	for i, file := range files {
+		ret = append(ret, Frame{Path: file, Time: float64(i) / rate})
+	}
+	return
+}
+
+// ExtractAudio writes the audio track of src to a small mono MP3 in dir for
+// transcription.
+func ExtractAudio(src string, dir string) (ret string, err error) {
+	if _, err = exec.LookPath("ffmpeg"); err != nil {
+		return "", fmt.Errorf("ffmpeg not found: please install it to transcribe videos")
+	}
+	ret = filepath.Join(dir, "audio.mp3")
+	cmd := exec.Command("ffmpeg", "-nostdin", "-v", "error", "-i", src, "-vn", "-ac", "1", "-ar", "16000",
+		"-b:a", "48k", ret)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("ffmpeg failed to extract the audio: %v: %s", err, stderr.String())
+	}
+	return
+}
+
+// Describe tells the model what the frames of a video are, as they arrive as
+// separate images.
+func Describe(name string, frames []Frame) string {
+	if len(frames) == 0 {
+		return ""
+	}
+	times := make([]string, 0, len(frames))
+	for _, frame := range frames {
+		times = append(times, formatTime(frame.Time))
+	}
+	return fmt.Sprintf("[Video %s: %d frames attached in order, sampled at %s]",
+		name, len(frames), strings.Join(times, ", "))
+}
+
+// formatTime formats seconds as m:ss or h:mm:ss.
+func formatTime(seconds float64) string {
+	total := int(seconds + 0.5)
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total%3600/60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
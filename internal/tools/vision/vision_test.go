@@ -0,0 +1,90 @@
+package vision
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSampleRate(t *testing.T) {
+	tests := []struct {
+		duration  float64
+		fps       float64
+		maxFrames int
+		want      float64
+	}{
+		{duration: 10, fps: 1, maxFrames: 20, want: 1},
+		{duration: 100, fps: 1, maxFrames: 20, want: 0.2},
+		{duration: 0, fps: 2, maxFrames: 20, want: 2},
+		{duration: 10, fps: 0, maxFrames: 20, want: DefaultFPS},
+		{duration: 10, fps: 5, maxFrames: 0, want: 5},
+	}
+	for _, test := range tests {
+		if got := SampleRate(test.duration, test.fps, test.maxFrames); got != test.want {
+			t.Errorf("SampleRate(%v, %v, %d) = %v, want %v", test.duration, test.fps, test.maxFrames, got, test.want)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	frames := []Frame{{Time: 0}, {Time: 65}, {Time: 3725}}
+	want := "[Video clip.mp4: 3 frames attached in order, sampled at 0:00, 1:05, 1:02:05]"
+	if got := Describe("clip.mp4", frames); got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+	if got := Describe("clip.mp4", nil); got != "" {
+		t.Errorf("expected no description without frames, got %q", got)
+	}
+}
+
+func TestIsVideo(t *testing.T) {
+	if !IsVideo("video/mp4") || !IsVideo("Video/WebM") {
+		t.Error("expected video MIME types to be videos")
+	}
+	if IsVideo("image/png") || IsVideo("audio/mpeg") {
+		t.Error("expected other MIME types not to be videos")
+	}
+}
+
+func TestCaptureTools(t *testing.T) {
+	for _, mode := range []string{ScreenshotScreen, ScreenshotWindow, ScreenshotRegion} {
+		for _, wayland := range []bool{false, true} {
+			tools, err := captureTools(mode, wayland)
+			if err != nil || len(tools) == 0 {
+				t.Errorf("expected tools for %s (wayland %v), got err %v", mode, wayland, err)
+			}
+			for _, tool := range tools {
+				if len(tool.Requires) == 0 || len(tool.Command) == 0 {
+					t.Errorf("incomplete tool %+v", tool)
+				}
+			}
+		}
+	}
+	if _, err := captureTools("desktop", false); err == nil || !strings.Contains(err.Error(), "invalid screenshot mode") {
+		t.Errorf("expected error for invalid mode, got %v", err)
+	}
+}
+
+func TestExtractFrames(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+	dir := t.TempDir()
+	video := filepath.Join(dir, "test.mp4")
+	if out, err := exec.Command("ffmpeg", "-v", "error", "-f", "lavfi", "-i", "testsrc=duration=10:size=320x240:rate=10",
+		video).CombinedOutput(); err != nil {
+		t.Fatalf("failed to create test video: %v: %s", err, out)
+	}
+
+	frames, err := ExtractFrames(video, dir, 1, 4)
+	if err != nil {
+		t.Fatalf("ExtractFrames failed: %v", err)
+	}
+	if len(frames) == 0 || len(frames) > 4 {
+		t.Errorf("expected up to 4 frames, got %d", len(frames))
+	}
+	if len(frames) > 1 && frames[1].Time != 2.5 {
+		t.Errorf("expected frames spread over the video, got second frame at %v", frames[1].Time)
+	}
+}
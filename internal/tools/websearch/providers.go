@@ -0,0 +1,185 @@
+package websearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxResponseSize limits search API responses (5MB)
+const maxResponseSize = 5 * 1024 * 1024
+
+const defaultBraveURL = "https://api.search.brave.com/res/v1/web/search"
+
+// SearXNG queries the JSON API of a SearXNG instance. The instance must have
+// the json format enabled in its settings.
+type SearXNG struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func (o *SearXNG) Search(ctx context.Context, query string, limit int) (ret []Result, err error) {
+	params := url.Values{"q": {query}, "format": {"json"}}
+	endpoint := strings.TrimSuffix(o.BaseURL, "/") + "/search?" + params.Encode()
+
+	var response struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err = getJSON(ctx, o.Client, endpoint, nil, &response); err != nil {
+		return
+	}
+	for _, result := range response.Results {
+		ret = append(ret, Result{Title: result.Title, URL: result.URL, Snippet: result.Content})
+	}
+	return
+}
+
+// Brave queries the Brave Search web API.
+type Brave struct {
+	ApiKey string
+	// BaseURL overrides the API endpoint, mainly for tests
+	BaseURL string
+	Client  *http.Client
+}
+
+func (o *Brave) Search(ctx context.Context, query string, limit int) (ret []Result, err error) {
+	endpoint := o.BaseURL
+	if endpoint == "" {
+		endpoint = defaultBraveURL
+	}
+	params := url.Values{"q": {query}, "count": {strconv.Itoa(limit)}}
+	headers := map[string]string{"X-Subscription-Token": o.ApiKey}
+
+	var response struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err = getJSON(ctx, o.Client, endpoint+"?"+params.Encode(), headers, &response); err != nil {
+		return
+	}
+	for _, result := range response.Web.Results {
+		ret = append(ret, Result{Title: result.Title, URL: result.URL, Snippet: stripTags(result.Description)})
+	}
+	return
+}
+
+// JSONEndpoint queries any endpoint returning a JSON array of results. The
+// {query} placeholder in URLTemplate is replaced by the escaped query and
+// {limit} by the result limit.
+type JSONEndpoint struct {
+	URLTemplate  string
+	Token        string
+	ResultsPath  string
+	TitleField   string
+	URLField     string
+	SnippetField string
+	Client       *http.Client
+}
+
+func (o *JSONEndpoint) Search(ctx context.Context, query string, limit int) (ret []Result, err error) {
+	endpoint := strings.NewReplacer(
+		"{query}", url.QueryEscape(query),
+		"{limit}", strconv.Itoa(limit),
+	).Replace(o.URLTemplate)
+	var headers map[string]string
+	if o.Token != "" {
+		headers = map[string]string{"Authorization": "Bearer " + o.Token}
+	}
+
+	var response any
+	if err = getJSON(ctx, o.Client, endpoint, headers, &response); err != nil {
+		return
+	}
+	items, ok := lookupPath(response, o.ResultsPath).([]any)
+	if !ok {
+		return nil, fmt.Errorf("no results array at %q in the response", o.ResultsPath)
+	}
+	for _, item := range items {
+		result := Result{
+			Title:   stringAt(item, o.TitleField),
+			URL:     stringAt(item, o.URLField),
+			Snippet: stringAt(item, o.SnippetField),
+		}
+		if result.URL != "" {
+			ret = append(ret, result)
+		}
+	}
+	return
+}
+
+func getJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, target any) (err error) {
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil); err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	var resp *http.Response
+	if resp, err = client.Do(req); err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err = json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return
+}
+
+// lookupPath follows a dotted path of object keys, an empty path is the value itself.
+func lookupPath(value any, path string) any {
+	if path == "" || path == "." {
+		return value
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func stringAt(value any, path string) string {
+	switch v := lookupPath(value, path).(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// stripTags removes the <strong> highlighting Brave puts in descriptions.
+func stripTags(text string) string {
+	return strings.NewReplacer("<strong>", "", "</strong>", "").Replace(text)
+}
@@ -0,0 +1,220 @@
+// Package websearch provides web search for vendors without a native search
+// tool. With --search the configured provider (SearXNG, Brave or a generic JSON
+// endpoint) is queried before the chat request, the results are injected into
+// the system message with numbered citations and listed under "## Sources"
+// after the response, like the Anthropic and OpenAI search tools do.
+package websearch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/plugins"
+)
+
+const (
+	ProviderSearXNG = "searxng"
+	ProviderBrave   = "brave"
+	ProviderJSON    = "json"
+
+	DefaultMaxResults = 5
+	DefaultTimeout    = 20 * time.Second
+
+	// maxQueryLength keeps queries within the limits of search APIs
+	maxQueryLength = 400
+	maxQueryWords  = 50
+
+	sourcesHeader = "## Sources"
+)
+
+// Result is one web search hit.
+type Result struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+// Provider searches the web and returns at most limit results.
+type Provider interface {
+	Search(ctx context.Context, query string, limit int) ([]Result, error)
+}
+
+func NewWebSearch() (ret *WebSearch) {
+
+	label := "Web Search"
+	ret = &WebSearch{}
+
+	ret.PluginBase = &plugins.PluginBase{
+		Name:             label,
+		SetupDescription: "Web Search - Search provider for --search with vendors lacking native search (SearXNG, Brave, JSON)",
+		EnvNamePrefix:    plugins.BuildEnvVariablePrefix(label),
+	}
+
+	ret.Provider = ret.AddSetupQuestionCustom("Provider", false,
+		"Enter the search provider: searxng, brave or json (leave empty to disable)")
+	ret.URL = ret.AddSetupQuestionCustom("URL", false,
+		"Enter the SearXNG base URL or the JSON endpoint with a {query} placeholder (e.g. http://localhost:8888)")
+	ret.ApiKey = ret.AddSetupQuestionCustom("API Key", false,
+		"Enter the Brave Search API key, or a bearer token for the JSON endpoint")
+	ret.MaxResults = ret.AddSetupQuestionCustom("Max Results", false,
+		"Enter the maximum number of search results injected into the prompt")
+	ret.ResultsPath = ret.AddSetupQuestionCustom("JSON Results Path", false,
+		"Enter the dotted path of the results array in JSON responses (default results)")
+	ret.TitleField = ret.AddSetupQuestionCustom("JSON Title Field", false,
+		"Enter the title field of JSON results (default title)")
+	ret.URLField = ret.AddSetupQuestionCustom("JSON URL Field", false,
+		"Enter the URL field of JSON results (default url)")
+	ret.SnippetField = ret.AddSetupQuestionCustom("JSON Snippet Field", false,
+		"Enter the snippet field of JSON results (default snippet)")
+
+	return
+}
+
+type WebSearch struct {
+	*plugins.PluginBase
+	Provider     *plugins.SetupQuestion
+	URL          *plugins.SetupQuestion
+	ApiKey       *plugins.SetupQuestion
+	MaxResults   *plugins.SetupQuestion
+	ResultsPath  *plugins.SetupQuestion
+	TitleField   *plugins.SetupQuestion
+	URLField     *plugins.SetupQuestion
+	SnippetField *plugins.SetupQuestion
+}
+
+// IsEnabled reports whether a search provider is configured.
+func (o *WebSearch) IsEnabled() bool {
+	return strings.TrimSpace(o.Provider.Value) != ""
+}
+
+// SearchProvider builds the configured provider.
+func (o *WebSearch) SearchProvider() (ret Provider, err error) {
+	client := &http.Client{Timeout: DefaultTimeout}
+	baseURL := strings.TrimSpace(o.URL.Value)
+
+	switch provider := strings.ToLower(strings.TrimSpace(o.Provider.Value)); provider {
+	case ProviderSearXNG:
+		if baseURL == "" {
+			return nil, fmt.Errorf("SearXNG requires the URL of the instance")
+		}
+		ret = &SearXNG{BaseURL: baseURL, Client: client}
+	case ProviderBrave:
+		if o.ApiKey.Value == "" {
+			return nil, fmt.Errorf("Brave Search requires an API key")
+		}
+		ret = &Brave{ApiKey: o.ApiKey.Value, BaseURL: baseURL, Client: client}
+	case ProviderJSON:
+		if !strings.Contains(baseURL, "{query}") {
+			return nil, fmt.Errorf("the JSON search URL must contain a {query} placeholder")
+		}
+		ret = &JSONEndpoint{
+			URLTemplate:  baseURL,
+			Token:        o.ApiKey.Value,
+			ResultsPath:  valueOrDefault(o.ResultsPath.Value, "results"),
+			TitleField:   valueOrDefault(o.TitleField.Value, "title"),
+			URLField:     valueOrDefault(o.URLField.Value, "url"),
+			SnippetField: valueOrDefault(o.SnippetField.Value, "snippet"),
+			Client:       client,
+		}
+	case "":
+		err = fmt.Errorf("no web search provider is configured, run fabric --setup and configure Web Search")
+	default:
+		err = fmt.Errorf("unknown web search provider %q, expected %s, %s or %s", provider, ProviderSearXNG, ProviderBrave, ProviderJSON)
+	}
+	return
+}
+
+// Search queries the configured provider with a query derived from the input.
+func (o *WebSearch) Search(ctx context.Context, input string) (query string, ret []Result, err error) {
+	var provider Provider
+	if provider, err = o.SearchProvider(); err != nil {
+		return
+	}
+	if query = Query(input); query == "" {
+		return
+	}
+	limit := DefaultMaxResults
+	if parsed, parseErr := strconv.Atoi(strings.TrimSpace(o.MaxResults.Value)); parseErr == nil && parsed > 0 {
+		limit = parsed
+	}
+	if ret, err = provider.Search(ctx, query, limit); err != nil {
+		err = fmt.Errorf("web search failed: %w", err)
+		return
+	}
+	if len(ret) > limit {
+		ret = ret[:limit]
+	}
+	return
+}
+
+// Query collapses whitespace and truncates the input to a length search
+// engines accept.
+func Query(input string) string {
+	words := strings.Fields(input)
+	if len(words) > maxQueryWords {
+		words = words[:maxQueryWords]
+	}
+	query := strings.Join(words, " ")
+	if len(query) > maxQueryLength {
+		query = query[:maxQueryLength]
+		if i := strings.LastIndex(query, " "); i > 0 {
+			query = query[:i]
+		}
+	}
+	return query
+}
+
+// FormatContext renders results as numbered excerpts for the system message.
+func FormatContext(query string, results []Result) string {
+	if len(results) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# WEB SEARCH RESULTS (query: %s)\n\n", query)
+	builder.WriteString("Use the following search results when relevant and cite them by their number, e.g. [1].\n\n")
+	for i, result := range results {
+		fmt.Fprintf(&builder, "[%d] %s\nURL: %s\n", i+1, resultTitle(result), result.URL)
+		if result.Snippet != "" {
+			builder.WriteString(result.Snippet)
+			builder.WriteString("\n")
+		}
+		builder.WriteString("\n")
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// FormatSources lists the results the way vendors with native search cite
+// their sources, to be appended to the response.
+func FormatSources(results []Result) string {
+	if len(results) == 0 {
+		return ""
+	}
+	seen := map[string]bool{}
+	var citations []string
+	for _, result := range results {
+		if seen[result.URL] {
+			continue
+		}
+		seen[result.URL] = true
+		citations = append(citations, fmt.Sprintf("- [%s](%s)", resultTitle(result), result.URL))
+	}
+	return "\n\n" + sourcesHeader + "\n\n" + strings.Join(citations, "\n")
+}
+
+func resultTitle(result Result) string {
+	if result.Title != "" {
+		return result.Title
+	}
+	return result.URL
+}
+
+func valueOrDefault(value string, def string) string {
+	if value = strings.TrimSpace(value); value != "" {
+		return value
+	}
+	return def
+}
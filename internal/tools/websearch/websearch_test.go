@@ -0,0 +1,146 @@
+package websearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearXNG(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/search", r.URL.Path)
+		assert.Equal(t, "go release", r.URL.Query().Get("q"))
+		assert.Equal(t, "json", r.URL.Query().Get("format"))
+		json.NewEncoder(w).Encode(map[string]any{"results": []map[string]string{
+			{"title": "Go 1.24", "url": "https://go.dev/doc/go1.24", "content": "Release notes"},
+		}})
+	}))
+	defer server.Close()
+
+	results, err := (&SearXNG{BaseURL: server.URL + "/"}).Search(context.Background(), "go release", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []Result{{Title: "Go 1.24", URL: "https://go.dev/doc/go1.24", Snippet: "Release notes"}}, results)
+}
+
+func TestBrave(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Subscription-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "3", r.URL.Query().Get("count"))
+		w.Write([]byte(`{"web":{"results":[{"title":"Brave","url":"https://brave.com","description":"A <strong>private</strong> browser"}]}}`))
+	}))
+	defer server.Close()
+
+	results, err := (&Brave{ApiKey: "secret", BaseURL: server.URL}).Search(context.Background(), "browser", 3)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "A private browser", results[0].Snippet)
+
+	_, err = (&Brave{ApiKey: "wrong", BaseURL: server.URL}).Search(context.Background(), "browser", 3)
+	assert.ErrorContains(t, err, "HTTP 401")
+}
+
+func TestJSONEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "a&b", r.URL.Query().Get("query"))
+		assert.Equal(t, "2", r.URL.Query().Get("n"))
+		w.Write([]byte(`{"data":{"items":[
+			{"name":"One","link":"https://one.example","meta":{"text":"first"}},
+			{"name":"No URL"},
+			{"name":"Two","link":"https://two.example"}
+		]}}`))
+	}))
+	defer server.Close()
+
+	endpoint := &JSONEndpoint{
+		URLTemplate:  server.URL + "/api?query={query}&n={limit}",
+		Token:        "token",
+		ResultsPath:  "data.items",
+		TitleField:   "name",
+		URLField:     "link",
+		SnippetField: "meta.text",
+	}
+	results, err := endpoint.Search(context.Background(), "a&b", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []Result{
+		{Title: "One", URL: "https://one.example", Snippet: "first"},
+		{Title: "Two", URL: "https://two.example"},
+	}, results)
+
+	endpoint.ResultsPath = "missing"
+	_, err = endpoint.Search(context.Background(), "a&b", 2)
+	assert.ErrorContains(t, err, "no results array")
+}
+
+func TestWebSearchProviderSelection(t *testing.T) {
+	search := NewWebSearch()
+	assert.False(t, search.IsEnabled())
+	_, err := search.SearchProvider()
+	assert.ErrorContains(t, err, "no web search provider")
+
+	search.Provider.Value = "brave"
+	_, err = search.SearchProvider()
+	assert.ErrorContains(t, err, "API key")
+
+	search.Provider.Value = "json"
+	search.URL.Value = "https://search.example/api"
+	_, err = search.SearchProvider()
+	assert.ErrorContains(t, err, "{query}")
+
+	search.Provider.Value = "other"
+	_, err = search.SearchProvider()
+	assert.ErrorContains(t, err, "unknown web search provider")
+}
+
+func TestWebSearchLimitsResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results":[{"url":"https://a"},{"url":"https://b"},{"url":"https://c"}]}`))
+	}))
+	defer server.Close()
+
+	search := NewWebSearch()
+	search.Provider.Value = "searxng"
+	search.URL.Value = server.URL
+	search.MaxResults.Value = "2"
+
+	query, results, err := search.Search(context.Background(), "  what\n is   new? ")
+	require.NoError(t, err)
+	assert.Equal(t, "what is new?", query)
+	assert.Len(t, results, 2)
+}
+
+func TestQuery(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	query := Query(long)
+	assert.Len(t, strings.Fields(query), maxQueryWords)
+
+	query = Query(strings.Repeat("abcdefghij ", 45))
+	assert.LessOrEqual(t, len(query), maxQueryLength)
+	assert.False(t, strings.HasSuffix(query, " "))
+}
+
+func TestFormatting(t *testing.T) {
+	results := []Result{
+		{Title: "One", URL: "https://one.example", Snippet: "first"},
+		{URL: "https://two.example"},
+		{Title: "Again", URL: "https://one.example"},
+	}
+
+	context := FormatContext("test", results)
+	assert.Contains(t, context, "# WEB SEARCH RESULTS (query: test)")
+	assert.Contains(t, context, "[1] One\nURL: https://one.example\nfirst")
+	assert.Contains(t, context, "[2] https://two.example\nURL: https://two.example")
+
+	assert.Equal(t, "\n\n## Sources\n\n- [One](https://one.example)\n- [https://two.example](https://two.example)", FormatSources(results))
+	assert.Empty(t, FormatSources(nil))
+	assert.Empty(t, FormatContext("test", nil))
+}
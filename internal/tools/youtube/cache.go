@@ -0,0 +1,150 @@
+package youtube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/kballard/go-shellquote"
+)
+
+// CacheDirEnv overrides the transcript cache directory, "none" disables the cache.
+const CacheDirEnv = "FABRIC_YOUTUBE_CACHE_DIR"
+
+const (
+	cacheKindTranscript           = "transcript"
+	cacheKindTranscriptTimestamps = "transcript-timestamps"
+	cacheMetadataFile             = "metadata.json"
+)
+
+// Video IDs and language codes become path elements, so anything else is not cached
+var cacheKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// cacheDir returns the transcript cache directory, or "" when caching is
+// disabled. It defaults to the user cache directory.
+func (o *YouTube) cacheDir() string {
+	switch dir := os.Getenv(CacheDirEnv); dir {
+	case "none":
+		return ""
+	case "":
+		if userCache, err := os.UserCacheDir(); err == nil {
+			return filepath.Join(userCache, "fabric", "youtube")
+		}
+		return ""
+	default:
+		return dir
+	}
+}
+
+// cachePath returns the cache file of a video, or "" when it cannot be cached.
+func (o *YouTube) cachePath(videoId string, name string) string {
+	dir := o.cacheDir()
+	if dir == "" || !cacheKeyRegex.MatchString(videoId) {
+		return ""
+	}
+	return filepath.Join(dir, videoId, name)
+}
+
+// transcriptCacheName returns the cache file name of a transcript, or "" when
+// it cannot be cached. Additional yt-dlp arguments may change the transcript,
+// so their normalized form is part of the name.
+func transcriptCacheName(kind string, language string, additionalArgs string) string {
+	if language == "" {
+		language = "default"
+	}
+	if !cacheKeyRegex.MatchString(language) {
+		return ""
+	}
+	name := kind + "." + language
+	if additionalArgs != "" {
+		args, err := shellquote.Split(additionalArgs)
+		if err != nil {
+			return ""
+		}
+		if len(args) > 0 {
+			sum := sha256.Sum256([]byte(strings.Join(args, "\x00")))
+			name += "." + hex.EncodeToString(sum[:8])
+		}
+	}
+	return name + ".txt"
+}
+
+// cachedTranscript returns the transcript of a video in a language fetched
+// with the additional yt-dlp arguments, fetching and caching it on a miss.
+// RefreshCache skips the lookup but still stores.
+func (o *YouTube) cachedTranscript(videoId string, language string, additionalArgs string, kind string, fetch func() (string, error)) (ret string, err error) {
+	var path string
+	if name := transcriptCacheName(kind, language, additionalArgs); name != "" {
+		path = o.cachePath(videoId, name)
+	}
+	if path != "" && !o.RefreshCache {
+		if content, readErr := os.ReadFile(path); readErr == nil && len(content) > 0 {
+			debuglog.Debug(debuglog.Detailed, "YouTube: using cached %s of %s (%s)\n", kind, videoId, language)
+			return string(content), nil
+		}
+	}
+	if ret, err = fetch(); err != nil {
+		return
+	}
+	if path != "" {
+		writeCacheFile(path, []byte(ret))
+	}
+	return
+}
+
+func (o *YouTube) cachedMetadata(videoId string) *VideoMetadata {
+	path := o.cachePath(videoId, cacheMetadataFile)
+	if path == "" || o.RefreshCache {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	metadata := &VideoMetadata{}
+	if err = json.Unmarshal(content, metadata); err != nil {
+		debuglog.Debug(debuglog.Detailed, "YouTube: ignoring unreadable cache entry %s: %v\n", path, err)
+		return nil
+	}
+	return metadata
+}
+
+func (o *YouTube) storeMetadata(metadata *VideoMetadata) {
+	path := o.cachePath(metadata.Id, cacheMetadataFile)
+	if path == "" {
+		return
+	}
+	if content, err := json.Marshal(metadata); err == nil {
+		writeCacheFile(path, content)
+	}
+}
+
+// writeCacheFile writes through a temporary file, so that concurrent playlist
+// workers never read a partial entry. Failures only cost a cache miss.
+func writeCacheFile(path string, content []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		debuglog.Debug(debuglog.Detailed, "YouTube: cannot create cache directory: %v\n", err)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		debuglog.Debug(debuglog.Detailed, "YouTube: cannot write cache entry %s: %v\n", path, err)
+		return
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		debuglog.Debug(debuglog.Detailed, "YouTube: cannot write cache entry %s: %v\n", path, err)
+	}
+}
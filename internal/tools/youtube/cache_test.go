@@ -0,0 +1,101 @@
+package youtube
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCachedTranscript(t *testing.T) {
+	t.Setenv(CacheDirEnv, t.TempDir())
+	yt := &YouTube{}
+
+	calls := 0
+	fetch := func() (string, error) {
+		calls++
+		return "transcript text", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		result, err := yt.cachedTranscript("abc_123-XY", "en", "", cacheKindTranscript, fetch)
+		if err != nil || result != "transcript text" {
+			t.Fatalf("Unexpected result %q (%v)", result, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the second call to be served from the cache, got %d fetches", calls)
+	}
+
+	// Other languages and the timestamped variant are cached separately
+	if _, err := yt.cachedTranscript("abc_123-XY", "de", "", cacheKindTranscript, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := yt.cachedTranscript("abc_123-XY", "en", "", cacheKindTranscriptTimestamps, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected separate cache entries per language and variant, got %d fetches", calls)
+	}
+
+	// Additional yt-dlp arguments are cached separately, in normalized form
+	if _, err := yt.cachedTranscript("abc_123-XY", "en", "--cookies-from-browser  firefox", cacheKindTranscript, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := yt.cachedTranscript("abc_123-XY", "en", "--cookies-from-browser 'firefox'", cacheKindTranscript, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected a separate cache entry per normalized yt-dlp arguments, got %d fetches", calls)
+	}
+
+	yt.RefreshCache = true
+	if _, err := yt.cachedTranscript("abc_123-XY", "en", "", cacheKindTranscript, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 5 {
+		t.Errorf("Expected RefreshCache to fetch again, got %d fetches", calls)
+	}
+}
+
+func TestCachedTranscriptErrorsAndUnsafeKeys(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(CacheDirEnv, dir)
+	yt := &YouTube{}
+
+	if _, err := yt.cachedTranscript("video", "en", "", cacheKindTranscript, func() (string, error) {
+		return "", errors.New("yt-dlp failed")
+	}); err == nil {
+		t.Error("Expected the fetch error to be returned")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "video")); !os.IsNotExist(err) {
+		t.Error("Expected failed fetches not to be cached")
+	}
+
+	if _, err := yt.cachedTranscript("../escape", "en", "", cacheKindTranscript, func() (string, error) {
+		return "text", nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected unsafe video IDs not to be cached, found %d entries", len(entries))
+	}
+}
+
+func TestCachedMetadata(t *testing.T) {
+	t.Setenv(CacheDirEnv, t.TempDir())
+	yt := &YouTube{}
+
+	if yt.cachedMetadata("video") != nil {
+		t.Fatal("Expected no cached metadata")
+	}
+	yt.storeMetadata(&VideoMetadata{Id: "video", Title: "Title"})
+	if metadata := yt.cachedMetadata("video"); metadata == nil || metadata.Title != "Title" {
+		t.Errorf("Expected cached metadata, got %+v", metadata)
+	}
+
+	t.Setenv(CacheDirEnv, "none")
+	if yt.cachedMetadata("video") != nil {
+		t.Error("Expected the cache to be disabled")
+	}
+}
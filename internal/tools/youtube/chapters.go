@@ -0,0 +1,215 @@
+package youtube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+const cacheChaptersFile = "chapters.json"
+
+// Chapter is a chapter marker of a video, in seconds from the start.
+type Chapter struct {
+	Title string  `json:"title"`
+	Start float64 `json:"start_time"`
+	End   float64 `json:"end_time"`
+}
+
+// TranscriptSegment is one line of a timestamped transcript.
+type TranscriptSegment struct {
+	Start int
+	Text  string
+}
+
+// ChapterTranscript is the part of a transcript spoken during a chapter.
+type ChapterTranscript struct {
+	Chapter  Chapter
+	Segments []TranscriptSegment
+}
+
+// TranscriptFormat controls how segments are rendered.
+type TranscriptFormat struct {
+	WithTimestamps bool
+	// VideoId, when set, links chapter headings to the video at their start
+	VideoId string
+	// LinkTimestamps links the timestamps of segments as well
+	LinkTimestamps bool
+}
+
+var timestampedLineRegex = regexp.MustCompile(`^\[(\d{1,2}:\d{2}(?::\d{2})?)\] ?(.*)$`)
+
+// ParseTimeOffset parses a position in a video given as seconds, MM:SS or HH:MM:SS.
+func ParseTimeOffset(value string) (seconds int, err error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, ":") {
+		if seconds, err = strconv.Atoi(value); err != nil || seconds < 0 {
+			return 0, fmt.Errorf("invalid time %q, expected seconds, MM:SS or HH:MM:SS", value)
+		}
+		return
+	}
+	if seconds, err = parseTimestampToSeconds(value); err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid time %q, expected seconds, MM:SS or HH:MM:SS", value)
+	}
+	return
+}
+
+// FormatTimeOffset renders seconds as HH:MM:SS.
+func FormatTimeOffset(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// TimestampURL links to a YouTube video at the given time.
+func TimestampURL(videoId string, seconds int) string {
+	return fmt.Sprintf("https://www.youtube.com/watch?v=%s&t=%ds", videoId, seconds)
+}
+
+// ParseTimestampedTranscript reads a transcript produced with timestamps, one
+// "[HH:MM:SS] text" line per segment, back into segments.
+func ParseTimestampedTranscript(transcript string) (ret []TranscriptSegment) {
+	for _, line := range strings.Split(transcript, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if match := timestampedLineRegex.FindStringSubmatch(line); match != nil {
+			if seconds, err := parseTimestampToSeconds(match[1]); err == nil {
+				ret = append(ret, TranscriptSegment{Start: seconds, Text: match[2]})
+				continue
+			}
+		}
+		// Continuation of the previous segment
+		if len(ret) > 0 {
+			ret[len(ret)-1].Text += " " + line
+		} else {
+			ret = append(ret, TranscriptSegment{Text: line})
+		}
+	}
+	return
+}
+
+// FilterSegments keeps the segments starting in [start, end). An end of 0 means
+// until the end of the video.
+func FilterSegments(segments []TranscriptSegment, start int, end int) (ret []TranscriptSegment) {
+	for _, segment := range segments {
+		if segment.Start >= start && (end <= 0 || segment.Start < end) {
+			ret = append(ret, segment)
+		}
+	}
+	return
+}
+
+// SplitByChapters assigns the segments to the chapters they start in. Chapters
+// without segments, e.g. outside a time range, are left out.
+func SplitByChapters(segments []TranscriptSegment, chapters []Chapter) (ret []ChapterTranscript) {
+	for i, chapter := range chapters {
+		end := chapter.End
+		if i+1 < len(chapters) {
+			end = chapters[i+1].Start
+		}
+		part := ChapterTranscript{Chapter: chapter}
+		for _, segment := range segments {
+			start := float64(segment.Start)
+			// The last chapter takes everything after its start
+			if start >= chapter.Start && (start < end || i == len(chapters)-1) {
+				part.Segments = append(part.Segments, segment)
+			}
+		}
+		if len(part.Segments) > 0 {
+			ret = append(ret, part)
+		}
+	}
+	return
+}
+
+// FormatSegments renders segments as a plain transcript or with one
+// timestamped line per segment.
+func FormatSegments(segments []TranscriptSegment, format TranscriptFormat) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		if format.WithTimestamps {
+			b.WriteString(format.timestamp(segment.Start, format.LinkTimestamps))
+			b.WriteString(" ")
+			b.WriteString(segment.Text)
+			b.WriteString("\n")
+		} else {
+			b.WriteString(segment.Text)
+			b.WriteString(" ")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// FormatChapterHeading renders a markdown heading with the chapter start time.
+func FormatChapterHeading(chapter Chapter, format TranscriptFormat) string {
+	return fmt.Sprintf("## %s (%s)", chapter.Title, format.timestamp(int(chapter.Start), true))
+}
+
+// FormatChapters renders the transcript with a heading per chapter.
+func FormatChapters(parts []ChapterTranscript, format TranscriptFormat) string {
+	sections := make([]string, 0, len(parts))
+	for _, part := range parts {
+		sections = append(sections, FormatChapterHeading(part.Chapter, format)+"\n\n"+FormatSegments(part.Segments, format))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+func (o TranscriptFormat) timestamp(seconds int, link bool) string {
+	if o.VideoId == "" || !link {
+		return "[" + FormatTimeOffset(seconds) + "]"
+	}
+	return fmt.Sprintf("[%s](%s)", FormatTimeOffset(seconds), TimestampURL(o.VideoId, seconds))
+}
+
+// GrabChapters fetches the chapter markers of a video with yt-dlp --dump-json.
+// Videos without chapters return none.
+func (o *YouTube) GrabChapters(videoId string, additionalArgs string) (ret []Chapter, err error) {
+	path := o.cachePath(videoId, cacheChaptersFile)
+	if path != "" && !o.RefreshCache {
+		if content, readErr := os.ReadFile(path); readErr == nil && json.Unmarshal(content, &ret) == nil {
+			return
+		}
+	}
+
+	if _, err = exec.LookPath("yt-dlp"); err != nil {
+		err = fmt.Errorf("yt-dlp not found in PATH. Please install yt-dlp to fetch video chapters")
+		return
+	}
+	args := []string{"--dump-json", "--skip-download", "--no-warnings", "--no-playlist"}
+	if additionalArgs != "" {
+		var additionalArgsList []string
+		if additionalArgsList, err = shellquote.Split(additionalArgs); err != nil {
+			return nil, fmt.Errorf("invalid yt-dlp arguments: %v", err)
+		}
+		args = append(args, additionalArgsList...)
+	}
+	args = append(args, "https://www.youtube.com/watch?v="+videoId)
+
+	cmd := exec.Command("yt-dlp", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("yt-dlp failed to fetch chapters: %v, stderr: %s", err, stderr.String())
+	}
+
+	var info struct {
+		Chapters []Chapter `json:"chapters"`
+	}
+	if err = json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		return nil, fmt.Errorf("failed to parse yt-dlp video info: %v", err)
+	}
+	ret = info.Chapters
+	if path != "" {
+		if content, marshalErr := json.Marshal(ret); marshalErr == nil {
+			writeCacheFile(path, content)
+		}
+	}
+	return
+}
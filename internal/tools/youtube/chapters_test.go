@@ -0,0 +1,70 @@
+package youtube
+
+import (
+	"testing"
+)
+
+const timestampedTranscript = `[00:00:01] welcome to the talk
+[00:00:30] first topic
+continues here
+[00:02:00] second topic
+[00:05:10] questions`
+
+func TestParseAndFilterSegments(t *testing.T) {
+	segments := ParseTimestampedTranscript(timestampedTranscript)
+	if len(segments) != 4 {
+		t.Fatalf("Expected 4 segments, got %d: %+v", len(segments), segments)
+	}
+	if segments[1].Start != 30 || segments[1].Text != "first topic continues here" {
+		t.Errorf("Expected continuation lines to be joined, got %+v", segments[1])
+	}
+
+	filtered := FilterSegments(segments, 30, 300)
+	if len(filtered) != 2 || filtered[0].Start != 30 || filtered[1].Start != 120 {
+		t.Errorf("Unexpected segments in range: %+v", filtered)
+	}
+	if result := FormatSegments(filtered, TranscriptFormat{}); result != "first topic continues here second topic" {
+		t.Errorf("Unexpected plain transcript %q", result)
+	}
+}
+
+func TestSplitByChaptersAndFormat(t *testing.T) {
+	segments := ParseTimestampedTranscript(timestampedTranscript)
+	chapters := []Chapter{
+		{Title: "Intro", Start: 0, End: 60},
+		{Title: "Main", Start: 60, End: 300},
+		{Title: "Q&A", Start: 300, End: 310},
+	}
+	parts := SplitByChapters(FilterSegments(segments, 60, 0), chapters)
+	if len(parts) != 2 || parts[0].Chapter.Title != "Main" || parts[1].Chapter.Title != "Q&A" {
+		t.Fatalf("Expected chapters outside the range to be dropped, got %+v", parts)
+	}
+
+	format := TranscriptFormat{WithTimestamps: true, VideoId: "abc", LinkTimestamps: true}
+	expected := "## Main ([00:01:00](https://www.youtube.com/watch?v=abc&t=60s))\n\n" +
+		"[00:02:00](https://www.youtube.com/watch?v=abc&t=120s) second topic\n\n" +
+		"## Q&A ([00:05:00](https://www.youtube.com/watch?v=abc&t=300s))\n\n" +
+		"[00:05:10](https://www.youtube.com/watch?v=abc&t=310s) questions"
+	if result := FormatChapters(parts, format); result != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, result)
+	}
+
+	format.LinkTimestamps = false
+	if result := FormatSegments(parts[0].Segments, format); result != "[00:02:00] second topic" {
+		t.Errorf("Expected unlinked segment timestamps, got %q", result)
+	}
+}
+
+func TestParseTimeOffset(t *testing.T) {
+	tests := map[string]int{"90": 90, "1:30": 90, "01:02:03": 3723}
+	for input, expected := range tests {
+		if result, err := ParseTimeOffset(input); err != nil || result != expected {
+			t.Errorf("For %s, expected %d, got %d (%v)", input, expected, result, err)
+		}
+	}
+	for _, input := range []string{"", "-5", "abc", "1:2:3:4"} {
+		if _, err := ParseTimeOffset(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
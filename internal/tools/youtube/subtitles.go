@@ -0,0 +1,158 @@
+package youtube
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Supported subtitle file extensions
+const (
+	SubtitleFormatVTT = ".vtt"
+	SubtitleFormatSRT = ".srt"
+)
+
+// SRT override tags like {\an8}
+var srtOverrideRegex = regexp.MustCompile(`\{\\[^}]*\}`)
+
+// IsSubtitleFile reports whether the file has a supported subtitle extension.
+func IsSubtitleFile(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == SubtitleFormatVTT || ext == SubtitleFormatSRT
+}
+
+// ReadSubtitleFile reads a .vtt or .srt file and returns its transcript,
+// optionally with [HH:MM:SS] timestamps.
+func ReadSubtitleFile(filename string, withTimestamps bool) (ret string, err error) {
+	if !IsSubtitleFile(filename) {
+		err = fmt.Errorf("unsupported subtitle file %s, expected %s or %s", filename, SubtitleFormatVTT, SubtitleFormatSRT)
+		return
+	}
+	var content []byte
+	if content, err = os.ReadFile(filename); err != nil {
+		return
+	}
+	return ParseSubtitles(string(content), withTimestamps)
+}
+
+// ParseSubtitles converts WebVTT or SRT content to a transcript. Formatting
+// tags and the duplicated lines of auto-generated captions are removed.
+func ParseSubtitles(content string, withTimestamps bool) (ret string, err error) {
+	if withTimestamps {
+		ret = formatSubtitlesWithTimestamps(content)
+	} else {
+		ret = cleanSubtitles(content)
+	}
+	if ret == "" {
+		err = fmt.Errorf("no transcript content found in subtitles")
+	}
+	return
+}
+
+// subtitleLines splits content into trimmed lines, dropping a byte order mark.
+func subtitleLines(content string) []string {
+	return strings.Split(strings.TrimPrefix(content, "\ufeff"), "\n")
+}
+
+// isSubtitleHeader reports whether a line is WebVTT metadata rather than text.
+func isSubtitleHeader(line string) bool {
+	return line == "WEBVTT" || strings.HasPrefix(line, "WEBVTT ") || strings.HasPrefix(line, "NOTE") ||
+		strings.HasPrefix(line, "STYLE") || strings.HasPrefix(line, "Kind:") ||
+		strings.HasPrefix(line, "Language:")
+}
+
+func cleanSubtitles(content string) string {
+	var textBuilder strings.Builder
+	seenSegments := make(map[string]struct{})
+
+	for _, line := range subtitleLines(content) {
+		line = strings.TrimSpace(line)
+		// Skip headers, timestamps, sequence numbers and empty lines
+		if line == "" || isSubtitleHeader(line) || strings.Contains(line, "-->") || isTimeStamp(line) {
+			continue
+		}
+		// Remove formatting tags
+		line = removeVTTTags(line)
+		if line != "" {
+			if _, exists := seenSegments[line]; !exists {
+				textBuilder.WriteString(line)
+				textBuilder.WriteString(" ")
+				seenSegments[line] = struct{}{}
+			}
+		}
+	}
+
+	return strings.TrimSpace(textBuilder.String())
+}
+
+func formatSubtitlesWithTimestamps(content string) string {
+	var textBuilder strings.Builder
+	var currentTimestamp string
+	// Track content with timestamps to allow repeats after significant time gaps
+	// This preserves legitimate repeated content (choruses, recurring phrases, etc.)
+	// while still filtering out immediate duplicates from VTT formatting issues
+	seenSegments := make(map[string]string) // text -> last timestamp seen
+
+	for _, line := range subtitleLines(content) {
+		line = strings.TrimSpace(line)
+
+		// Skip headers and empty lines
+		if line == "" || isSubtitleHeader(line) {
+			continue
+		}
+
+		// Check if this line is a timestamp
+		if strings.Contains(line, "-->") {
+			// Extract start time for this segment
+			parts := strings.Split(line, "-->")
+			currentTimestamp = formatVTTTimestamp(strings.TrimSpace(parts[0]))
+			continue
+		}
+
+		// Skip numeric sequence identifiers
+		if isTimeStamp(line) && !strings.Contains(line, ":") {
+			continue
+		}
+
+		// This should be transcript text
+		cleanText := removeVTTTags(line)
+		if cleanText != "" && currentTimestamp != "" {
+			// Check if we should include this segment
+			shouldInclude := true
+			if lastTimestamp, exists := seenSegments[cleanText]; exists {
+				// Calculate time difference to determine if this is a legitimate repeat
+				if !shouldIncludeRepeat(lastTimestamp, currentTimestamp) {
+					shouldInclude = false
+				}
+			}
+
+			if shouldInclude {
+				timestampedLine := fmt.Sprintf("[%s] %s", currentTimestamp, cleanText)
+				textBuilder.WriteString(timestampedLine + "\n")
+				seenSegments[cleanText] = currentTimestamp
+			}
+		}
+	}
+
+	return strings.TrimSpace(textBuilder.String())
+}
+
+func formatVTTTimestamp(vttTime string) string {
+	// VTT timestamps are in format "00:00:01.234", SRT ones "00:00:01,234" - convert to "00:00:01"
+	if i := strings.IndexAny(vttTime, ".,"); i >= 0 {
+		return vttTime[:i]
+	}
+	return vttTime
+}
+
+func isTimeStamp(s string) bool {
+	return timestampRegex.MatchString(s)
+}
+
+func removeVTTTags(s string) string {
+	// Remove VTT tags like <c.colorE5E5E5>, </c>, SRT tags like <i> and {\an8}
+	s = vttTagRegex.ReplaceAllString(s, "")
+	return strings.TrimSpace(srtOverrideRegex.ReplaceAllString(s, ""))
+}
@@ -0,0 +1,71 @@
+package youtube
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSubtitleFile(t *testing.T) {
+	tests := []struct {
+		file           string
+		withTimestamps bool
+		expected       string
+	}{
+		{
+			file:     "sample.vtt",
+			expected: "welcome to the talk today we cover subtitles",
+		},
+		{
+			file:           "sample.vtt",
+			withTimestamps: true,
+			// The immediate duplicates are dropped, the repeat after more than
+			// a minute is kept
+			expected: "[00:00:01] welcome to the talk\n[00:00:03] today we cover subtitles\n[00:01:10] welcome to the talk",
+		},
+		{
+			file:     "sample.srt",
+			expected: "Hello there. This is a subtitle file. It has two lines.",
+		},
+		{
+			file:           "sample.srt",
+			withTimestamps: true,
+			expected:       "[00:00:01] Hello there.\n[00:00:03] This is a subtitle file.\n[00:00:05] It has two\n[00:00:05] lines.",
+		},
+	}
+
+	for _, test := range tests {
+		result, err := ReadSubtitleFile(filepath.Join("testdata", test.file), test.withTimestamps)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", test.file, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("For %s (timestamps %v), expected %q, got %q", test.file, test.withTimestamps, test.expected, result)
+		}
+	}
+}
+
+func TestReadSubtitleFileErrors(t *testing.T) {
+	if _, err := ReadSubtitleFile(filepath.Join("testdata", "sample.txt"), false); err == nil {
+		t.Error("Expected error for unsupported extension, but got none")
+	}
+	if _, err := ReadSubtitleFile(filepath.Join("testdata", "missing.vtt"), false); err == nil {
+		t.Error("Expected error for missing file, but got none")
+	}
+	if _, err := ParseSubtitles("WEBVTT\n\n00:00:01.000 --> 00:00:02.000\n<c></c>\n", false); err == nil {
+		t.Error("Expected error for subtitles without text, but got none")
+	}
+}
+
+func TestFormatVTTTimestamp(t *testing.T) {
+	tests := map[string]string{
+		"00:00:01.234": "00:00:01",
+		"00:01:02,500": "00:01:02",
+		"01:02:03":     "01:02:03",
+	}
+	for input, expected := range tests {
+		if result := formatVTTTimestamp(input); result != expected {
+			t.Errorf("For %s, expected %s, got %s", input, expected, result)
+		}
+	}
+}
@@ -42,8 +42,8 @@ const TimeGapForRepeats = 10 // seconds
 func init() {
 	// Match timestamps like "00:00:01.234" or just numbers or sequence numbers
 	timestampRegex = regexp.MustCompile(`^\d+$|^\d{1,2}:\d{2}(:\d{2})?(\.\d{3})?$`)
-	// Match language-specific VTT files like .en.vtt, .es.vtt, .en-US.vtt, .pt-BR.vtt
-	languageFileRegex = regexp.MustCompile(`\.[a-z]{2}(-[A-Z]{2})?\.vtt$`)
+	// Match language-specific subtitle files like .en.vtt, .es.vtt, .en-US.vtt, .pt-BR.srt
+	languageFileRegex = regexp.MustCompile(`\.[a-z]{2}(-[A-Z]{2})?\.(vtt|srt)$`)
 	// YouTube video ID pattern
 	videoPatternRegex = regexp.MustCompile(`(?:https?:\/\/)?(?:www\.)?(?:youtube\.com\/(?:live\/|[^\/\n\s]+\/\S+\/|(?:v|e(?:mbed)?)\/|(?:s(?:horts)\/)|\S*?[?&]v=)|youtu\.be\/)([a-zA-Z0-9_-]*)`)
 	// YouTube playlist ID pattern
@@ -74,6 +74,9 @@ type YouTube struct {
 	*plugins.PluginBase
 	ApiKey *plugins.SetupQuestion
 
+	// RefreshCache fetches transcripts and metadata again instead of using the cache
+	RefreshCache bool
+
 	normalizeRegex *regexp.Regexp
 	service        *youtube.Service
 }
@@ -130,7 +133,9 @@ func (o *YouTube) GrabTranscript(videoId string, language string) (ret string, e
 
 func (o *YouTube) GrabTranscriptWithArgs(videoId string, language string, additionalArgs string) (ret string, err error) {
 	// Use yt-dlp for reliable transcript extraction
-	return o.tryMethodYtDlp(videoId, language, additionalArgs)
+	return o.cachedTranscript(videoId, language, additionalArgs, cacheKindTranscript, func() (string, error) {
+		return o.tryMethodYtDlp(videoId, language, additionalArgs)
+	})
 }
 
 func (o *YouTube) GrabTranscriptWithTimestamps(videoId string, language string) (ret string, err error) {
@@ -140,38 +145,46 @@ func (o *YouTube) GrabTranscriptWithTimestamps(videoId string, language string)
 
 func (o *YouTube) GrabTranscriptWithTimestampsWithArgs(videoId string, language string, additionalArgs string) (ret string, err error) {
 	// Use yt-dlp for reliable transcript extraction with timestamps
-	return o.tryMethodYtDlpWithTimestamps(videoId, language, additionalArgs)
+	return o.cachedTranscript(videoId, language, additionalArgs, cacheKindTranscriptTimestamps, func() (string, error) {
+		return o.tryMethodYtDlpWithTimestamps(videoId, language, additionalArgs)
+	})
 }
 
 // tryMethodYtDlpInternal is a helper function to reduce duplication between
 // tryMethodYtDlp and tryMethodYtDlpWithTimestamps.
 func (o *YouTube) tryMethodYtDlpInternal(videoId string, language string, additionalArgs string, processVTTFileFunc func(filename string) (string, error)) (ret string, err error) {
+	videoURL := "https://www.youtube.com/watch?v=" + videoId
+	subtitleArgs := []string{"--write-auto-subs", "--skip-download", "--sub-format", "vtt"}
+	return o.grabSubtitles(videoURL, "YouTube", subtitleArgs, language, additionalArgs, processVTTFileFunc)
+}
+
+// grabSubtitles downloads the subtitles of videoURL with yt-dlp into a
+// temporary directory and processes the best matching file. site names the
+// service in error messages.
+func (o *YouTube) grabSubtitles(videoURL string, site string, subtitleArgs []string, language string, additionalArgs string,
+	processVTTFileFunc func(filename string) (string, error)) (ret string, err error) {
 	// Check if yt-dlp is available
 	if _, err = exec.LookPath("yt-dlp"); err != nil {
-		err = fmt.Errorf("yt-dlp not found in PATH. Please install yt-dlp to use YouTube transcript functionality")
+		err = fmt.Errorf("yt-dlp not found in PATH. Please install yt-dlp to use transcript functionality")
 		return
 	}
 
 	// Create a temporary directory for yt-dlp output (cross-platform)
-	tempDir := filepath.Join(os.TempDir(), "fabric-youtube-"+videoId)
-	if err = os.MkdirAll(tempDir, 0755); err != nil {
+	var tempDir string
+	if tempDir, err = os.MkdirTemp("", "fabric-youtube-"); err != nil {
 		err = fmt.Errorf("failed to create temp directory: %v", err)
 		return
 	}
 	defer os.RemoveAll(tempDir)
 
 	// Use yt-dlp to get transcript
-	videoURL := "https://www.youtube.com/watch?v=" + videoId
 	outputPath := filepath.Join(tempDir, "%(title)s.%(ext)s")
 
-	baseArgs := []string{
-		"--write-auto-subs",
-		"--skip-download",
-		"--sub-format", "vtt",
+	baseArgs := append(append([]string{}, subtitleArgs...),
 		"--quiet",
 		"--no-warnings",
 		"-o", outputPath,
-	}
+	)
 
 	args := append([]string{}, baseArgs...)
 
@@ -204,14 +217,14 @@ func (o *YouTube) tryMethodYtDlpInternal(videoId string, language string, additi
 	if err = cmd.Run(); err != nil {
 		stderrStr := stderr.String()
 
-		// Check for specific YouTube errors
+		// Check for specific site errors
 		if strings.Contains(stderrStr, "429") || strings.Contains(stderrStr, "Too Many Requests") {
-			err = fmt.Errorf("YouTube rate limit exceeded. Try again later or use different yt-dlp arguments like '--sleep-requests 1' to slow down requests. Error: %v", err)
+			err = fmt.Errorf("%s rate limit exceeded. Try again later or use different yt-dlp arguments like '--sleep-requests 1' to slow down requests. Error: %v", site, err)
 			return
 		}
 
 		if strings.Contains(stderrStr, "Sign in to confirm you're not a bot") || strings.Contains(stderrStr, "Use --cookies-from-browser") {
-			err = fmt.Errorf("YouTube requires authentication (bot detection). Use --yt-dlp-args='--cookies-from-browser BROWSER' where BROWSER is chrome, firefox, brave, etc. Error: %v", err)
+			err = fmt.Errorf("%s requires authentication (bot detection). Use --yt-dlp-args='--cookies-from-browser BROWSER' where BROWSER is chrome, firefox, brave, etc. Error: %v", site, err)
 			return
 		}
 
@@ -236,7 +249,7 @@ func (o *YouTube) tryMethodYtDlpInternal(videoId string, language string, additi
 			if err = cmd.Run(); err != nil {
 				stderrStr2 := stderr.String()
 				if strings.Contains(stderrStr2, "429") || strings.Contains(stderrStr2, "Too Many Requests") {
-					err = fmt.Errorf("YouTube rate limit exceeded. Try again later or use different yt-dlp arguments like '--sleep-requests 1'. Error: %v", err)
+					err = fmt.Errorf("%s rate limit exceeded. Try again later or use different yt-dlp arguments like '--sleep-requests 1'. Error: %v", site, err)
 				} else {
 					err = fmt.Errorf("yt-dlp failed with language '%s' and fallback. Original error: %s. Fallback error: %s", language, stderrStr, stderrStr2)
 				}
@@ -259,137 +272,31 @@ func (o *YouTube) tryMethodYtDlpInternal(videoId string, language string, additi
 }
 
 func (o *YouTube) tryMethodYtDlp(videoId string, language string, additionalArgs string) (ret string, err error) {
-	return o.tryMethodYtDlpInternal(videoId, language, additionalArgs, o.readAndCleanVTTFile)
+	return o.tryMethodYtDlpInternal(videoId, language, additionalArgs, readSubtitles)
 }
 
 func (o *YouTube) tryMethodYtDlpWithTimestamps(videoId string, language string, additionalArgs string) (ret string, err error) {
-	return o.tryMethodYtDlpInternal(videoId, language, additionalArgs, o.readAndFormatVTTWithTimestamps)
-}
-
-func (o *YouTube) readAndCleanVTTFile(filename string) (ret string, err error) {
-	var content []byte
-	if content, err = os.ReadFile(filename); err != nil {
-		return
-	}
-
-	// Convert VTT to plain text
-	lines := strings.Split(string(content), "\n")
-	var textBuilder strings.Builder
-	seenSegments := make(map[string]struct{})
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// Skip WEBVTT header, timestamps, and empty lines
-		if line == "" || line == "WEBVTT" || strings.Contains(line, "-->") ||
-			strings.HasPrefix(line, "NOTE") || strings.HasPrefix(line, "STYLE") ||
-			strings.HasPrefix(line, "Kind:") || strings.HasPrefix(line, "Language:") ||
-			isTimeStamp(line) {
-			continue
-		}
-		// Remove VTT formatting tags
-		line = removeVTTTags(line)
-		if line != "" {
-			if _, exists := seenSegments[line]; !exists {
-				textBuilder.WriteString(line)
-				textBuilder.WriteString(" ")
-				seenSegments[line] = struct{}{}
-			}
-		}
-	}
-
-	ret = strings.TrimSpace(textBuilder.String())
-	if ret == "" {
-		err = fmt.Errorf("no transcript content found in VTT file")
-	}
-	return
-}
-
-func (o *YouTube) readAndFormatVTTWithTimestamps(filename string) (ret string, err error) {
-	var content []byte
-	if content, err = os.ReadFile(filename); err != nil {
-		return
-	}
-
-	// Parse VTT and preserve timestamps
-	lines := strings.Split(string(content), "\n")
-	var textBuilder strings.Builder
-	var currentTimestamp string
-	// Track content with timestamps to allow repeats after significant time gaps
-	// This preserves legitimate repeated content (choruses, recurring phrases, etc.)
-	// while still filtering out immediate duplicates from VTT formatting issues
-	seenSegments := make(map[string]string) // text -> last timestamp seen
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		// Skip WEBVTT header and empty lines
-		if line == "" || line == "WEBVTT" || strings.HasPrefix(line, "NOTE") ||
-			strings.HasPrefix(line, "STYLE") || strings.HasPrefix(line, "Kind:") ||
-			strings.HasPrefix(line, "Language:") {
-			continue
-		}
-
-		// Check if this line is a timestamp
-		if strings.Contains(line, "-->") {
-			// Extract start time for this segment
-			parts := strings.Split(line, " --> ")
-			if len(parts) >= 1 {
-				currentTimestamp = formatVTTTimestamp(parts[0])
-			}
-			continue
-		}
-
-		// Skip numeric sequence identifiers
-		if isTimeStamp(line) && !strings.Contains(line, ":") {
-			continue
-		}
-
-		// This should be transcript text
-		if line != "" {
-			// Remove VTT formatting tags
-			cleanText := removeVTTTags(line)
-			if cleanText != "" && currentTimestamp != "" {
-				// Check if we should include this segment
-				shouldInclude := true
-				if lastTimestamp, exists := seenSegments[cleanText]; exists {
-					// Calculate time difference to determine if this is a legitimate repeat
-					if !shouldIncludeRepeat(lastTimestamp, currentTimestamp) {
-						shouldInclude = false
-					}
-				}
-
-				if shouldInclude {
-					timestampedLine := fmt.Sprintf("[%s] %s", currentTimestamp, cleanText)
-					textBuilder.WriteString(timestampedLine + "\n")
-					seenSegments[cleanText] = currentTimestamp
-				}
-			}
-		}
-	}
-
-	ret = strings.TrimSpace(textBuilder.String())
-	if ret == "" {
-		err = fmt.Errorf("no transcript content found in VTT file")
-	}
-	return
+	return o.tryMethodYtDlpInternal(videoId, language, additionalArgs, readSubtitlesWithTimestamps)
 }
 
-func formatVTTTimestamp(vttTime string) string {
-	// VTT timestamps are in format "00:00:01.234" - convert to "00:00:01"
-	parts := strings.Split(vttTime, ".")
-	if len(parts) > 0 {
-		return parts[0]
+// GrabMediaTranscript grabs the subtitles of any media URL supported by yt-dlp
+// (Vimeo, podcasts, conference sites, ...). Uploaded subtitles are preferred
+// over automatic captions.
+func (o *YouTube) GrabMediaTranscript(mediaURL string, language string, additionalArgs string, withTimestamps bool) (ret string, err error) {
+	process := readSubtitles
+	if withTimestamps {
+		process = readSubtitlesWithTimestamps
 	}
-	return vttTime
+	subtitleArgs := []string{"--write-subs", "--write-auto-subs", "--skip-download", "--sub-format", "vtt/srt/best"}
+	return o.grabSubtitles(mediaURL, "the media site", subtitleArgs, language, additionalArgs, process)
 }
 
-func isTimeStamp(s string) bool {
-	return timestampRegex.MatchString(s)
+func readSubtitles(filename string) (string, error) {
+	return ReadSubtitleFile(filename, false)
 }
 
-func removeVTTTags(s string) string {
-	// Remove VTT tags like <c.colorE5E5E5>, </c>, etc.
-	return vttTagRegex.ReplaceAllString(s, "")
+func readSubtitlesWithTimestamps(filename string) (string, error) {
+	return ReadSubtitleFile(filename, true)
 }
 
 // shouldIncludeRepeat determines if repeated content should be included based on time gap
@@ -673,8 +580,9 @@ func (o *YouTube) normalizeFileName(name string) string {
 
 }
 
-// findVTTFilesWithFallback searches for VTT files, handling fallback scenarios
-// where the requested language might not be available
+// findVTTFilesWithFallback searches for subtitle files (VTT, or SRT for sites
+// without VTT), handling fallback scenarios where the requested language
+// might not be available
 func (o *YouTube) findVTTFilesWithFallback(dir, requestedLanguage string) ([]string, error) {
 	var vttFiles []string
 
@@ -684,7 +592,7 @@ func (o *YouTube) findVTTFilesWithFallback(dir, requestedLanguage string) ([]str
 			return err
 		}
 
-		if !info.IsDir() && strings.HasSuffix(strings.ToLower(path), ".vtt") {
+		if !info.IsDir() && IsSubtitleFile(path) {
 			vttFiles = append(vttFiles, path)
 		}
 		return nil
@@ -695,7 +603,7 @@ func (o *YouTube) findVTTFilesWithFallback(dir, requestedLanguage string) ([]str
 	}
 
 	if len(vttFiles) == 0 {
-		return nil, fmt.Errorf("no VTT files found in directory")
+		return nil, fmt.Errorf("no subtitle files found in directory")
 	}
 
 	// If no specific language requested, return the first file
@@ -705,7 +613,7 @@ func (o *YouTube) findVTTFilesWithFallback(dir, requestedLanguage string) ([]str
 
 	// First, try to find files with the requested language
 	for _, file := range vttFiles {
-		if strings.Contains(file, "."+requestedLanguage+".vtt") {
+		if strings.Contains(file, "."+requestedLanguage+".vtt") || strings.Contains(file, "."+requestedLanguage+".srt") {
 			return []string{file}, nil
 		}
 	}
@@ -759,6 +667,9 @@ type VideoMetadata struct {
 }
 
 func (o *YouTube) GrabMetadata(videoId string) (metadata *VideoMetadata, err error) {
+	if metadata = o.cachedMetadata(videoId); metadata != nil {
+		return
+	}
 	if err = o.initService(); err != nil {
 		return
 	}
@@ -789,6 +700,7 @@ func (o *YouTube) GrabMetadata(videoId string) (metadata *VideoMetadata, err err
 		ViewCount:    viewCount,
 		LikeCount:    likeCount,
 	}
+	o.storeMetadata(metadata)
 	return
 }
 
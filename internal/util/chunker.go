@@ -0,0 +1,140 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// TextChunk is a contiguous piece of a text, with 1-based line numbers.
+type TextChunk struct {
+	Text      string
+	StartLine int
+	EndLine   int
+}
+
+// Chunker splits text into chunks of at most Size characters. It prefers to
+// break on blank lines (paragraphs, markdown sections, code blocks), falls back
+// to single lines (e.g. timestamped transcript lines), and repeats roughly Overlap characters of trailing lines at
+// the start of the next chunk so that context is not lost at the boundaries.
+type Chunker struct {
+	Size    int
+	Overlap int
+}
+
+type line struct {
+	text   string
+	number int
+}
+
+func (o *Chunker) Split(text string) (ret []TextChunk) {
+	var lines []line
+	for i, l := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		lines = append(lines, line{text: l, number: i + 1})
+	}
+
+	var current []line
+	currentSize := 0
+	lastBlank := -1
+	// carried counts the overlap lines at the start of current that were already emitted
+	carried := 0
+
+	flush := func(upTo int) {
+		if upTo <= 0 {
+			return
+		}
+		if chunk, ok := buildChunk(current[:upTo]); ok {
+			ret = append(ret, chunk)
+		}
+		rest := current[upTo:]
+		overlap := o.overlapLines(current[:upTo])
+		carried = len(overlap)
+		current = append(overlap, rest...)
+		currentSize = 0
+		lastBlank = -1
+		for i, l := range current {
+			currentSize += len(l.text) + 1
+			if strings.TrimSpace(l.text) == "" {
+				lastBlank = i
+			}
+		}
+	}
+
+	for _, l := range lines {
+		for len(l.text) > o.Size {
+			// A single overlong line is hard split.
+			flush(len(current))
+			current = nil
+			currentSize = 0
+			carried = 0
+			// Cut on a rune boundary, but always keep at least one rune.
+			cut := o.Size
+			for cut > 0 && !utf8.RuneStart(l.text[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				_, cut = utf8.DecodeRuneInString(l.text)
+			}
+			ret = append(ret, TextChunk{Text: l.text[:cut], StartLine: l.number, EndLine: l.number})
+			l.text = l.text[cut:]
+		}
+		if currentSize+len(l.text)+1 > o.Size && len(current) > 0 {
+			if lastBlank > 0 {
+				flush(lastBlank + 1)
+			} else {
+				flush(len(current))
+			}
+		}
+		current = append(current, l)
+		currentSize += len(l.text) + 1
+		if strings.TrimSpace(l.text) == "" {
+			lastBlank = len(current) - 1
+		}
+	}
+	if len(current) > carried {
+		if chunk, ok := buildChunk(current); ok {
+			ret = append(ret, chunk)
+		}
+	}
+	return
+}
+
+// overlapLines returns the trailing lines of a flushed chunk that fit in Overlap.
+func (o *Chunker) overlapLines(lines []line) (ret []line) {
+	size := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		size += len(lines[i].text) + 1
+		if size > o.Overlap {
+			break
+		}
+		ret = append([]line{lines[i]}, ret...)
+	}
+	// Never carry over the whole chunk, otherwise the split would not advance.
+	if len(ret) == len(lines) {
+		ret = nil
+	}
+	return
+}
+
+func buildChunk(lines []line) (ret TextChunk, ok bool) {
+	start, end := 0, len(lines)
+	for start < end && strings.TrimSpace(lines[start].text) == "" {
+		start++
+	}
+	for end > start && strings.TrimSpace(lines[end-1].text) == "" {
+		end--
+	}
+	if start == end {
+		return
+	}
+	texts := make([]string, 0, end-start)
+	for _, l := range lines[start:end] {
+		texts = append(texts, l.text)
+	}
+	ret = TextChunk{
+		Text:      strings.Join(texts, "\n"),
+		StartLine: lines[start].number,
+		EndLine:   lines[end-1].number,
+	}
+	ok = true
+	return
+}
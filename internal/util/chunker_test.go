@@ -0,0 +1,65 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunker_Split(t *testing.T) {
+	chunker := &Chunker{Size: 40, Overlap: 0}
+	text := "first paragraph line one\nline two\n\nsecond paragraph here\nand more\n\nthird"
+
+	chunks := chunker.Split(text)
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+	if chunks[0].StartLine != 1 {
+		t.Errorf("expected first chunk to start at line 1, got %d", chunks[0].StartLine)
+	}
+	for _, chunk := range chunks {
+		if len(chunk.Text) > chunker.Size {
+			t.Errorf("chunk exceeds size: %q", chunk.Text)
+		}
+		if strings.TrimSpace(chunk.Text) != chunk.Text {
+			t.Errorf("chunk not trimmed: %q", chunk.Text)
+		}
+	}
+	if last := chunks[len(chunks)-1]; !strings.HasSuffix(last.Text, "third") || last.EndLine != 7 {
+		t.Errorf("unexpected last chunk: %+v", last)
+	}
+}
+
+func TestChunker_SplitLongLine(t *testing.T) {
+	chunker := &Chunker{Size: 10, Overlap: 0}
+	chunks := chunker.Split(strings.Repeat("a", 25))
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+}
+
+func TestChunker_SplitLongLineOnRuneBoundaries(t *testing.T) {
+	line := strings.Repeat("日本語のテキスト", 10)
+	chunker := &Chunker{Size: 10, Overlap: 0}
+	chunks := chunker.Split(line)
+
+	var joined strings.Builder
+	for _, chunk := range chunks {
+		if !utf8.ValidString(chunk.Text) {
+			t.Errorf("chunk is not valid UTF-8: %q", chunk.Text)
+		}
+		if len(chunk.Text) > chunker.Size {
+			t.Errorf("chunk exceeds size: %q", chunk.Text)
+		}
+		joined.WriteString(chunk.Text)
+	}
+	if joined.String() != line {
+		t.Errorf("chunks do not add up to the line")
+	}
+
+	// a size smaller than one rune still advances
+	chunks = (&Chunker{Size: 2}).Split("日本")
+	if len(chunks) != 2 || chunks[0].Text != "日" || chunks[1].Text != "本" {
+		t.Errorf("unexpected chunks %+v", chunks)
+	}
+}